@@ -11,8 +11,10 @@ import (
 	"github.com/leetatech/leeta_backend/adapt/routes"
 	"github.com/leetatech/leeta_backend/pkg/config"
 	"github.com/leetatech/leeta_backend/pkg/database"
+	"github.com/leetatech/leeta_backend/pkg/idempotency"
 	"github.com/leetatech/leeta_backend/pkg/jwtmiddleware"
 	mailer "github.com/leetatech/leeta_backend/pkg/notification/mailer/aws"
+	"github.com/leetatech/leeta_backend/pkg/pubsub"
 	stateApplication "github.com/leetatech/leeta_backend/services/state/application"
 	stateInfrastructure "github.com/leetatech/leeta_backend/services/state/infrastructure"
 	stateInterface "github.com/leetatech/leeta_backend/services/state/interfaces"
@@ -43,6 +45,42 @@ import (
 	feesInfrastructure "github.com/leetatech/leeta_backend/services/fees/infrastructure"
 	feeInterface "github.com/leetatech/leeta_backend/services/fees/interfaces"
 
+	"github.com/leetatech/leeta_backend/pkg/payment"
+	"github.com/leetatech/leeta_backend/pkg/payment/cod"
+	"github.com/leetatech/leeta_backend/pkg/payment/fake"
+	paymentApplication "github.com/leetatech/leeta_backend/services/payment/application"
+	paymentInfrastructure "github.com/leetatech/leeta_backend/services/payment/infrastructure"
+	paymentInterface "github.com/leetatech/leeta_backend/services/payment/interfaces"
+
+	refundApplication "github.com/leetatech/leeta_backend/services/refund/application"
+	refundInfrastructure "github.com/leetatech/leeta_backend/services/refund/infrastructure"
+	refundInterface "github.com/leetatech/leeta_backend/services/refund/interfaces"
+
+	subscriptionApplication "github.com/leetatech/leeta_backend/services/subscription/application"
+	subscriptionInfrastructure "github.com/leetatech/leeta_backend/services/subscription/infrastructure"
+	subscriptionInterface "github.com/leetatech/leeta_backend/services/subscription/interfaces"
+
+	analyticsApplication "github.com/leetatech/leeta_backend/services/analytics/application"
+	analyticsInfrastructure "github.com/leetatech/leeta_backend/services/analytics/infrastructure"
+	analyticsInterface "github.com/leetatech/leeta_backend/services/analytics/interfaces"
+
+	exportApplication "github.com/leetatech/leeta_backend/services/export/application"
+	exportInfrastructure "github.com/leetatech/leeta_backend/services/export/infrastructure"
+	exportInterface "github.com/leetatech/leeta_backend/services/export/interfaces"
+
+	promotionApplication "github.com/leetatech/leeta_backend/services/promotion/application"
+	promotionInfrastructure "github.com/leetatech/leeta_backend/services/promotion/infrastructure"
+	promotionInterface "github.com/leetatech/leeta_backend/services/promotion/interfaces"
+	reviewApplication "github.com/leetatech/leeta_backend/services/review/application"
+	reviewInfrastructure "github.com/leetatech/leeta_backend/services/review/infrastructure"
+	reviewInterface "github.com/leetatech/leeta_backend/services/review/interfaces"
+	slotApplication "github.com/leetatech/leeta_backend/services/slot/application"
+	slotInfrastructure "github.com/leetatech/leeta_backend/services/slot/infrastructure"
+	slotInterface "github.com/leetatech/leeta_backend/services/slot/interfaces"
+	ticketApplication "github.com/leetatech/leeta_backend/services/ticket/application"
+	ticketInfrastructure "github.com/leetatech/leeta_backend/services/ticket/infrastructure"
+	ticketInterface "github.com/leetatech/leeta_backend/services/ticket/interfaces"
+
 	"net/http"
 	"time"
 
@@ -53,10 +91,13 @@ import (
 type Application struct {
 	Config              *config.ServerConfig
 	Db                  *mongo.Client
-	Ctx                 context.Context
 	Router              *chi.Mux
 	NotificationService notification.AWSClient
 	RepositoryManager   pkg.RepositoryManager
+	// subscriptions creates the orders of refill subscriptions in the background while the application runs
+	subscriptions subscriptionApplication.Subscription
+	// exports runs export jobs in the background while the application runs
+	exports exportApplication.Export
 }
 
 // New instances a new application
@@ -78,6 +119,10 @@ func New(configFile string) (*Application, error) {
 		return nil, errors.New("application config is empty")
 	}
 
+	if app.Config.Checkout.QuoteSigningSecret == "" {
+		return nil, errors.New("QUOTE_SIGNING_SECRET is required to sign checkout quotes")
+	}
+
 	app.Db, err = database.Client(ctx, app.Config)
 	if err != nil {
 		return nil, fmt.Errorf("error connecting to mongo db client %w", err)
@@ -86,6 +131,54 @@ func New(configFile string) (*Application, error) {
 		return nil, errors.New("error pinging database")
 	}
 
+	// migrations rewrite whole collections and indexes are built over them, which takes longer than connecting on a real
+	// dataset, so they are not bound by the connection timeout
+	setupCtx := context.Background()
+
+	// amounts stored before they were kept in minor units are converted before anything reads them
+	err = database.Migrate(setupCtx, app.Db, app.Config.Database.DBName,
+		database.Migration{
+			ID: "money",
+			Migrate: func(ctx context.Context) error {
+				return database.MigrateMoney(ctx, app.Db, app.Config.Database.DBName, app.Config.Payment.Currency)
+			},
+		},
+		database.Migration{
+			ID: "fee_effective_windows",
+			Migrate: func(ctx context.Context) error {
+				return feesInfrastructure.MigrateEffectiveWindows(ctx, app.Db, app.Config.Database.DBName)
+			},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = idempotency.EnsureIndexes(setupCtx, app.Db, app.Config.Database.DBName)
+	if err != nil {
+		return nil, err
+	}
+
+	err = orderInfrastructure.EnsureIndexes(setupCtx, app.Db, app.Config.Database.DBName)
+	if err != nil {
+		return nil, err
+	}
+
+	err = reviewInfrastructure.EnsureIndexes(setupCtx, app.Db, app.Config.Database.DBName)
+	if err != nil {
+		return nil, err
+	}
+
+	err = promotionInfrastructure.EnsureIndexes(setupCtx, app.Db, app.Config.Database.DBName)
+	if err != nil {
+		return nil, err
+	}
+
+	err = feesInfrastructure.EnsureIndexes(setupCtx, app.Db, app.Config.Database.DBName)
+	if err != nil {
+		return nil, err
+	}
+
 	app.NotificationService = notification.AWSClient{
 		Config: &app.Config.AWSConfig,
 	}
@@ -99,27 +192,39 @@ func New(configFile string) (*Application, error) {
 		return nil, err
 	}
 
-	allInterfaces := app.buildApplicationConnection(*jwtManager, *app.Config)
+	paymentProviders, err := app.buildPaymentProviders()
+	if err != nil {
+		return nil, err
+	}
+
+	allInterfaces := app.buildApplicationConnection(*jwtManager, *app.Config, paymentProviders)
+
+	idempotencyMiddleware := idempotency.New(idempotency.NewStore(app.Db, app.Config.Database.DBName), jwtManager, app.Config.IdempotencyKeyTTL)
 
-	router, _, err := routes.SetupRouter(jwtManager, allInterfaces)
+	router, _, err := routes.SetupRouter(jwtManager, idempotencyMiddleware, allInterfaces)
 	if err != nil {
 		return nil, err
 	}
 	app.Router = router
 
-	app.Ctx = ctx
-
 	return &app, nil
 }
 
 // Run executes the application
 func (app *Application) Run() error {
 	defer func() {
-		if err := app.Db.Disconnect(app.Ctx); err != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := app.Db.Disconnect(ctx); err != nil {
 			log.Debug().Msgf("error disconnecting from database: %v", err)
 		}
 	}()
 
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go app.subscriptions.RunScheduler(ctx)
+	go app.exports.RunWorker(ctx)
+
 	app.Router.Get("/", func(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, "/api/swagger/", http.StatusFound)
 	})
@@ -148,23 +253,60 @@ func (app *Application) buildConfig(configFile string) (*config.ServerConfig, er
 	return config.ReadConfig(configFile)
 }
 
-func (app *Application) buildApplicationConnection(jwtManager jwtmiddleware.Manager, config config.ServerConfig) *routes.AllHTTPHandlers {
+// buildPaymentProviders resolves the configured online payment provider. Cash on delivery is always available.
+func (app *Application) buildPaymentProviders() (payment.Providers, error) {
+	var online payment.PaymentProvider
+	switch app.Config.Payment.Provider {
+	case "":
+		return payment.Providers{}, errors.New("no payment provider is configured, set PAYMENT_PROVIDER")
+	case fake.Name:
+		if app.Config.AppEnv == "production" {
+			return payment.Providers{}, errors.New("the fake payment provider cannot be used in production")
+		}
+		online = fake.New(app.Config.Payment.WebhookSecret)
+	default:
+		return payment.Providers{}, fmt.Errorf("unknown payment provider %s", app.Config.Payment.Provider)
+	}
+
+	return payment.NewProviders(online, cod.New()), nil
+}
+
+func (app *Application) buildApplicationConnection(jwtManager jwtmiddleware.Manager, config config.ServerConfig, paymentProviders payment.Providers) *routes.AllHTTPHandlers {
 	authPersistence := authInfrastructure.New(app.Db, app.Config.Database.DBName)
 	orderPersistence := orderInfrastructure.New(app.Db, app.Config.Database.DBName)
 	userPersistence := userInfrastructure.New(app.Db, app.Config.Database.DBName)
 	productPersistence := productInfrastructure.New(app.Db, app.Config.Database.DBName)
 	cartPersistence := cartInfrastructure.New(app.Db, app.Config.Database.DBName)
-	feesPersistence := feesInfrastructure.New(app.Db, app.Config.Database.DBName)
+	feesPersistence := feesInfrastructure.New(app.Db, app.Config.Database.DBName, app.Config.Payment.Currency)
 	statePersistence := stateInfrastructure.New(app.Db, app.Config.Database.DBName)
+	paymentPersistence := paymentInfrastructure.New(app.Db, app.Config.Database.DBName)
+	subscriptionPersistence := subscriptionInfrastructure.New(app.Db, app.Config.Database.DBName)
+	refundPersistence := refundInfrastructure.New(app.Db, app.Config.Database.DBName)
+	analyticsPersistence := analyticsInfrastructure.New(app.Db, app.Config.Database.DBName)
+	exportPersistence := exportInfrastructure.New(app.Db, app.Config.Database.DBName)
+	slotPersistence := slotInfrastructure.New(app.Db, app.Config.Database.DBName)
+	reviewPersistence := reviewInfrastructure.New(app.Db, app.Config.Database.DBName)
+	ticketPersistence := ticketInfrastructure.New(app.Db, app.Config.Database.DBName)
+	promotionPersistence := promotionInfrastructure.New(app.Db, app.Config.Database.DBName)
 
 	repositoryManager := pkg.RepositoryManager{
-		OrderRepository:   orderPersistence,
-		AuthRepository:    authPersistence,
-		UserRepository:    userPersistence,
-		ProductRepository: productPersistence,
-		CartRepository:    cartPersistence,
-		FeesRepository:    feesPersistence,
-		StatesRepository:  statePersistence,
+		OrderRepository:        orderPersistence,
+		AuthRepository:         authPersistence,
+		UserRepository:         userPersistence,
+		ProductRepository:      productPersistence,
+		CartRepository:         cartPersistence,
+		FeesRepository:         feesPersistence,
+		StatesRepository:       statePersistence,
+		PaymentRepository:      paymentPersistence,
+		SubscriptionRepository: subscriptionPersistence,
+		RefundRepository:       refundPersistence,
+		AnalyticsRepository:    analyticsPersistence,
+		ExportRepository:       exportPersistence,
+		SlotRepository:         slotPersistence,
+		ReviewRepository:       reviewPersistence,
+		TicketRepository:       ticketPersistence,
+		PromotionRepository:    promotionPersistence,
+		Transactor:             database.NewTransactor(app.Db),
 	}
 
 	app.RepositoryManager = repositoryManager
@@ -179,6 +321,8 @@ func (app *Application) buildApplicationConnection(jwtManager jwtmiddleware.Mana
 		Domain:            app.Config.Notification.Domain,
 		Config:            config,
 		SMSClient:         awsSMSClient,
+		PaymentProviders:  paymentProviders,
+		TrackingHub:       pubsub.NewInMemoryHub(),
 	}
 
 	orderApplications := orderApplication.New(request)
@@ -188,6 +332,17 @@ func (app *Application) buildApplicationConnection(jwtManager jwtmiddleware.Mana
 	cartsApplication := cartApplication.New(request)
 	feeApplication := feesApplication.New(request)
 	statesApplication := stateApplication.New(request, app.Config.NgnStates)
+	paymentsApplication := paymentApplication.New(request)
+	subscriptionsApplication := subscriptionApplication.New(request)
+	app.subscriptions = subscriptionsApplication
+	refundsApplication := refundApplication.New(request)
+	analyticsApplications := analyticsApplication.New(request)
+	exportsApplication := exportApplication.New(request)
+	app.exports = exportsApplication
+	slotsApplication := slotApplication.New(request)
+	reviewsApplication := reviewApplication.New(request)
+	ticketsApplication := ticketApplication.New(request)
+	promotionsApplication := promotionApplication.New(request)
 
 	orderInterfaces := orderInterface.New(orderApplications)
 	authInterfaces := authInterface.New(authApplications)
@@ -196,15 +351,33 @@ func (app *Application) buildApplicationConnection(jwtManager jwtmiddleware.Mana
 	cartInterfaces := cartInterface.New(cartsApplication)
 	feesInterfaces := feeInterface.New(feeApplication)
 	statesInterfaces := stateInterface.New(statesApplication)
+	paymentInterfaces := paymentInterface.New(paymentsApplication)
+	subscriptionInterfaces := subscriptionInterface.New(subscriptionsApplication)
+	refundInterfaces := refundInterface.New(refundsApplication)
+	analyticsInterfaces := analyticsInterface.New(analyticsApplications)
+	exportInterfaces := exportInterface.New(exportsApplication)
+	slotInterfaces := slotInterface.New(slotsApplication)
+	reviewInterfaces := reviewInterface.New(reviewsApplication)
+	ticketInterfaces := ticketInterface.New(ticketsApplication)
+	promotionInterfaces := promotionInterface.New(promotionsApplication)
 
 	allInterfaces := routes.AllHTTPHandlers{
-		Order:   orderInterfaces,
-		Auth:    authInterfaces,
-		User:    userInterfaces,
-		Product: productInterfaces,
-		Cart:    cartInterfaces,
-		Fees:    feesInterfaces,
-		State:   statesInterfaces,
+		Order:        orderInterfaces,
+		Auth:         authInterfaces,
+		User:         userInterfaces,
+		Product:      productInterfaces,
+		Cart:         cartInterfaces,
+		Fees:         feesInterfaces,
+		State:        statesInterfaces,
+		Payment:      paymentInterfaces,
+		Subscription: subscriptionInterfaces,
+		Refund:       refundInterfaces,
+		Analytics:    analyticsInterfaces,
+		Export:       exportInterfaces,
+		Slot:         slotInterfaces,
+		Review:       reviewInterfaces,
+		Ticket:       ticketInterfaces,
+		Promotion:    promotionInterfaces,
 	}
 	return routes.AllInterfaces(&allInterfaces)
 }
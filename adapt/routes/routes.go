@@ -5,60 +5,98 @@ import (
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	_ "github.com/leetatech/leeta_backend/docs"
+	"github.com/leetatech/leeta_backend/pkg/idempotency"
 	middleware2 "github.com/leetatech/leeta_backend/pkg/jwtmiddleware"
+	analyticsInterfaces "github.com/leetatech/leeta_backend/services/analytics/interfaces"
 	authInterfaces "github.com/leetatech/leeta_backend/services/auth/interfaces"
 	cartInterfaces "github.com/leetatech/leeta_backend/services/cart/interfaces"
+	exportInterfaces "github.com/leetatech/leeta_backend/services/export/interfaces"
 	feesInterfaces "github.com/leetatech/leeta_backend/services/fees/interfaces"
 	orderInterfaces "github.com/leetatech/leeta_backend/services/order/interfaces"
+	paymentInterfaces "github.com/leetatech/leeta_backend/services/payment/interfaces"
 	productInterfaces "github.com/leetatech/leeta_backend/services/product/interfaces"
+	promotionInterfaces "github.com/leetatech/leeta_backend/services/promotion/interfaces"
+	refundInterfaces "github.com/leetatech/leeta_backend/services/refund/interfaces"
+	reviewInterfaces "github.com/leetatech/leeta_backend/services/review/interfaces"
+	slotInterfaces "github.com/leetatech/leeta_backend/services/slot/interfaces"
 	stateInterfaces "github.com/leetatech/leeta_backend/services/state/interfaces"
+	subscriptionInterfaces "github.com/leetatech/leeta_backend/services/subscription/interfaces"
+	ticketInterfaces "github.com/leetatech/leeta_backend/services/ticket/interfaces"
 	userInterfaces "github.com/leetatech/leeta_backend/services/user/interfaces"
 	httpSwagger "github.com/swaggo/http-swagger"
 	"net/http"
 )
 
 type AllHTTPHandlers struct {
-	Order   *orderInterfaces.OrderHttpHandler
-	Auth    *authInterfaces.AuthHttpHandler
-	User    *userInterfaces.UserHttpHandler
-	Product *productInterfaces.ProductHttpHandler
-	Cart    *cartInterfaces.CartHttpHandler
-	Fees    *feesInterfaces.FeesHttpHandler
-	State   *stateInterfaces.StateHttpHandler
+	Order        *orderInterfaces.OrderHttpHandler
+	Auth         *authInterfaces.AuthHttpHandler
+	User         *userInterfaces.UserHttpHandler
+	Product      *productInterfaces.ProductHttpHandler
+	Cart         *cartInterfaces.CartHttpHandler
+	Fees         *feesInterfaces.FeesHttpHandler
+	State        *stateInterfaces.StateHttpHandler
+	Payment      *paymentInterfaces.PaymentHttpHandler
+	Subscription *subscriptionInterfaces.SubscriptionHttpHandler
+	Refund       *refundInterfaces.RefundHttpHandler
+	Analytics    *analyticsInterfaces.AnalyticsHttpHandler
+	Export       *exportInterfaces.ExportHttpHandler
+	Slot         *slotInterfaces.SlotHttpHandler
+	Review       *reviewInterfaces.ReviewHttpHandler
+	Ticket       *ticketInterfaces.TicketHttpHandler
+	Promotion    *promotionInterfaces.PromotionHttpHandler
 }
 
 func AllInterfaces(interfaces *AllHTTPHandlers) *AllHTTPHandlers {
 	return &AllHTTPHandlers{
-		Order:   interfaces.Order,
-		Auth:    interfaces.Auth,
-		User:    interfaces.User,
-		Product: interfaces.Product,
-		Cart:    interfaces.Cart,
-		Fees:    interfaces.Fees,
-		State:   interfaces.State,
+		Order:        interfaces.Order,
+		Auth:         interfaces.Auth,
+		User:         interfaces.User,
+		Product:      interfaces.Product,
+		Cart:         interfaces.Cart,
+		Fees:         interfaces.Fees,
+		State:        interfaces.State,
+		Payment:      interfaces.Payment,
+		Subscription: interfaces.Subscription,
+		Refund:       interfaces.Refund,
+		Analytics:    interfaces.Analytics,
+		Export:       interfaces.Export,
+		Slot:         interfaces.Slot,
+		Review:       interfaces.Review,
+		Ticket:       interfaces.Ticket,
+		Promotion:    interfaces.Promotion,
 	}
 }
 
-func SetupRouter(jwtManager *middleware2.Manager, interfaces *AllHTTPHandlers) (*chi.Mux, *middleware2.Manager, error) {
+func SetupRouter(jwtManager *middleware2.Manager, idempotencyMiddleware *idempotency.Middleware, interfaces *AllHTTPHandlers) (*chi.Mux, *middleware2.Manager, error) {
 	router := chi.NewRouter()
 
 	router.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"https://*", "http://*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", idempotency.HeaderKey},
+		ExposedHeaders:   []string{"Link", idempotency.ReplayedHeader},
 		AllowCredentials: false,
 		MaxAge:           300,
 	}))
 	router.Use(middleware.Logger)
+	router.Use(middleware.Recoverer)
 
 	orderRouter := buildOrderEndpoints(*interfaces.Order, jwtManager)
-	authRouter := buildAuthEndpoints(*interfaces.Auth)
+	authRouter := buildAuthEndpoints(*interfaces.Auth, jwtManager, idempotencyMiddleware)
 	userRouter := buildUserEndpoints(*interfaces.User, jwtManager)
 	productRouter := buildProductEndpoints(*interfaces.Product, jwtManager)
-	cartRouter := buildCartEndpoints(*interfaces.Cart, jwtManager)
+	cartRouter := buildCartEndpoints(*interfaces.Cart, jwtManager, idempotencyMiddleware)
 	feesRouter := buildFeesEndpoints(*interfaces.Fees, jwtManager)
 	stateRouter := buildStatesEndpoints(*interfaces.State, jwtManager)
+	paymentRouter := buildPaymentEndpoints(*interfaces.Payment, jwtManager)
+	subscriptionRouter := buildSubscriptionEndpoints(*interfaces.Subscription, jwtManager)
+	refundRouter := buildRefundEndpoints(*interfaces.Refund, jwtManager)
+	analyticsRouter := buildAnalyticsEndpoints(*interfaces.Analytics, jwtManager)
+	exportRouter := buildExportEndpoints(*interfaces.Export, jwtManager)
+	slotRouter := buildSlotEndpoints(*interfaces.Slot, jwtManager)
+	reviewRouter := buildReviewEndpoints(*interfaces.Review, jwtManager)
+	ticketRouter := buildTicketEndpoints(*interfaces.Ticket, jwtManager)
+	promotionRouter := buildPromotionEndpoints(*interfaces.Promotion, jwtManager)
 
 	router.Route("/api", func(r chi.Router) {
 		r.Handle("/swagger/*", httpSwagger.WrapHandler)
@@ -69,18 +107,29 @@ func SetupRouter(jwtManager *middleware2.Manager, interfaces *AllHTTPHandlers) (
 		r.Mount("/cart", cartRouter)
 		r.Mount("/fees", feesRouter)
 		r.Mount("/state", stateRouter)
+		r.Mount("/payment", paymentRouter)
+		r.Mount("/subscription", subscriptionRouter)
+		r.Mount("/refund", refundRouter)
+		r.Mount("/analytics", analyticsRouter)
+		r.Mount("/export", exportRouter)
+		r.Mount("/slot", slotRouter)
+		r.Mount("/review", reviewRouter)
+		r.Mount("/ticket", ticketRouter)
+		r.Mount("/promotion", promotionRouter)
 	})
 
 	return router, jwtManager, nil
 }
 
-func buildAuthEndpoints(session authInterfaces.AuthHttpHandler) http.Handler {
+func buildAuthEndpoints(session authInterfaces.AuthHttpHandler, jwtManager *middleware2.Manager, idempotencyMiddleware *idempotency.Middleware) http.Handler {
 	router := chi.NewRouter()
 
-	// Signing
-	router.Post("/signup", session.SignUpHandler)
-	router.Post("/signin", session.SignInHandler)
+	// Signing, a guest signing up or in sends its guest token to keep the cart built on the device.
+	// A retried sign up is answered with a new token for the user it created, tokens are never stored.
+	router.With(jwtManager.ValidateOptionalMiddleware, idempotencyMiddleware.HandleCredentials(session.ReissueSignUp)).Post("/signup", session.SignUpHandler)
+	router.With(jwtManager.ValidateOptionalMiddleware).Post("/signin", session.SignInHandler)
 	router.Post("/admin/signup", session.AdminSignUpHandler)
+	router.With(jwtManager.ValidateRestrictedAccessMiddleware).Post("/admin/rider", session.CreateRiderHandler)
 
 	// guest session management
 	router.Post("/guest", session.ReceiveGuestjwtManager)
@@ -116,6 +165,32 @@ func buildOrderEndpoints(order orderInterfaces.OrderHttpHandler, jwtManager *mid
 	router.Put("/", order.ListOrdersHandler)
 	router.Get("/options", order.ListOrdersOptions)
 	router.Get("/status/history/{order_id}", order.ListOrderStatusHistoryHandler)
+	router.Get("/checkout/{checkout_id}", order.GetCheckoutHandler)
+	router.With(jwtManager.ValidateRestrictedAccessMiddleware).Put("/{order_id}/rider", order.AssignRiderHandler)
+	router.Get("/{order_id}/track", order.TrackOrderHandler)
+	router.Get("/{order_id}/invoice", order.GetInvoiceHandler)
+	router.Get("/{order_id}/handover-code", order.GetHandoverCodeHandler)
+	router.Get("/{order_id}/delivery-photo", order.GetDeliveryPhotoHandler)
+	router.Post("/{order_id}/reorder", order.ReorderHandler)
+	router.Post("/{order_id}/deposit-returns", order.ReturnDepositHandler)
+
+	// vendor order inbox
+	router.Route("/vendor/inbox", func(r chi.Router) {
+		r.Use(jwtManager.ValidateRestrictedAccessMiddleware)
+		r.Put("/", order.VendorInboxHandler)
+		r.Get("/options", order.VendorInboxOptions)
+		r.Get("/counts", order.VendorInboxCountsHandler)
+		r.Put("/{order_id}/accept", order.AcceptOrderHandler)
+		r.Put("/{order_id}/reject", order.RejectOrderHandler)
+	})
+
+	// rider delivery jobs
+	router.Route("/rider/jobs", func(r chi.Router) {
+		r.Get("/", order.ListRiderJobsHandler)
+		r.Put("/{order_id}/pickup", order.PickUpOrderHandler)
+		r.Put("/{order_id}/deliver", order.DeliverOrderHandler)
+		r.Post("/{order_id}/location", order.UpdateRiderLocationHandler)
+	})
 	return router
 }
 
@@ -169,25 +244,28 @@ func buildProductEndpoints(product productInterfaces.ProductHttpHandler, jwtMana
 	return router
 }
 
-func buildCartEndpoints(handler cartInterfaces.CartHttpHandler, jwtManager *middleware2.Manager) http.Handler {
+func buildCartEndpoints(handler cartInterfaces.CartHttpHandler, jwtManager *middleware2.Manager, idempotencyMiddleware *idempotency.Middleware) http.Handler {
 	router := chi.NewRouter()
 
 	router.Group(func(r chi.Router) {
 		r.Use(jwtManager.ValidateMiddleware)
 		// post endpoints
-		r.Post("/add", handler.AddToCart)
+		r.With(idempotencyMiddleware.Handle).Post("/add", handler.AddToCart)
 		r.Put("/", handler.ListCart)
-		r.Post("/checkout", handler.Checkout)
+		r.Post("/quote", handler.Quote)
+		r.With(idempotencyMiddleware.Handle).Post("/checkout", handler.Checkout)
 
 		// get endpoints
 		r.Get("/options", handler.ListCartOptions)
 
 		// update endpoints
 		r.Put("/item/quantity", handler.UpdateCartItemQuantity)
+		r.Put("/promo", handler.ApplyPromoCode)
 
 		// delete endpoints
 		r.Delete("/{cart_id}", handler.DeleteCart)
 		r.Delete("/item/{cart_item_id}", handler.DeleteCartItem)
+		r.Delete("/promo", handler.RemovePromoCode)
 	})
 
 	return router
@@ -201,6 +279,9 @@ func buildFeesEndpoints(handler feesInterfaces.FeesHttpHandler, jwtManager *midd
 	router.Route("/", func(r chi.Router) {
 		r.Use(jwtManager.ValidateRestrictedAccessMiddleware)
 		router.Post("/", handler.CreateFeeHandler)
+		r.Post("/schedule", handler.ScheduleFeeHandler)
+		r.Post("/{fee_id}/cancel", handler.CancelFeeHandler)
+		r.Get("/history", handler.FeeHistoryHandler)
 	})
 	router.Put("/", handler.FetchFeesHandler)
 	router.Get("/options", handler.ListFeesOptions)
@@ -217,3 +298,147 @@ func buildStatesEndpoints(handler stateInterfaces.StateHttpHandler, jwtManager *
 
 	return router
 }
+
+func buildPaymentEndpoints(handler paymentInterfaces.PaymentHttpHandler, jwtManager *middleware2.Manager) http.Handler {
+	router := chi.NewRouter()
+
+	// called by payment providers, authenticated by the webhook signature
+	router.Post("/webhook/{provider}", handler.WebhookHandler)
+
+	router.Group(func(r chi.Router) {
+		r.Use(jwtManager.ValidateMiddleware)
+		r.Get("/verify/{reference}", handler.VerifyPaymentHandler)
+	})
+
+	return router
+}
+
+func buildSubscriptionEndpoints(handler subscriptionInterfaces.SubscriptionHttpHandler, jwtManager *middleware2.Manager) http.Handler {
+	router := chi.NewRouter()
+	router.Use(jwtManager.ValidateMiddleware)
+
+	router.Post("/", handler.CreateSubscriptionHandler)
+	router.Get("/", handler.ListSubscriptionsHandler)
+	router.Get("/{subscription_id}", handler.GetSubscriptionHandler)
+	router.Put("/{subscription_id}", handler.UpdateSubscriptionHandler)
+	router.Put("/{subscription_id}/pause", handler.PauseSubscriptionHandler)
+	router.Put("/{subscription_id}/resume", handler.ResumeSubscriptionHandler)
+	router.Put("/{subscription_id}/skip", handler.SkipSubscriptionHandler)
+	router.Put("/{subscription_id}/cancel", handler.CancelSubscriptionHandler)
+
+	return router
+}
+
+func buildRefundEndpoints(handler refundInterfaces.RefundHttpHandler, jwtManager *middleware2.Manager) http.Handler {
+	router := chi.NewRouter()
+	router.Use(jwtManager.ValidateMiddleware)
+
+	router.Get("/", handler.ListRefundsHandler)
+	router.Get("/{refund_id}", handler.GetRefundHandler)
+
+	// admin review
+	router.Group(func(r chi.Router) {
+		r.Use(jwtManager.ValidateRestrictedAccessMiddleware)
+		r.Put("/{refund_id}/approve", handler.ApproveRefundHandler)
+		r.Put("/{refund_id}/reject", handler.RejectRefundHandler)
+		r.Put("/{refund_id}/pay", handler.PayRefundHandler)
+	})
+
+	return router
+}
+
+func buildAnalyticsEndpoints(handler analyticsInterfaces.AnalyticsHttpHandler, jwtManager *middleware2.Manager) http.Handler {
+	router := chi.NewRouter()
+	router.Use(jwtManager.ValidateMiddleware)
+	router.Use(jwtManager.ValidateRestrictedAccessMiddleware)
+
+	router.Put("/orders", handler.OrderMetricsHandler)
+	router.Get("/orders/options", handler.OrderMetricsOptions)
+
+	return router
+}
+
+func buildExportEndpoints(handler exportInterfaces.ExportHttpHandler, jwtManager *middleware2.Manager) http.Handler {
+	router := chi.NewRouter()
+	router.Use(jwtManager.ValidateMiddleware)
+	router.Use(jwtManager.ValidateRestrictedAccessMiddleware)
+
+	router.Get("/jobs", handler.ListExportJobsHandler)
+	router.Get("/jobs/{job_id}", handler.GetExportJobHandler)
+	router.Get("/jobs/{job_id}/download", handler.DownloadExportJobHandler)
+
+	router.Put("/{dataset}", handler.ExportHandler)
+	router.Get("/{dataset}/options", handler.ExportOptions)
+	router.Post("/{dataset}/jobs", handler.CreateExportJobHandler)
+
+	return router
+}
+
+func buildSlotEndpoints(handler slotInterfaces.SlotHttpHandler, jwtManager *middleware2.Manager) http.Handler {
+	router := chi.NewRouter()
+	router.Use(jwtManager.ValidateMiddleware)
+
+	router.Post("/available", handler.AvailableSlotsHandler)
+
+	router.Group(func(r chi.Router) {
+		r.Use(jwtManager.ValidateRestrictedAccessMiddleware)
+		r.Post("/", handler.CreateSlotsHandler)
+		r.Put("/{slot_id}", handler.UpdateSlotHandler)
+	})
+
+	return router
+}
+
+func buildReviewEndpoints(handler reviewInterfaces.ReviewHttpHandler, jwtManager *middleware2.Manager) http.Handler {
+	router := chi.NewRouter()
+	router.Use(jwtManager.ValidateMiddleware)
+
+	router.Post("/order/{order_id}", handler.CreateReviewHandler)
+	router.Get("/order/{order_id}", handler.GetOrderReviewHandler)
+	router.Put("/vendor/{vendor_id}", handler.VendorReviewsHandler)
+	router.Get("/vendor/options", handler.VendorReviewsOptions)
+	router.Get("/vendor/{vendor_id}/rating", handler.VendorRatingHandler)
+
+	// moderation
+	router.Group(func(r chi.Router) {
+		r.Use(jwtManager.ValidateRestrictedAccessMiddleware)
+		r.Put("/", handler.ListReviewsHandler)
+		r.Get("/options", handler.ListReviewsOptions)
+		r.Put("/{review_id}/moderation", handler.ModerateReviewHandler)
+	})
+
+	return router
+}
+
+func buildTicketEndpoints(handler ticketInterfaces.TicketHttpHandler, jwtManager *middleware2.Manager) http.Handler {
+	router := chi.NewRouter()
+	router.Use(jwtManager.ValidateMiddleware)
+
+	router.Post("/", handler.OpenTicketHandler)
+	router.Put("/", handler.ListTicketsHandler)
+	router.Get("/options", handler.ListTicketsOptions)
+	router.Get("/{ticket_id}", handler.GetTicketHandler)
+	router.Post("/{ticket_id}/messages", handler.ReplyTicketHandler)
+
+	// support
+	router.Group(func(r chi.Router) {
+		r.Use(jwtManager.ValidateRestrictedAccessMiddleware)
+		r.Put("/{ticket_id}/triage", handler.TriageTicketHandler)
+		r.Put("/{ticket_id}/resolve", handler.ResolveTicketHandler)
+	})
+
+	return router
+}
+
+func buildPromotionEndpoints(handler promotionInterfaces.PromotionHttpHandler, jwtManager *middleware2.Manager) http.Handler {
+	router := chi.NewRouter()
+	router.Use(jwtManager.ValidateRestrictedAccessMiddleware)
+
+	router.Post("/", handler.CreatePromotionHandler)
+	router.Put("/", handler.ListPromotionsHandler)
+	router.Get("/options", handler.ListPromotionsOptions)
+	router.Get("/{promotion_id}", handler.GetPromotionHandler)
+	router.Put("/{promotion_id}/status", handler.UpdatePromotionStatusHandler)
+
+	return router
+}
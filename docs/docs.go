@@ -372,7 +372,7 @@ const docTemplate = `{
                         "BearerToken": []
                     }
                 ],
-                "description": "The endpoint to get all list fees. Use filter t filter by type",
+                "description": "The endpoint to get all list fees. Use filter t filter by type. Fees are filtered and listed with their status now: ACTIVE fees are in force, SCHEDULED fees take effect later and INACTIVE fees were replaced",
                 "consumes": [
                     "application/json"
                 ],
@@ -3206,15 +3206,21 @@ const docTemplate = `{
             "type": "string",
             "enum": [
                 "ACTIVE",
-                "INACTIVE"
+                "INACTIVE",
+                "SCHEDULED",
+                "CANCELLED"
             ],
             "x-enum-comments": {
-                "FeesActive": "fees has been created and active",
-                "FeesInactive": "fees has been inactivated"
+                "FeesActive": "fees is in force, it is charged until its effective window closes",
+                "FeesCancelled": "fees were scheduled and cancelled before they took effect, they are never charged",
+                "FeesInactive": "fees has been replaced, its effective window closed",
+                "FeesScheduled": "fees take effect later, they are listed with this status but stored as active"
             },
             "x-enum-varnames": [
                 "FeesActive",
-                "FeesInactive"
+                "FeesInactive",
+                "FeesScheduled",
+                "FeesCancelled"
             ]
         },
         "models.Guest": {
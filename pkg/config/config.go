@@ -28,6 +28,15 @@ type ServerConfig struct {
 	Notification NotificationConfig
 	NgnStates    NgnStatesConfig // configure resource API to retrieve NGN states
 	AWSConfig    AWSConfig
+	Payment      PaymentConfig
+	Checkout     CheckoutConfig
+	Subscription SubscriptionConfig
+	Invoice      InvoiceConfig
+	Export       ExportConfig
+	Slot         SlotConfig
+	Ticket       TicketConfig
+	// IdempotencyKeyTTL is how long responses are kept for replay to retries with the same Idempotency-Key
+	IdempotencyKeyTTL time.Duration `env:"IDEMPOTENCY_KEY_TTL" envDefault:"24h"`
 }
 
 type DatabaseConfig struct {
@@ -62,6 +71,49 @@ type AWSConfig struct {
 	Secret   string `env:"AWS_SECRET"`
 }
 
+type PaymentConfig struct {
+	Provider      string `env:"PAYMENT_PROVIDER"`
+	WebhookSecret string `env:"PAYMENT_WEBHOOK_SECRET"`
+	Currency      string `env:"PAYMENT_CURRENCY" envDefault:"NGN"`
+	CallbackURL   string `env:"PAYMENT_CALLBACK_URL"`
+}
+
+type CheckoutConfig struct {
+	QuoteSigningSecret string        `env:"QUOTE_SIGNING_SECRET"`
+	QuoteTTL           time.Duration `env:"QUOTE_TTL" envDefault:"15m"`
+}
+
+type SubscriptionConfig struct {
+	SchedulerInterval time.Duration `env:"SUBSCRIPTION_SCHEDULER_INTERVAL" envDefault:"1m"`
+	// ReminderLead is how long before a run the customer is reminded of it
+	ReminderLead time.Duration `env:"SUBSCRIPTION_REMINDER_LEAD" envDefault:"24h"`
+}
+
+type InvoiceConfig struct {
+	// VATRate is the VAT percentage included in order amounts
+	VATRate float64 `env:"INVOICE_VAT_RATE" envDefault:"7.5"`
+}
+
+type ExportConfig struct {
+	WorkerInterval time.Duration `env:"EXPORT_WORKER_INTERVAL" envDefault:"10s"`
+	// JobTimeout is how long an export job may run before another worker can pick it up again
+	JobTimeout time.Duration `env:"EXPORT_JOB_TIMEOUT" envDefault:"30m"`
+}
+
+type SlotConfig struct {
+	// BookingLead is how long before it starts a delivery slot stops taking orders
+	BookingLead time.Duration `env:"SLOT_BOOKING_LEAD" envDefault:"1h"`
+}
+
+type TicketConfig struct {
+	// FirstResponseSLA is how long support has to first reply to a ticket
+	FirstResponseSLA time.Duration `env:"TICKET_FIRST_RESPONSE_SLA" envDefault:"4h"`
+	// ResolutionSLA is how long support has to resolve a ticket
+	ResolutionSLA time.Duration `env:"TICKET_RESOLUTION_SLA" envDefault:"72h"`
+	// SupportEmail receives the messages customers send on their tickets
+	SupportEmail string `env:"TICKET_SUPPORT_EMAIL" envDefault:"support@getleeta.com"`
+}
+
 func LoadEnv(configFile string) error {
 	err := godotenv.Load(configFile)
 	if err != nil {
@@ -84,6 +136,13 @@ func ReadConfig(configFile string) (*ServerConfig, error) {
 		&serverConfig.Notification,
 		&serverConfig.NgnStates,
 		&serverConfig.AWSConfig,
+		&serverConfig.Payment,
+		&serverConfig.Checkout,
+		&serverConfig.Subscription,
+		&serverConfig.Invoice,
+		&serverConfig.Export,
+		&serverConfig.Slot,
+		&serverConfig.Ticket,
 	}
 
 	for _, target := range targets {
@@ -2,8 +2,14 @@ package pkg
 
 // email template IDs
 const (
-	EarlyAccessTemplatePath    = "early_access.page.gohtml"
-	ForgotPasswordTemplatePath = "forgot_password.page.gohtml"
-	AdminSignUpTemplatePath    = "admin_signup.page.gohtml"
-	VerifySignUPTemplatePath   = "verify_signup.page.gohtml"
+	EarlyAccessTemplatePath          = "early_access.page.gohtml"
+	ForgotPasswordTemplatePath       = "forgot_password.page.gohtml"
+	AdminSignUpTemplatePath          = "admin_signup.page.gohtml"
+	VerifySignUPTemplatePath         = "verify_signup.page.gohtml"
+	SubscriptionReminderTemplatePath = "subscription_reminder.page.gohtml"
+	OrderCancelledTemplatePath       = "order_cancelled.page.gohtml"
+	RefundStatusTemplatePath         = "refund_status.page.gohtml"
+	OrderCompletedTemplatePath       = "order_completed.page.gohtml"
+	OrderShippedTemplatePath         = "order_shipped.page.gohtml"
+	TicketMessageTemplatePath        = "ticket_message.page.gohtml"
 )
@@ -45,8 +45,13 @@ func BuildMongoFilterQuery(requestFilter *filter.Request, fieldMapping map[strin
 			fieldName = field.Name
 		}
 
-		if field.Operator == filter.CompareOperatorContains {
+		switch field.Operator {
+		case filter.CompareOperatorContains:
 			return bson.M{fieldName: bson.M{"$in": field.Value}}
+		case filter.CompareOperatorIsGreaterThanOrEqualTo:
+			return bson.M{fieldName: bson.M{"$gte": field.Value}}
+		case filter.CompareOperatorIsLessThanOrEqualTo:
+			return bson.M{fieldName: bson.M{"$lte": field.Value}}
 		}
 		return bson.M{fieldName: field.Value}
 	}
@@ -56,6 +61,15 @@ func BuildMongoFilterQuery(requestFilter *filter.Request, fieldMapping map[strin
 		for _, field := range requestFilter.Fields {
 			fieldQuery := buildFieldQuery(field)
 			for key, value := range fieldQuery {
+				// range conditions on the same field, such as a date range, are combined rather than replaced
+				existing, existingIsOperator := query[key].(bson.M)
+				operators, isOperator := value.(bson.M)
+				if existingIsOperator && isOperator {
+					for operator, operand := range operators {
+						existing[operator] = operand
+					}
+					continue
+				}
 				query[key] = value
 			}
 		}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/leetatech/leeta_backend/services/models"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"time"
+)
+
+// Migration converts the documents stored before a change of their shape
+type Migration struct {
+	// ID records the migration once it ran, it must not change afterwards
+	ID      string
+	Migrate func(ctx context.Context) error
+}
+
+type migrationRecord struct {
+	ID string `bson:"_id"`
+	Ts int64  `bson:"ts"`
+}
+
+// Migrate runs, in order, the migrations that have not run on the database yet. A migration is recorded once it succeeded,
+// so later starts skip it without scanning the collections it converted. Migrations must be safe to run again, as
+// applications starting together may both run one before either recorded it.
+func Migrate(ctx context.Context, client *mongo.Client, databaseName string, migrations ...Migration) error {
+	col := client.Database(databaseName).Collection(models.MigrationsCollectionName)
+
+	for _, migration := range migrations {
+		err := col.FindOne(ctx, bson.M{"_id": migration.ID}).Err()
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return fmt.Errorf("error checking migration %s: %w", migration.ID, err)
+		}
+
+		log.Info().Msgf("running migration %s", migration.ID)
+		err = migration.Migrate(ctx)
+		if err != nil {
+			return fmt.Errorf("error running migration %s: %w", migration.ID, err)
+		}
+
+		_, err = col.InsertOne(ctx, migrationRecord{ID: migration.ID, Ts: time.Now().Unix()})
+		if err != nil && !mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("error recording migration %s: %w", migration.ID, err)
+		}
+	}
+
+	return nil
+}
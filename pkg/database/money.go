@@ -0,0 +1,117 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"github.com/leetatech/leeta_backend/services/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"strings"
+)
+
+// moneyFields are the amounts of each collection that were stored as numbers of major units before amounts were kept as
+// models.Money. Fields of array elements are written array.field.
+var moneyFields = map[string][]string{
+	models.FeesCollectionName:      {"cost.cost_per_kg", "cost.cost_per_qty", "cost.cost_per_type"},
+	models.CartsCollectionName:     {"total", "cart_items.cost", "cart_items.deposit"},
+	models.CheckoutsCollectionName: {"total"},
+	models.OrderCollectionName: {
+		"delivery_fee", "service_fee", "total", "cancellation_fee", "orders.cost", "orders.deposit",
+		"discount.items_discount", "discount.delivery_discount", "discount.total", "deposit_returns.amount",
+	},
+	models.PaymentsCollectionName: {"amount"},
+	models.RefundsCollectionName:  {"order_total", "cancellation_fee", "amount"},
+	models.InvoicesCollectionName: {
+		"items_total", "delivery_fee", "service_fee", "discount", "subtotal", "vat", "total",
+		"lines.unit_price", "lines.amount",
+	},
+	models.ProductCollectionName:              {"original_price", "vat", "original_price_and_vat", "discount_price", "final_price"},
+	models.PromotionsCollectionName:           {"amount", "max_discount"},
+	models.PromotionRedemptionsCollectionName: {"discount"},
+	models.TicketsCollectionName:              {"resolution.refund_amount"},
+}
+
+// arrayFields are the arrays holding amounts, the other dotted fields are embedded documents
+var arrayFields = map[string]bool{"cart_items": true, "orders": true, "deposit_returns": true, "lines": true}
+
+// MigrateMoney converts the amounts stored as numbers of major units to models.Money, rounding them half away from zero to
+// the minor unit. Payments and invoices kept their currency in a field of their own, which is moved into their amounts,
+// other amounts are in the payment currency. It runs once through Migrate, and only converts numbers, so running it again
+// leaves converted amounts as they are.
+func MigrateMoney(ctx context.Context, client *mongo.Client, databaseName, currency string) error {
+	db := client.Database(databaseName)
+
+	// fixed amount promotions kept their amount in value, which is now only the percentage of percentage promotions
+	_, err := db.Collection(models.PromotionsCollectionName).UpdateMany(ctx,
+		bson.M{"type": models.PromotionFixedAmount, "value": bson.M{"$type": "number"}},
+		mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{"amount": "$value"}}},
+			{{Key: "$unset", Value: "value"}},
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("error migrating fixed amount promotions: %w", err)
+	}
+
+	for collection, fields := range moneyFields {
+		documentCurrency := any(currency)
+		if collection == models.PaymentsCollectionName || collection == models.InvoicesCollectionName {
+			documentCurrency = bson.M{"$ifNull": bson.A{"$currency", currency}}
+		}
+
+		for _, field := range fields {
+			err := migrateMoneyField(ctx, db.Collection(collection), field, documentCurrency)
+			if err != nil {
+				return fmt.Errorf("error migrating %s of %s: %w", field, collection, err)
+			}
+		}
+
+		if collection == models.PaymentsCollectionName || collection == models.InvoicesCollectionName {
+			_, err := db.Collection(collection).UpdateMany(ctx,
+				bson.M{"currency": bson.M{"$exists": true}},
+				bson.M{"$unset": bson.M{"currency": ""}},
+			)
+			if err != nil {
+				return fmt.Errorf("error removing the currency of %s: %w", collection, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// migrateMoneyField converts the field on the documents still holding a number in it. Documents without the field are
+// left as they are, so amounts stored with omitempty stay absent.
+func migrateMoneyField(ctx context.Context, col *mongo.Collection, field string, currency any) error {
+	filter := bson.M{field: bson.M{"$type": "number"}}
+
+	var update bson.M
+	array, element, found := strings.Cut(field, ".")
+	if found && arrayFields[array] {
+		update = bson.M{array: bson.M{"$map": bson.M{
+			"input": "$" + array,
+			"in": bson.M{"$mergeObjects": bson.A{
+				"$$this",
+				bson.M{element: majorToMoney("$$this."+element, currency)},
+			}},
+		}}}
+	} else {
+		update = bson.M{field: majorToMoney("$"+field, currency)}
+	}
+
+	_, err := col.UpdateMany(ctx, filter, mongo.Pipeline{{{Key: "$set", Value: update}}})
+	return err
+}
+
+// majorToMoney converts a number of major units to an amount of minor units, rounding half away from zero like
+// models.MoneyFromMajor. Values that are not numbers, such as amounts already converted, are returned unchanged.
+func majorToMoney(value string, currency any) bson.M {
+	half := bson.M{"$cond": bson.A{bson.M{"$lt": bson.A{value, 0}}, -0.5, 0.5}}
+	amount := bson.M{"$toLong": bson.M{"$trunc": bson.M{"$add": bson.A{bson.M{"$multiply": bson.A{value, 100}}, half}}}}
+
+	return bson.M{"$cond": bson.A{
+		bson.M{"$isNumber": value},
+		bson.M{"amount": amount, "currency": currency},
+		value,
+	}}
+}
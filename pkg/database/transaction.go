@@ -0,0 +1,37 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Transactor runs a unit of work spanning several repositories in a single mongo transaction.
+// Repositories take part in the transaction by using the context passed to the unit of work.
+// Transactions require mongo to run as a replica set.
+type Transactor interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type mongoTransactor struct {
+	client *mongo.Client
+}
+
+func NewTransactor(client *mongo.Client) Transactor {
+	return &mongoTransactor{client: client}
+}
+
+// WithTransaction commits the writes made by fn, or aborts them all when fn returns an error.
+// fn may be retried on transient transaction errors, so it must not have side effects outside the database.
+func (t *mongoTransactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := t.client.StartSession()
+	if err != nil {
+		return fmt.Errorf("error starting mongo session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessionCtx)
+	})
+	return err
+}
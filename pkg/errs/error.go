@@ -43,7 +43,6 @@ const (
 	InvalidIdentityError         ErrorCode = 1031
 	InvalidOTPError              ErrorCode = 1032
 	CartStatusesError            ErrorCode = 1033
-	AmountPaidError              ErrorCode = 1034
 	FeesStatusesError            ErrorCode = 1035
 	InvalidPageRequestError      ErrorCode = 1036
 	CartItemQuantityError        ErrorCode = 1037
@@ -51,8 +50,6 @@ const (
 	InvalidRequestError          ErrorCode = 1039 // generic
 	InternalError                ErrorCode = 1040
 	InvalidProductIdError        ErrorCode = 1041
-	InvalidDeliveryFeeError      ErrorCode = 1042
-	InvalidServiceFeeError       ErrorCode = 1043
 	RestrictedAccessError        ErrorCode = 1044
 	FeesError                    ErrorCode = 1045
 	TemplateCreationError        ErrorCode = 1046
@@ -60,6 +57,23 @@ const (
 	SesSendEmailError            ErrorCode = 1048
 	SnsSendSMSError              ErrorCode = 1049
 	LGANotFoundError             ErrorCode = 1050
+	OrderStatusTransitionError   ErrorCode = 1051
+	OrderStatusConflictError     ErrorCode = 1052
+	PaymentError                 ErrorCode = 1053
+	PaymentMethodError           ErrorCode = 1054
+	WebhookSignatureError        ErrorCode = 1055
+	InvalidQuoteError            ErrorCode = 1056
+	IdempotencyKeyError          ErrorCode = 1057
+	RefundStatusTransitionError  ErrorCode = 1058
+	ExportJobNotReadyError       ErrorCode = 1059
+	SlotUnavailableError         ErrorCode = 1060
+	HandoverCodeError            ErrorCode = 1061
+	ReviewExistsError            ErrorCode = 1062
+	TicketStatusTransitionError  ErrorCode = 1063
+	TicketStatusConflictError    ErrorCode = 1064
+	PromotionError               ErrorCode = 1065
+	PromotionExistsError         ErrorCode = 1066
+	FeeScheduleConflictError     ErrorCode = 1067
 )
 
 var (
@@ -97,7 +111,6 @@ var (
 		InvalidIdentityError:         "InvalidIdentityError",
 		InvalidOTPError:              "InvalidOTPError",
 		CartStatusesError:            "CartStatusesError",
-		AmountPaidError:              "AmountPaidError",
 		FeesStatusesError:            "FeesStatusesError",
 		InvalidPageRequestError:      "InvalidPageRequestError",
 		CartItemQuantityError:        "CartItemQuantityError",
@@ -105,8 +118,6 @@ var (
 		InvalidRequestError:          "InvalidRequestError",
 		InternalError:                "InternalError",
 		InvalidProductIdError:        "InvalidProductIdError",
-		InvalidDeliveryFeeError:      "InvalidDeliveryFeeError",
-		InvalidServiceFeeError:       "InvalidServiceFeeError",
 		RestrictedAccessError:        "RestrictedAccessError",
 		FeesError:                    "FeesError",
 		TemplateCreationError:        "TemplateCreationError",
@@ -114,6 +125,23 @@ var (
 		SesSendEmailError:            "SesSendEmailError",
 		SnsSendSMSError:              "SnsSendSMSError",
 		LGANotFoundError:             "LGANotFoundError",
+		OrderStatusTransitionError:   "OrderStatusTransitionError",
+		OrderStatusConflictError:     "OrderStatusConflictError",
+		PaymentError:                 "PaymentError",
+		PaymentMethodError:           "PaymentMethodError",
+		WebhookSignatureError:        "WebhookSignatureError",
+		InvalidQuoteError:            "InvalidQuoteError",
+		IdempotencyKeyError:          "IdempotencyKeyError",
+		RefundStatusTransitionError:  "RefundStatusTransitionError",
+		ExportJobNotReadyError:       "ExportJobNotReadyError",
+		SlotUnavailableError:         "SlotUnavailableError",
+		HandoverCodeError:            "HandoverCodeError",
+		ReviewExistsError:            "ReviewExistsError",
+		TicketStatusTransitionError:  "TicketStatusTransitionError",
+		TicketStatusConflictError:    "TicketStatusConflictError",
+		PromotionError:               "PromotionError",
+		PromotionExistsError:         "PromotionExistsError",
+		FeeScheduleConflictError:     "FeeScheduleConflictError",
 	}
 
 	errorMessages = map[ErrorCode]string{
@@ -150,7 +178,6 @@ var (
 		InvalidIdentityError:         "An error occurred because the user identity data is invalid",
 		InvalidOTPError:              "An error occurred because the OTP is invalid",
 		CartStatusesError:            "An error occurred because the cart status is invalid",
-		AmountPaidError:              "An error occurred because the amount paid is invalid",
 		FeesStatusesError:            "An error occurred because the fees status is invalid",
 		InvalidPageRequestError:      "An error occurred because the page request field is required",
 		CartItemQuantityError:        "An error occurred because the stored cart item quantity/weight is already 0. Please delete the item or increase the quantity to continue",
@@ -158,8 +185,6 @@ var (
 		InvalidRequestError:          "An error occurred because the request is invalid",
 		InternalError:                "An error has occurred in the server",
 		InvalidProductIdError:        "An error occurred because the product id is invalid",
-		InvalidDeliveryFeeError:      "An error occurred because the delivery fee is invalid",
-		InvalidServiceFeeError:       "An error occurred because the service fee is invalid",
 		RestrictedAccessError:        "User do not have authorization to access this endpoint",
 		FeesError:                    "There is an error with the application fees",
 		TemplateCreationError:        "An error occurred while creating template",
@@ -167,6 +192,23 @@ var (
 		AwsSessionError:              "An error occurred while creating aws session",
 		SesSendEmailError:            "An error occurred while sending email",
 		LGANotFoundError:             "Leeta is not available in your region",
+		OrderStatusTransitionError:   "An error occurred because the order status transition is not allowed",
+		OrderStatusConflictError:     "An error occurred because the order status was changed by another request. Please refresh the order and try again",
+		PaymentError:                 "An error occurred while processing the payment",
+		PaymentMethodError:           "An error occurred because the payment method is invalid",
+		WebhookSignatureError:        "An error occurred because the webhook signature is invalid",
+		InvalidQuoteError:            "An error occurred because the checkout quote is invalid or has expired. Please request a new quote",
+		IdempotencyKeyError:          "An error occurred because the idempotency key was already used for another request or its request is still being processed",
+		RefundStatusTransitionError:  "An error occurred because the refund cannot be moved to the requested status",
+		ExportJobNotReadyError:       "An error occurred because the export job has not completed",
+		SlotUnavailableError:         "An error occurred because the delivery slot cannot take more orders",
+		HandoverCodeError:            "An error occurred because the handover code is missing or does not match",
+		ReviewExistsError:            "An error occurred because the order has already been reviewed",
+		TicketStatusTransitionError:  "An error occurred because the ticket cannot move to the requested status",
+		TicketStatusConflictError:    "An error occurred because the ticket was updated by someone else",
+		PromotionError:               "An error occurred because the promo code cannot be applied",
+		PromotionExistsError:         "An error occurred because a promotion with the code already exists",
+		FeeScheduleConflictError:     "An error occurred because another fee already takes effect at that time",
 	}
 )
 
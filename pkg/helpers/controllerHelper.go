@@ -12,15 +12,18 @@ func CheckErrorType(err error, w http.ResponseWriter) {
 	switch {
 	case errors.As(err, &lerr):
 		switch lerr.ErrorCode {
-		case errs.ErrorUnauthorized:
+		case errs.ErrorUnauthorized, errs.WebhookSignatureError:
 			jwtmiddleware.WriteJSONErrorResponse(w, http.StatusUnauthorized, err)
 			return
 		case errs.DatabaseNoRecordError, errs.LGANotFoundError:
 			jwtmiddleware.WriteJSONErrorResponse(w, http.StatusNotFound, err)
 			return
-		case errs.InvalidRequestError:
+		case errs.InvalidRequestError, errs.OrderStatusesError, errs.OrderStatusTransitionError, errs.PaymentMethodError, errs.InvalidQuoteError, errs.RefundStatusTransitionError, errs.HandoverCodeError, errs.TicketStatusTransitionError, errs.PromotionError:
 			jwtmiddleware.WriteJSONErrorResponse(w, http.StatusBadRequest, err)
 			return
+		case errs.OrderStatusConflictError, errs.IdempotencyKeyError, errs.ExportJobNotReadyError, errs.SlotUnavailableError, errs.ReviewExistsError, errs.TicketStatusConflictError, errs.PromotionExistsError, errs.FeeScheduleConflictError:
+			jwtmiddleware.WriteJSONErrorResponse(w, http.StatusConflict, err)
+			return
 		default:
 			jwtmiddleware.WriteJSONErrorResponse(w, http.StatusInternalServerError, err)
 			return
@@ -0,0 +1,34 @@
+package helpers
+
+import (
+	"github.com/leetatech/leeta_backend/services/models"
+	"math"
+)
+
+const earthRadiusKm = 6371.0
+
+// HaversineDistanceKm returns the great-circle distance in kilometres between two points given in degrees
+func HaversineDistanceKm(fromLatitude, fromLongitude, toLatitude, toLongitude float64) float64 {
+	toRadians := func(degrees float64) float64 {
+		return degrees * math.Pi / 180
+	}
+
+	latitudeDelta := toRadians(toLatitude - fromLatitude)
+	longitudeDelta := toRadians(toLongitude - fromLongitude)
+
+	a := math.Sin(latitudeDelta/2)*math.Sin(latitudeDelta/2) +
+		math.Cos(toRadians(fromLatitude))*math.Cos(toRadians(toLatitude))*math.Sin(longitudeDelta/2)*math.Sin(longitudeDelta/2)
+
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+// DeliveryCost prices a delivery with a delivery fee. Fees with bands price it by the distance from the vendor to the
+// customer and the weight in kg delivered, the flat cost per type is charged when either location is unknown.
+func DeliveryCost(fee *models.Fee, vendor, customer models.Coordinates, weightKg float64) models.Money {
+	if fee.Bands == nil || vendor == (models.Coordinates{}) || customer == (models.Coordinates{}) {
+		return fee.Cost.CostPerType
+	}
+
+	distanceKm := HaversineDistanceKm(vendor.Latitude, vendor.Longitude, customer.Latitude, customer.Longitude)
+	return fee.Bands.Cost(distanceKm, weightKg)
+}
@@ -0,0 +1,71 @@
+package helpers
+
+import (
+	"github.com/leetatech/leeta_backend/services/models"
+	"math"
+	"testing"
+)
+
+func TestHaversineDistanceKm(t *testing.T) {
+	tests := []struct {
+		name                        string
+		fromLatitude, fromLongitude float64
+		toLatitude, toLongitude     float64
+		want                        float64
+	}{
+		{name: "same point", fromLatitude: 6.5244, fromLongitude: 3.3792, toLatitude: 6.5244, toLongitude: 3.3792, want: 0},
+		{name: "one degree of longitude on the equator", toLongitude: 1, want: 111.19},
+		{name: "one degree of latitude", toLatitude: 1, want: 111.19},
+		{name: "lagos island to ikeja", fromLatitude: 6.4541, fromLongitude: 3.3947, toLatitude: 6.6018, toLongitude: 3.3515, want: 17.1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := HaversineDistanceKm(tt.fromLatitude, tt.fromLongitude, tt.toLatitude, tt.toLongitude)
+			if math.Abs(got-tt.want) > 0.1 {
+				t.Errorf("HaversineDistanceKm() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeliveryCost(t *testing.T) {
+	bands := &models.DeliveryBands{
+		Distance: []models.DeliveryBand{
+			{UpTo: 5, Cost: models.NewMoney(100000, "NGN")},
+			{UpTo: 20, Cost: models.NewMoney(250000, "NGN")},
+		},
+		Weight: []models.DeliveryBand{
+			{UpTo: 12.5, Cost: models.NewMoney(0, "NGN")},
+			{UpTo: 50, Cost: models.NewMoney(50000, "NGN")},
+		},
+	}
+	flat := models.Cost{CostPerType: models.NewMoney(200000, "NGN")}
+	vendor := models.Coordinates{Latitude: 6.4541, Longitude: 3.3947}
+	near := models.Coordinates{Latitude: 6.4700, Longitude: 3.4000}
+	far := models.Coordinates{Latitude: 6.6018, Longitude: 3.3515}
+
+	tests := []struct {
+		name     string
+		fee      *models.Fee
+		vendor   models.Coordinates
+		customer models.Coordinates
+		weightKg float64
+		want     int64
+	}{
+		{name: "flat fee without bands", fee: &models.Fee{Cost: flat}, vendor: vendor, customer: far, weightKg: 12.5, want: 200000},
+		{name: "near and light", fee: &models.Fee{Cost: flat, Bands: bands}, vendor: vendor, customer: near, weightKg: 12.5, want: 100000},
+		{name: "far and heavy", fee: &models.Fee{Cost: flat, Bands: bands}, vendor: vendor, customer: far, weightKg: 25, want: 300000},
+		{name: "flat fee without a vendor location", fee: &models.Fee{Cost: flat, Bands: bands}, customer: far, weightKg: 25, want: 200000},
+		{name: "flat fee without a customer location", fee: &models.Fee{Cost: flat, Bands: bands}, vendor: vendor, weightKg: 25, want: 200000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DeliveryCost(tt.fee, tt.vendor, tt.customer, tt.weightKg)
+			if got.Amount != tt.want || got.Currency != "NGN" {
+				t.Errorf("DeliveryCost() = %v, want %d NGN", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,5 +3,11 @@ package helpers
 import "math"
 
 func RoundToTwoDecimalPlaces(num float64) float64 {
-	return math.Round(num*100) / 100
+	return RoundToDecimalPlaces(num, 2)
+}
+
+// RoundToDecimalPlaces rounds the number half away from zero to the given number of decimal places
+func RoundToDecimalPlaces(num float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(num*scale) / scale
 }
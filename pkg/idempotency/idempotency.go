@@ -0,0 +1,216 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/pkg/jwtmiddleware"
+	"github.com/rs/zerolog/log"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// HeaderKey is the request header clients set to the same value when retrying a request
+	HeaderKey = "Idempotency-Key"
+	// ReplayedHeader is set on responses replayed from a previous request with the same key
+	ReplayedHeader = "Idempotent-Replayed"
+
+	maxKeyLength    = 255
+	maxBodyBytes    = 1 << 20
+	lockTimeout     = time.Minute
+	storeTimeout    = 5 * time.Second
+	anonymousUserID = "anonymous"
+)
+
+type Middleware struct {
+	store      Store
+	jwtManager *jwtmiddleware.Manager
+	ttl        time.Duration
+}
+
+func New(store Store, jwtManager *jwtmiddleware.Manager, ttl time.Duration) *Middleware {
+	return &Middleware{store: store, jwtManager: jwtManager, ttl: ttl}
+}
+
+// Handle replays the stored response when a request is retried with the same Idempotency-Key, instead of processing it again.
+// Keys are scoped to the authenticated user, so Handle must run after the authentication middleware on authenticated routes.
+// Unauthenticated requests are scoped to the client address. Responses are stored, so routes returning credentials use
+// HandleCredentials instead.
+// Requests without the header are processed as usual. Server errors are not stored, so the request can be retried.
+func (m *Middleware) Handle(next http.Handler) http.Handler {
+	return m.handle(next, nil)
+}
+
+// Reissuer answers the retry of a request that authenticated the user with the id, with credentials issued again
+type Reissuer func(w http.ResponseWriter, r *http.Request, userID string)
+
+// HandleCredentials is Handle for routes returning credentials, such as sign up. The credentials are never stored: the
+// handler records the user it authenticated with SetSubject, and a retry is answered by reissue for that user.
+func (m *Middleware) HandleCredentials(reissue Reissuer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return m.handle(next, reissue)
+	}
+}
+
+type subjectKey struct{}
+
+// SetSubject records the user the request authenticated, on routes handled with HandleCredentials
+func SetSubject(ctx context.Context, userID string) {
+	if subject, ok := ctx.Value(subjectKey{}).(*string); ok {
+		*subject = userID
+	}
+}
+
+func (m *Middleware) handle(next http.Handler, reissue Reissuer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(HeaderKey)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if len(key) > maxKeyLength {
+			jwtmiddleware.WriteJSONErrorResponse(w, http.StatusBadRequest, errs.Body(errs.InvalidRequestError, fmt.Errorf("%s cannot be longer than %d characters", HeaderKey, maxKeyLength)))
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+		if err != nil {
+			jwtmiddleware.WriteJSONErrorResponse(w, http.StatusBadRequest, errs.Body(errs.InvalidRequestError, fmt.Errorf("error reading request body: %w", err)))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		userID := anonymousUserID + ":" + clientAddress(r)
+		if claims, err := m.jwtManager.ExtractUserClaims(r.Context()); err == nil {
+			userID = claims.UserID
+		}
+
+		now := time.Now()
+		requestFingerprint := fingerprint(r, body)
+		existing, reserved, err := m.store.Reserve(r.Context(), Record{
+			UserID:      userID,
+			Key:         key,
+			Fingerprint: requestFingerprint,
+			LockedUntil: now.Add(lockTimeout),
+			ExpiresAt:   now.Add(m.ttl),
+		})
+		if err != nil {
+			jwtmiddleware.WriteJSONErrorResponse(w, http.StatusInternalServerError, errs.Body(errs.DatabaseError, fmt.Errorf("error reserving idempotency key: %w", err)))
+			return
+		}
+
+		if !reserved {
+			replay(w, r, requestFingerprint, existing, reissue)
+			return
+		}
+
+		subject := new(string)
+		if reissue != nil {
+			r = r.WithContext(context.WithValue(r.Context(), subjectKey{}, subject))
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		// the response was already sent, failing to store it only means a retry is processed again. It is stored even when the
+		// client disconnected before receiving it, so the retry of a dropped connection replays it instead of processing it again.
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), storeTimeout)
+		defer cancel()
+		switch {
+		case recorder.statusCode >= http.StatusInternalServerError:
+			err = m.store.Release(ctx, userID, key)
+		case reissue != nil && isSuccess(recorder.statusCode):
+			// the user id is stored in place of the credentials, a success without one cannot be replayed
+			if *subject == "" {
+				err = m.store.Release(ctx, userID, key)
+				break
+			}
+			err = m.store.Complete(ctx, userID, key, recorder.statusCode, "", []byte(*subject))
+		default:
+			err = m.store.Complete(ctx, userID, key, recorder.statusCode, recorder.Header().Get("Content-Type"), recorder.body.Bytes())
+		}
+		if err != nil {
+			log.Error().Msgf("error storing response for idempotency key %s: %v", key, err)
+		}
+	})
+}
+
+func replay(w http.ResponseWriter, r *http.Request, requestFingerprint string, record *Record, reissue Reissuer) {
+	if record.Fingerprint != requestFingerprint {
+		jwtmiddleware.WriteJSONErrorResponse(w, http.StatusConflict, errs.Body(errs.IdempotencyKeyError, errors.New("idempotency key was used for a different request")))
+		return
+	}
+
+	if !record.Completed {
+		jwtmiddleware.WriteJSONErrorResponse(w, http.StatusConflict, errs.Body(errs.IdempotencyKeyError, errors.New("a request with this idempotency key is still being processed")))
+		return
+	}
+
+	w.Header().Set(ReplayedHeader, strconv.FormatBool(true))
+	if reissue != nil && isSuccess(record.StatusCode) {
+		reissue(w, r, string(record.Body))
+		return
+	}
+
+	if record.ContentType != "" {
+		w.Header().Set("Content-Type", record.ContentType)
+	}
+	w.WriteHeader(record.StatusCode)
+	_, err := w.Write(record.Body)
+	if err != nil {
+		log.Error().Msgf("error replaying response: %v", err)
+	}
+}
+
+func isSuccess(statusCode int) bool {
+	return statusCode >= http.StatusOK && statusCode < http.StatusMultipleChoices
+}
+
+// clientAddress is the address the request came from, without its port
+func clientAddress(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// fingerprint identifies a request by its method, path and body
+func fingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method))
+	hash.Write([]byte(r.URL.Path))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder keeps a copy of the response written to the client
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	if !r.wroteHeader {
+		r.statusCode = statusCode
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,122 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/leetatech/leeta_backend/services/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+// Record is the request fingerprint and, once the request completed, the response stored for an idempotency key
+type Record struct {
+	UserID      string `bson:"user_id"`
+	Key         string `bson:"key"`
+	Fingerprint string `bson:"fingerprint"`
+	Completed   bool   `bson:"completed"`
+	StatusCode  int    `bson:"status_code"`
+	ContentType string `bson:"content_type"`
+	// Body is the response body, or the id of the user authenticated by a successful request returning credentials
+	Body []byte `bson:"body"`
+	// LockedUntil lets a retry take over a request whose processing was abandoned before it completed
+	LockedUntil time.Time `bson:"locked_until"`
+	ExpiresAt   time.Time `bson:"expires_at"`
+}
+
+type Store interface {
+	// Reserve stores the record for a new key. When the key is already used, it returns the stored record and false.
+	Reserve(ctx context.Context, record Record) (*Record, bool, error)
+	Complete(ctx context.Context, userID, key string, statusCode int, contentType string, body []byte) error
+	Release(ctx context.Context, userID, key string) error
+}
+
+type mongoStore struct {
+	client       *mongo.Client
+	databaseName string
+}
+
+func NewStore(client *mongo.Client, databaseName string) Store {
+	return &mongoStore{client: client, databaseName: databaseName}
+}
+
+func (s *mongoStore) col() *mongo.Collection {
+	return s.client.Database(s.databaseName).Collection(models.IdempotencyCollectionName)
+}
+
+// EnsureIndexes makes keys unique per user and lets mongo remove records once they expire
+func EnsureIndexes(ctx context.Context, client *mongo.Client, databaseName string) error {
+	_, err := client.Database(databaseName).Collection(models.IdempotencyCollectionName).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "key", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error creating idempotency key indexes: %w", err)
+	}
+
+	return nil
+}
+
+func (s *mongoStore) Reserve(ctx context.Context, record Record) (*Record, bool, error) {
+	_, err := s.col().InsertOne(ctx, record)
+	if err == nil {
+		return nil, true, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, false, err
+	}
+
+	// take over the key when the same request was abandoned while in progress, or the record expired but was not removed yet
+	now := time.Now()
+	filter := bson.M{
+		"user_id": record.UserID,
+		"key":     record.Key,
+		"$or": bson.A{
+			bson.M{"completed": false, "fingerprint": record.Fingerprint, "locked_until": bson.M{"$lt": now}},
+			bson.M{"expires_at": bson.M{"$lt": now}},
+		},
+	}
+	result, err := s.col().ReplaceOne(ctx, filter, record)
+	if err != nil {
+		return nil, false, err
+	}
+	if result.MatchedCount == 1 {
+		return nil, true, nil
+	}
+
+	existing := &Record{}
+	err = s.col().FindOne(ctx, bson.M{"user_id": record.UserID, "key": record.Key}).Decode(existing)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			// the record expired in the meantime, the client can retry with the key
+			return nil, false, fmt.Errorf("idempotency key %s was released while reserving it", record.Key)
+		}
+		return nil, false, err
+	}
+
+	return existing, false, nil
+}
+
+func (s *mongoStore) Complete(ctx context.Context, userID, key string, statusCode int, contentType string, body []byte) error {
+	update := bson.M{"$set": bson.M{
+		"completed":    true,
+		"status_code":  statusCode,
+		"content_type": contentType,
+		"body":         body,
+	}}
+	_, err := s.col().UpdateOne(ctx, bson.M{"user_id": userID, "key": key}, update)
+	return err
+}
+
+func (s *mongoStore) Release(ctx context.Context, userID, key string) error {
+	_, err := s.col().DeleteOne(ctx, bson.M{"user_id": userID, "key": key, "completed": false})
+	return err
+}
@@ -124,6 +124,20 @@ func (handler *Manager) ValidateMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// ValidateOptionalMiddleware puts the claims of the token on the context when the request has one, for public endpoints
+// that behave differently for a signed in session such as a guest signing in. Requests with an invalid token are rejected.
+func (handler *Manager) ValidateOptionalMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorizationHeader := r.Header.Get("authorization")
+		if authorizationHeader == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		handler.validateHeaderToken(authorizationHeader, next, w, r, false)
+	})
+}
+
 // ValidateRestrictedAccessMiddleware middleware required endpoints: verify claims
 // extensively check if they have superior access to these endpoints
 // and put claims on context
@@ -161,8 +175,8 @@ func (handler *Manager) validateHeaderToken(authorizationHeader string, next htt
 		}
 
 		if isAdminPrivileged {
-			// validate that user if user has permission to access the endpoint
-			if claims.Role == models.CustomerCategory {
+			// only vendors and admins have access to restricted endpoints
+			if claims.Role != models.VendorCategory && claims.Role != models.AdminCategory {
 				WriteJSONResponse(w, errs.Body(errs.RestrictedAccessError, err), http.StatusUnauthorized)
 				return
 			}
@@ -1,12 +1,18 @@
 package mailer
 
 import (
+	"bytes"
+	"encoding/base64"
 	"fmt"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ses"
 	"github.com/leetatech/leeta_backend/pkg/notification"
 	"github.com/leetatech/leeta_backend/pkg/notification/mailer/parseTemplates"
 	"github.com/leetatech/leeta_backend/services/models"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
 )
 
 type Client struct {
@@ -38,6 +44,11 @@ func (client *Client) Send(templatePath string, message models.Message) error {
 
 	validCcRecipients := filterValidEmails(message.CcRecipients)
 	validBccRecipients := filterValidEmails(message.BccRecipients)
+	source := fmt.Sprintf("Leeta Technologies <%s>", message.Sender)
+
+	if len(message.Attachments) > 0 {
+		return client.sendRaw(source, message, templateBody, validRecipients, validCcRecipients, validBccRecipients)
+	}
 
 	emailInput := &ses.SendEmailInput{
 		Destination: &ses.Destination{
@@ -57,7 +68,7 @@ func (client *Client) Send(templatePath string, message models.Message) error {
 				Data:    aws.String(message.Title),
 			},
 		},
-		Source: aws.String(fmt.Sprintf("Leeta Technologies <%s>", message.Sender)),
+		Source: aws.String(source),
 	}
 
 	_, err = client.Client.SES.SendEmail(emailInput) // TODO: enhance response validation
@@ -68,6 +79,79 @@ func (client *Client) Send(templatePath string, message models.Message) error {
 	return nil
 }
 
+// sendRaw sends the email as a multipart MIME message, which SES requires to carry attachments
+func (client *Client) sendRaw(source string, message models.Message, templateBody string, recipients, ccRecipients, bccRecipients []string) error {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	fmt.Fprintf(&buf, "From: %s\r\n", source)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(recipients, ", "))
+	if len(ccRecipients) > 0 {
+		fmt.Fprintf(&buf, "Cc: %s\r\n", strings.Join(ccRecipients, ", "))
+	}
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", message.Title))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", writer.Boundary())
+
+	part, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/html; charset=UTF-8"},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create email body: %w", err)
+	}
+	if _, err = part.Write(encodeBase64Lines([]byte(templateBody))); err != nil {
+		return fmt.Errorf("failed to write email body: %w", err)
+	}
+
+	for _, attachment := range message.Attachments {
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {attachment.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create attachment %s: %w", attachment.Filename, err)
+		}
+		if _, err = part.Write(encodeBase64Lines(attachment.Data)); err != nil {
+			return fmt.Errorf("failed to write attachment %s: %w", attachment.Filename, err)
+		}
+	}
+
+	if err = writer.Close(); err != nil {
+		return fmt.Errorf("failed to close email: %w", err)
+	}
+
+	// bcc recipients are only listed as destinations, so they are hidden from the other recipients
+	destinations := append(append(append([]string{}, recipients...), ccRecipients...), bccRecipients...)
+	_, err = client.Client.SES.SendRawEmail(&ses.SendRawEmailInput{
+		Destinations: toStringPointerSlice(destinations),
+		RawMessage:   &ses.RawMessage{Data: buf.Bytes()},
+		Source:       aws.String(source),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send email using aws: %w", err)
+	}
+
+	return nil
+}
+
+// encodeBase64Lines encodes data in base64 wrapped at 76 characters, as MIME requires
+func encodeBase64Lines(data []byte) []byte {
+	encoded := base64.StdEncoding.EncodeToString(data)
+
+	var buf bytes.Buffer
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76])
+		buf.WriteString("\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded)
+	buf.WriteString("\r\n")
+
+	return buf.Bytes()
+}
+
 func filterValidEmails(emails []string) []string {
 	var validEmails []string
 	for _, email := range emails {
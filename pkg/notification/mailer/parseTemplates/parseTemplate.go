@@ -28,7 +28,7 @@ func CreateSingleTemplate(templatePath string, data models.Message) (string, err
 var functions = template.FuncMap{}
 
 func (t *Template) Create(buf io.Writer, fileName string, data any) error {
-	basePath := "pkg/messaging/mailer/templates"
+	basePath := "pkg/notification/mailer/templates"
 	page := filepath.Join(basePath, fileName)
 
 	ts, err := template.New(fileName).Funcs(functions).ParseFiles(page)
@@ -0,0 +1,39 @@
+package cod
+
+import (
+	"context"
+	"errors"
+	"github.com/leetatech/leeta_backend/pkg/payment"
+	"github.com/leetatech/leeta_backend/services/models"
+	"net/http"
+)
+
+const Name = "cash_on_delivery"
+
+// Provider handles cash on delivery payments. The cash is collected by the rider at handover,
+// so there is nothing to charge up front and no webhook to receive.
+type Provider struct{}
+
+func New() *Provider {
+	return &Provider{}
+}
+
+func (p *Provider) Name() string {
+	return Name
+}
+
+func (p *Provider) Initialize(_ context.Context, _ payment.InitializeRequest) (*payment.InitializeResponse, error) {
+	return &payment.InitializeResponse{Status: models.PaymentPending}, nil
+}
+
+func (p *Provider) Verify(_ context.Context, reference string) (*payment.VerifyResponse, error) {
+	return &payment.VerifyResponse{Reference: reference, Status: models.PaymentPending}, nil
+}
+
+func (p *Provider) Refund(_ context.Context, _ payment.RefundRequest) (*payment.RefundResponse, error) {
+	return nil, errors.New("cash on delivery payments cannot be refunded through a provider")
+}
+
+func (p *Provider) ParseWebhook(_ http.Header, _ []byte) (*payment.WebhookEvent, error) {
+	return nil, errors.New("cash on delivery does not receive webhooks")
+}
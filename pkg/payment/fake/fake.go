@@ -0,0 +1,157 @@
+package fake
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/leetatech/leeta_backend/pkg/payment"
+	"github.com/leetatech/leeta_backend/services/models"
+	"net/http"
+	"sync"
+)
+
+const (
+	Name            = "fake"
+	SignatureHeader = "X-Fake-Signature"
+
+	EventChargeSuccess = "charge.success"
+	EventChargeFailed  = "charge.failed"
+)
+
+// Provider is an in-memory payment provider used to exercise the payment flow locally.
+// A payment is confirmed by posting a webhook signed with the configured secret:
+//
+//	body:   {"event": "charge.success", "data": {"reference": "<payment reference>", "amount": 150000, "currency": "NGN"}}
+//
+// Amounts are in the minor unit of the currency, kobo for naira.
+//
+//	header: X-Fake-Signature: hex(hmac-sha512(body, secret))
+type Provider struct {
+	secret       string
+	mu           sync.Mutex
+	transactions map[string]*transaction
+}
+
+type transaction struct {
+	amount models.Money
+	status models.PaymentStatus
+	// refunds are the refunds paid on the transaction by idempotency key
+	refunds  map[string]models.Money
+	refunded models.Money
+}
+
+type webhookBody struct {
+	Event string `json:"event"`
+	Data  struct {
+		Reference string `json:"reference"`
+		Amount    int64  `json:"amount"`
+		Currency  string `json:"currency"`
+	} `json:"data"`
+}
+
+func New(secret string) *Provider {
+	return &Provider{secret: secret, transactions: make(map[string]*transaction)}
+}
+
+func (p *Provider) Name() string {
+	return Name
+}
+
+func (p *Provider) Initialize(_ context.Context, request payment.InitializeRequest) (*payment.InitializeResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.transactions[request.Reference] = &transaction{amount: request.Amount, status: models.PaymentPending}
+
+	return &payment.InitializeResponse{
+		AuthorizationURL: fmt.Sprintf("https://checkout.fake.local/pay/%s", request.Reference),
+		Status:           models.PaymentPending,
+	}, nil
+}
+
+func (p *Provider) Verify(_ context.Context, reference string) (*payment.VerifyResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	txn, ok := p.transactions[reference]
+	if !ok {
+		return nil, fmt.Errorf("transaction %s not found", reference)
+	}
+
+	return &payment.VerifyResponse{Reference: reference, Amount: txn.amount, Status: txn.status}, nil
+}
+
+func (p *Provider) Refund(_ context.Context, request payment.RefundRequest) (*payment.RefundResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	txn, ok := p.transactions[request.Reference]
+	if !ok {
+		return nil, fmt.Errorf("transaction %s not found", request.Reference)
+	}
+
+	if txn.status != models.PaymentSuccessful {
+		return nil, fmt.Errorf("transaction %s has not been paid", request.Reference)
+	}
+
+	if amount, ok := txn.refunds[request.IdempotencyKey]; ok && request.IdempotencyKey != "" {
+		return &payment.RefundResponse{Reference: request.Reference, Amount: amount}, nil
+	}
+
+	if request.Amount.Amount > txn.amount.Amount-txn.refunded.Amount {
+		return nil, fmt.Errorf("refund amount %s exceeds the amount paid and not refunded yet", request.Amount)
+	}
+
+	if txn.refunds == nil {
+		txn.refunds = make(map[string]models.Money)
+	}
+	txn.refunds[request.IdempotencyKey] = request.Amount
+	txn.refunded = txn.refunded.Add(request.Amount)
+
+	return &payment.RefundResponse{Reference: request.Reference, Amount: request.Amount}, nil
+}
+
+func (p *Provider) ParseWebhook(header http.Header, body []byte) (*payment.WebhookEvent, error) {
+	if p.secret == "" {
+		return nil, errors.New("webhook secret is not configured")
+	}
+
+	mac := hmac.New(sha512.New, []byte(p.secret))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(header.Get(SignatureHeader))) {
+		return nil, errors.New("invalid webhook signature")
+	}
+
+	var event webhookBody
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, fmt.Errorf("error decoding webhook body: %w", err)
+	}
+
+	var status models.PaymentStatus
+	switch event.Event {
+	case EventChargeSuccess:
+		status = models.PaymentSuccessful
+	case EventChargeFailed:
+		status = models.PaymentFailed
+	default:
+		return nil, fmt.Errorf("unsupported webhook event %s", event.Event)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	txn, ok := p.transactions[event.Data.Reference]
+	if !ok {
+		// the transaction was initialized before a restart
+		txn = &transaction{amount: models.NewMoney(event.Data.Amount, event.Data.Currency)}
+		p.transactions[event.Data.Reference] = txn
+	}
+	txn.status = status
+
+	return &payment.WebhookEvent{Reference: event.Data.Reference, Status: status}, nil
+}
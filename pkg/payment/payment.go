@@ -0,0 +1,86 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"github.com/leetatech/leeta_backend/services/models"
+	"net/http"
+)
+
+// PaymentProvider is implemented by every gateway checkouts can be paid through
+type PaymentProvider interface {
+	// Name identifies the provider on payment records and in the webhook route
+	Name() string
+	Initialize(ctx context.Context, request InitializeRequest) (*InitializeResponse, error)
+	Verify(ctx context.Context, reference string) (*VerifyResponse, error)
+	Refund(ctx context.Context, request RefundRequest) (*RefundResponse, error)
+	// ParseWebhook authenticates a webhook call made by the provider and extracts the payment event from it
+	ParseWebhook(header http.Header, body []byte) (*WebhookEvent, error)
+}
+
+type InitializeRequest struct {
+	Reference   string
+	Amount      models.Money
+	Email       string
+	CallbackURL string
+}
+
+type InitializeResponse struct {
+	AuthorizationURL string
+	Status           models.PaymentStatus
+}
+
+type VerifyResponse struct {
+	Reference string
+	Amount    models.Money
+	Status    models.PaymentStatus
+}
+
+type RefundRequest struct {
+	Reference string
+	Amount    models.Money
+	Reason    string
+	// IdempotencyKey identifies the refund with the provider, a refund retried with the same key is only paid once
+	IdempotencyKey string
+}
+
+type RefundResponse struct {
+	Reference string
+	Amount    models.Money
+}
+
+type WebhookEvent struct {
+	Reference string
+	Status    models.PaymentStatus
+}
+
+// Providers resolves the provider used for each payment method
+type Providers struct {
+	online         PaymentProvider
+	cashOnDelivery PaymentProvider
+}
+
+func NewProviders(online, cashOnDelivery PaymentProvider) Providers {
+	return Providers{online: online, cashOnDelivery: cashOnDelivery}
+}
+
+func (p Providers) ForMethod(method models.PaymentMethod) (PaymentProvider, error) {
+	switch method {
+	case models.PaymentMethodCashOnDelivery:
+		return p.cashOnDelivery, nil
+	case models.PaymentMethodCard, models.PaymentMethodBankTransfer:
+		return p.online, nil
+	default:
+		return nil, fmt.Errorf("no payment provider configured for payment method %s", method)
+	}
+}
+
+func (p Providers) ByName(name string) (PaymentProvider, error) {
+	for _, provider := range []PaymentProvider{p.online, p.cashOnDelivery} {
+		if provider != nil && provider.Name() == name {
+			return provider, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown payment provider %s", name)
+}
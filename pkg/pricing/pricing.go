@@ -0,0 +1,161 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/leetatech/leeta_backend/pkg"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/pkg/helpers"
+	"github.com/leetatech/leeta_backend/services/models"
+	"go.mongodb.org/mongo-driver/mongo"
+	"strings"
+)
+
+// Pricer prices cart items, and the fees of the orders they are checked out as, with the fees in force at a time.
+// Carts are quoted and subscriptions are ordered through it, so both charge the same.
+type Pricer struct {
+	repositoryManager pkg.RepositoryManager
+}
+
+func New(repositoryManager pkg.RepositoryManager) *Pricer {
+	return &Pricer{repositoryManager: repositoryManager}
+}
+
+// Line is the part of the items a vendor delivers as one order, with the fees charged on it
+type Line struct {
+	VendorID    string
+	Items       []models.CartItem
+	ItemsTotal  models.Money
+	DeliveryFee models.Money
+	ServiceFee  models.Money
+	Total       models.Money
+}
+
+// Price is a set of items priced for delivery to an address
+type Price struct {
+	LGA models.LGA
+	// Location is the delivery address coordinates the delivery fees were priced by distance to, it is zero when they
+	// were priced at the flat lga fee
+	Location models.Coordinates
+	// Items are the items priced, their costs include the cylinder deposits of new cylinder purchases
+	Items []models.CartItem
+	Lines []Line
+	Total models.Money
+}
+
+// AddressLGA is the lga fees are looked up by for an address
+func AddressLGA(address models.Address) models.LGA {
+	return models.LGA{LGA: address.LGA, State: strings.ToUpper(address.State)}
+}
+
+// ItemFees returns the product fee of the item in force at the unix time, and the deposit fee when the item buys new cylinders
+func (p *Pricer) ItemFees(ctx context.Context, item models.CartItem, at int64) (*models.Fee, *models.Fee, error) {
+	fee, err := p.repositoryManager.FeesRepository.ByProductID(ctx, item.ProductID, models.ProductFee, at)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error retrieving fee for product with id '%s': %w", item.ProductID, err)
+	}
+
+	if !item.IsNewCylinder() {
+		return fee, nil, nil
+	}
+
+	depositFee, err := p.repositoryManager.FeesRepository.ByProductID(ctx, item.ProductID, models.DepositFee, at)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error retrieving cylinder deposit fee for product with id '%s': %w", item.ProductID, err)
+	}
+
+	return fee, depositFee, nil
+}
+
+// Price prices every item at the product fee in force at the unix time, with the cylinder deposit of new cylinder
+// purchases, and splits the items into a line per vendor. Every vendor delivers its order separately, so each line is
+// charged the lga delivery fee, priced by the distance from the vendor and the weight delivered when the fee has bands and
+// both locations are known. The service fee is charged once per checkout and split across the lines by their items total.
+func (p *Pricer) Price(ctx context.Context, items []models.CartItem, address models.Address, at int64) (*Price, error) {
+	lga := AddressLGA(address)
+	deliveryFee, err := p.repositoryManager.FeesRepository.ByFeeType(ctx, models.DeliveryFee, lga, at)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errs.Body(errs.LGANotFoundError, fmt.Errorf("no delivery fee for %s, %s", lga.LGA, lga.State))
+		}
+		return nil, errs.Body(errs.FeesError, fmt.Errorf("error getting delivery fee: %w", err))
+	}
+
+	fee, err := p.repositoryManager.FeesRepository.ByFeeType(ctx, models.ServiceFee, models.LGA{}, at)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errs.Body(errs.FeesError, errors.New("no service fee is in force"))
+		}
+		return nil, errs.Body(errs.FeesError, fmt.Errorf("error getting service fee: %w", err))
+	}
+	serviceFee := fee.Cost.CostPerType
+
+	price := &Price{
+		LGA:   lga,
+		Items: make([]models.CartItem, len(items)),
+	}
+	if deliveryFee.Bands != nil {
+		price.Location = address.Coordinates
+	}
+
+	copy(price.Items, items)
+	for i, item := range price.Items {
+		productFee, depositFee, err := p.ItemFees(ctx, item, at)
+		if err != nil {
+			return nil, errs.Body(errs.FeesError, err)
+		}
+
+		price.Items[i].Cost, err = price.Items[i].CalculateCartItemFee(productFee, depositFee)
+		if err != nil {
+			return nil, errs.Body(errs.FeesError, fmt.Errorf("error pricing cart item %s: %w", item.ID, err))
+		}
+	}
+
+	pricedCart := models.Cart{CartItems: price.Items}
+	vendors := pricedCart.GroupItemsByVendor()
+	weights := make([]int64, len(vendors))
+	for i, vendor := range vendors {
+		weights[i] = vendor.Total.Amount
+	}
+	serviceFees := serviceFee.Allocate(weights)
+
+	for i, vendor := range vendors {
+		vendorLocation, err := p.vendorLocation(ctx, deliveryFee, vendor.VendorID)
+		if err != nil {
+			return nil, err
+		}
+
+		line := Line{
+			VendorID:    vendor.VendorID,
+			Items:       vendor.Items,
+			ItemsTotal:  vendor.Total,
+			DeliveryFee: helpers.DeliveryCost(deliveryFee, vendorLocation, address.Coordinates, vendor.Weight()),
+			ServiceFee:  serviceFees[i],
+		}
+		line.Total = line.ItemsTotal.Add(line.DeliveryFee).Add(line.ServiceFee)
+
+		price.Lines = append(price.Lines, line)
+		price.Total = price.Total.Add(line.Total)
+	}
+
+	return price, nil
+}
+
+// vendorLocation returns where the deliveries of the vendor leave from, it is only looked up for delivery fees priced by
+// distance. It is zero when the vendor has no business address with coordinates, so the flat fee is charged.
+func (p *Pricer) vendorLocation(ctx context.Context, deliveryFee *models.Fee, vendorID string) (models.Coordinates, error) {
+	if deliveryFee.Bands == nil {
+		return models.Coordinates{}, nil
+	}
+
+	business, err := p.repositoryManager.UserRepository.VendorBusiness(ctx, vendorID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return models.Coordinates{}, nil
+		}
+		return models.Coordinates{}, errs.Body(errs.DatabaseError, fmt.Errorf("error getting business of vendor %s: %w", vendorID, err))
+	}
+
+	return business.Location(), nil
+}
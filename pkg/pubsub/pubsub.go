@@ -0,0 +1,77 @@
+package pubsub
+
+import (
+	"sync"
+)
+
+// subscriptionBuffer is the number of messages held for a subscriber before newer messages are dropped for it
+const subscriptionBuffer = 16
+
+// Hub delivers messages published on a topic to every current subscriber of the topic.
+// Delivery is best effort: subscribers that fall behind miss messages instead of slowing down publishers.
+type Hub interface {
+	Publish(topic string, message []byte)
+	Subscribe(topic string) *Subscription
+}
+
+type Subscription struct {
+	messages chan []byte
+	close    func()
+	once     sync.Once
+}
+
+// Messages returns the channel messages are delivered on. It is closed once the subscription is closed.
+func (s *Subscription) Messages() <-chan []byte {
+	return s.messages
+}
+
+// Close stops delivering messages to the subscription
+func (s *Subscription) Close() {
+	s.once.Do(s.close)
+}
+
+// inMemoryHub delivers messages between subscribers of a single process
+type inMemoryHub struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[*Subscription]struct{}
+}
+
+func NewInMemoryHub() Hub {
+	return &inMemoryHub{subscribers: make(map[string]map[*Subscription]struct{})}
+}
+
+func (h *inMemoryHub) Publish(topic string, message []byte) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for subscription := range h.subscribers[topic] {
+		select {
+		case subscription.messages <- message:
+		default:
+		}
+	}
+}
+
+func (h *inMemoryHub) Subscribe(topic string) *Subscription {
+	subscription := &Subscription{messages: make(chan []byte, subscriptionBuffer)}
+	subscription.close = func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		delete(h.subscribers[topic], subscription)
+		if len(h.subscribers[topic]) == 0 {
+			delete(h.subscribers, topic)
+		}
+		close(subscription.messages)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.subscribers[topic] == nil {
+		h.subscribers[topic] = make(map[*Subscription]struct{})
+	}
+	h.subscribers[topic][subscription] = struct{}{}
+
+	return subscription
+}
@@ -2,27 +2,50 @@ package pkg
 
 import (
 	"github.com/leetatech/leeta_backend/pkg/config"
+	"github.com/leetatech/leeta_backend/pkg/database"
 	"github.com/leetatech/leeta_backend/pkg/errs"
 	"github.com/leetatech/leeta_backend/pkg/jwtmiddleware"
 	mailer "github.com/leetatech/leeta_backend/pkg/notification/mailer/aws"
 	sms "github.com/leetatech/leeta_backend/pkg/notification/sms/aws"
+	"github.com/leetatech/leeta_backend/pkg/payment"
+	"github.com/leetatech/leeta_backend/pkg/pubsub"
+	analyticsDomain "github.com/leetatech/leeta_backend/services/analytics/domain"
 	authDomain "github.com/leetatech/leeta_backend/services/auth/domain"
 	cartDomain "github.com/leetatech/leeta_backend/services/cart/domain"
+	exportDomain "github.com/leetatech/leeta_backend/services/export/domain"
 	feesDomain "github.com/leetatech/leeta_backend/services/fees/domain"
 	orderDomain "github.com/leetatech/leeta_backend/services/order/domain"
+	paymentDomain "github.com/leetatech/leeta_backend/services/payment/domain"
 	productDomain "github.com/leetatech/leeta_backend/services/product/domain"
+	promotionDomain "github.com/leetatech/leeta_backend/services/promotion/domain"
+	refundDomain "github.com/leetatech/leeta_backend/services/refund/domain"
+	reviewDomain "github.com/leetatech/leeta_backend/services/review/domain"
+	slotDomain "github.com/leetatech/leeta_backend/services/slot/domain"
 	statesDomain "github.com/leetatech/leeta_backend/services/state/domain"
+	subscriptionDomain "github.com/leetatech/leeta_backend/services/subscription/domain"
+	ticketDomain "github.com/leetatech/leeta_backend/services/ticket/domain"
 	userDomain "github.com/leetatech/leeta_backend/services/user/domain"
 )
 
 type RepositoryManager struct {
-	OrderRepository   orderDomain.OrderRepository
-	UserRepository    userDomain.UserRepository
-	AuthRepository    authDomain.AuthRepository
-	ProductRepository productDomain.ProductRepository
-	CartRepository    cartDomain.CartRepository
-	FeesRepository    feesDomain.FeesRepository
-	StatesRepository  statesDomain.StateRepository
+	OrderRepository        orderDomain.OrderRepository
+	UserRepository         userDomain.UserRepository
+	AuthRepository         authDomain.AuthRepository
+	ProductRepository      productDomain.ProductRepository
+	CartRepository         cartDomain.CartRepository
+	FeesRepository         feesDomain.FeesRepository
+	StatesRepository       statesDomain.StateRepository
+	PaymentRepository      paymentDomain.PaymentRepository
+	SubscriptionRepository subscriptionDomain.SubscriptionRepository
+	RefundRepository       refundDomain.RefundRepository
+	AnalyticsRepository    analyticsDomain.AnalyticsRepository
+	ExportRepository       exportDomain.ExportRepository
+	SlotRepository         slotDomain.SlotRepository
+	ReviewRepository       reviewDomain.ReviewRepository
+	TicketRepository       ticketDomain.TicketRepository
+	PromotionRepository    promotionDomain.PromotionRepository
+	// Transactor runs writes to several repositories atomically
+	Transactor database.Transactor
 }
 
 type DefaultResponse struct {
@@ -37,6 +60,9 @@ type ApplicationContext struct {
 	MailClient        mailer.Client
 	SMSClient         sms.Client
 	Config            config.ServerConfig
+	PaymentProviders  payment.Providers
+	// TrackingHub carries order delivery tracking events to the users following the order
+	TrackingHub pubsub.Hub
 }
 
 type DefaultErrorResponse struct {
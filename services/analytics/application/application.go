@@ -0,0 +1,100 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query"
+	"github.com/leetatech/leeta_backend/pkg"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/pkg/helpers"
+	"github.com/leetatech/leeta_backend/pkg/jwtmiddleware"
+	"github.com/leetatech/leeta_backend/services/analytics/domain"
+	"github.com/leetatech/leeta_backend/services/models"
+)
+
+type analyticsAppHandler struct {
+	jwtManager    jwtmiddleware.Manager
+	allRepository pkg.RepositoryManager
+	currency      string
+}
+
+type Analytics interface {
+	// OrderMetrics breaks the order metrics down by the group. Vendors only see their own orders, admins see every order.
+	OrderMetrics(ctx context.Context, groupBy domain.GroupBy, request query.ResultSelector) (*domain.OrderMetricsResponse, error)
+}
+
+func New(request pkg.ApplicationContext) Analytics {
+	return &analyticsAppHandler{
+		jwtManager:    request.JwtManager,
+		allRepository: request.RepositoryManager,
+		currency:      request.Config.Payment.Currency,
+	}
+}
+
+func (a *analyticsAppHandler) OrderMetrics(ctx context.Context, groupBy domain.GroupBy, request query.ResultSelector) (*domain.OrderMetricsResponse, error) {
+	claims, err := a.jwtManager.ExtractUserClaims(ctx)
+	if err != nil {
+		return nil, errs.Body(errs.ErrorUnauthorized, err)
+	}
+
+	if groupBy == "" {
+		groupBy = domain.GroupByDay
+	}
+	groupBy, err = domain.SetGroupBy(groupBy)
+	if err != nil {
+		return nil, err
+	}
+
+	metricsQuery := domain.OrderMetricsQuery{GroupBy: groupBy, Filter: request}
+	switch claims.Role {
+	case models.AdminCategory:
+	case models.VendorCategory:
+		metricsQuery.VendorID = claims.UserID
+	default:
+		return nil, errs.Body(errs.ErrorUnauthorized, errors.New("you cannot view analytics"))
+	}
+
+	groups, err := a.allRepository.AnalyticsRepository.OrderTotals(ctx, metricsQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	// the total is aggregated on its own, as an order can be in several product groups
+	metricsQuery.GroupBy = ""
+	total, err := a.allRepository.AnalyticsRepository.OrderTotals(ctx, metricsQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &domain.OrderMetricsResponse{
+		GroupBy: groupBy,
+		Groups:  make([]domain.OrderMetrics, len(groups)),
+		Total:   a.metrics(domain.GroupTotals{Key: "total"}),
+	}
+	for i, group := range groups {
+		response.Groups[i] = a.metrics(group)
+	}
+	if len(total) > 0 {
+		response.Total = a.metrics(total[0])
+	}
+
+	return response, nil
+}
+
+func (a *analyticsAppHandler) metrics(totals domain.GroupTotals) domain.OrderMetrics {
+	// orders are charged in the payment currency, so their amounts add up
+	orderMetrics := domain.OrderMetrics{
+		Key:         totals.Key,
+		GMV:         models.NewMoney(totals.GMV, a.currency),
+		OrderCount:  totals.OrderCount,
+		KgDelivered: helpers.RoundToTwoDecimalPlaces(totals.KgDelivered),
+	}
+	if totals.SoldOrderCount > 0 {
+		orderMetrics.AverageBasket = orderMetrics.GMV.MulRate(1 / float64(totals.SoldOrderCount))
+	}
+	if totals.OrderCount > 0 {
+		orderMetrics.CancellationRate = helpers.RoundToDecimalPlaces(float64(totals.CancelledCount)/float64(totals.OrderCount), 4)
+	}
+
+	return orderMetrics
+}
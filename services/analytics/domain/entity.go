@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"errors"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/services/models"
+)
+
+// GroupBy is the dimension order metrics are broken down by
+type GroupBy string
+
+const (
+	GroupByDay     GroupBy = "day"
+	GroupByWeek    GroupBy = "week"
+	GroupByMonth   GroupBy = "month"
+	GroupByState   GroupBy = "state"
+	GroupByLGA     GroupBy = "lga"
+	GroupByProduct GroupBy = "product"
+	GroupByVendor  GroupBy = "vendor"
+)
+
+func IsValidGroupBy(groupBy GroupBy) bool {
+	switch groupBy {
+	case GroupByDay, GroupByWeek, GroupByMonth, GroupByState, GroupByLGA, GroupByProduct, GroupByVendor:
+		return true
+	default:
+		return false
+	}
+}
+
+func SetGroupBy(groupBy GroupBy) (GroupBy, error) {
+	switch IsValidGroupBy(groupBy) {
+	case true:
+		return groupBy, nil
+	default:
+		return "", errs.Body(errs.InvalidRequestError, errors.New("invalid group by, expected one of day, week, month, state, lga, product or vendor"))
+	}
+}
+
+// OrderMetricsQuery selects the orders to aggregate and how to group them
+type OrderMetricsQuery struct {
+	GroupBy GroupBy
+	Filter  query.ResultSelector
+	// VendorID limits the metrics to the orders of a vendor when set
+	VendorID string
+}
+
+// GroupTotals are the sums an order metrics group is computed from
+type GroupTotals struct {
+	Key string `bson:"_id"`
+	// GMV is counted in the minor unit of the payment currency
+	GMV            int64   `bson:"gmv"`
+	OrderCount     int64   `bson:"order_count"`
+	SoldOrderCount int64   `bson:"sold_order_count"`
+	CancelledCount int64   `bson:"cancelled_count"`
+	KgDelivered    float64 `bson:"kg_delivered"`
+}
+
+// OrderMetrics summarises the orders of a group.
+// GMV and the average basket only count orders that were paid or are to be paid on delivery, and were not cancelled or rejected.
+type OrderMetrics struct {
+	Key              string       `json:"key"`
+	GMV              models.Money `json:"gmv"`
+	OrderCount       int64        `json:"order_count"`
+	KgDelivered      float64      `json:"kg_delivered"`
+	AverageBasket    models.Money `json:"average_basket"`
+	CancellationRate float64      `json:"cancellation_rate"`
+} // @name OrderMetrics
+
+type OrderMetricsResponse struct {
+	GroupBy GroupBy        `json:"group_by"`
+	Groups  []OrderMetrics `json:"groups"`
+	Total   OrderMetrics   `json:"total"`
+} // @name OrderMetricsResponse
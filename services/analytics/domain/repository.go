@@ -0,0 +1,8 @@
+package domain
+
+import "context"
+
+type AnalyticsRepository interface {
+	// OrderTotals sums the orders matching the query per group. An empty GroupBy sums every order into a single group.
+	OrderTotals(ctx context.Context, request OrderMetricsQuery) ([]GroupTotals, error)
+}
@@ -0,0 +1,182 @@
+package infrastructure
+
+import (
+	"context"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query/sorting"
+	"github.com/leetatech/leeta_backend/pkg/database"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/services/analytics/domain"
+	"github.com/leetatech/leeta_backend/services/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"strings"
+	"time"
+)
+
+// timezone the day, week and month groups are cut in
+const timezone = "Africa/Lagos"
+
+// itemFieldPrefix marks filters on the items of an order rather than the order itself
+const itemFieldPrefix = "orders."
+
+// filterFields maps the analytics filter names to the order document fields
+var filterFields = map[string]string{
+	"state":            "delivery_details.address.state",
+	"lga":              "delivery_details.address.lga",
+	"product_id":       "orders.product_id",
+	"product_category": "orders.product_category",
+}
+
+// sortFields maps the analytics sort columns to the group fields
+var sortFields = map[string]string{
+	"key":          "_id",
+	"gmv":          "gmv",
+	"order_count":  "order_count",
+	"kg_delivered": "kg_delivered",
+}
+
+// soldStatuses are the statuses of orders counted towards GMV
+var soldStatuses = bson.A{
+	models.OrderPending,
+	models.OrderApproved,
+	models.OrderShipped,
+	models.OrderCompleted,
+}
+
+type analyticsStoreHandler struct {
+	client       *mongo.Client
+	databaseName string
+}
+
+func (a *analyticsStoreHandler) col(collectionName string) *mongo.Collection {
+	return a.client.Database(a.databaseName).Collection(collectionName)
+}
+
+func New(client *mongo.Client, databaseName string) domain.AnalyticsRepository {
+	return &analyticsStoreHandler{client: client, databaseName: databaseName}
+}
+
+func (a *analyticsStoreHandler) OrderTotals(ctx context.Context, request domain.OrderMetricsQuery) ([]domain.GroupTotals, error) {
+	updatedCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	cursor, err := a.col(models.OrderCollectionName).Aggregate(updatedCtx, orderTotalsPipeline(request))
+	if err != nil {
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	totals := make([]domain.GroupTotals, 0)
+	if err = cursor.All(updatedCtx, &totals); err != nil {
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	return totals, nil
+}
+
+// orderTotalsPipeline first reduces the orders to one document per group and order, so an order with several matching
+// items is counted once, then sums those documents per group.
+// When the items of an order are filtered or grouped on, only the cost of those items counts towards GMV instead of the order total.
+func orderTotalsPipeline(request domain.OrderMetricsQuery) mongo.Pipeline {
+	orderFilter := bson.M{}
+	if request.Filter.Filter != nil {
+		orderFilter = database.BuildMongoFilterQuery(request.Filter.Filter, filterFields)
+	}
+	// the vendor is applied last, so a filter can never widen it
+	if request.VendorID != "" {
+		orderFilter["vendor_id"] = request.VendorID
+	}
+
+	itemFilter := bson.M{}
+	for key, value := range orderFilter {
+		if strings.HasPrefix(key, itemFieldPrefix) {
+			itemFilter[key] = value
+		}
+	}
+
+	amount := "$total"
+	if len(itemFilter) > 0 || request.GroupBy == domain.GroupByProduct {
+		amount = "$items_cost"
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: orderFilter}},
+		{{Key: "$unwind", Value: bson.M{"path": "$orders", "preserveNullAndEmptyArrays": true}}},
+	}
+	if len(itemFilter) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: itemFilter}})
+	}
+
+	pipeline = append(pipeline,
+		bson.D{{Key: "$group", Value: bson.M{
+			"_id": bson.M{"key": groupKey(request.GroupBy), "order": "$id"},
+			// orders that were never updated have no status field stored yet
+			"status":     bson.M{"$first": bson.M{"$ifNull": bson.A{"$status", models.OrderPending}}},
+			"total":      bson.M{"$first": "$total.amount"},
+			"items_cost": bson.M{"$sum": "$orders.cost.amount"},
+			"kg":         bson.M{"$sum": bson.M{"$multiply": bson.A{"$orders.weight", "$orders.quantity"}}},
+		}}},
+		bson.D{{Key: "$addFields", Value: bson.M{
+			"sold": bson.M{"$in": bson.A{"$status", soldStatuses}},
+		}}},
+		bson.D{{Key: "$group", Value: bson.M{
+			"_id":              "$_id.key",
+			"gmv":              bson.M{"$sum": bson.M{"$cond": bson.A{"$sold", amount, 0}}},
+			"order_count":      bson.M{"$sum": 1},
+			"sold_order_count": bson.M{"$sum": bson.M{"$cond": bson.A{"$sold", 1, 0}}},
+			"cancelled_count":  bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$status", models.OrderCancelled}}, 1, 0}}},
+			"kg_delivered":     bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$status", models.OrderCompleted}}, "$kg", 0}}},
+		}}},
+		bson.D{{Key: "$sort", Value: groupSort(request.Filter.Sorting)}},
+	)
+
+	return pipeline
+}
+
+// groupKey is the expression an order is grouped by. Orders are summed into a single group without one.
+func groupKey(groupBy domain.GroupBy) any {
+	switch groupBy {
+	case domain.GroupByDay:
+		return tsFormat("%Y-%m-%d")
+	case domain.GroupByWeek:
+		return tsFormat("%G-W%V")
+	case domain.GroupByMonth:
+		return tsFormat("%Y-%m")
+	case domain.GroupByState:
+		return "$delivery_details.address.state"
+	case domain.GroupByLGA:
+		// lga names are only unique within a state
+		return bson.M{"$concat": bson.A{"$delivery_details.address.state", "/", "$delivery_details.address.lga"}}
+	case domain.GroupByProduct:
+		return "$orders.product_id"
+	case domain.GroupByVendor:
+		return "$vendor_id"
+	default:
+		return "total"
+	}
+}
+
+// tsFormat formats the unix time an order was placed
+func tsFormat(format string) bson.M {
+	return bson.M{"$dateToString": bson.M{
+		"format":   format,
+		"date":     bson.M{"$toDate": bson.M{"$multiply": bson.A{"$ts", 1000}}},
+		"timezone": timezone,
+	}}
+}
+
+func groupSort(request *sorting.Request) bson.D {
+	if request == nil || sortFields[request.SortColumn] == "" {
+		return bson.D{{Key: "_id", Value: 1}}
+	}
+
+	direction := 1
+	if request.SortDirection == sorting.DirectionDescending {
+		direction = -1
+	}
+	sort := bson.D{{Key: sortFields[request.SortColumn], Value: direction}}
+	if request.SortColumn != "key" {
+		sort = append(sort, bson.E{Key: "_id", Value: 1})
+	}
+
+	return sort
+}
@@ -0,0 +1,72 @@
+package interfaces
+
+import (
+	"github.com/greenbone/opensight-golang-libraries/pkg/query/filter"
+	_ "github.com/leetatech/leeta_backend/pkg"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/pkg/helpers"
+	"github.com/leetatech/leeta_backend/pkg/jwtmiddleware"
+	"github.com/leetatech/leeta_backend/services/analytics/application"
+	"github.com/leetatech/leeta_backend/services/analytics/domain"
+	"github.com/leetatech/leeta_backend/services/web"
+	"github.com/samber/lo"
+	"net/http"
+)
+
+type AnalyticsHttpHandler struct {
+	AnalyticsApplication application.Analytics
+}
+
+func New(analyticsApplication application.Analytics) *AnalyticsHttpHandler {
+	return &AnalyticsHttpHandler{
+		AnalyticsApplication: analyticsApplication,
+	}
+}
+
+// OrderMetricsHandler godoc
+// @Summary Get order metrics
+// @Description The endpoint returns the GMV, order count, kg delivered, average basket and cancellation rate of orders grouped by day, week, month, state, lga, product or vendor. Vendors only see their own orders, admins see every order
+// @Tags Analytics
+// @Accept json
+// @produce json
+// @Param group_by query string false "day, week, month, state, lga, product or vendor. Defaults to day"
+// @param query.ResultSelector body query.ResultSelector true "order metrics request body"
+// @Security BearerToken
+// @success 200 {object} domain.OrderMetricsResponse
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Router /analytics/orders [put]
+func (handler *AnalyticsHttpHandler) OrderMetricsHandler(w http.ResponseWriter, r *http.Request) {
+	resultSelector, err := web.PrepareResultSelector(r, orderMetricsOptions, orderMetricsSortFields, web.ResultSelectorDefaults(orderMetricsSortingRequest))
+	if err != nil {
+		jwtmiddleware.WriteJSONErrorResponse(w, http.StatusBadRequest, errs.Body(errs.InvalidRequestError, err))
+		return
+	}
+
+	metrics, err := handler.AnalyticsApplication.OrderMetrics(r.Context(), domain.GroupBy(r.URL.Query().Get("group_by")), resultSelector)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, metrics, http.StatusOK)
+}
+
+// OrderMetricsOptions godoc
+// @Summary Get order metrics filter options
+// @Description Retrieve the order metrics filter options
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Security BearerToken
+// @Success 200 {object} filter.RequestOption
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Router /analytics/orders/options [get]
+func (handler *AnalyticsHttpHandler) OrderMetricsOptions(w http.ResponseWriter, r *http.Request) {
+	requestOptions := lo.Map(orderMetricsOptions, toFilterOption)
+	jwtmiddleware.WriteJSONResponse(w, requestOptions, http.StatusOK)
+}
+
+func toFilterOption(options filter.RequestOption, _ int) filter.RequestOption {
+	return options
+}
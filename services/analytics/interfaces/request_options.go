@@ -0,0 +1,89 @@
+package interfaces
+
+import (
+	"github.com/greenbone/opensight-golang-libraries/pkg/query/filter"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query/sorting"
+	"github.com/leetatech/leeta_backend/services/models"
+)
+
+// LabelIsEqualTo holds filter request options operator labels
+const (
+	LabelIsEqualTo    = "is equal to"
+	LabelIsOnOrAfter  = "is on or after"
+	LabelIsOnOrBefore = "is on or before"
+)
+
+var operatorEqual = filter.ReadableValue[filter.CompareOperator]{
+	Label: LabelIsEqualTo,
+	Value: filter.CompareOperatorIsEqualTo,
+}
+
+var operatorOnOrAfter = filter.ReadableValue[filter.CompareOperator]{
+	Label: LabelIsOnOrAfter,
+	Value: filter.CompareOperatorIsGreaterThanOrEqualTo,
+}
+
+var operatorOnOrBefore = filter.ReadableValue[filter.CompareOperator]{
+	Label: LabelIsOnOrBefore,
+	Value: filter.CompareOperatorIsLessThanOrEqualTo,
+}
+
+func equalOption(label, value string) filter.RequestOption {
+	return filter.RequestOption{
+		Name: filter.ReadableValue[string]{
+			Label: label,
+			Value: value,
+		},
+		Control: filter.RequestOptionType{
+			Type: filter.ControlTypeString,
+		},
+		Operators: []filter.ReadableValue[filter.CompareOperator]{
+			operatorEqual,
+		},
+	}
+}
+
+// orderMetricsOptions filter the aggregated orders by the unix time they were placed, where they were delivered, what was ordered and who sold it
+var orderMetricsOptions = []filter.RequestOption{
+	{
+		Name: filter.ReadableValue[string]{
+			Label: "date",
+			Value: "ts",
+		},
+		Control: filter.RequestOptionType{
+			Type: filter.ControlTypeInteger,
+		},
+		Operators: []filter.ReadableValue[filter.CompareOperator]{
+			operatorOnOrAfter,
+			operatorOnOrBefore,
+		},
+	},
+	equalOption("state", "state"),
+	equalOption("lga", "lga"),
+	equalOption("product", "product_id"),
+	{
+		Name: filter.ReadableValue[string]{
+			Label: "product category",
+			Value: "product_category",
+		},
+		Control: filter.RequestOptionType{
+			Type: filter.ControlTypeEnum,
+		},
+		Operators: []filter.ReadableValue[filter.CompareOperator]{
+			operatorEqual,
+		},
+		Values: []string{
+			string(models.LPGProductCategory),
+			string(models.LNGProductCategory),
+		},
+	},
+	// vendors only ever see their own orders, so this only narrows the metrics of admins
+	equalOption("vendor", "vendor_id"),
+}
+
+var orderMetricsSortFields = []string{"key", "gmv", "order_count", "kg_delivered"}
+
+var orderMetricsSortingRequest = &sorting.Request{
+	SortColumn:    "key",
+	SortDirection: sorting.DirectionAscending,
+}
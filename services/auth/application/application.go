@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	mailer "github.com/leetatech/leeta_backend/pkg/notification/mailer/aws"
+	"strings"
 	"time"
 
 	"github.com/leetatech/leeta_backend/pkg/config"
@@ -39,6 +40,8 @@ type notification struct {
 
 type Auth interface {
 	SignUp(ctx context.Context, request domain.SignupRequest) (*domain.DefaultSigningResponse, error)
+	// ReissueSignUp answers the retry of a sign up that created the user with the id, with a new token for the user
+	ReissueSignUp(ctx context.Context, request domain.SignupRequest, userID string) (*domain.DefaultSigningResponse, error)
 	RequestOTP(ctx context.Context, request domain.OTPRequest) (*pkg.DefaultResponse, error)
 	EarlyAccess(ctx context.Context, request models.EarlyAccess) (*pkg.DefaultResponse, error)
 	SignIn(ctx context.Context, request domain.SigningRequest) (*domain.DefaultSigningResponse, error)
@@ -46,6 +49,7 @@ type Auth interface {
 	ValidateOTP(ctx context.Context, request domain.OTPValidationRequest) (*pkg.DefaultResponse, error)
 	CreateNewPassword(ctx context.Context, request domain.CreateNewPasswordRequest) (*domain.DefaultSigningResponse, error)
 	AdminSignUp(ctx context.Context, request domain.AdminSignUpRequest) (*domain.DefaultSigningResponse, error)
+	CreateRider(ctx context.Context, request domain.CreateRiderRequest) (*models.User, error)
 	ReceiveGuestToken(request domain.ReceiveGuestRequest) (*domain.ReceiveGuestResponse, error)
 	UpdateGuestRecord(ctx context.Context, request models.Guest) (*pkg.DefaultResponse, error)
 	GetGuestRecord(ctx context.Context, deviceId string) (models.Guest, error)
@@ -88,11 +92,52 @@ func (a authAppHandler) SignUp(ctx context.Context, request domain.SignupRequest
 
 	case models.CustomerCategory:
 		return a.customerSignUP(ctx, request)
+
+	case models.RiderCategory:
+		return nil, errs.Body(errs.UserCategoryError, errors.New("riders cannot sign up, their accounts are created by an admin"))
 	}
 
 	return nil, nil
 }
 
+func (a authAppHandler) ReissueSignUp(ctx context.Context, request domain.SignupRequest, userID string) (*domain.DefaultSigningResponse, error) {
+	identity, err := a.repositoryManager.AuthRepository.IdentityByUserID(ctx, userID)
+	if err != nil {
+		return nil, errs.Body(errs.DatabaseError, fmt.Errorf("error getting identity of user %s: %w", userID, err))
+	}
+
+	var user models.User
+	email := request.TrimEmailSpace()
+	switch identity.Role {
+	case models.VendorCategory:
+		vendor, err := a.repositoryManager.AuthRepository.VendorByEmail(ctx, email)
+		if err != nil {
+			return nil, errs.Body(errs.DatabaseError, fmt.Errorf("error getting vendor %s: %w", userID, err))
+		}
+		user = vendor.User
+	case models.CustomerCategory:
+		customer, err := a.repositoryManager.AuthRepository.UserByEmail(ctx, email)
+		if err != nil {
+			return nil, errs.Body(errs.DatabaseError, fmt.Errorf("error getting customer %s: %w", userID, err))
+		}
+		user = customer.User
+	default:
+		return nil, errs.Body(errs.UserCategoryError, fmt.Errorf("%s accounts are not created by sign up", identity.Role))
+	}
+
+	// the retry carries the request that created the user, a user changed since cannot be signed in this way
+	if user.ID != userID {
+		return nil, errs.Body(errs.ErrorUnauthorized, errors.New("sign up was made for another user"))
+	}
+
+	token, err := a.jwtManager.BuildAuthResponse(user.Email.Address, user.ID, identity.DeviceID, identity.Role)
+	if err != nil {
+		return nil, errs.Body(errs.TokenGenerationError, fmt.Errorf("error building authentication response on sign up retry: %w", err))
+	}
+
+	return &domain.DefaultSigningResponse{AuthToken: token, Body: user}, nil
+}
+
 func (a authAppHandler) createOTP(ctx context.Context, request domain.OTPRequest) (*pkg.DefaultResponse, error) {
 	expirationDuration := time.Duration(5) * time.Minute
 
@@ -158,6 +203,8 @@ func (a authAppHandler) SignIn(ctx context.Context, request domain.SigningReques
 		return a.adminSignIN(ctx, request)
 	case models.CustomerCategory:
 		return a.customerSignIN(ctx, request)
+	case models.RiderCategory:
+		return a.riderSignIN(ctx, request)
 	}
 
 	return nil, nil
@@ -313,6 +360,32 @@ func (a authAppHandler) AdminSignUp(ctx context.Context, request domain.AdminSig
 	return a.adminSignUp(ctx, request)
 }
 
+// CreateRider creates the account of a rider. Riders are taken on by the platform and assigned deliveries with the orders
+// of customers, so only admins create their accounts.
+func (a authAppHandler) CreateRider(ctx context.Context, request domain.CreateRiderRequest) (*models.User, error) {
+	claims, err := a.jwtManager.ExtractUserClaims(ctx)
+	if err != nil {
+		return nil, errs.Body(errs.ErrorUnauthorized, err)
+	}
+	if claims.Role != models.AdminCategory {
+		return nil, errs.Body(errs.ErrorUnauthorized, errors.New("only admins can create rider accounts"))
+	}
+
+	request.Email = strings.TrimSpace(request.Email)
+	err = a.encryptor.ValidateEmailFormat(request.Email)
+	if err != nil {
+		return nil, errs.Body(errs.EmailFormatError, err)
+	}
+
+	hashedPassword, err := a.validateAndEncryptPassword(request.Password)
+	if err != nil {
+		return nil, fmt.Errorf("error validating and encrypting password on rider creation: %w", err)
+	}
+	request.Password = hashedPassword
+
+	return a.createRider(ctx, request)
+}
+
 func (a authAppHandler) ReceiveGuestToken(request domain.ReceiveGuestRequest) (*domain.ReceiveGuestResponse, error) {
 	ctx := context.Background()
 
@@ -0,0 +1,158 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/services/auth/domain"
+	"github.com/leetatech/leeta_backend/services/models"
+	"go.mongodb.org/mongo-driver/mongo"
+	"time"
+)
+
+// mergeGuestCart moves the items of the cart a guest built on the device into the customer's active cart. Only the guest
+// session signing in can hand its cart over, so the cart is found through the claims of the guest token sent with the
+// request, never through a device id the client names.
+// Items already in the customer cart are combined with the guest items instead of being added twice, and every merged item
+// is priced again at the current product and cylinder deposit fees. Items whose product is gone, out of stock or has no
+// active fee are returned instead of failing the merge, the guest cart is closed without them.
+func (a authAppHandler) mergeGuestCart(ctx context.Context, customerID string) ([]domain.SkippedCartItem, error) {
+	claims, err := a.jwtManager.ExtractUserClaims(ctx)
+	if err != nil || claims.Role != models.GuestCategory || claims.DeviceID == "" {
+		return nil, nil
+	}
+
+	guestCart, err := a.repositoryManager.CartRepository.GetCartByDeviceID(ctx, claims.DeviceID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, errs.Body(errs.DatabaseError, fmt.Errorf("error getting guest cart of device %s: %w", claims.DeviceID, err))
+	}
+
+	if guestCart.CustomerID != claims.UserID {
+		return nil, errs.Body(errs.ErrorUnauthorized, errors.New("guest cart does not belong to the guest session"))
+	}
+
+	if len(guestCart.CartItems) == 0 {
+		return nil, nil
+	}
+
+	now := time.Now().Unix()
+	isNewCart := false
+	cart, err := a.repositoryManager.CartRepository.GetActiveCartByCustomerID(ctx, customerID)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errs.Body(errs.DatabaseError, fmt.Errorf("error getting active cart of customer %s: %w", customerID, err))
+		}
+		isNewCart = true
+		cart = models.Cart{
+			ID:         a.idGenerator.Generate(),
+			CustomerID: customerID,
+			Status:     models.CartActive,
+			Ts:         now,
+		}
+	}
+
+	var skipped []domain.SkippedCartItem
+	for _, guestItem := range guestCart.CartItems {
+		reason, err := a.mergeItem(ctx, &cart, guestItem)
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			skipped = append(skipped, domain.SkippedCartItem{
+				ProductID: guestItem.ProductID,
+				Weight:    guestItem.Weight,
+				Quantity:  guestItem.Quantity,
+				Reason:    reason,
+			})
+		}
+	}
+	cart.Total = cart.CalculateCartTotalFee()
+	cart.StatusTs = now
+
+	// the guest cart is closed in the same transaction, so signing in again on the device cannot merge it twice
+	err = a.repositoryManager.Transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if isNewCart {
+			err := a.repositoryManager.CartRepository.AddToCart(ctx, cart)
+			if err != nil {
+				return err
+			}
+		} else {
+			err := a.repositoryManager.CartRepository.UpdateCart(ctx, cart)
+			if err != nil {
+				return err
+			}
+		}
+
+		return a.repositoryManager.CartRepository.MergeCart(ctx, guestCart.ID, cart.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return skipped, nil
+}
+
+// mergeItem adds a guest cart item to the cart at the current product fee, or returns why it cannot be bought anymore
+func (a authAppHandler) mergeItem(ctx context.Context, cart *models.Cart, guestItem models.CartItem) (domain.MergeSkipReason, error) {
+	product, err := a.repositoryManager.ProductRepository.Product(ctx, guestItem.ProductID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return domain.MergeProductNotFound, nil
+		}
+		return "", errs.Body(errs.DatabaseError, fmt.Errorf("error getting product %s: %w", guestItem.ProductID, err))
+	}
+
+	if product.Status == models.OutOfStock {
+		return domain.MergeOutOfStock, nil
+	}
+
+	fee, err := a.repositoryManager.FeesRepository.ByProductID(ctx, product.ID, models.ProductFee, time.Now().Unix())
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return domain.MergeNoActiveFee, nil
+		}
+		return "", errs.Body(errs.FeesError, fmt.Errorf("error getting fee of product %s: %w", product.ID, err))
+	}
+
+	var depositFee *models.Fee
+	if guestItem.IsNewCylinder() {
+		depositFee, err = a.repositoryManager.FeesRepository.ByProductID(ctx, product.ID, models.DepositFee, time.Now().Unix())
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return domain.MergeNoActiveFee, nil
+			}
+			return "", errs.Body(errs.FeesError, fmt.Errorf("error getting cylinder deposit fee of product %s: %w", product.ID, err))
+		}
+	}
+
+	index := -1
+	for i, item := range cart.CartItems {
+		if item.SameItem(guestItem) {
+			index = i
+			break
+		}
+	}
+
+	item := guestItem
+	if index >= 0 {
+		item = cart.CartItems[index]
+		item.Quantity += guestItem.Quantity
+	}
+
+	item.Cost, err = item.CalculateCartItemFee(fee, depositFee)
+	if err != nil {
+		return "", errs.Body(errs.InvalidRequestError, fmt.Errorf("error pricing merged cart item %s: %w", item.ID, err))
+	}
+
+	if index >= 0 {
+		cart.CartItems[index] = item
+	} else {
+		cart.CartItems = append(cart.CartItems, item)
+	}
+
+	return "", nil
+}
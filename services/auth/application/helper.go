@@ -10,6 +10,7 @@ import (
 	"github.com/leetatech/leeta_backend/pkg/errs"
 	"github.com/leetatech/leeta_backend/services/auth/domain"
 	"github.com/leetatech/leeta_backend/services/models"
+	"github.com/rs/zerolog/log"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
@@ -186,7 +187,85 @@ func (a authAppHandler) customerSignUP(ctx context.Context, request domain.Signu
 				return nil, errs.Body(errs.InternalError, err)
 			}
 
-			return &domain.DefaultSigningResponse{AuthToken: response, Body: customer.User}, nil
+			// a guest signing up keeps the cart built on the device. The account exists by now, so a failed merge does not
+			// fail the sign up, the guest cart stays on the device and is merged when the customer signs in with the guest token.
+			skipped, err := a.mergeGuestCart(ctx, customer.ID)
+			if err != nil {
+				log.Error().Err(err).Msgf("error merging guest cart into the cart of customer %s", customer.ID)
+			}
+
+			return &domain.DefaultSigningResponse{AuthToken: response, Body: customer.User, SkippedCartItems: skipped}, nil
+		default:
+			return nil, errs.Body(errs.InternalError, err)
+		}
+	}
+
+	return nil, errs.Body(errs.DuplicateUserError, errors.New("user already exists"))
+}
+
+func (a authAppHandler) createRider(ctx context.Context, request domain.CreateRiderRequest) (*models.User, error) {
+	_, err := a.repositoryManager.AuthRepository.UserByEmail(ctx, request.Email)
+	if err != nil {
+		switch {
+		case errors.Is(err, mongo.ErrNoDocuments):
+			timestamp := time.Now().Unix()
+
+			rider := models.Rider{
+				User: models.User{
+					ID: a.idGenerator.Generate(),
+					Email: models.Email{
+						Address: request.Email,
+					},
+					Phone: models.Phone{
+						Primary: true,
+						Number:  request.Phone,
+					},
+					Status: models.SignedUp,
+				},
+				TimeStamps: models.TimeStamps{
+					Time: timestamp,
+				},
+			}
+
+			err = rider.User.ExtractName(request.FullName)
+			if err != nil {
+				return nil, errs.Body(errs.MissingUserNames, err)
+			}
+
+			identity := models.Identity{
+				ID:     a.idGenerator.Generate(),
+				UserID: rider.ID,
+				Role:   models.RiderCategory,
+				Credentials: []models.Credentials{
+					{
+						Type:            models.CredentialsTypeLogin,
+						Password:        request.Password,
+						Status:          models.CredentialStatusActive,
+						StatusTimestamp: timestamp,
+						Timestamp:       timestamp,
+					},
+				},
+			}
+
+			// a rider is only created together with the identity it signs in with
+			err = a.repositoryManager.Transactor.WithTransaction(ctx, func(ctx context.Context) error {
+				err := a.repositoryManager.AuthRepository.CreateUser(ctx, rider)
+				if err != nil {
+					return err
+				}
+
+				return a.repositoryManager.AuthRepository.CreateIdentity(ctx, identity)
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			err = a.sendAccountVerificationEmail(ctx, request.FullName, rider.ID, rider.Email.Address, pkg.VerifySignUPTemplatePath)
+			if err != nil {
+				return nil, errs.Body(errs.InternalError, err)
+			}
+
+			return &rider.User, nil
 		default:
 			return nil, errs.Body(errs.InternalError, err)
 		}
@@ -245,7 +324,31 @@ func (a authAppHandler) customerSignIN(ctx context.Context, request domain.Signi
 		return nil, errs.Body(errs.InvalidUserRoleError, validateErr)
 	}
 
-	return a.buildSignIn(ctx, customer.User, customer.Status, request)
+	response, err := a.buildSignIn(ctx, customer.User, customer.Status, request)
+	if err != nil {
+		return nil, err
+	}
+
+	// a failed merge leaves the guest cart on the device and does not fail the sign in, it is merged on a later sign in
+	response.SkippedCartItems, err = a.mergeGuestCart(ctx, customer.ID)
+	if err != nil {
+		log.Error().Err(err).Msgf("error merging guest cart into the cart of customer %s", customer.ID)
+	}
+
+	return response, nil
+}
+
+func (a authAppHandler) riderSignIN(ctx context.Context, request domain.SigningRequest) (*domain.DefaultSigningResponse, error) {
+	rider, err := a.repositoryManager.AuthRepository.UserByEmail(ctx, request.Email)
+	if err != nil {
+		return nil, errs.Body(errs.UserNotFoundError, fmt.Errorf("error getting rider identity by email %s when signing in: %w", request.Email, err))
+	}
+
+	if validateErr := a.validateUserRole(ctx, &request, &rider.User); validateErr != nil {
+		return nil, errs.Body(errs.InvalidUserRoleError, validateErr)
+	}
+
+	return a.buildSignIn(ctx, rider.User, rider.Status, request)
 }
 
 func (a authAppHandler) validateUserRole(ctx context.Context, request *domain.SigningRequest, user *models.User) error {
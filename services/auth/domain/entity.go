@@ -23,8 +23,27 @@ type SigningRequest struct {
 type DefaultSigningResponse struct {
 	AuthToken string `json:"auth_token,omitempty"`
 	Body      any    `json:"body"`
+	// SkippedCartItems are the items of the guest cart that could not be merged into the customer cart on signing
+	SkippedCartItems []SkippedCartItem `json:"skipped_cart_items,omitempty"`
 } // @name DefaultSigningResponse
 
+// SkippedCartItem is an item of the guest cart that could not be merged into the customer cart
+type SkippedCartItem struct {
+	ProductID string          `json:"product_id"`
+	Weight    float64         `json:"weight,omitempty"`
+	Quantity  int             `json:"quantity"`
+	Reason    MergeSkipReason `json:"reason"`
+} // @name SkippedCartItem
+
+// MergeSkipReason tells why an item of the guest cart was not merged into the customer cart
+type MergeSkipReason string
+
+const (
+	MergeProductNotFound MergeSkipReason = "PRODUCT_NOT_FOUND"
+	MergeOutOfStock      MergeSkipReason = "OUT_OF_STOCK"
+	MergeNoActiveFee     MergeSkipReason = "NO_ACTIVE_FEE"
+)
+
 type APIResponseWithoutToken struct {
 	Body any `json:"body"`
 } // @name APIResponseWithoutToken
@@ -64,6 +83,14 @@ type AdminSignUpRequest struct {
 	DeviceID   string         `json:"device_id"`
 } // @name AdminSignUpRequest
 
+// CreateRiderRequest is sent by an admin taking a rider on. The rider signs in with the password the admin sets.
+type CreateRiderRequest struct {
+	FullName string `json:"full_name"`
+	Email    string `json:"email"`
+	Phone    string `json:"phone"`
+	Password string `json:"password"`
+} // @name CreateRiderRequest
+
 type ReceiveGuestRequest struct {
 	Location models.Coordinates `json:"location,omitempty" bson:"location"`
 	DeviceID string             `json:"device_id" validate:"required" bson:"device_id"`
@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"github.com/go-chi/chi/v5"
 	_ "github.com/leetatech/leeta_backend/pkg"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/pkg/helpers"
+	"github.com/leetatech/leeta_backend/pkg/idempotency"
 	"github.com/leetatech/leeta_backend/pkg/jwtmiddleware"
 	"github.com/leetatech/leeta_backend/services/auth/application"
 	"github.com/leetatech/leeta_backend/services/auth/domain"
@@ -29,6 +32,8 @@ func New(authApplication application.Auth) *AuthHttpHandler {
 // @Accept json
 // @Produce json
 // @Param domain.SignupRequest body domain.SignupRequest true "user sign up request body"
+// @Param Authorization header string false "guest token of the device, its cart is merged into the customer cart"
+// @Param Idempotency-Key header string false "key the client retries the sign up with, a retry of a sign up that succeeded is answered with a new token"
 // @Success 200 {object} domain.DefaultSigningResponse
 // @Router /session/signup [post]
 func (handler *AuthHttpHandler) SignUpHandler(w http.ResponseWriter, r *http.Request) {
@@ -45,6 +50,26 @@ func (handler *AuthHttpHandler) SignUpHandler(w http.ResponseWriter, r *http.Req
 		jwtmiddleware.WriteJSONResponse(w, err, http.StatusBadRequest)
 		return
 	}
+	if user, ok := token.Body.(models.User); ok {
+		idempotency.SetSubject(r.Context(), user.ID)
+	}
+	jwtmiddleware.WriteJSONResponse(w, token, http.StatusOK)
+}
+
+// ReissueSignUp answers a sign up retried with the idempotency key of a sign up that created the user with the id
+func (handler *AuthHttpHandler) ReissueSignUp(w http.ResponseWriter, r *http.Request, userID string) {
+	var signUpRequest domain.SignupRequest
+	err := json.NewDecoder(r.Body).Decode(&signUpRequest)
+	if err != nil {
+		jwtmiddleware.WriteJSONResponse(w, errs.Body(errs.UnmarshalError, err), http.StatusBadRequest)
+		return
+	}
+
+	token, err := handler.AuthApplication.ReissueSignUp(r.Context(), signUpRequest, userID)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
 	jwtmiddleware.WriteJSONResponse(w, token, http.StatusOK)
 }
 
@@ -106,6 +131,7 @@ func (handler *AuthHttpHandler) EarlyAccessHandler(w http.ResponseWriter, r *htt
 // @Accept json
 // @Produce json
 // @Param domain.SigningRequest body domain.SigningRequest true "user sign in request body"
+// @Param Authorization header string false "guest token of the device, its cart is merged into the customer cart"
 // @Success 200 {object} domain.DefaultSigningResponse
 // @Router /session/signin [post]
 func (handler *AuthHttpHandler) SignInHandler(w http.ResponseWriter, r *http.Request) {
@@ -227,6 +253,35 @@ func (handler *AuthHttpHandler) AdminSignUpHandler(w http.ResponseWriter, r *htt
 
 }
 
+// CreateRiderHandler godoc
+// @Summary Create Rider
+// @Description The endpoint allows admins to create the account of a rider, riders cannot sign up themselves
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param domain.CreateRiderRequest body domain.CreateRiderRequest true "create rider request body"
+// @Security BearerToken
+// @Success 200 {object} models.User
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Router /session/admin/rider [post]
+func (handler *AuthHttpHandler) CreateRiderHandler(w http.ResponseWriter, r *http.Request) {
+	var request domain.CreateRiderRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		jwtmiddleware.WriteJSONErrorResponse(w, http.StatusBadRequest, errs.Body(errs.InvalidRequestError, err))
+		return
+	}
+
+	rider, err := handler.AuthApplication.CreateRider(r.Context(), request)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, rider, http.StatusOK)
+}
+
 // ReceiveGuestjwtManager godoc
 // @Summary Request accept guests
 // @Description The endpoint to allow guests to shop
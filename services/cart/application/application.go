@@ -5,15 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"github.com/greenbone/opensight-golang-libraries/pkg/query"
-	"github.com/greenbone/opensight-golang-libraries/pkg/query/filter"
-	"github.com/greenbone/opensight-golang-libraries/pkg/query/paging"
+	"github.com/leetatech/leeta_backend/pkg/config"
 	"github.com/leetatech/leeta_backend/pkg/errs"
-	"github.com/leetatech/leeta_backend/pkg/helpers"
 	"github.com/leetatech/leeta_backend/pkg/idgenerator"
 	"github.com/leetatech/leeta_backend/pkg/jwtmiddleware"
 	mailer "github.com/leetatech/leeta_backend/pkg/notification/mailer/aws"
-	"go.mongodb.org/mongo-driver/bson"
-	"strings"
+	"github.com/leetatech/leeta_backend/pkg/payment"
+	"github.com/leetatech/leeta_backend/pkg/pricing"
+	"github.com/samber/lo"
 	"time"
 
 	"github.com/leetatech/leeta_backend/pkg"
@@ -27,6 +26,11 @@ type CartApplicationManager struct {
 	jwtManager        jwtmiddleware.Manager
 	EmailClient       mailer.Client
 	repositoryManager pkg.RepositoryManager
+	paymentProviders  payment.Providers
+	paymentConfig     config.PaymentConfig
+	checkoutConfig    config.CheckoutConfig
+	slotConfig        config.SlotConfig
+	pricer            *pricing.Pricer
 }
 
 type Cart interface {
@@ -35,7 +39,10 @@ type Cart interface {
 	Add(ctx context.Context, item domain.CartItem) (models.Cart, error)
 	UpdateItemQuantity(ctx context.Context, itemQuantity domain.UpdateCartItemQuantityRequest) (models.Cart, error)
 	ListCart(ctx context.Context, request query.ResultSelector) (models.Cart, uint64, error)
-	Checkout(ctx context.Context, request domain.CartCheckoutRequest) (*pkg.DefaultResponse, error)
+	Quote(ctx context.Context, request domain.QuoteRequest) (*domain.QuoteResponse, error)
+	Checkout(ctx context.Context, request domain.CartCheckoutRequest) (*domain.CheckoutResponse, error)
+	ApplyPromoCode(ctx context.Context, request domain.ApplyPromoCodeRequest) (models.Cart, error)
+	RemovePromoCode(ctx context.Context) (models.Cart, error)
 }
 
 func New(applicationContext pkg.ApplicationContext) Cart {
@@ -44,6 +51,11 @@ func New(applicationContext pkg.ApplicationContext) Cart {
 		jwtManager:        applicationContext.JwtManager,
 		EmailClient:       applicationContext.MailClient,
 		repositoryManager: applicationContext.RepositoryManager,
+		paymentProviders:  applicationContext.PaymentProviders,
+		paymentConfig:     applicationContext.Config.Payment,
+		checkoutConfig:    applicationContext.Config.Checkout,
+		slotConfig:        applicationContext.Config.Slot,
+		pricer:            pricing.New(applicationContext.RepositoryManager),
 	}
 }
 
@@ -73,9 +85,13 @@ func (c *CartApplicationManager) Add(ctx context.Context, request domain.CartIte
 		}
 	}
 
-	fee, err := c.repositoryManager.FeesRepository.ByProductID(ctx, product.ID, models.FeesActive)
-	if err != nil {
-		return cart, errs.Body(errs.FeesError, fmt.Errorf("error getting fee %w", err))
+	switch {
+	case request.Mode == "" && product.ParentCategory == models.LPGProductCategory:
+		request.Mode = models.CylinderExchange
+	case request.Mode != "" && product.ParentCategory != models.LPGProductCategory:
+		return cart, errs.Body(errs.InvalidRequestError, fmt.Errorf("cylinder mode only applies to %s products", models.LPGProductCategory))
+	case request.Mode != "" && !models.IsValidCylinderMode(request.Mode):
+		return cart, errs.Body(errs.InvalidRequestError, fmt.Errorf("invalid cylinder mode %s", request.Mode))
 	}
 
 	cartItem := models.CartItem{
@@ -85,14 +101,20 @@ func (c *CartApplicationManager) Add(ctx context.Context, request domain.CartIte
 		VendorID:        product.VendorID,
 		Weight:          request.Weight,
 		Quantity:        request.Quantity,
+		Mode:            request.Mode,
 	}
 
-	cartItem.Cost, err = cartItem.CalculateCartItemFee(fee)
-	if cartItem.Cost == 0 || err != nil {
+	fee, depositFee, err := c.retrieveItemFees(ctx, cartItem)
+	if err != nil {
+		return cart, errs.Body(errs.FeesError, fmt.Errorf("error getting fee %w", err))
+	}
+
+	cartItem.Cost, err = cartItem.CalculateCartItemFee(fee, depositFee)
+	if !cartItem.Cost.IsPositive() || err != nil {
 		return cart, errs.Body(errs.InternalError, fmt.Errorf("unable to calculate cart fee %w", err))
 	}
 
-	cart, err = c.repositoryManager.CartRepository.GetActiveCartByCustomerID(ctx, claims.UserID)
+	cart, err = c.userCart(ctx, claims)
 	if err != nil {
 		switch {
 		case errors.Is(err, mongo.ErrNoDocuments):
@@ -105,12 +127,17 @@ func (c *CartApplicationManager) Add(ctx context.Context, request domain.CartIte
 				StatusTs:   time.Now().Unix(),
 				Ts:         time.Now().Unix(),
 			}
+			if claims.Role == models.GuestCategory {
+				cart.DeviceID = claims.DeviceID
+			}
 
 			addToCartErr := c.repositoryManager.CartRepository.AddToCart(ctx, cart)
 			if addToCartErr != nil {
 				return cart, errs.Body(errs.InternalError, fmt.Errorf("error when adding item to cart store %w", addToCartErr))
 			}
 			return cart, nil
+		case errors.Is(err, errNoGuestDevice):
+			return cart, errs.Body(errs.ErrorUnauthorized, err)
 		default:
 			return cart, errs.Body(errs.InternalError, fmt.Errorf("error getting cart item by customer id %w", err))
 		}
@@ -132,30 +159,42 @@ func (c *CartApplicationManager) Add(ctx context.Context, request domain.CartIte
 	return cart, nil
 }
 
-func (c *CartApplicationManager) calculateCartItemTotalCost(ctx context.Context, items []models.CartItem) (float64, error) {
-	var total float64
+func (c *CartApplicationManager) calculateCartItemTotalCost(ctx context.Context, items []models.CartItem) (models.Money, error) {
+	var total models.Money
 
-	fees, err := c.repositoryManager.FeesRepository.FeesByStatus(ctx, models.FeesActive)
+	fees, err := c.repositoryManager.FeesRepository.FeesInForce(ctx, time.Now().Unix())
 	if err != nil {
-		return 0, err
+		return total, err
+	}
+
+	productFees := make(map[string]*models.Fee)
+	depositFees := make(map[string]*models.Fee)
+	for i, fee := range fees {
+		switch fee.FeeType {
+		case models.ProductFee:
+			productFees[fee.ProductID] = &fees[i]
+		case models.DepositFee:
+			depositFees[fee.ProductID] = &fees[i]
+		}
 	}
 
 	for _, item := range items {
-		for _, fee := range fees {
-			if fee.ProductID == item.ProductID {
-				cartTotalFee, err := item.CalculateCartItemFee(&fee)
-				if err != nil {
-					return 0, fmt.Errorf("error calculating cart fee %w", err)
-				}
-				total += cartTotalFee
-			}
+		fee, ok := productFees[item.ProductID]
+		if !ok {
+			continue
 		}
+
+		cartTotalFee, err := item.CalculateCartItemFee(fee, depositFees[item.ProductID])
+		if err != nil {
+			return total, fmt.Errorf("error calculating cart fee %w", err)
+		}
+		total = total.Add(cartTotalFee)
 	}
 
 	return total, nil
 }
 func (c *CartApplicationManager) UpdateItemQuantity(ctx context.Context, request domain.UpdateCartItemQuantityRequest) (updatedCart models.Cart, err error) {
-	_, err = c.jwtManager.ExtractUserClaims(ctx)
+	claims, err := c.jwtManager.ExtractUserClaims(ctx)
 	if err != nil {
 		return updatedCart, errs.Body(errs.ErrorUnauthorized, err)
 	}
@@ -164,6 +203,9 @@ func (c *CartApplicationManager) UpdateItemQuantity(ctx context.Context, request
 	if err != nil {
 		return updatedCart, errs.Body(errs.InternalError, fmt.Errorf("error getting cart item with cart item id '%s': %w", request.CartItemID, err))
 	}
+	if !ownsCart(claims, cart) {
+		return updatedCart, errs.Body(errs.ErrorUnauthorized, errors.New("cart item belongs to another user"))
+	}
 	cartItem, index, err := c.retrieveCartItemFromUpdateRequest(ctx, request, cart)
 	if err != nil {
 		return updatedCart, errs.Body(errs.InternalError, fmt.Errorf("error retrieving cartItem: %w", err))
@@ -207,17 +249,13 @@ func (c *CartApplicationManager) retrieveCartItemFromUpdateRequest(ctx context.C
 	return
 }
 
-func (c *CartApplicationManager) retrieveProductFee(ctx context.Context, productID string) (*models.Fee, error) {
-	fee, err := c.repositoryManager.FeesRepository.ByProductID(ctx, productID, models.FeesActive)
-	if err != nil {
-		return nil, fmt.Errorf("error retrieving fee for product with id '%s': %w", productID, err)
-	}
-
-	return fee, nil
+// retrieveItemFees returns the product fee of the cart item, and the deposit fee when the item buys new cylinders
+func (c *CartApplicationManager) retrieveItemFees(ctx context.Context, item models.CartItem) (*models.Fee, *models.Fee, error) {
+	return c.pricer.ItemFees(ctx, item, time.Now().Unix())
 }
 
 func (c *CartApplicationManager) adjustCartItemAndCalculateCost(ctx context.Context, item models.CartItem) (cartItem models.CartItem, err error) {
-	fee, err := c.retrieveProductFee(ctx, item.ProductID)
+	fee, depositFee, err := c.retrieveItemFees(ctx, item)
 	if err != nil {
 		err = fmt.Errorf("error retriving cart item product fee %w", err)
 		return
@@ -225,9 +263,10 @@ func (c *CartApplicationManager) adjustCartItemAndCalculateCost(ctx context.Cont
 
 	switch item.ProductCategory {
 	case models.LNGProductCategory, models.LPGProductCategory:
-		itemWeightCost := item.Weight * float32(fee.Cost.CostPerKG)
-		itemCost := itemWeightCost * float32(item.Quantity)
-		item.Cost = float64(itemCost)
+		item.Cost, err = item.CalculateCartItemFee(fee, depositFee)
+		if err != nil {
+			return
+		}
 	default:
 		// TODO: refactor this when we know more product categories
 		err = errors.New("invalid product category, when updating product quantity")
@@ -238,7 +277,7 @@ func (c *CartApplicationManager) adjustCartItemAndCalculateCost(ctx context.Cont
 }
 
 func (c *CartApplicationManager) DeleteItem(ctx context.Context, itemId string) error {
-	_, err := c.jwtManager.ExtractUserClaims(ctx)
+	claims, err := c.jwtManager.ExtractUserClaims(ctx)
 	if err != nil {
 		return errs.Body(errs.ErrorUnauthorized, err)
 	}
@@ -247,8 +286,11 @@ func (c *CartApplicationManager) DeleteItem(ctx context.Context, itemId string)
 	if err != nil {
 		return errs.Body(errs.DatabaseError, fmt.Errorf("error getting cart item with cart item id '%s': %w", itemId, err))
 	}
+	if !ownsCart(claims, cart) {
+		return errs.Body(errs.ErrorUnauthorized, errors.New("cart item belongs to another user"))
+	}
 
-	var itemCost float64
+	var itemCost models.Money
 	for _, item := range cart.CartItems {
 		if item.ID == itemId {
 			itemCost = item.Cost
@@ -277,121 +319,258 @@ func (c *CartApplicationManager) ListCart(ctx context.Context, request query.Res
 	return cart, totalResults, nil
 }
 
-func (c *CartApplicationManager) Checkout(ctx context.Context, request domain.CartCheckoutRequest) (*pkg.DefaultResponse, error) {
+func (c *CartApplicationManager) Checkout(ctx context.Context, request domain.CartCheckoutRequest) (*domain.CheckoutResponse, error) {
 	claims, err := c.jwtManager.ExtractUserClaims(ctx)
 	if err != nil {
 		return nil, errs.Body(errs.ErrorUnauthorized, err)
 	}
 
-	cart, err := c.repositoryManager.CartRepository.GetCartByCustomerID(ctx, claims.UserID)
+	request.PaymentMethod, err = models.SetPaymentMethod(request.PaymentMethod)
 	if err != nil {
-		switch {
-		case errors.Is(err, mongo.ErrNoDocuments):
-			return nil, errs.Body(errs.InvalidRequestError, fmt.Errorf("invalid cart id: %w", err))
-		default:
-			return nil, errs.Body(errs.InternalError, fmt.Errorf("error retrieving cart item on checkout: %w", err))
-		}
+		return nil, err
 	}
 
-	if cart.Status == models.CartCheckedOut {
-		return nil, errs.Body(errs.InvalidRequestError, fmt.Errorf("cart id '%s' is already checked out", cart.ID))
+	if request.SlotID == "" {
+		return nil, errs.Body(errs.InvalidRequestError, errors.New("delivery slot is required"))
 	}
 
-	err = c.validateFees(ctx, request.DeliveryDetails.Address, request.DeliveryFee, request.ServiceFee)
+	quote, err := c.parseQuote(request.QuoteID)
 	if err != nil {
 		return nil, err
 	}
 
-	err = c.checkout(ctx, claims.UserID, request, cart)
+	if quote.Subject != claims.UserID {
+		return nil, errs.Body(errs.InvalidQuoteError, errors.New("quote was issued to another user"))
+	}
+
+	cart, err := c.activeCart(ctx, claims)
 	if err != nil {
 		return nil, err
 	}
 
-	return &pkg.DefaultResponse{Success: "success", Message: "Checkout successful"}, nil
-}
+	if claims.Role == models.GuestCategory {
+		request.DeliveryDetails, err = c.guestDeliveryDetails(ctx, claims, request.DeliveryDetails)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-func (c *CartApplicationManager) checkout(ctx context.Context, userID string, request domain.CartCheckoutRequest, cart models.Cart) (err error) {
+	if cart.ID != quote.CartID || cartFingerprint(cart) != quote.CartFingerprint {
+		return nil, errs.Body(errs.InvalidQuoteError, errors.New("cart has changed since it was quoted"))
+	}
 
-	totalCost := cart.Total + request.DeliveryFee + request.ServiceFee
+	if pricing.AddressLGA(request.DeliveryDetails.Address) != quote.LGA {
+		return nil, errs.Body(errs.InvalidQuoteError, errors.New("delivery address is not in the quoted lga"))
+	}
 
-	if helpers.RoundToTwoDecimalPlaces(request.TotalFee) < helpers.RoundToTwoDecimalPlaces(totalCost) {
-		return errs.Body(errs.AmountPaidError, errors.New("amount paid does not match total cost"))
+	// delivery fees priced by distance are only valid for the location they were priced to
+	if quote.Location != (models.Coordinates{}) && request.DeliveryDetails.Address.Coordinates != quote.Location {
+		return nil, errs.Body(errs.InvalidQuoteError, errors.New("delivery address is not at the quoted location"))
 	}
 
-	orderStatus := []models.StatusHistory{
-		{
-			Status:   models.OrderPending,
-			StatusTs: time.Now().Unix(),
-		},
+	// the promotion may have expired, been deactivated or used up since the cart was quoted
+	if quote.PromotionID != "" {
+		promotion, err := c.redeemablePromotion(ctx, claims, cart.PromoCode)
+		if err != nil {
+			return nil, err
+		}
+		if promotion.ID != quote.PromotionID {
+			return nil, errs.Body(errs.InvalidQuoteError, errors.New("promotion has changed since the cart was quoted"))
+		}
 	}
 
-	order := models.Order{
-		ID:              c.idgenerator.Generate(),
-		Orders:          cart.CartItems,
-		CustomerID:      userID,
-		DeliveryDetails: request.DeliveryDetails,
-		PaymentMethod:   request.PaymentMethod,
-		DeliveryFee:     request.DeliveryFee,
-		ServiceFee:      request.ServiceFee,
-		Total:           request.TotalFee,
-		StatusHistory:   orderStatus,
-		StatusTs:        time.Now().Unix(),
-		Ts:              time.Now().Unix(),
+	return c.checkout(ctx, claims.UserID, request, cart, quote)
+}
+
+// activeCart returns the user's active cart, which must hold at least one item to be quoted or checked out
+func (c *CartApplicationManager) activeCart(ctx context.Context, claims *jwtmiddleware.UserClaims) (models.Cart, error) {
+	cart, err := c.userCart(ctx, claims)
+	if err != nil {
+		switch {
+		case errors.Is(err, errNoGuestDevice):
+			return cart, errs.Body(errs.ErrorUnauthorized, err)
+		case errors.Is(err, mongo.ErrNoDocuments):
+			return cart, errs.Body(errs.InvalidRequestError, errors.New("no active cart found"))
+		default:
+			return cart, errs.Body(errs.InternalError, fmt.Errorf("error retrieving active cart: %w", err))
+		}
 	}
 
-	err = c.repositoryManager.OrderRepository.Create(ctx, order)
+	if len(cart.CartItems) == 0 {
+		return cart, errs.Body(errs.InvalidRequestError, fmt.Errorf("cart id '%s' is empty", cart.ID))
+	}
+
+	return cart, nil
+}
+
+// checkout creates one child order per vendor in the cart under a single parent checkout and initializes its payment.
+// Prices and fees are taken from the signed quote, every vendor order is delivered separately so each carries its own delivery and service fee.
+// Orders wait for the payment to be confirmed unless they are paid on delivery.
+func (c *CartApplicationManager) checkout(ctx context.Context, userID string, request domain.CartCheckoutRequest, cart models.Cart, quote *quoteClaims) (*domain.CheckoutResponse, error) {
+	provider, err := c.paymentProviders.ForMethod(request.PaymentMethod)
 	if err != nil {
-		return errs.Body(errs.InternalError, fmt.Errorf("error creating order when checking out of cart %w", err))
+		return nil, errs.Body(errs.PaymentMethodError, err)
 	}
 
-	err = c.repositoryManager.CartRepository.CheckoutCart(ctx, cart.ID)
+	// orders can only be booked in slots starting after the booking lead
+	bookableAfter := time.Now().Add(c.slotConfig.BookingLead).Unix()
+	slot, err := c.bookableSlot(ctx, request.SlotID, quote.LGA, len(quote.Lines), bookableAfter)
 	if err != nil {
-		return errs.Body(errs.InternalError, fmt.Errorf("error clearing cart %w", err))
+		return nil, err
 	}
 
-	return nil
-}
+	for i, item := range cart.CartItems {
+		cart.CartItems[i].Cost = quote.ItemCosts[item.ID]
+		cart.CartItems[i].Deposit = quote.ItemDeposits[item.ID]
+	}
+	vendorItems := cart.GroupItemsByVendor()
+	quoteLines := lo.KeyBy(quote.Lines, func(line domain.QuoteLine) string {
+		return line.VendorID
+	})
 
-func (c *CartApplicationManager) validateFees(ctx context.Context, address models.Address, deliveryFee, serviceFee float64) error {
-	// get delivery fee from database
-	getRequest := query.ResultSelector{
-		Filter: &filter.Request{
-			Operator: "and",
-			Fields: []filter.RequestField{
-				{
-					Name:  "lga",
-					Value: models.LGA{LGA: address.LGA, State: strings.ToUpper(address.State)},
-				},
-				{
-					Name:  "fee_type",
-					Value: bson.M{"$in": []models.FeeType{models.DeliveryFee, models.ServiceFee}},
-				},
+	orderStatus := models.OrderAwaitingPayment
+	if request.PaymentMethod.IsPaidOnDelivery() {
+		orderStatus = models.OrderPending
+	}
+
+	now := time.Now().Unix()
+	checkout := models.Checkout{
+		ID:              c.idgenerator.Generate(),
+		CartID:          cart.ID,
+		CustomerID:      userID,
+		DeliveryDetails: request.DeliveryDetails,
+		PaymentMethod:   request.PaymentMethod,
+		Total:           quote.Total,
+		Ts:              now,
+	}
+
+	orders := make([]models.Order, 0, len(vendorItems))
+	for _, vendor := range vendorItems {
+		line, ok := quoteLines[vendor.VendorID]
+		if !ok {
+			return nil, errs.Body(errs.InvalidQuoteError, fmt.Errorf("vendor '%s' is missing from the quote", vendor.VendorID))
+		}
+
+		order := models.Order{
+			ID:              c.idgenerator.Generate(),
+			CheckoutID:      checkout.ID,
+			Orders:          vendor.Items,
+			CustomerID:      userID,
+			VendorID:        vendor.VendorID,
+			DeliveryDetails: request.DeliveryDetails,
+			PaymentMethod:   request.PaymentMethod,
+			DeliverySlot:    &models.OrderSlot{ID: slot.ID, StartTs: slot.StartTs, EndTs: slot.EndTs},
+			DeliveryFee:     line.DeliveryFee,
+			ServiceFee:      line.ServiceFee,
+			Discount:        line.Discount,
+			Total:           line.Total,
+			Status:          orderStatus,
+			StatusHistory: []models.StatusHistory{
 				{
-					Name:  "status",
-					Value: models.FeesActive,
+					Status:   orderStatus,
+					StatusTs: now,
 				},
 			},
-		},
-		Paging: &paging.Request{},
+			StatusTs: now,
+			Ts:       now,
+		}
+
+		orders = append(orders, order)
+		checkout.OrderIDs = append(checkout.OrderIDs, order.ID)
+	}
+
+	paymentRecord := models.Payment{
+		ID:         c.idgenerator.Generate(),
+		CheckoutID: checkout.ID,
+		CustomerID: userID,
+		Method:     request.PaymentMethod,
+		Provider:   provider.Name(),
+		Amount:     quote.Total,
+		Status:     models.PaymentPending,
+		StatusTs:   now,
+		Ts:         now,
+	}
+	paymentRecord.Reference = paymentRecord.ID
+	checkout.PaymentID = paymentRecord.ID
+
+	var redemption *models.PromotionRedemption
+	if quote.PromotionID != "" {
+		redemption = &models.PromotionRedemption{
+			ID:          c.idgenerator.Generate(),
+			PromotionID: quote.PromotionID,
+			Code:        cart.PromoCode,
+			CustomerID:  userID,
+			CheckoutID:  checkout.ID,
+			OrderIDs:    checkout.OrderIDs,
+			Ts:          now,
+		}
+		for _, order := range orders {
+			if order.Discount != nil {
+				redemption.Discount = redemption.Discount.Add(order.Discount.Total)
+			}
+		}
 	}
-	fees, _, err := c.repositoryManager.FeesRepository.Fees(ctx, getRequest)
+
+	initialized, err := provider.Initialize(ctx, payment.InitializeRequest{
+		Reference:   paymentRecord.Reference,
+		Amount:      paymentRecord.Amount,
+		Email:       request.DeliveryDetails.Email,
+		CallbackURL: c.paymentConfig.CallbackURL,
+	})
 	if err != nil {
-		return err
+		return nil, errs.Body(errs.PaymentError, fmt.Errorf("error initializing payment: %w", err))
 	}
-	// validate delivery fee
-	for _, fee := range fees {
-		switch fee.FeeType {
-		case models.DeliveryFee:
-			if fee.Cost.CostPerType != deliveryFee {
-				return errs.Body(errs.InvalidDeliveryFeeError, errors.New("invalid delivery fee"))
-			}
+	paymentRecord.AuthorizationURL = initialized.AuthorizationURL
+
+	// the orders, the payment, the slot reservation, the promotion redemption and the checked out cart are written together,
+	// so a failed checkout can be retried without leaving orders behind an active cart or holding room in the slot
+	err = c.repositoryManager.Transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		// every vendor order is delivered separately, so each takes room in the slot
+		err := c.repositoryManager.SlotRepository.Reserve(ctx, slot.ID, quote.LGA, len(orders), bookableAfter)
+		if err != nil {
+			return err
+		}
 
-		case models.ServiceFee:
-			if fee.Cost.CostPerType != serviceFee {
-				return errs.Body(errs.InvalidServiceFeeError, errors.New("invalid service fee"))
+		err = c.repositoryManager.OrderRepository.CreateCheckout(ctx, checkout, orders)
+		if err != nil {
+			return errs.Body(errs.InternalError, fmt.Errorf("error creating orders when checking out of cart %w", err))
+		}
+
+		err = c.repositoryManager.PaymentRepository.Create(ctx, paymentRecord)
+		if err != nil {
+			return errs.Body(errs.InternalError, fmt.Errorf("error creating payment when checking out of cart %w", err))
+		}
+
+		if redemption != nil {
+			err = c.repositoryManager.PromotionRepository.Redeem(ctx, *redemption)
+			if err != nil {
+				return err
 			}
 		}
 
+		return c.repositoryManager.CartRepository.CheckoutCart(ctx, cart.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.CheckoutResponse{Checkout: checkout, Payment: paymentRecord}, nil
+}
+
+// bookableSlot checks the slot can take the orders before the payment is initialized. The room is only taken when the orders are created.
+func (c *CartApplicationManager) bookableSlot(ctx context.Context, slotID string, lga models.LGA, orders int, bookableAfter int64) (*models.DeliverySlot, error) {
+	slot, err := c.repositoryManager.SlotRepository.ByID(ctx, slotID)
+	if err != nil {
+		return nil, err
+	}
+
+	if slot.LGA != lga {
+		return nil, errs.Body(errs.InvalidRequestError, errors.New("delivery slot is not in the lga of the delivery address"))
 	}
-	return nil
+
+	if slot.Status != models.SlotActive || slot.StartTs <= bookableAfter || slot.Available() < orders {
+		return nil, errs.Body(errs.SlotUnavailableError, fmt.Errorf("delivery slot %s cannot take %d more orders", slot.ID, orders))
+	}
+
+	return slot, nil
 }
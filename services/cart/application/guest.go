@@ -0,0 +1,69 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/pkg/jwtmiddleware"
+	"github.com/leetatech/leeta_backend/services/models"
+	"strings"
+)
+
+// errNoGuestDevice rejects guest sessions that cannot be tied to a device cart
+var errNoGuestDevice = errors.New("guest session has no device")
+
+// userCart returns the active cart of the signed in user. Guests have no account, so their cart is kept per device
+// until they sign up or sign in on it and the cart is merged into their customer cart.
+func (c *CartApplicationManager) userCart(ctx context.Context, claims *jwtmiddleware.UserClaims) (models.Cart, error) {
+	if claims.Role != models.GuestCategory {
+		return c.repositoryManager.CartRepository.GetActiveCartByCustomerID(ctx, claims.UserID)
+	}
+
+	if claims.DeviceID == "" {
+		return models.Cart{}, errNoGuestDevice
+	}
+
+	return c.repositoryManager.CartRepository.GetCartByDeviceID(ctx, claims.DeviceID)
+}
+
+// ownsCart reports whether the signed in user may change the cart
+func ownsCart(claims *jwtmiddleware.UserClaims, cart models.Cart) bool {
+	if claims.Role == models.GuestCategory {
+		return cart.CustomerID == claims.UserID && cart.DeviceID == claims.DeviceID
+	}
+
+	return cart.CustomerID == claims.UserID
+}
+
+// guestDeliveryDetails completes the delivery details of a guest checkout with the contact details of the guest record.
+// Details sent with the checkout take precedence, guests must be reachable by phone and email to get their order.
+func (c *CartApplicationManager) guestDeliveryDetails(ctx context.Context, claims *jwtmiddleware.UserClaims, details models.ShippingInfo) (models.ShippingInfo, error) {
+	guest, err := c.repositoryManager.AuthRepository.GuestRecord(ctx, claims.DeviceID)
+	if err != nil {
+		return details, errs.Body(errs.UserNotFoundError, fmt.Errorf("error getting guest record of device %s: %w", claims.DeviceID, err))
+	}
+
+	if guest.ID != claims.UserID {
+		return details, errs.Body(errs.ErrorUnauthorized, errors.New("guest session does not belong to the device"))
+	}
+
+	if details.Name == "" {
+		details.Name = strings.TrimSpace(guest.FirstName + " " + guest.LastName)
+	}
+	if details.Phone == "" {
+		details.Phone = guest.Number
+	}
+	if details.Email == "" {
+		details.Email = guest.Email
+	}
+	if details.Address.State == "" && details.Address.LGA == "" {
+		details.Address = guest.Address
+	}
+
+	if details.Name == "" || details.Phone == "" || details.Email == "" {
+		return details, errs.Body(errs.InvalidRequestError, errors.New("guest name, phone and email are required to check out"))
+	}
+
+	return details, nil
+}
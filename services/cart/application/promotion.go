@@ -0,0 +1,223 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/pkg/jwtmiddleware"
+	"github.com/leetatech/leeta_backend/services/cart/domain"
+	"github.com/leetatech/leeta_backend/services/models"
+	"time"
+)
+
+// ApplyPromoCode applies a promo code to the active cart. The discount is priced when the cart is quoted,
+// and the promotion is checked again then and at checkout.
+func (c *CartApplicationManager) ApplyPromoCode(ctx context.Context, request domain.ApplyPromoCodeRequest) (models.Cart, error) {
+	claims, err := c.jwtManager.ExtractUserClaims(ctx)
+	if err != nil {
+		return models.Cart{}, errs.Body(errs.ErrorUnauthorized, err)
+	}
+
+	cart, err := c.activeCart(ctx, claims)
+	if err != nil {
+		return cart, err
+	}
+
+	promotion, err := c.redeemablePromotion(ctx, claims, request.Code)
+	if err != nil {
+		return cart, err
+	}
+
+	if _, _, err = eligibleItems(promotion, cart.CartItems); err != nil {
+		return cart, err
+	}
+
+	err = c.repositoryManager.CartRepository.SetPromoCode(ctx, cart.ID, promotion.Code)
+	if err != nil {
+		return cart, err
+	}
+	cart.PromoCode = promotion.Code
+
+	return cart, nil
+}
+
+func (c *CartApplicationManager) RemovePromoCode(ctx context.Context) (models.Cart, error) {
+	claims, err := c.jwtManager.ExtractUserClaims(ctx)
+	if err != nil {
+		return models.Cart{}, errs.Body(errs.ErrorUnauthorized, err)
+	}
+
+	cart, err := c.activeCart(ctx, claims)
+	if err != nil {
+		return cart, err
+	}
+
+	err = c.repositoryManager.CartRepository.SetPromoCode(ctx, cart.ID, "")
+	if err != nil {
+		return cart, err
+	}
+	cart.PromoCode = ""
+
+	return cart, nil
+}
+
+// redeemablePromotion returns the promotion of the code when the signed in user can redeem it now
+func (c *CartApplicationManager) redeemablePromotion(ctx context.Context, claims *jwtmiddleware.UserClaims, code string) (*models.Promotion, error) {
+	code = models.NormalizePromoCode(code)
+	if code == "" {
+		return nil, errs.Body(errs.InvalidRequestError, errors.New("promo code is required"))
+	}
+
+	promotion, err := c.repositoryManager.PromotionRepository.ByCode(ctx, code)
+	if err != nil {
+		var lerr *errs.Response
+		if errors.As(err, &lerr) && lerr.ErrorCode == errs.DatabaseNoRecordError {
+			return nil, errs.Body(errs.PromotionError, fmt.Errorf("promo code %s does not exist", code))
+		}
+		return nil, err
+	}
+
+	segment, err := c.customerSegment(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = promotion.CheckRedeemable(time.Now().Unix(), segment); err != nil {
+		return nil, errs.Body(errs.PromotionError, err)
+	}
+
+	// checked again when the promotion is redeemed, this only spares the customer a checkout that would fail
+	if promotion.PerCustomerLimit > 0 {
+		redemptions, err := c.repositoryManager.PromotionRepository.CustomerRedemptions(ctx, promotion.ID, claims.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if redemptions >= int64(promotion.PerCustomerLimit) {
+			return nil, errs.Body(errs.PromotionError, fmt.Errorf("you have already used promo code %s", code))
+		}
+	}
+
+	return promotion, nil
+}
+
+// customerSegment returns the segment of the signed in user promotions can be limited to
+func (c *CartApplicationManager) customerSegment(ctx context.Context, claims *jwtmiddleware.UserClaims) (models.UserSegment, error) {
+	if claims.Role == models.GuestCategory {
+		return models.SegmentGuest, nil
+	}
+
+	orders, err := c.repositoryManager.OrderRepository.CountPlacedOrders(ctx, claims.UserID)
+	if err != nil {
+		return "", err
+	}
+
+	if orders == 0 {
+		return models.SegmentNewCustomer, nil
+	}
+	return models.SegmentReturningCustomer, nil
+}
+
+// eligibleItems sums the cost of the cart items eligible for the promotion per vendor, and checks they weigh enough.
+// Cylinder deposits are refunded when the cylinders come back, so they are never discounted.
+func eligibleItems(promotion *models.Promotion, items []models.CartItem) (map[string]models.Money, models.Money, error) {
+	eligible := make(map[string]models.Money)
+	var total models.Money
+	var weight float64
+	for _, item := range items {
+		if !promotion.AppliesToProduct(item.ProductID) {
+			continue
+		}
+		cost := item.Cost.Sub(item.Deposit)
+		eligible[item.VendorID] = eligible[item.VendorID].Add(cost)
+		total = total.Add(cost)
+		weight += item.Weight * float64(item.Quantity)
+	}
+
+	if len(eligible) == 0 {
+		return nil, total, errs.Body(errs.PromotionError, fmt.Errorf("no item in the cart is eligible for promo code %s", promotion.Code))
+	}
+
+	if weight < promotion.MinWeight {
+		return nil, total, errs.Body(errs.PromotionError, fmt.Errorf("promo code %s needs at least %gkg of eligible items", promotion.Code, promotion.MinWeight))
+	}
+
+	return eligible, total, nil
+}
+
+// applyPromotion takes the discount of the promotion off the vendor lines of the quote. Items are priced at the quoted costs,
+// a discount shared by several vendors is split in proportion to their eligible items.
+func applyPromotion(promotion *models.Promotion, cart models.Cart, quote *quoteClaims) error {
+	if !promotion.AppliesToLGA(quote.LGA) {
+		return errs.Body(errs.PromotionError, fmt.Errorf("promo code %s is not valid in %s, %s", promotion.Code, quote.LGA.LGA, quote.LGA.State))
+	}
+
+	items := make([]models.CartItem, len(cart.CartItems))
+	for i, item := range cart.CartItems {
+		items[i] = item
+		items[i].Cost = quote.ItemCosts[item.ID]
+		items[i].Deposit = quote.ItemDeposits[item.ID]
+	}
+
+	eligible, eligibleTotal, err := eligibleItems(promotion, items)
+	if err != nil {
+		return err
+	}
+
+	var itemsDiscount models.Money
+	switch promotion.Type {
+	case models.PromotionPercentage:
+		itemsDiscount = eligibleTotal.Percent(promotion.Value)
+		if promotion.MaxDiscount.IsPositive() {
+			itemsDiscount = itemsDiscount.Min(promotion.MaxDiscount)
+		}
+	case models.PromotionFixedAmount:
+		itemsDiscount = promotion.Amount.Min(eligibleTotal)
+	}
+	itemsDiscounts := splitDiscount(itemsDiscount, eligible, quote.Lines)
+
+	quote.PromotionID = promotion.ID
+	quote.Total = models.Money{}
+	for i, line := range quote.Lines {
+		if _, ok := eligible[line.VendorID]; ok {
+			discount := &models.OrderDiscount{
+				PromotionID:   promotion.ID,
+				Code:          promotion.Code,
+				Type:          promotion.Type,
+				ItemsDiscount: itemsDiscounts[line.VendorID],
+			}
+			if promotion.Type == models.PromotionFreeDelivery {
+				discount.DeliveryDiscount = line.DeliveryFee
+			}
+			discount.Total = discount.ItemsDiscount.Add(discount.DeliveryDiscount)
+
+			quote.Lines[i].Discount = discount
+			quote.Lines[i].Total = line.ItemsTotal.Add(line.DeliveryFee).Add(line.ServiceFee).Sub(discount.Total)
+		}
+		quote.Total = quote.Total.Add(quote.Lines[i].Total)
+	}
+
+	return nil
+}
+
+// splitDiscount splits the amount over the vendors in proportion to their eligible items, in the order of the lines.
+// The parts are shared by largest remainder, so they add up to the amount.
+func splitDiscount(amount models.Money, eligible map[string]models.Money, lines []domain.QuoteLine) map[string]models.Money {
+	var vendors []string
+	var weights []int64
+	for _, line := range lines {
+		vendorTotal, ok := eligible[line.VendorID]
+		if !ok {
+			continue
+		}
+		vendors = append(vendors, line.VendorID)
+		weights = append(weights, vendorTotal.Amount)
+	}
+
+	discounts := make(map[string]models.Money, len(vendors))
+	for i, part := range amount.Allocate(weights) {
+		discounts[vendors[i]] = part
+	}
+
+	return discounts
+}
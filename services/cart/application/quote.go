@@ -0,0 +1,168 @@
+package application
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/services/cart/domain"
+	"github.com/leetatech/leeta_backend/services/models"
+	"sort"
+	"strings"
+	"time"
+)
+
+// quoteClaims is the content of a signed quote. The quote is bound to the customer, the exact cart content
+// and the delivery lga, and to the delivery location when it was priced by distance, so checkout can trust its prices without recomputing them from client input.
+type quoteClaims struct {
+	jwt.StandardClaims
+	CartID          string     `json:"cart_id"`
+	CartFingerprint string     `json:"cart_fingerprint"`
+	LGA             models.LGA `json:"lga"`
+	// Location is the delivery address coordinates the delivery fees were priced by distance to, it is zero when they were
+	// priced at the flat lga fee
+	Location  models.Coordinates      `json:"location,omitempty"`
+	ItemCosts map[string]models.Money `json:"item_costs"`
+	// ItemDeposits holds the cylinder deposits included in the costs of new cylinder purchases
+	ItemDeposits map[string]models.Money `json:"item_deposits,omitempty"`
+	Lines        []domain.QuoteLine      `json:"lines"`
+	// PromotionID is the promotion discounted on the lines, it is redeemed on checkout
+	PromotionID string       `json:"promotion_id,omitempty"`
+	Total       models.Money `json:"total"`
+}
+
+func (c *CartApplicationManager) Quote(ctx context.Context, request domain.QuoteRequest) (*domain.QuoteResponse, error) {
+	claims, err := c.jwtManager.ExtractUserClaims(ctx)
+	if err != nil {
+		return nil, errs.Body(errs.ErrorUnauthorized, err)
+	}
+
+	if request.Address.State == "" || request.Address.LGA == "" {
+		return nil, errs.Body(errs.InvalidRequestError, errors.New("address state and lga are required"))
+	}
+
+	cart, err := c.activeCart(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	quote, err := c.priceCart(ctx, cart, request.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	if cart.PromoCode != "" {
+		promotion, err := c.redeemablePromotion(ctx, claims, cart.PromoCode)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = applyPromotion(promotion, cart, quote); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	quote.StandardClaims = jwt.StandardClaims{
+		Subject:   claims.UserID,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(c.checkoutConfig.QuoteTTL).Unix(),
+	}
+
+	quoteID, err := jwt.NewWithClaims(jwt.SigningMethodHS256, quote).SignedString([]byte(c.checkoutConfig.QuoteSigningSecret))
+	if err != nil {
+		return nil, errs.Body(errs.TokenGenerationError, fmt.Errorf("error signing quote: %w", err))
+	}
+
+	response := &domain.QuoteResponse{
+		QuoteID:   quoteID,
+		CartID:    cart.ID,
+		PromoCode: cart.PromoCode,
+		Lines:     quote.Lines,
+		Total:     quote.Total,
+		ExpiresAt: quote.ExpiresAt,
+	}
+	for _, line := range quote.Lines {
+		response.ItemsTotal = response.ItemsTotal.Add(line.ItemsTotal)
+		response.DeliveryFee = response.DeliveryFee.Add(line.DeliveryFee)
+		response.ServiceFee = response.ServiceFee.Add(line.ServiceFee)
+		if line.Discount != nil {
+			response.Discount = response.Discount.Add(line.Discount.Total)
+		}
+	}
+
+	return response, nil
+}
+
+// priceCart prices the cart for delivery to the address, see pricing.Pricer.Price, and binds the prices to the cart content
+func (c *CartApplicationManager) priceCart(ctx context.Context, cart models.Cart, address models.Address) (*quoteClaims, error) {
+	price, err := c.pricer.Price(ctx, cart.CartItems, address, time.Now().Unix())
+	if err != nil {
+		return nil, err
+	}
+
+	quote := &quoteClaims{
+		CartID:          cart.ID,
+		CartFingerprint: cartFingerprint(cart),
+		LGA:             price.LGA,
+		Location:        price.Location,
+		ItemCosts:       make(map[string]models.Money, len(price.Items)),
+		Total:           price.Total,
+	}
+
+	for _, item := range price.Items {
+		quote.ItemCosts[item.ID] = item.Cost
+		if item.Deposit.IsPositive() {
+			if quote.ItemDeposits == nil {
+				quote.ItemDeposits = make(map[string]models.Money)
+			}
+			quote.ItemDeposits[item.ID] = item.Deposit
+		}
+	}
+
+	for _, line := range price.Lines {
+		quote.Lines = append(quote.Lines, domain.QuoteLine{
+			VendorID:    line.VendorID,
+			ItemsTotal:  line.ItemsTotal,
+			DeliveryFee: line.DeliveryFee,
+			ServiceFee:  line.ServiceFee,
+			Total:       line.Total,
+		})
+	}
+
+	return quote, nil
+}
+
+func (c *CartApplicationManager) parseQuote(quoteID string) (*quoteClaims, error) {
+	quote := &quoteClaims{}
+	token, err := jwt.ParseWithClaims(quoteID, quote, func(t *jwt.Token) (interface{}, error) {
+		if t.Method.Alg() != jwt.SigningMethodHS256.Alg() {
+			return nil, errors.New("invalid signing algorithm")
+		}
+		return []byte(c.checkoutConfig.QuoteSigningSecret), nil
+	})
+	if err != nil {
+		return nil, errs.Body(errs.InvalidQuoteError, err)
+	}
+	if !token.Valid {
+		return nil, errs.Body(errs.InvalidQuoteError, errors.New("invalid quote"))
+	}
+
+	return quote, nil
+}
+
+// cartFingerprint identifies the content of a cart, so a quote cannot be used once items were added, removed or changed
+// or the promo code was changed
+func cartFingerprint(cart models.Cart) string {
+	items := make([]string, 0, len(cart.CartItems))
+	for _, item := range cart.CartItems {
+		items = append(items, fmt.Sprintf("%s|%s|%g|%d|%s", item.ID, item.ProductID, item.Weight, item.Quantity, item.Mode))
+	}
+	sort.Strings(items)
+
+	sum := sha256.Sum256([]byte(strings.Join(items, ";") + "#" + cart.PromoCode))
+	return hex.EncodeToString(sum[:])
+}
@@ -7,9 +7,11 @@ import (
 
 type CartItem struct {
 	ProductID string  `json:"product_id" bson:"product_id"`
-	Weight    float32 `json:"weight,omitempty" bson:"weight"`
+	Weight    float64 `json:"weight,omitempty" bson:"weight"`
 	Quantity  int     `json:"quantity,omitempty" bson:"quantity"`
-	Cost      float64 `json:"cost" bson:"cost"`
+	// Mode is EXCHANGE when the customer swaps empty cylinders and NEW_CYLINDER when buying new ones, it defaults to EXCHANGE for LPG
+	Mode models.CylinderMode `json:"mode,omitempty" bson:"mode,omitempty"`
+	Cost models.Money        `json:"cost" bson:"cost"`
 } // @name CartRefillDetails
 
 type UpdateCartItemQuantityRequest struct {
@@ -27,11 +29,54 @@ func (u *UpdateCartItemQuantityRequest) IsValid() (bool, error) {
 	return true, nil
 }
 
+// CartCheckoutRequest checks out the active cart at the prices of a quote obtained from the quote endpoint.
+// The delivery address must be in the lga the quote was priced for.
+// Guests may leave out delivery details held on their guest record, which are then used instead.
 type CartCheckoutRequest struct {
-	CartID          string              `json:"cart_id" bson:"cart_id"`
-	DeliveryDetails models.ShippingInfo `json:"delivery_details" bson:"delivery_details"`
-	PaymentMethod   string              `json:"payment_method" bson:"payment_method"`
-	DeliveryFee     float64             `json:"delivery_fee" bson:"delivery_fee"`
-	ServiceFee      float64             `json:"service_fee" bson:"service_fee"`
-	TotalFee        float64             `json:"total_fee" bson:"total_fee"`
+	QuoteID         string               `json:"quote_id" bson:"quote_id"`
+	DeliveryDetails models.ShippingInfo  `json:"delivery_details" bson:"delivery_details"`
+	PaymentMethod   models.PaymentMethod `json:"payment_method" bson:"payment_method"`
+	// SlotID is the delivery slot the orders are delivered in, from the slots available for the delivery address
+	SlotID string `json:"slot_id" bson:"slot_id"`
 } // @name CartCheckoutRequest
+
+type QuoteRequest struct {
+	Address models.Address `json:"address"`
+} // @name QuoteRequest
+
+// QuoteLine prices the part of the cart fulfilled by a single vendor
+type QuoteLine struct {
+	VendorID   string       `json:"vendor_id"`
+	ItemsTotal models.Money `json:"items_total"`
+	// DeliveryFee is charged on every vendor's order, as each vendor delivers its order separately
+	DeliveryFee models.Money `json:"delivery_fee"`
+	// ServiceFee is this vendor's share of the service fee charged once on the checkout
+	ServiceFee models.Money `json:"service_fee"`
+	// Discount is the part of the promotion applied to the cart that is taken off this vendor's order
+	Discount *models.OrderDiscount `json:"discount,omitempty"`
+	Total    models.Money          `json:"total"`
+} // @name QuoteLine
+
+type QuoteResponse struct {
+	// QuoteID is a signed token to send back on checkout before it expires
+	QuoteID     string       `json:"quote_id"`
+	CartID      string       `json:"cart_id"`
+	Lines       []QuoteLine  `json:"lines"`
+	ItemsTotal  models.Money `json:"items_total"`
+	DeliveryFee models.Money `json:"delivery_fee"`
+	ServiceFee  models.Money `json:"service_fee"`
+	PromoCode   string       `json:"promo_code,omitempty"`
+	Discount    models.Money `json:"discount"`
+	Total       models.Money `json:"total"`
+	ExpiresAt   int64        `json:"expires_at"`
+} // @name QuoteResponse
+
+type ApplyPromoCodeRequest struct {
+	Code string `json:"code"`
+} // @name ApplyPromoCodeRequest
+
+type CheckoutResponse struct {
+	Checkout models.Checkout `json:"checkout"`
+	// Payment holds the authorization url the customer completes the payment on, unless paying on delivery
+	Payment models.Payment `json:"payment"`
+} // @name CheckoutResponse
@@ -12,11 +12,16 @@ type CartRepository interface {
 	GetActiveCartByCustomerID(ctx context.Context, customerID string) (models.Cart, error)
 	GetCartByCustomerID(ctx context.Context, customerID string) (models.Cart, error)
 	GetCartByDeviceID(ctx context.Context, deviceID string) (models.Cart, error)
+	// UpdateCart replaces the cart. It fails if the cart is no longer active.
 	UpdateCart(ctx context.Context, request models.Cart) error
-	AddToCartItem(ctx context.Context, cartID string, cartItems models.CartItem, total float64, statusTs int64) error
-	DeleteCartItem(ctx context.Context, cartItemID string, itemTotalCost float64) error
+	// AddToCartItem adds the item to an active cart. It fails if the cart was checked out since it was read.
+	AddToCartItem(ctx context.Context, cartID string, cartItems models.CartItem, total models.Money, statusTs int64) error
+	DeleteCartItem(ctx context.Context, cartItemID string, itemTotalCost models.Money) error
 	DeleteCart(ctx context.Context, id string) error
 	GetCartByCartItemID(ctx context.Context, cartItemID string) (models.Cart, error)
 	ListCartItems(ctx context.Context, request query.ResultSelector, userID string) (models.Cart, uint64, error)
 	CheckoutCart(ctx context.Context, cartID string) error
+	MergeCart(ctx context.Context, cartID, intoCartID string) error
+	// SetPromoCode applies a promo code to an active cart, an empty code removes it
+	SetPromoCode(ctx context.Context, cartID, code string) error
 }
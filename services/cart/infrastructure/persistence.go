@@ -2,6 +2,7 @@ package infrastructure
 
 import (
 	"context"
+	"fmt"
 	"github.com/greenbone/opensight-golang-libraries/pkg/query"
 	"github.com/leetatech/leeta_backend/pkg/database"
 	"github.com/leetatech/leeta_backend/pkg/errs"
@@ -58,34 +59,46 @@ func (c *CartStoreHandler) GetCartByCustomerID(ctx context.Context, customerID s
 	return cart, nil
 }
 
+// UpdateCart only updates an active cart, so a cart checked out or merged since it was read is not reopened
 func (c *CartStoreHandler) UpdateCart(ctx context.Context, request models.Cart) error {
-	_, err := c.col(models.CartsCollectionName).UpdateOne(ctx, bson.M{"id": request.ID}, bson.M{"$set": request})
+	filter := bson.M{"id": request.ID, "status": models.CartActive}
+
+	result, err := c.col(models.CartsCollectionName).UpdateOne(ctx, filter, bson.M{"$set": request})
 	if err != nil {
 		return errs.Body(errs.DatabaseError, err)
 	}
+
+	if result.MatchedCount == 0 {
+		return errs.Body(errs.InvalidRequestError, fmt.Errorf("cart id '%s' is no longer active", request.ID))
+	}
+
 	return nil
 }
 
-func (c *CartStoreHandler) AddToCartItem(ctx context.Context, cartID string, cartItems models.CartItem, total float64, statusTs int64) error {
-	filter := bson.M{"id": cartID}
+func (c *CartStoreHandler) AddToCartItem(ctx context.Context, cartID string, cartItems models.CartItem, total models.Money, statusTs int64) error {
+	filter := bson.M{"id": cartID, "status": models.CartActive}
 	update := bson.M{"$push": bson.M{"cart_items": cartItems}, "$set": bson.M{"total": total, "status_ts": statusTs}}
 
-	_, err := c.col(models.CartsCollectionName).UpdateOne(ctx, filter, update)
+	result, err := c.col(models.CartsCollectionName).UpdateOne(ctx, filter, update)
 	if err != nil {
-		return err
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	if result.MatchedCount == 0 {
+		return errs.Body(errs.InvalidRequestError, fmt.Errorf("cart id '%s' is no longer active", cartID))
 	}
 
 	return nil
 }
 
-func (c *CartStoreHandler) DeleteCartItem(ctx context.Context, cartItemID string, itemTotalCost float64) error {
+func (c *CartStoreHandler) DeleteCartItem(ctx context.Context, cartItemID string, itemTotalCost models.Money) error {
 	filter := bson.M{"cart_items.id": cartItemID}
 
 	update := bson.M{
 		"$pull": bson.M{
 			"cart_items": bson.M{"id": cartItemID},
 		},
-		"$inc": bson.M{"total": -itemTotalCost},
+		"$inc": bson.M{"total.amount": -itemTotalCost.Amount},
 		"$set": bson.M{"status_ts": time.Now().Unix()},
 	}
 
@@ -141,7 +154,7 @@ func (c *CartStoreHandler) ListCartItems(ctx context.Context, request query.Resu
 		ID           string              `json:"id" bson:"id"`
 		CustomerID   string              `json:"customer_id" bson:"customer_id"`
 		CartItems    []models.CartItem   `json:"cart_items" bson:"cart_items"`
-		Total        float64             `json:"total" bson:"total"`
+		Total        models.Money        `json:"total" bson:"total"`
 		Status       models.CartStatuses `json:"status" bson:"status"`
 		StatusTs     int64               `json:"status_ts" bson:"status_ts"`
 		Ts           int64               `json:"ts" bson:"ts"`
@@ -193,21 +206,74 @@ func (c *CartStoreHandler) ListCartItems(ctx context.Context, request query.Resu
 	}, uint64(pipelineResp.TotalRecords), nil
 }
 
+// CheckoutCart empties an active cart and marks it checked out. It fails when the cart was already checked out,
+// so concurrent checkouts of the same cart cannot both succeed.
 func (c *CartStoreHandler) CheckoutCart(ctx context.Context, cartID string) error {
-	filter := bson.M{"id": cartID}
+	filter := bson.M{"id": cartID, "status": models.CartActive}
 
 	update := bson.M{
 		"$set": bson.M{
-			"cart_items": []models.CartItem{},
-			"total":      0,
-			"status_ts":  time.Now().Unix(),
-			"status":     models.CartCheckedOut,
+			"cart_items":   []models.CartItem{},
+			"total.amount": 0,
+			"status_ts":    time.Now().Unix(),
+			"status":       models.CartCheckedOut,
 		},
 	}
 
-	_, err := c.col(models.CartsCollectionName).UpdateOne(ctx, filter, update)
+	result, err := c.col(models.CartsCollectionName).UpdateOne(ctx, filter, update)
 	if err != nil {
-		return err
+		return errs.Body(errs.DatabaseError, fmt.Errorf("error clearing cart: %w", err))
+	}
+
+	if result.MatchedCount == 0 {
+		return errs.Body(errs.InvalidRequestError, fmt.Errorf("cart id '%s' is already checked out", cartID))
+	}
+
+	return nil
+}
+
+// MergeCart empties an active guest cart and marks it merged into a customer cart. It fails when the cart is no longer active,
+// so the items of a guest cart are merged only once.
+func (c *CartStoreHandler) MergeCart(ctx context.Context, cartID, intoCartID string) error {
+	filter := bson.M{"id": cartID, "status": models.CartActive}
+
+	update := bson.M{
+		"$set": bson.M{
+			"cart_items":   []models.CartItem{},
+			"total.amount": 0,
+			"merged_into":  intoCartID,
+			"status_ts":    time.Now().Unix(),
+			"status":       models.CartMerged,
+		},
+	}
+
+	result, err := c.col(models.CartsCollectionName).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errs.Body(errs.DatabaseError, fmt.Errorf("error merging cart: %w", err))
+	}
+
+	if result.MatchedCount == 0 {
+		return errs.Body(errs.InvalidRequestError, fmt.Errorf("cart id '%s' is no longer active", cartID))
+	}
+
+	return nil
+}
+
+func (c *CartStoreHandler) SetPromoCode(ctx context.Context, cartID, code string) error {
+	filter := bson.M{"id": cartID, "status": models.CartActive}
+
+	update := bson.M{"$set": bson.M{"promo_code": code, "status_ts": time.Now().Unix()}}
+	if code == "" {
+		update = bson.M{"$unset": bson.M{"promo_code": ""}, "$set": bson.M{"status_ts": time.Now().Unix()}}
+	}
+
+	result, err := c.col(models.CartsCollectionName).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errs.Body(errs.DatabaseError, fmt.Errorf("error setting cart promo code: %w", err))
+	}
+
+	if result.MatchedCount == 0 {
+		return errs.Body(errs.InvalidRequestError, fmt.Errorf("cart id '%s' is no longer active", cartID))
 	}
 
 	return nil
@@ -36,6 +36,7 @@ func New(cartApplication application.Cart) *CartHttpHandler {
 // @Produce json
 // @Security BearerToken
 // @Param domain.CartItem body domain.CartItem true "add to cart request body"
+// @Param Idempotency-Key header string false "key to safely retry the request, the response of the first request is replayed"
 // @Success 201 {object} pkg.DefaultResponse
 // @Failure 401 {object} pkg.DefaultErrorResponse
 // @Failure 400 {object} pkg.DefaultErrorResponse
@@ -198,17 +199,47 @@ func toFilterOption(options filter.RequestOption, _ int) filter.RequestOption {
 	return options
 }
 
+// Quote is the endpoint to price the active cart for checkout
+// @Summary Get a checkout quote
+// @Description The endpoint prices the active cart, delivery and service fees for the delivery address and returns a quote id to check out with before it expires. Every vendor delivers its order separately and is charged a delivery fee, the service fee is charged once and split across the vendor orders
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Param domain.QuoteRequest body domain.QuoteRequest true "Cart quote request body"
+// @Security BearerToken
+// @Success 200 {object} domain.QuoteResponse
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Router /cart/quote [post]
+func (handler *CartHttpHandler) Quote(w http.ResponseWriter, r *http.Request) {
+	var request domain.QuoteRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		jwtmiddleware.WriteJSONResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	response, err := handler.CartApplication.Quote(r.Context(), request)
+	if err != nil {
+		jwtmiddleware.WriteJSONResponse(w, err, http.StatusBadRequest)
+		return
+	}
+	jwtmiddleware.WriteJSONResponse(w, response, http.StatusOK)
+}
+
 // Checkout is the endpoint to check out from cart
 // @Summary Check out from cart
-// @Description The endpoint to allows the user to check out from the cart
+// @Description The endpoint to allows the user to check out from the cart. The orders are booked in the chosen delivery slot
 // @Tags Cart
 // @Accept json
 // @Produce json
 // @Param domain.CartCheckoutRequest body domain.CartCheckoutRequest true "Cart checkout request body"
+// @Param Idempotency-Key header string false "key to safely retry the request, the response of the first request is replayed"
 // @Security BearerToken
-// @Success 200 {object} pkg.DefaultResponse
+// @Success 200 {object} domain.CheckoutResponse
 // @Failure 401 {object} pkg.DefaultErrorResponse
 // @Failure 400 {object} pkg.DefaultErrorResponse
+// @Failure 409 {object} pkg.DefaultErrorResponse
 // @Router /cart/checkout [post]
 func (handler *CartHttpHandler) Checkout(w http.ResponseWriter, r *http.Request) {
 	var request domain.CartCheckoutRequest
@@ -225,3 +256,51 @@ func (handler *CartHttpHandler) Checkout(w http.ResponseWriter, r *http.Request)
 	}
 	jwtmiddleware.WriteJSONResponse(w, response, http.StatusOK)
 }
+
+// ApplyPromoCode is the endpoint to apply a promo code to the active cart
+// @Summary Apply promo code
+// @Description The endpoint applies a promo code to the active cart. The discount is shown on the next quote, and the promotion is checked again when the cart is quoted and checked out
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Param domain.ApplyPromoCodeRequest body domain.ApplyPromoCodeRequest true "Apply promo code request body"
+// @Security BearerToken
+// @Success 200 {object} models.Cart
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Router /cart/promo [put]
+func (handler *CartHttpHandler) ApplyPromoCode(w http.ResponseWriter, r *http.Request) {
+	var request domain.ApplyPromoCodeRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		jwtmiddleware.WriteJSONResponse(w, err, http.StatusBadRequest)
+		return
+	}
+
+	cart, err := handler.CartApplication.ApplyPromoCode(r.Context(), request)
+	if err != nil {
+		jwtmiddleware.WriteJSONResponse(w, err, http.StatusBadRequest)
+		return
+	}
+	jwtmiddleware.WriteJSONResponse(w, cart, http.StatusOK)
+}
+
+// RemovePromoCode is the endpoint to remove the promo code from the active cart
+// @Summary Remove promo code
+// @Description The endpoint removes the promo code applied to the active cart
+// @Tags Cart
+// @Accept json
+// @Produce json
+// @Security BearerToken
+// @Success 200 {object} models.Cart
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Router /cart/promo [delete]
+func (handler *CartHttpHandler) RemovePromoCode(w http.ResponseWriter, r *http.Request) {
+	cart, err := handler.CartApplication.RemovePromoCode(r.Context())
+	if err != nil {
+		jwtmiddleware.WriteJSONResponse(w, err, http.StatusBadRequest)
+		return
+	}
+	jwtmiddleware.WriteJSONResponse(w, cart, http.StatusOK)
+}
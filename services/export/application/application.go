@@ -0,0 +1,189 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query"
+	"github.com/leetatech/leeta_backend/pkg"
+	"github.com/leetatech/leeta_backend/pkg/config"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/pkg/idgenerator"
+	"github.com/leetatech/leeta_backend/pkg/jwtmiddleware"
+	"github.com/leetatech/leeta_backend/services/models"
+	"io"
+	"time"
+)
+
+const (
+	// jobsLimit bounds how many of the latest export jobs are listed
+	jobsLimit = 100
+	// downloadPath is where the file of a completed export job is downloaded from
+	downloadPath = "/api/export/jobs/%s/download"
+)
+
+type exportAppHandler struct {
+	jwtManager    jwtmiddleware.Manager
+	idGenerator   idgenerator.Generator
+	allRepository pkg.RepositoryManager
+	exportConfig  config.ExportConfig
+}
+
+type Export interface {
+	// Export streams the dataset rows matching the request to w. Nothing is written when the request is refused.
+	Export(ctx context.Context, dataset models.ExportDataset, format models.ExportFormat, request query.ResultSelector, w io.Writer) (int64, error)
+	CreateJob(ctx context.Context, dataset models.ExportDataset, format models.ExportFormat, request query.ResultSelector) (*models.ExportJob, error)
+	Job(ctx context.Context, id string) (*models.ExportJob, error)
+	Jobs(ctx context.Context) ([]models.ExportJob, error)
+	// JobFile opens the file of a completed export job, which the caller must close
+	JobFile(ctx context.Context, id string) (*models.ExportJob, io.ReadCloser, error)
+	// RunWorker runs the pending export jobs until the context is cancelled
+	RunWorker(ctx context.Context)
+}
+
+func New(request pkg.ApplicationContext) Export {
+	return &exportAppHandler{
+		jwtManager:    request.JwtManager,
+		idGenerator:   idgenerator.New(),
+		allRepository: request.RepositoryManager,
+		exportConfig:  request.Config.Export,
+	}
+}
+
+// Filename is the name an export of the dataset is downloaded as
+func Filename(dataset models.ExportDataset, format models.ExportFormat, ts time.Time) string {
+	return fmt.Sprintf("%s-%s.%s", dataset, ts.UTC().Format("20060102-150405"), format)
+}
+
+func (e *exportAppHandler) Export(ctx context.Context, dataset models.ExportDataset, format models.ExportFormat, request query.ResultSelector, w io.Writer) (int64, error) {
+	if _, err := e.authorize(ctx); err != nil {
+		return 0, err
+	}
+
+	dataset, format, err := validate(dataset, format)
+	if err != nil {
+		return 0, err
+	}
+
+	return e.write(ctx, dataset, format, request, w)
+}
+
+func (e *exportAppHandler) CreateJob(ctx context.Context, dataset models.ExportDataset, format models.ExportFormat, request query.ResultSelector) (*models.ExportJob, error) {
+	claims, err := e.authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dataset, format, err = validate(dataset, format)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	job := models.ExportJob{
+		ID:          e.idGenerator.Generate(),
+		Dataset:     dataset,
+		Format:      format,
+		Filter:      request.Filter,
+		Sorting:     request.Sorting,
+		RequestedBy: claims.UserID,
+		Status:      models.ExportJobPending,
+		Filename:    Filename(dataset, format, now),
+		StatusTs:    now.Unix(),
+		Ts:          now.Unix(),
+	}
+
+	err = e.allRepository.ExportRepository.CreateJob(ctx, job)
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+func (e *exportAppHandler) Job(ctx context.Context, id string) (*models.ExportJob, error) {
+	if _, err := e.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	job, err := e.allRepository.ExportRepository.JobByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return withDownloadURL(*job), nil
+}
+
+func (e *exportAppHandler) Jobs(ctx context.Context) ([]models.ExportJob, error) {
+	if _, err := e.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	jobs, err := e.allRepository.ExportRepository.Jobs(ctx, jobsLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range jobs {
+		jobs[i] = *withDownloadURL(jobs[i])
+	}
+
+	return jobs, nil
+}
+
+func (e *exportAppHandler) JobFile(ctx context.Context, id string) (*models.ExportJob, io.ReadCloser, error) {
+	if _, err := e.authorize(ctx); err != nil {
+		return nil, nil, err
+	}
+
+	job, err := e.allRepository.ExportRepository.JobByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if job.Status != models.ExportJobCompleted {
+		return nil, nil, errs.Body(errs.ExportJobNotReadyError, fmt.Errorf("export job %s is %s", id, job.Status))
+	}
+
+	file, err := e.allRepository.ExportRepository.OpenFile(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return job, file, nil
+}
+
+// authorize lets only admins export data, as exports are not scoped to a vendor or customer
+func (e *exportAppHandler) authorize(ctx context.Context) (*jwtmiddleware.UserClaims, error) {
+	claims, err := e.jwtManager.ExtractUserClaims(ctx)
+	if err != nil {
+		return nil, errs.Body(errs.ErrorUnauthorized, err)
+	}
+
+	if claims.Role != models.AdminCategory {
+		return nil, errs.Body(errs.ErrorUnauthorized, errors.New("only admins can export data"))
+	}
+
+	return claims, nil
+}
+
+func validate(dataset models.ExportDataset, format models.ExportFormat) (models.ExportDataset, models.ExportFormat, error) {
+	dataset, err := models.SetExportDataset(dataset)
+	if err != nil {
+		return "", "", err
+	}
+
+	format, err = models.SetExportFormat(format)
+	if err != nil {
+		return "", "", err
+	}
+
+	return dataset, format, nil
+}
+
+func withDownloadURL(job models.ExportJob) *models.ExportJob {
+	if job.Status == models.ExportJobCompleted {
+		job.DownloadURL = fmt.Sprintf(downloadPath, job.ID)
+	}
+	return &job
+}
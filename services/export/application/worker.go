@@ -0,0 +1,89 @@
+package application
+
+import (
+	"context"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query"
+	"github.com/leetatech/leeta_backend/services/models"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+const (
+	// maxJobAttempts is how many times a job is started before it is failed, for workers that stop before finishing it
+	maxJobAttempts = 3
+	// maxJobsPerTick bounds the work of a single tick, the rest is picked up by the next ones
+	maxJobsPerTick = 10
+)
+
+func (e *exportAppHandler) RunWorker(ctx context.Context) {
+	ticker := time.NewTicker(e.exportConfig.WorkerInterval)
+	defer ticker.Stop()
+
+	for {
+		e.runJobs(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *exportAppHandler) runJobs(ctx context.Context) {
+	err := e.allRepository.ExportRepository.FailAbandonedJobs(ctx, time.Now().Unix(), maxJobAttempts)
+	if err != nil {
+		log.Error().Err(err).Msg("error failing abandoned export jobs")
+	}
+
+	for i := 0; i < maxJobsPerTick; i++ {
+		now := time.Now()
+		job, err := e.allRepository.ExportRepository.ClaimJob(ctx, now.Unix(), now.Add(e.exportConfig.JobTimeout).Unix(), maxJobAttempts)
+		if err != nil {
+			log.Error().Err(err).Msg("error claiming export job")
+			return
+		}
+		if job == nil {
+			return
+		}
+
+		rows, err := e.runJob(ctx, *job)
+		if err != nil {
+			log.Error().Err(err).Msgf("error running export job %s", job.ID)
+			if err = e.allRepository.ExportRepository.FailJob(ctx, job.ID, err.Error()); err != nil {
+				log.Error().Err(err).Msgf("error failing export job %s", job.ID)
+			}
+			continue
+		}
+
+		if err = e.allRepository.ExportRepository.CompleteJob(ctx, job.ID, rows); err != nil {
+			log.Error().Err(err).Msgf("error completing export job %s", job.ID)
+		}
+	}
+}
+
+// runJob writes the file of the job. The job may not run past its lock, or another worker could start it over.
+func (e *exportAppHandler) runJob(ctx context.Context, job models.ExportJob) (int64, error) {
+	jobCtx, cancel := context.WithTimeout(ctx, e.exportConfig.JobTimeout)
+	defer cancel()
+
+	upload, err := e.allRepository.ExportRepository.UploadFile(jobCtx, job.ID, job.Filename)
+	if err != nil {
+		return 0, err
+	}
+
+	request := query.ResultSelector{Filter: job.Filter, Sorting: job.Sorting}
+	rows, err := e.write(jobCtx, job.Dataset, job.Format, request, upload)
+	if err != nil {
+		if abortErr := upload.Abort(); abortErr != nil {
+			log.Error().Err(abortErr).Msgf("error discarding file of export job %s", job.ID)
+		}
+		return 0, err
+	}
+
+	if err = upload.Close(); err != nil {
+		return 0, err
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,238 @@
+package application
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/services/models"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	orderColumns = []string{
+		"id", "checkout_id", "customer_id", "vendor_id", "status", "payment_method", "product_ids", "quantity", "weight_kg",
+		"delivery_fee", "service_fee", "promo_code", "discount", "cancellation_fee", "total", "state", "lga", "rider_id", "ts", "status_ts",
+	}
+	customerColumns = []string{
+		"id", "first_name", "last_name", "email", "email_verified", "phone", "phone_verified", "status", "is_blocked", "state", "lga", "ts",
+	}
+	feeColumns = []string{
+		"id", "fee_type", "product_id", "state", "lga", "cost_per_kg", "cost_per_qty", "cost_per_type", "status", "ts", "status_ts",
+	}
+)
+
+// recordWriter writes the rows of an export. CSV files are made of the records, NDJSON files of the documents.
+type recordWriter interface {
+	header(columns []string) error
+	write(record []string, document any) error
+	flush() error
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func (c *csvWriter) header(columns []string) error {
+	return c.writer.Write(columns)
+}
+
+func (c *csvWriter) write(record []string, _ any) error {
+	for i, value := range record {
+		record[i] = escapeFormula(value)
+	}
+	return c.writer.Write(record)
+}
+
+func (c *csvWriter) flush() error {
+	c.writer.Flush()
+	return c.writer.Error()
+}
+
+type ndjsonWriter struct {
+	buffer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+func (n *ndjsonWriter) header([]string) error {
+	return nil
+}
+
+func (n *ndjsonWriter) write(_ []string, document any) error {
+	return n.encoder.Encode(document)
+}
+
+func (n *ndjsonWriter) flush() error {
+	return n.buffer.Flush()
+}
+
+func newRecordWriter(format models.ExportFormat, w io.Writer) recordWriter {
+	if format == models.ExportNDJSON {
+		buffer := bufio.NewWriter(w)
+		return &ndjsonWriter{buffer: buffer, encoder: json.NewEncoder(buffer)}
+	}
+	return &csvWriter{writer: csv.NewWriter(w)}
+}
+
+// escapeFormula keeps spreadsheets from running text that starts like a formula
+func escapeFormula(value string) string {
+	if value == "" || !strings.ContainsAny(value[:1], "=+-@\t\r") {
+		return value
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return value
+	}
+	return "'" + value
+}
+
+// write streams the rows of the dataset to w and returns how many were written
+func (e *exportAppHandler) write(ctx context.Context, dataset models.ExportDataset, format models.ExportFormat, request query.ResultSelector, w io.Writer) (int64, error) {
+	writer := newRecordWriter(format, w)
+	var rows int64
+	var err error
+
+	switch dataset {
+	case models.ExportOrders:
+		if err = writer.header(orderColumns); err != nil {
+			break
+		}
+		err = e.allRepository.ExportRepository.EachOrder(ctx, request, func(order models.Order) error {
+			rows++
+			return writer.write(orderRecord(order), order)
+		})
+	case models.ExportCustomers:
+		if err = writer.header(customerColumns); err != nil {
+			break
+		}
+		err = e.allRepository.ExportRepository.EachCustomer(ctx, request, func(customer models.Customer) error {
+			rows++
+			return writer.write(customerRecord(customer), customer)
+		})
+	case models.ExportFees:
+		if err = writer.header(feeColumns); err != nil {
+			break
+		}
+		err = e.allRepository.ExportRepository.EachFee(ctx, request, func(fee models.Fee) error {
+			rows++
+			return writer.write(feeRecord(fee), fee)
+		})
+	}
+	if err != nil {
+		return rows, err
+	}
+
+	if err = writer.flush(); err != nil {
+		return rows, errs.Body(errs.InternalError, err)
+	}
+
+	return rows, nil
+}
+
+func orderRecord(order models.Order) []string {
+	status := order.Status
+	if status == "" {
+		// orders that were never updated have no status stored yet
+		status = models.OrderPending
+	}
+
+	productIDs := make([]string, len(order.Orders))
+	var quantity int
+	var weight float64
+	for i, item := range order.Orders {
+		productIDs[i] = item.ProductID
+		quantity += item.Quantity
+		weight += item.Weight * float64(item.Quantity)
+	}
+
+	var riderID string
+	if order.Delivery != nil {
+		riderID = order.Delivery.RiderID
+	}
+
+	var promoCode string
+	var discount models.Money
+	if order.Discount != nil {
+		promoCode = order.Discount.Code
+		discount = order.Discount.Total
+	}
+
+	return []string{
+		order.ID,
+		order.CheckoutID,
+		order.CustomerID,
+		order.VendorID,
+		string(status),
+		string(order.PaymentMethod),
+		strings.Join(productIDs, ";"),
+		strconv.Itoa(quantity),
+		strconv.FormatFloat(weight, 'f', -1, 64),
+		formatAmount(order.DeliveryFee),
+		formatAmount(order.ServiceFee),
+		promoCode,
+		formatAmount(discount),
+		formatAmount(order.CancellationFee),
+		formatAmount(order.Total),
+		order.DeliveryDetails.Address.State,
+		order.DeliveryDetails.Address.LGA,
+		riderID,
+		formatTs(order.Ts),
+		formatTs(order.StatusTs),
+	}
+}
+
+func customerRecord(customer models.Customer) []string {
+	var address models.Address
+	for i, candidate := range customer.Addresses {
+		if i == 0 || candidate.DefaultDeliveryAddress {
+			address = candidate
+		}
+	}
+
+	return []string{
+		customer.ID,
+		customer.FirstName,
+		customer.LastName,
+		customer.Email.Address,
+		strconv.FormatBool(customer.Email.Verified),
+		customer.Phone.Number,
+		strconv.FormatBool(customer.Phone.Verified),
+		string(customer.Status),
+		strconv.FormatBool(customer.IsBlocked),
+		address.State,
+		address.LGA,
+		formatTs(customer.Time),
+	}
+}
+
+func feeRecord(fee models.Fee) []string {
+	return []string{
+		fee.ID,
+		string(fee.FeeType),
+		fee.ProductID,
+		fee.LGA.State,
+		fee.LGA.LGA,
+		formatAmount(fee.Cost.CostPerKG),
+		formatAmount(fee.Cost.CostPerQt),
+		formatAmount(fee.Cost.CostPerType),
+		string(fee.Status),
+		formatTs(fee.Ts),
+		formatTs(fee.StatusTs),
+	}
+}
+
+// formatAmount writes the amount in major units, which is how spreadsheets expect it
+func formatAmount(amount models.Money) string {
+	return strconv.FormatFloat(amount.Major(), 'f', 2, 64)
+}
+
+func formatTs(ts int64) string {
+	if ts == 0 {
+		return ""
+	}
+	return time.Unix(ts, 0).UTC().Format(time.RFC3339)
+}
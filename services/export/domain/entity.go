@@ -0,0 +1,11 @@
+package domain
+
+import "io"
+
+// FileUpload writes the file of an export job. The file is only stored once it is closed.
+type FileUpload interface {
+	io.Writer
+	Close() error
+	// Abort discards what was written
+	Abort() error
+}
@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"context"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query"
+	"github.com/leetatech/leeta_backend/services/models"
+	"io"
+)
+
+type ExportRepository interface {
+	// EachOrder calls fn for every order matching the request, one document at a time. Paging is ignored.
+	EachOrder(ctx context.Context, request query.ResultSelector, fn func(models.Order) error) error
+	// EachCustomer calls fn for every customer matching the request, one document at a time. Paging is ignored.
+	EachCustomer(ctx context.Context, request query.ResultSelector, fn func(models.Customer) error) error
+	// EachFee calls fn for every fee matching the request, one document at a time. Paging is ignored.
+	EachFee(ctx context.Context, request query.ResultSelector, fn func(models.Fee) error) error
+
+	CreateJob(ctx context.Context, job models.ExportJob) error
+	JobByID(ctx context.Context, id string) (*models.ExportJob, error)
+	Jobs(ctx context.Context, limit int64) ([]models.ExportJob, error)
+	// ClaimJob locks the oldest job that is pending, or whose worker stopped before finishing it, and returns nil when there is none
+	ClaimJob(ctx context.Context, now, lockUntil int64, maxAttempts int) (*models.ExportJob, error)
+	CompleteJob(ctx context.Context, id string, rowCount int64) error
+	FailJob(ctx context.Context, id, reason string) error
+	// FailAbandonedJobs fails the running jobs whose workers stopped before finishing them too many times
+	FailAbandonedJobs(ctx context.Context, now int64, maxAttempts int) error
+
+	// UploadFile replaces the file of a job
+	UploadFile(ctx context.Context, jobID, filename string) (FileUpload, error)
+	OpenFile(ctx context.Context, jobID string) (io.ReadCloser, error)
+}
@@ -0,0 +1,322 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query/sorting"
+	"github.com/leetatech/leeta_backend/pkg/database"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/services/export/domain"
+	"github.com/leetatech/leeta_backend/services/models"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"io"
+	"time"
+)
+
+// the filter mappings match the listings the datasets are exported from
+var (
+	feesFilterMapping = map[string]string{
+		"lga": "lga.lga",
+	}
+	customersFilterMapping = map[string]string{
+		"status": "user.status",
+		"email":  "user.email.address",
+		"state":  "user.addresses.state",
+		"ts":     "timestamps.ts",
+	}
+)
+
+var (
+	ordersSortMapping = map[string]string{
+		"ts":        "ts",
+		"status_ts": "status_ts",
+	}
+	customersSortMapping = map[string]string{
+		"ts": "timestamps.ts",
+	}
+	feesSortMapping = map[string]string{
+		"ts": "ts",
+	}
+)
+
+type exportStoreHandler struct {
+	client       *mongo.Client
+	databaseName string
+}
+
+func (e *exportStoreHandler) col(collectionName string) *mongo.Collection {
+	return e.client.Database(e.databaseName).Collection(collectionName)
+}
+
+func (e *exportStoreHandler) bucket() (*gridfs.Bucket, error) {
+	return gridfs.NewBucket(e.client.Database(e.databaseName), options.GridFSBucket().SetName(models.ExportFilesBucketName))
+}
+
+func New(client *mongo.Client, databaseName string) domain.ExportRepository {
+	return &exportStoreHandler{client: client, databaseName: databaseName}
+}
+
+func (e *exportStoreHandler) EachOrder(ctx context.Context, request query.ResultSelector, fn func(models.Order) error) error {
+	filter := bson.M{}
+	if request.Filter != nil {
+		filter = database.BuildMongoFilterQuery(request.Filter, nil)
+	}
+
+	cursor, err := e.col(models.OrderCollectionName).Find(ctx, filter, options.Find().SetSort(sortDocument(request.Sorting, ordersSortMapping)))
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	return each(ctx, cursor, fn)
+}
+
+func (e *exportStoreHandler) EachCustomer(ctx context.Context, request query.ResultSelector, fn func(models.Customer) error) error {
+	filter := bson.M{}
+	if request.Filter != nil {
+		filter = database.BuildMongoFilterQuery(request.Filter, customersFilterMapping)
+	}
+
+	// vendors, riders and admins are kept in the users collection too, only their identities tell them apart
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         models.IdentityCollectionName,
+			"localField":   "user.id",
+			"foreignField": "user_id",
+			"as":           "identity",
+		}}},
+		{{Key: "$match", Value: bson.M{"identity.role": models.CustomerCategory}}},
+		{{Key: "$project", Value: bson.M{"identity": 0}}},
+	}
+	if sort := sortDocument(request.Sorting, customersSortMapping); len(sort) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$sort", Value: sort}})
+	}
+
+	cursor, err := e.col(models.UsersCollectionName).Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	return each(ctx, cursor, fn)
+}
+
+func (e *exportStoreHandler) EachFee(ctx context.Context, request query.ResultSelector, fn func(models.Fee) error) error {
+	filter := bson.M{}
+	if request.Filter != nil {
+		filter = database.BuildMongoFilterQuery(request.Filter, feesFilterMapping)
+	}
+
+	cursor, err := e.col(models.FeesCollectionName).Find(ctx, filter, options.Find().SetSort(sortDocument(request.Sorting, feesSortMapping)))
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	return each(ctx, cursor, fn)
+}
+
+// each decodes the documents of the cursor one at a time, so large results are never held in memory
+func each[T any](ctx context.Context, cursor *mongo.Cursor, fn func(T) error) error {
+	defer func(cursor *mongo.Cursor, ctx context.Context) {
+		err := cursor.Close(ctx)
+		if err != nil {
+			log.Debug().Msgf("error closing mongo cursor %v", err)
+		}
+	}(cursor, ctx)
+
+	for cursor.Next(ctx) {
+		var document T
+		if err := cursor.Decode(&document); err != nil {
+			return errs.Body(errs.DatabaseError, err)
+		}
+		if err := fn(document); err != nil {
+			return err
+		}
+	}
+
+	if err := cursor.Err(); err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	return nil
+}
+
+func sortDocument(request *sorting.Request, mapping map[string]string) bson.D {
+	if request == nil || mapping[request.SortColumn] == "" {
+		return bson.D{}
+	}
+
+	direction := 1
+	if request.SortDirection == sorting.DirectionDescending {
+		direction = -1
+	}
+	return bson.D{{Key: mapping[request.SortColumn], Value: direction}}
+}
+
+func (e *exportStoreHandler) CreateJob(ctx context.Context, job models.ExportJob) error {
+	updatedCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := e.col(models.ExportJobsCollectionName).InsertOne(updatedCtx, job)
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	return nil
+}
+
+func (e *exportStoreHandler) JobByID(ctx context.Context, id string) (*models.ExportJob, error) {
+	job := &models.ExportJob{}
+	err := e.col(models.ExportJobsCollectionName).FindOne(ctx, bson.M{"id": id}).Decode(job)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errs.Body(errs.DatabaseNoRecordError, fmt.Errorf("export job with id %s not found", id))
+		}
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	return job, nil
+}
+
+func (e *exportStoreHandler) Jobs(ctx context.Context, limit int64) ([]models.ExportJob, error) {
+	opts := options.Find().SetSort(bson.M{"ts": -1}).SetLimit(limit)
+	cursor, err := e.col(models.ExportJobsCollectionName).Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	jobs := make([]models.ExportJob, 0)
+	if err = cursor.All(ctx, &jobs); err != nil {
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	return jobs, nil
+}
+
+func (e *exportStoreHandler) ClaimJob(ctx context.Context, now, lockUntil int64, maxAttempts int) (*models.ExportJob, error) {
+	filter := bson.M{
+		"status":       bson.M{"$in": bson.A{models.ExportJobPending, models.ExportJobRunning}},
+		"locked_until": bson.M{"$lt": now},
+		"attempts":     bson.M{"$lt": maxAttempts},
+	}
+	update := bson.M{
+		"$set": bson.M{"status": models.ExportJobRunning, "status_ts": now, "locked_until": lockUntil},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().SetSort(bson.M{"ts": 1}).SetReturnDocument(options.After)
+
+	job := &models.ExportJob{}
+	err := e.col(models.ExportJobsCollectionName).FindOneAndUpdate(ctx, filter, update, opts).Decode(job)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	return job, nil
+}
+
+func (e *exportStoreHandler) CompleteJob(ctx context.Context, id string, rowCount int64) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":       models.ExportJobCompleted,
+			"row_count":    rowCount,
+			"locked_until": 0,
+			"status_ts":    time.Now().Unix(),
+		},
+	}
+
+	return e.updateJob(ctx, id, update)
+}
+
+func (e *exportStoreHandler) FailJob(ctx context.Context, id, reason string) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":       models.ExportJobFailed,
+			"error":        reason,
+			"locked_until": 0,
+			"status_ts":    time.Now().Unix(),
+		},
+	}
+
+	return e.updateJob(ctx, id, update)
+}
+
+func (e *exportStoreHandler) updateJob(ctx context.Context, id string, update bson.M) error {
+	result, err := e.col(models.ExportJobsCollectionName).UpdateOne(ctx, bson.M{"id": id}, update)
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	if result.MatchedCount == 0 {
+		return errs.Body(errs.DatabaseNoRecordError, fmt.Errorf("export job with id %s not found", id))
+	}
+
+	return nil
+}
+
+func (e *exportStoreHandler) FailAbandonedJobs(ctx context.Context, now int64, maxAttempts int) error {
+	filter := bson.M{
+		"status":       models.ExportJobRunning,
+		"locked_until": bson.M{"$lt": now},
+		"attempts":     bson.M{"$gte": maxAttempts},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":       models.ExportJobFailed,
+			"error":        "the export was interrupted too many times",
+			"locked_until": 0,
+			"status_ts":    now,
+		},
+	}
+
+	_, err := e.col(models.ExportJobsCollectionName).UpdateMany(ctx, filter, update)
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	return nil
+}
+
+func (e *exportStoreHandler) UploadFile(ctx context.Context, jobID, filename string) (domain.FileUpload, error) {
+	bucket, err := e.bucket()
+	if err != nil {
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	// an earlier attempt of the job may have left chunks of the file behind
+	err = bucket.DeleteContext(ctx, jobID)
+	if err != nil && !errors.Is(err, gridfs.ErrFileNotFound) {
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	upload, err := bucket.OpenUploadStreamWithID(jobID, filename)
+	if err != nil {
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	return upload, nil
+}
+
+func (e *exportStoreHandler) OpenFile(ctx context.Context, jobID string) (io.ReadCloser, error) {
+	bucket, err := e.bucket()
+	if err != nil {
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	download, err := bucket.OpenDownloadStream(jobID)
+	if err != nil {
+		if errors.Is(err, gridfs.ErrFileNotFound) {
+			return nil, errs.Body(errs.DatabaseNoRecordError, fmt.Errorf("file of export job %s not found", jobID))
+		}
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	return download, nil
+}
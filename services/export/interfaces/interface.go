@@ -0,0 +1,249 @@
+package interfaces
+
+import (
+	"fmt"
+	"github.com/go-chi/chi/v5"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query/filter"
+	_ "github.com/leetatech/leeta_backend/pkg"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/pkg/helpers"
+	"github.com/leetatech/leeta_backend/pkg/jwtmiddleware"
+	"github.com/leetatech/leeta_backend/services/export/application"
+	"github.com/leetatech/leeta_backend/services/models"
+	"github.com/leetatech/leeta_backend/services/web"
+	"github.com/rs/zerolog/log"
+	"github.com/samber/lo"
+	"io"
+	"net/http"
+	"time"
+)
+
+type ExportHttpHandler struct {
+	ExportApplication application.Export
+}
+
+func New(exportApplication application.Export) *ExportHttpHandler {
+	return &ExportHttpHandler{
+		ExportApplication: exportApplication,
+	}
+}
+
+// attachmentWriter only sends the attachment headers once the export writes its first bytes,
+// so an export refused before that can still be answered with an error
+type attachmentWriter struct {
+	w           http.ResponseWriter
+	contentType string
+	filename    string
+	written     bool
+}
+
+func (a *attachmentWriter) Write(p []byte) (int, error) {
+	if !a.written {
+		a.written = true
+		a.w.Header().Set("Content-Type", a.contentType)
+		a.w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", a.filename))
+		a.w.WriteHeader(http.StatusOK)
+	}
+	return a.w.Write(p)
+}
+
+// resultSelector reads the filter of an export of the dataset in the path from the request body
+func resultSelector(r *http.Request) (models.ExportDataset, query.ResultSelector, error) {
+	dataset, err := models.SetExportDataset(models.ExportDataset(chi.URLParam(r, "dataset")))
+	if err != nil {
+		return "", query.ResultSelector{}, err
+	}
+
+	selector, err := web.PrepareResultSelector(r, exportOptions[dataset], exportSortFields[dataset], web.ResultSelectorDefaults(exportSortingRequest))
+	if err != nil {
+		return "", query.ResultSelector{}, errs.Body(errs.InvalidRequestError, err)
+	}
+
+	return dataset, selector, nil
+}
+
+// ExportHandler godoc
+// @Summary Export data
+// @Description The endpoint streams every order, customer or fee matching the filter as CSV or NDJSON. Paging is ignored. Only admins can export data
+// @Tags Export
+// @Accept json
+// @produce text/csv
+// @produce application/x-ndjson
+// @Param			dataset	path		string	true	"orders, customers or fees"
+// @Param format query string false "csv or ndjson. Defaults to csv"
+// @param query.ResultSelector body query.ResultSelector true "export request body"
+// @Security BearerToken
+// @success 200 {file} file
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Router /export/{dataset} [put]
+func (handler *ExportHttpHandler) ExportHandler(w http.ResponseWriter, r *http.Request) {
+	dataset, selector, err := resultSelector(r)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	format := exportFormat(r)
+	writer := &attachmentWriter{
+		w:           w,
+		contentType: format.ContentType(),
+		filename:    application.Filename(dataset, format, time.Now()),
+	}
+
+	rows, err := handler.ExportApplication.Export(r.Context(), dataset, format, selector, writer)
+	if err != nil {
+		if !writer.written {
+			helpers.CheckErrorType(err, w)
+			return
+		}
+		// the headers are gone, the client only sees the export end early
+		log.Error().Err(err).Msgf("error exporting %s after %d rows", dataset, rows)
+		return
+	}
+
+	if !writer.written {
+		_, _ = writer.Write(nil)
+	}
+}
+
+// ExportOptions godoc
+// @Summary Get export filter options
+// @Description Retrieve the filter options of a dataset export
+// @Tags Export
+// @Accept json
+// @Produce json
+// @Param			dataset	path		string	true	"orders, customers or fees"
+// @Security BearerToken
+// @Success 200 {object} filter.RequestOption
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Router /export/{dataset}/options [get]
+func (handler *ExportHttpHandler) ExportOptions(w http.ResponseWriter, r *http.Request) {
+	dataset, err := models.SetExportDataset(models.ExportDataset(chi.URLParam(r, "dataset")))
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	requestOptions := lo.Map(exportOptions[dataset], toFilterOption)
+	jwtmiddleware.WriteJSONResponse(w, requestOptions, http.StatusOK)
+}
+
+// CreateExportJobHandler godoc
+// @Summary Create export job
+// @Description The endpoint exports every order, customer or fee matching the filter to a file in the background, for exports too long to stream. The job links to the file once it completed
+// @Tags Export
+// @Accept json
+// @produce json
+// @Param			dataset	path		string	true	"orders, customers or fees"
+// @Param format query string false "csv or ndjson. Defaults to csv"
+// @param query.ResultSelector body query.ResultSelector true "export request body"
+// @Security BearerToken
+// @success 202 {object} models.ExportJob
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Router /export/{dataset}/jobs [post]
+func (handler *ExportHttpHandler) CreateExportJobHandler(w http.ResponseWriter, r *http.Request) {
+	dataset, selector, err := resultSelector(r)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	job, err := handler.ExportApplication.CreateJob(r.Context(), dataset, exportFormat(r), selector)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, job, http.StatusAccepted)
+}
+
+// ListExportJobsHandler godoc
+// @Summary List export jobs
+// @Description The endpoint lists the latest export jobs, newest first
+// @Tags Export
+// @Accept json
+// @produce json
+// @Security BearerToken
+// @success 200 {object} []models.ExportJob
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Router /export/jobs [get]
+func (handler *ExportHttpHandler) ListExportJobsHandler(w http.ResponseWriter, r *http.Request) {
+	jobs, err := handler.ExportApplication.Jobs(r.Context())
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, jobs, http.StatusOK)
+}
+
+// GetExportJobHandler godoc
+// @Summary Get export job
+// @Description The endpoint returns an export job, with the link to its file once it completed
+// @Tags Export
+// @Accept json
+// @produce json
+// @Param			job_id	path		string	true	"export job id"
+// @Security BearerToken
+// @success 200 {object} models.ExportJob
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 404 {object} pkg.DefaultErrorResponse
+// @Router /export/jobs/{job_id} [get]
+func (handler *ExportHttpHandler) GetExportJobHandler(w http.ResponseWriter, r *http.Request) {
+	job, err := handler.ExportApplication.Job(r.Context(), chi.URLParam(r, "job_id"))
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, job, http.StatusOK)
+}
+
+// DownloadExportJobHandler godoc
+// @Summary Download export job file
+// @Description The endpoint downloads the file of a completed export job
+// @Tags Export
+// @produce text/csv
+// @produce application/x-ndjson
+// @Param			job_id	path		string	true	"export job id"
+// @Security BearerToken
+// @success 200 {file} file
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 404 {object} pkg.DefaultErrorResponse
+// @Failure 409 {object} pkg.DefaultErrorResponse
+// @Router /export/jobs/{job_id}/download [get]
+func (handler *ExportHttpHandler) DownloadExportJobHandler(w http.ResponseWriter, r *http.Request) {
+	job, file, err := handler.ExportApplication.JobFile(r.Context(), chi.URLParam(r, "job_id"))
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			log.Debug().Msgf("error closing export file %v", err)
+		}
+	}()
+
+	w.Header().Set("Content-Type", job.Format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", job.Filename))
+	w.WriteHeader(http.StatusOK)
+	if _, err = io.Copy(w, file); err != nil {
+		log.Error().Err(err).Msgf("error downloading file of export job %s", job.ID)
+	}
+}
+
+func exportFormat(r *http.Request) models.ExportFormat {
+	format := models.ExportFormat(r.URL.Query().Get("format"))
+	if format == "" {
+		return models.ExportCSV
+	}
+	return format
+}
+
+func toFilterOption(options filter.RequestOption, _ int) filter.RequestOption {
+	return options
+}
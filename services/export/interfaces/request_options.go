@@ -0,0 +1,131 @@
+package interfaces
+
+import (
+	"github.com/greenbone/opensight-golang-libraries/pkg/query/filter"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query/sorting"
+	"github.com/leetatech/leeta_backend/services/models"
+)
+
+// LabelIsEqualTo holds filter request options operator labels
+const (
+	LabelIsEqualTo    = "is equal to"
+	LabelIsOnOrAfter  = "is on or after"
+	LabelIsOnOrBefore = "is on or before"
+)
+
+var operatorEqual = filter.ReadableValue[filter.CompareOperator]{
+	Label: LabelIsEqualTo,
+	Value: filter.CompareOperatorIsEqualTo,
+}
+
+var operatorOnOrAfter = filter.ReadableValue[filter.CompareOperator]{
+	Label: LabelIsOnOrAfter,
+	Value: filter.CompareOperatorIsGreaterThanOrEqualTo,
+}
+
+var operatorOnOrBefore = filter.ReadableValue[filter.CompareOperator]{
+	Label: LabelIsOnOrBefore,
+	Value: filter.CompareOperatorIsLessThanOrEqualTo,
+}
+
+var tsOption = filter.RequestOption{
+	Name: filter.ReadableValue[string]{
+		Label: "date",
+		Value: "ts",
+	},
+	Control: filter.RequestOptionType{
+		Type: filter.ControlTypeInteger,
+	},
+	Operators: []filter.ReadableValue[filter.CompareOperator]{
+		operatorOnOrAfter,
+		operatorOnOrBefore,
+	},
+}
+
+func equalOption(label, value string) filter.RequestOption {
+	return filter.RequestOption{
+		Name: filter.ReadableValue[string]{
+			Label: label,
+			Value: value,
+		},
+		Control: filter.RequestOptionType{
+			Type: filter.ControlTypeString,
+		},
+		Operators: []filter.ReadableValue[filter.CompareOperator]{
+			operatorEqual,
+		},
+	}
+}
+
+// exportOptions filter each dataset the way its listing does, with the unix time the documents were created to export a period
+var exportOptions = map[models.ExportDataset][]filter.RequestOption{
+	models.ExportOrders: {
+		{
+			Name: filter.ReadableValue[string]{
+				Label: "status",
+				Value: "status",
+			},
+			Control: filter.RequestOptionType{
+				Type: "OrderStatuses",
+			},
+			Operators: []filter.ReadableValue[filter.CompareOperator]{
+				operatorEqual,
+			},
+			MultiSelect: true,
+		},
+		tsOption,
+	},
+	models.ExportCustomers: {
+		equalOption("Status", "status"),
+		equalOption("Email", "email"),
+		equalOption("State", "state"),
+		tsOption,
+	},
+	models.ExportFees: {
+		equalOption("LGA", "lga"),
+		equalOption("Product", "product_id"),
+		{
+			Name: filter.ReadableValue[string]{
+				Label: "Fee Type",
+				Value: "fee_type",
+			},
+			Control: filter.RequestOptionType{
+				Type: filter.ControlTypeEnum,
+			},
+			Operators: []filter.ReadableValue[filter.CompareOperator]{
+				operatorEqual,
+			},
+			Values: []string{
+				string(models.DeliveryFee),
+				string(models.ServiceFee),
+				string(models.ProductFee),
+				string(models.CancellationFee),
+			},
+		},
+		{
+			Name: filter.ReadableValue[string]{
+				Label: "Status",
+				Value: "status",
+			},
+			Control: filter.RequestOptionType{
+				Type: filter.ControlTypeString,
+			},
+			Operators: []filter.ReadableValue[filter.CompareOperator]{
+				operatorEqual,
+			},
+			MultiSelect: true,
+		},
+		tsOption,
+	},
+}
+
+var exportSortFields = map[models.ExportDataset][]string{
+	models.ExportOrders:    {"ts", "status_ts"},
+	models.ExportCustomers: {"ts"},
+	models.ExportFees:      {"ts"},
+}
+
+var exportSortingRequest = &sorting.Request{
+	SortColumn:    "ts",
+	SortDirection: sorting.DirectionAscending,
+}
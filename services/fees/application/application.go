@@ -9,8 +9,6 @@ import (
 	"time"
 
 	"github.com/greenbone/opensight-golang-libraries/pkg/query"
-	"github.com/greenbone/opensight-golang-libraries/pkg/query/filter"
-	"github.com/greenbone/opensight-golang-libraries/pkg/query/paging"
 	"github.com/leetatech/leeta_backend/pkg"
 	"github.com/leetatech/leeta_backend/pkg/errs"
 	"github.com/leetatech/leeta_backend/pkg/idgenerator"
@@ -25,10 +23,17 @@ type FeesManager struct {
 	jwtManager        jwtmiddleware.Manager
 	EmailClient       mailer.Client
 	repositoryManager pkg.RepositoryManager
+	currency          string
 }
 
 type Fees interface {
 	HandleFeeQuotationRequest(ctx context.Context, request domain.FeeQuotationRequest) (*pkg.DefaultResponse, error)
+	// ScheduleFee sets a fee that takes effect at a later time, the fee in force until then is charged until it does
+	ScheduleFee(ctx context.Context, request domain.ScheduleFeeRequest) (*models.Fee, error)
+	// CancelFee cancels a scheduled fee before it takes effect, the fee it was to replace is charged in its place
+	CancelFee(ctx context.Context, id string) (*models.Fee, error)
+	// FeeHistory lists the fees of a type, product and lga in the order they took effect
+	FeeHistory(ctx context.Context, request domain.FeeHistoryRequest) ([]models.Fee, error)
 	Fees(ctx context.Context, request query.ResultSelector) ([]models.Fee, uint64, error)
 }
 
@@ -38,79 +43,17 @@ func New(applicationContext pkg.ApplicationContext) Fees {
 		jwtManager:        applicationContext.JwtManager,
 		EmailClient:       applicationContext.MailClient,
 		repositoryManager: applicationContext.RepositoryManager,
+		currency:          applicationContext.Config.Payment.Currency,
 	}
 }
 
+// HandleFeeQuotationRequest sets a fee that takes effect immediately, replacing the fee in force
 func (fm *FeesManager) HandleFeeQuotationRequest(ctx context.Context, request domain.FeeQuotationRequest) (*pkg.DefaultResponse, error) {
-	lga, err := fm.typeValidation(ctx, request)
+	_, err := fm.addFee(ctx, request, time.Now().Unix())
 	if err != nil {
 		return nil, err
 	}
 
-	newFees := models.Fee{
-		ID:        fm.idgenerator.Generate(),
-		ProductID: request.ProductID,
-		FeeType:   request.FeeType,
-		LGA:       *lga,
-		Cost: models.Cost{
-			CostPerKG:   request.Cost.CostPerKG,
-			CostPerQt:   request.Cost.CostPerQt,
-			CostPerType: request.Cost.CostPerType,
-		},
-		Status:   models.FeesActive,
-		StatusTs: time.Now().Unix(),
-		Ts:       time.Now().Unix(),
-	}
-
-	getRequest := query.ResultSelector{
-		Filter: &filter.Request{
-			Operator: "and",
-			Fields: []filter.RequestField{
-				{
-					Name:  "lga",
-					Value: lga,
-				},
-				{
-					Name:  "product_id",
-					Value: request.ProductID,
-				},
-				{
-					Name:  "fee_type",
-					Value: request.FeeType,
-				},
-				{
-					Name:  "status",
-					Value: newFees.Status,
-				},
-			},
-		},
-		Paging: &paging.Request{},
-	}
-
-	fees, _, err := fm.repositoryManager.FeesRepository.Fees(ctx, getRequest)
-	if err != nil {
-		if errors.Is(err, mongo.ErrNoDocuments) {
-			err = fm.repositoryManager.FeesRepository.Create(ctx, newFees)
-			if err != nil {
-				return nil, errs.Body(errs.DatabaseError, fmt.Errorf("error creating fees: %w", err))
-			}
-
-			return &pkg.DefaultResponse{Success: "success", Message: "Fee created successfully"}, nil
-		}
-		return nil, errs.Body(errs.DatabaseError, err)
-	}
-
-	if fees != nil {
-		err = fm.repositoryManager.FeesRepository.Update(ctx, models.FeesInactive, request.FeeType, *lga, request.ProductID)
-		if err != nil {
-			return nil, errs.Body(errs.DatabaseError, fmt.Errorf("error updating fees: %w", err))
-		}
-		err = fm.repositoryManager.FeesRepository.Create(ctx, newFees)
-		if err != nil {
-			return nil, errs.Body(errs.DatabaseError, fmt.Errorf("create fees after inactivating stale fee: %w", err))
-		}
-	}
-
 	return &pkg.DefaultResponse{Success: "success", Message: "Fee created successfully"}, nil
 }
 
@@ -126,12 +69,12 @@ func (fm *FeesManager) validateProductFeeRequest(ctx context.Context, request do
 
 		switch product.ParentCategory {
 		case models.LNGProductCategory, models.LPGProductCategory:
-			if request.Cost.CostPerKG <= 0 {
+			if !request.Cost.CostPerKG.IsPositive() {
 				return errs.Body(errs.InvalidRequestError, errors.New("cost per kg is required for product fee"))
 			}
 
 		default:
-			if request.Cost.CostPerQt <= 0 {
+			if !request.Cost.CostPerQt.IsPositive() {
 				return errs.Body(errs.InvalidRequestError, errors.New("cost per quantity is required for product fee"))
 			}
 		}
@@ -140,6 +83,27 @@ func (fm *FeesManager) validateProductFeeRequest(ctx context.Context, request do
 	return nil
 }
 
+// validateDepositFeeRequest checks the deposit fee is for a product sold in cylinders
+func (fm *FeesManager) validateDepositFeeRequest(ctx context.Context, request domain.FeeQuotationRequest) error {
+	if request.FeeType != models.DepositFee {
+		return nil
+	}
+
+	product, err := fm.repositoryManager.ProductRepository.Product(ctx, request.ProductID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return errs.Body(errs.InvalidProductIdError, fmt.Errorf("no product found with product id %s: %w", request.ProductID, err))
+		}
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	if product.ParentCategory != models.LPGProductCategory {
+		return errs.Body(errs.InvalidRequestError, fmt.Errorf("deposit fees only apply to %s products", models.LPGProductCategory))
+	}
+
+	return nil
+}
+
 func (fm *FeesManager) validateLGA(ctx context.Context, lga models.LGA) error {
 
 	state, err := fm.repositoryManager.StatesRepository.GetState(ctx, lga.State)
@@ -164,6 +128,11 @@ func (fm *FeesManager) typeValidation(ctx context.Context, request domain.FeeQuo
 		return nil, err
 	}
 
+	err = fm.validateDepositFeeRequest(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
 	var lga models.LGA
 
 	if request.FeeType == models.DeliveryFee && request.LGA.State != "" && request.LGA.LGA != "" {
@@ -178,11 +147,16 @@ func (fm *FeesManager) typeValidation(ctx context.Context, request domain.FeeQuo
 }
 
 func (fm *FeesManager) Fees(ctx context.Context, request query.ResultSelector) ([]models.Fee, uint64, error) {
-	fees, totalRecord, err := fm.repositoryManager.FeesRepository.Fees(ctx, request)
+	now := time.Now().Unix()
+	fees, totalRecord, err := fm.repositoryManager.FeesRepository.Fees(ctx, request, now)
 	if err != nil {
 		return nil, 0, errs.Body(errs.InternalError, fmt.Errorf("error fetching fees: %w", err))
 	}
 
+	for i := range fees {
+		fees[i].Status = fees[i].StatusAt(now)
+	}
+
 	for _, field := range request.Filter.Fields {
 		if field.Name == "lga" && len(fees) == 0 {
 			return nil, 0, errs.Body(errs.LGANotFoundError, fmt.Errorf("lga not found, leeta is not available in this region: %s", field.Name))
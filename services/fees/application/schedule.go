@@ -0,0 +1,189 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/pkg/jwtmiddleware"
+	"github.com/leetatech/leeta_backend/services/fees/domain"
+	"github.com/leetatech/leeta_backend/services/models"
+	"github.com/samber/lo"
+	"time"
+)
+
+func (fm *FeesManager) ScheduleFee(ctx context.Context, request domain.ScheduleFeeRequest) (*models.Fee, error) {
+	if _, err := fm.adminClaims(ctx); err != nil {
+		return nil, err
+	}
+
+	if request.EffectiveFrom <= time.Now().Unix() {
+		return nil, errs.Body(errs.InvalidRequestError, errors.New("a scheduled fee must take effect in the future"))
+	}
+
+	return fm.addFee(ctx, request.FeeQuotationRequest, request.EffectiveFrom)
+}
+
+// addFee adds the fee to the price history of its type, product and lga, taking effect at the unix time. It ends the
+// window of the fee it replaces and is charged until the next scheduled fee takes effect.
+func (fm *FeesManager) addFee(ctx context.Context, request domain.FeeQuotationRequest, effectiveFrom int64) (*models.Fee, error) {
+	lga, err := fm.typeValidation(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	// fees are charged in the payment currency, so every amount they are added to shares it
+	cost, err := request.Cost.InCurrency(fm.currency)
+	if err != nil {
+		return nil, errs.Body(errs.InvalidRequestError, err)
+	}
+
+	var bands *models.DeliveryBands
+	if request.Bands != nil {
+		bands, err = request.Bands.InCurrency(fm.currency)
+		if err != nil {
+			return nil, errs.Body(errs.InvalidRequestError, err)
+		}
+	}
+
+	now := time.Now().Unix()
+	fee := models.Fee{
+		ID:            fm.idgenerator.Generate(),
+		ProductID:     request.ProductID,
+		FeeType:       request.FeeType,
+		LGA:           *lga,
+		Cost:          cost,
+		Bands:         bands,
+		Status:        models.FeesActive,
+		EffectiveFrom: effectiveFrom,
+		StatusTs:      now,
+		Ts:            now,
+	}
+
+	// the replaced fee is only ended together with creating its replacement, so there is always a fee in force
+	err = fm.repositoryManager.Transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		history, err := fm.repositoryManager.FeesRepository.History(ctx, fee.FeeType, fee.ProductID, fee.LGA)
+		if err != nil {
+			return err
+		}
+
+		var replaced *models.Fee
+		for i, existing := range history {
+			if existing.Status == models.FeesCancelled {
+				continue
+			}
+
+			switch {
+			case existing.EffectiveFrom == effectiveFrom:
+				return errs.Body(errs.FeeScheduleConflictError, fmt.Errorf("fee %s already takes effect at %d", existing.ID, effectiveFrom))
+			case existing.EffectiveFrom < effectiveFrom:
+				replaced = &history[i]
+			case fee.EffectiveTo == 0:
+				// history is in the order fees take effect, so this is the first fee scheduled after the new one
+				fee.EffectiveTo = existing.EffectiveFrom
+			}
+		}
+
+		if replaced != nil && (replaced.EffectiveTo == 0 || replaced.EffectiveTo > effectiveFrom) {
+			err = fm.repositoryManager.FeesRepository.SetEffectiveTo(ctx, replaced.ID, replaced.EffectiveTo, effectiveFrom)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = fm.repositoryManager.FeesRepository.Create(ctx, fee)
+		if err != nil {
+			return errs.Body(errs.DatabaseError, fmt.Errorf("error creating fees: %w", err))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &fee, nil
+}
+
+func (fm *FeesManager) CancelFee(ctx context.Context, id string) (*models.Fee, error) {
+	if _, err := fm.adminClaims(ctx); err != nil {
+		return nil, err
+	}
+
+	fee, err := fm.repositoryManager.FeesRepository.FeeByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	if fee.Status == models.FeesCancelled || fee.EffectiveFrom <= now {
+		return nil, errs.Body(errs.InvalidRequestError, errors.New("only fees that have not taken effect can be cancelled"))
+	}
+
+	err = fm.repositoryManager.Transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		history, err := fm.repositoryManager.FeesRepository.History(ctx, fee.FeeType, fee.ProductID, fee.LGA)
+		if err != nil {
+			return err
+		}
+
+		// the fee the cancelled fee was to replace is charged until the fee after it takes effect instead
+		for _, existing := range history {
+			if existing.ID != fee.ID && existing.Status != models.FeesCancelled && existing.EffectiveTo == fee.EffectiveFrom {
+				err = fm.repositoryManager.FeesRepository.SetEffectiveTo(ctx, existing.ID, existing.EffectiveTo, fee.EffectiveTo)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		return fm.repositoryManager.FeesRepository.Cancel(ctx, fee.ID, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fee.Status = models.FeesCancelled
+	fee.StatusTs = now
+	return fee, nil
+}
+
+func (fm *FeesManager) FeeHistory(ctx context.Context, request domain.FeeHistoryRequest) ([]models.Fee, error) {
+	if _, err := fm.adminClaims(ctx); err != nil {
+		return nil, err
+	}
+
+	request, err := request.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	fees, err := fm.repositoryManager.FeesRepository.History(ctx, request.FeeType, request.ProductID, request.LGA)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.At != 0 {
+		fees = lo.Filter(fees, func(fee models.Fee, _ int) bool {
+			return fee.InForce(request.At)
+		})
+	}
+
+	now := time.Now().Unix()
+	for i := range fees {
+		fees[i].Status = fees[i].StatusAt(now)
+	}
+
+	return fees, nil
+}
+
+func (fm *FeesManager) adminClaims(ctx context.Context) (*jwtmiddleware.UserClaims, error) {
+	claims, err := fm.jwtManager.ExtractUserClaims(ctx)
+	if err != nil {
+		return nil, errs.Body(errs.ErrorUnauthorized, err)
+	}
+
+	if claims.Role != models.AdminCategory {
+		return nil, errs.Body(errs.ErrorUnauthorized, errors.New("only admins can manage fee schedules"))
+	}
+
+	return claims, nil
+}
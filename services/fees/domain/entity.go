@@ -2,8 +2,10 @@ package domain
 
 import (
 	"errors"
+	"fmt"
 	"github.com/leetatech/leeta_backend/pkg/errs"
 	"github.com/leetatech/leeta_backend/services/models"
+	"strings"
 )
 
 type FeeQuotationRequest struct {
@@ -11,6 +13,8 @@ type FeeQuotationRequest struct {
 	FeeType   models.FeeType `json:"fee_type" bson:"fee_type"`
 	LGA       models.LGA     `json:"lga,omitempty" bson:"lga"`
 	ProductID string         `json:"product_id,omitempty" bson:"product_id"`
+	// Bands price delivery fees by distance and weight, the cost per type is still required for deliveries they cannot price
+	Bands *models.DeliveryBands `json:"bands,omitempty" bson:"bands,omitempty"`
 } // @name FeeQuotationRequest
 
 type GetTypedFeesRequest struct {
@@ -21,29 +25,91 @@ type GetTypedFeesRequest struct {
 } //@name GetTypedFeesRequest
 
 func (request FeeQuotationRequest) FeeTypeValidation() (FeeQuotationRequest, error) {
+	if request.FeeType != models.DeliveryFee {
+		request.Bands = nil
+	}
+
 	switch request.FeeType {
 	case models.ServiceFee:
 		request.LGA = models.LGA{}
 		request.ProductID = ""
-		request.Cost.CostPerQt = 0
-		request.Cost.CostPerKG = 0
-		if request.Cost.CostPerType == 0 {
+		request.Cost.CostPerQt = models.Money{}
+		request.Cost.CostPerKG = models.Money{}
+		if !request.Cost.CostPerType.IsPositive() {
 			return FeeQuotationRequest{}, errs.Body(errs.InvalidRequestError, errors.New("cost per type is required for service fee"))
 		}
+	case models.CancellationFee:
+		request.LGA = models.LGA{}
+		request.ProductID = ""
+		request.Cost.CostPerQt = models.Money{}
+		request.Cost.CostPerKG = models.Money{}
+		if !request.Cost.CostPerType.IsPositive() {
+			return FeeQuotationRequest{}, errs.Body(errs.InvalidRequestError, errors.New("cost per type is required for cancellation fee"))
+		}
 	case models.ProductFee:
 		request.LGA = models.LGA{}
-		request.Cost.CostPerType = 0
+		request.Cost.CostPerType = models.Money{}
 		if request.ProductID == "" {
 			return FeeQuotationRequest{}, errs.Body(errs.InvalidRequestError, errors.New("product id, cost per kg and cost per quantity is required for product fee"))
 		}
 
+	case models.DepositFee:
+		request.LGA = models.LGA{}
+		request.Cost.CostPerType = models.Money{}
+		if request.ProductID == "" || (!request.Cost.CostPerQt.IsPositive() && !request.Cost.CostPerKG.IsPositive()) {
+			return FeeQuotationRequest{}, errs.Body(errs.InvalidRequestError, errors.New("product id and cost per quantity or cost per kg is required for deposit fee"))
+		}
+
 	case models.DeliveryFee:
 		request.ProductID = ""
-		request.Cost.CostPerQt = 0
-		request.Cost.CostPerKG = 0
-		if request.LGA.LGA == "" || request.Cost.CostPerType == 0 {
+		request.Cost.CostPerQt = models.Money{}
+		request.Cost.CostPerKG = models.Money{}
+		if request.LGA.LGA == "" || !request.Cost.CostPerType.IsPositive() {
 			return FeeQuotationRequest{}, errs.Body(errs.InvalidRequestError, errors.New("lga and cost per type is required for delivery fee"))
 		}
+		if request.Bands != nil {
+			if err := request.Bands.Validate(); err != nil {
+				return FeeQuotationRequest{}, errs.Body(errs.InvalidRequestError, err)
+			}
+		}
+	}
+
+	return request, nil
+}
+
+// ScheduleFeeRequest sets a fee that takes effect at EffectiveFrom, a unix time in the future
+type ScheduleFeeRequest struct {
+	FeeQuotationRequest
+	EffectiveFrom int64 `json:"effective_from"`
+} // @name ScheduleFeeRequest
+
+// FeeHistoryRequest selects the fees of a type, product and lga. At limits them to the fee in force at the unix time when set.
+type FeeHistoryRequest struct {
+	FeeType   models.FeeType
+	ProductID string
+	LGA       models.LGA
+	At        int64
+}
+
+// Validate checks the fee type and clears the product or lga fees of the type are not set for, the way fees are stored
+func (request FeeHistoryRequest) Validate() (FeeHistoryRequest, error) {
+	switch request.FeeType {
+	case models.ServiceFee, models.CancellationFee:
+		request.LGA = models.LGA{}
+		request.ProductID = ""
+	case models.ProductFee, models.DepositFee:
+		request.LGA = models.LGA{}
+		if request.ProductID == "" {
+			return FeeHistoryRequest{}, errs.Body(errs.InvalidRequestError, fmt.Errorf("product id is required for %s", request.FeeType))
+		}
+	case models.DeliveryFee:
+		request.ProductID = ""
+		request.LGA.State = strings.ToUpper(request.LGA.State)
+		if request.LGA.LGA == "" || request.LGA.State == "" {
+			return FeeHistoryRequest{}, errs.Body(errs.InvalidRequestError, errors.New("state and lga are required for delivery fee"))
+		}
+	default:
+		return FeeHistoryRequest{}, errs.Body(errs.InvalidRequestError, fmt.Errorf("invalid fee type %s", request.FeeType))
 	}
 
 	return request, nil
@@ -8,8 +8,21 @@ import (
 
 type FeesRepository interface {
 	Create(ctx context.Context, request models.Fee) error
-	ByProductID(ctx context.Context, productID string, status models.FeesStatuses) (*models.Fee, error)
-	FeesByStatus(ctx context.Context, status models.FeesStatuses) ([]models.Fee, error)
-	Update(ctx context.Context, status models.FeesStatuses, feeType models.FeeType, lga models.LGA, productID string) error
-	Fees(ctx context.Context, request query.ResultSelector) ([]models.Fee, uint64, error)
+	FeeByID(ctx context.Context, id string) (*models.Fee, error)
+	// ByProductID returns the fee of the given type in force for the product at the unix time, the product fee or the
+	// cylinder deposit fee
+	ByProductID(ctx context.Context, productID string, feeType models.FeeType, at int64) (*models.Fee, error)
+	// ByFeeType returns the fee of the given type in force at the unix time, scoped to the lga when one is provided
+	ByFeeType(ctx context.Context, feeType models.FeeType, lga models.LGA, at int64) (*models.Fee, error)
+	// FeesInForce returns every fee in force at the unix time
+	FeesInForce(ctx context.Context, at int64) ([]models.Fee, error)
+	// History returns the fees of the type, product and lga ordered by the time they take effect, cancelled fees included
+	History(ctx context.Context, feeType models.FeeType, productID string, lga models.LGA) ([]models.Fee, error)
+	// SetEffectiveTo moves the end of the fee's window from effectiveTo to newEffectiveTo. It fails with a
+	// FeeScheduleConflictError when the window was changed by another request.
+	SetEffectiveTo(ctx context.Context, id string, effectiveTo, newEffectiveTo int64) error
+	// Cancel cancels the fee if it has not taken effect at the unix time
+	Cancel(ctx context.Context, id string, ts int64) error
+	// Fees lists the fees of the request. Statuses are filtered on the status the fees have at the unix time.
+	Fees(ctx context.Context, request query.ResultSelector, at int64) ([]models.Fee, uint64, error)
 }
@@ -13,19 +13,22 @@ import (
 	"github.com/rs/zerolog/log"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type feeStoreHandler struct {
 	client       *mongo.Client
 	databaseName string
+	// currency is the payment currency, the fees charged are checked to be in it
+	currency string
 }
 
 func (f *feeStoreHandler) col(collectionName string) *mongo.Collection {
 	return f.client.Database(f.databaseName).Collection(collectionName)
 }
 
-func New(client *mongo.Client, databaseName string) domain.FeesRepository {
-	return &feeStoreHandler{client: client, databaseName: databaseName}
+func New(client *mongo.Client, databaseName, currency string) domain.FeesRepository {
+	return &feeStoreHandler{client: client, databaseName: databaseName, currency: currency}
 }
 
 func (f *feeStoreHandler) Create(ctx context.Context, request models.Fee) error {
@@ -37,10 +40,108 @@ func (f *feeStoreHandler) Create(ctx context.Context, request models.Fee) error
 	return nil
 }
 
-func (f *feeStoreHandler) FeesByStatus(ctx context.Context, status models.FeesStatuses) ([]models.Fee, error) {
-	filter := bson.M{"status": status}
+// EnsureIndexes creates the index fees are resolved by
+func EnsureIndexes(ctx context.Context, client *mongo.Client, databaseName string) error {
+	col := client.Database(databaseName).Collection(models.FeesCollectionName)
+	_, err := col.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "fee_type", Value: 1},
+			{Key: "product_id", Value: 1},
+			{Key: "lga.state", Value: 1},
+			{Key: "lga.lga", Value: 1},
+			{Key: "effective_from", Value: -1},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error creating fee indexes: %w", err)
+	}
+
+	return nil
+}
+
+// MigrateEffectiveWindows gives fees created before effective windows theirs. Active fees took effect when they were
+// created, inactive fees were replaced when they were inactivated.
+func MigrateEffectiveWindows(ctx context.Context, client *mongo.Client, databaseName string) error {
+	col := client.Database(databaseName).Collection(models.FeesCollectionName)
+	_, err := col.UpdateMany(ctx,
+		bson.M{"effective_from": bson.M{"$exists": false}, "status": models.FeesInactive},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{"effective_from": "$ts", "effective_to": "$status_ts"}}}},
+	)
+	if err != nil {
+		return fmt.Errorf("error setting the effective window of inactive fees: %w", err)
+	}
+
+	_, err = col.UpdateMany(ctx,
+		bson.M{"effective_from": bson.M{"$exists": false}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{"effective_from": "$ts"}}}},
+	)
+	if err != nil {
+		return fmt.Errorf("error setting the effective window of fees: %w", err)
+	}
+
+	return nil
+}
+
+func (f *feeStoreHandler) FeeByID(ctx context.Context, id string) (*models.Fee, error) {
+	fee := &models.Fee{}
+	err := f.col(models.FeesCollectionName).FindOne(ctx, bson.M{"id": id}).Decode(fee)
+	if err != nil {
+		return nil, errs.Body(errs.DatabaseError, fmt.Errorf("error getting fee %s: %w", id, err))
+	}
+
+	return fee, nil
+}
+
+// inForce filters the fees charged at the unix time
+func inForce(at int64) bson.M {
+	return bson.M{
+		"status":         bson.M{"$ne": models.FeesCancelled},
+		"effective_from": bson.M{"$lte": at},
+		"$or": bson.A{
+			bson.M{"effective_to": bson.M{"$exists": false}},
+			bson.M{"effective_to": bson.M{"$gt": at}},
+		},
+	}
+}
+
+// statusAt matches the fees that have one of the statuses at the unix time, see models.Fee.StatusAt
+func statusAt(value any, at int64) bson.M {
+	var statuses []string
+	switch value := value.(type) {
+	case string:
+		statuses = []string{value}
+	case []string:
+		statuses = value
+	case []any:
+		for _, status := range value {
+			statuses = append(statuses, fmt.Sprint(status))
+		}
+	}
+
+	conditions := bson.A{}
+	for _, status := range statuses {
+		switch models.FeesStatuses(status) {
+		case models.FeesActive:
+			active := inForce(at)
+			active["status"] = models.FeesActive
+			conditions = append(conditions, active)
+		case models.FeesScheduled:
+			conditions = append(conditions, bson.M{"status": models.FeesActive, "effective_from": bson.M{"$gt": at}})
+		case models.FeesInactive:
+			conditions = append(conditions, bson.M{"status": models.FeesInactive}, bson.M{"status": models.FeesActive, "effective_to": bson.M{"$lte": at}})
+		case models.FeesCancelled:
+			conditions = append(conditions, bson.M{"status": models.FeesCancelled})
+		}
+	}
 
-	cursor, err := f.col(models.FeesCollectionName).Find(ctx, filter)
+	if len(conditions) == 0 {
+		return bson.M{"status": bson.M{"$in": statuses}}
+	}
+	return bson.M{"$or": conditions}
+}
+
+func (f *feeStoreHandler) FeesInForce(ctx context.Context, at int64) ([]models.Fee, error) {
+	cursor, err := f.col(models.FeesCollectionName).Find(ctx, inForce(at))
 	if err != nil {
 		return nil, err
 	}
@@ -50,6 +151,12 @@ func (f *feeStoreHandler) FeesByStatus(ctx context.Context, status models.FeesSt
 		return nil, err
 	}
 
+	for _, fee := range fees {
+		if err := fee.CheckCurrency(f.currency); err != nil {
+			return nil, errs.Body(errs.FeesError, err)
+		}
+	}
+
 	defer func(cursor *mongo.Cursor, ctx context.Context) {
 		err := cursor.Close(ctx)
 		if err != nil {
@@ -60,55 +167,131 @@ func (f *feeStoreHandler) FeesByStatus(ctx context.Context, status models.FeesSt
 	return fees, nil
 }
 
-func (f *feeStoreHandler) Update(ctx context.Context, status models.FeesStatuses, feeType models.FeeType, lga models.LGA, productID string) error {
-	filter := bson.M{}
-	if status != "" {
-		filter["status"] = models.FeesActive
+func (f *feeStoreHandler) ByProductID(ctx context.Context, productID string, feeType models.FeeType, at int64) (*models.Fee, error) {
+	filter := inForce(at)
+	filter["product_id"] = productID
+	filter["fee_type"] = feeType
+
+	return f.feeInForce(ctx, filter)
+}
+
+func (f *feeStoreHandler) ByFeeType(ctx context.Context, feeType models.FeeType, lga models.LGA, at int64) (*models.Fee, error) {
+	filter := inForce(at)
+	filter["fee_type"] = feeType
+	if lga != (models.LGA{}) {
+		filter["lga.lga"] = lga.LGA
+		filter["lga.state"] = lga.State
 	}
 
-	if feeType != "" {
-		filter["fee_type"] = feeType
+	return f.feeInForce(ctx, filter)
+}
+
+// feeInForce finds the fee matching the filter, preferring the one that took effect last should windows ever overlap
+func (f *feeStoreHandler) feeInForce(ctx context.Context, filter bson.M) (*models.Fee, error) {
+	fee := &models.Fee{}
+
+	newCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	opts := options.FindOne().SetSort(bson.D{{Key: "effective_from", Value: -1}})
+	err := f.col(models.FeesCollectionName).FindOne(newCtx, filter, opts).Decode(fee)
+	if err != nil {
+		return nil, err
 	}
 
-	if lga != (models.LGA{}) {
-		filter["lga"] = lga
+	err = fee.CheckCurrency(f.currency)
+	if err != nil {
+		return nil, errs.Body(errs.FeesError, err)
+	}
+
+	return fee, nil
+}
+
+func (f *feeStoreHandler) History(ctx context.Context, feeType models.FeeType, productID string, lga models.LGA) ([]models.Fee, error) {
+	filter := bson.M{"fee_type": feeType, "product_id": productID, "lga.state": lga.State, "lga.lga": lga.LGA}
+	opts := options.Find().SetSort(bson.D{{Key: "effective_from", Value: 1}, {Key: "ts", Value: 1}})
+
+	cursor, err := f.col(models.FeesCollectionName).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, errs.Body(errs.DatabaseError, fmt.Errorf("error getting fee history: %w", err))
 	}
 
-	if productID != "" {
-		filter["product_id"] = productID
+	fees := make([]models.Fee, 0, cursor.RemainingBatchLength())
+	if err := cursor.All(ctx, &fees); err != nil {
+		return nil, errs.Body(errs.DatabaseError, fmt.Errorf("error decoding fee history: %w", err))
 	}
 
-	update := bson.M{"$set": bson.M{"status": status, "status_ts": time.Now().Unix()}}
-	_, err := f.col(models.FeesCollectionName).UpdateMany(ctx, filter, update)
+	return fees, nil
+}
+
+func (f *feeStoreHandler) SetEffectiveTo(ctx context.Context, id string, effectiveTo, newEffectiveTo int64) error {
+	filter := bson.M{"id": id, "status": bson.M{"$ne": models.FeesCancelled}}
+	if effectiveTo == 0 {
+		filter["effective_to"] = bson.M{"$exists": false}
+	} else {
+		filter["effective_to"] = effectiveTo
+	}
+
+	update := bson.M{"$set": bson.M{"effective_to": newEffectiveTo}}
+	if newEffectiveTo == 0 {
+		update = bson.M{"$unset": bson.M{"effective_to": ""}}
+	}
+
+	result, err := f.col(models.FeesCollectionName).UpdateOne(ctx, filter, update)
 	if err != nil {
 		return errs.Body(errs.DatabaseError, err)
 	}
+	if result.MatchedCount == 0 {
+		return errs.Body(errs.FeeScheduleConflictError, fmt.Errorf("fee %s was changed by another request", id))
+	}
 
 	return nil
 }
 
-func (f *feeStoreHandler) ByProductID(ctx context.Context, productID string, status models.FeesStatuses) (*models.Fee, error) {
-	filter := bson.M{"product_id": productID, "status": status}
-	fee := &models.Fee{}
+func (f *feeStoreHandler) Cancel(ctx context.Context, id string, ts int64) error {
+	filter := bson.M{"id": id, "status": bson.M{"$ne": models.FeesCancelled}, "effective_from": bson.M{"$gt": ts}}
+	update := bson.M{"$set": bson.M{"status": models.FeesCancelled, "status_ts": ts}}
 
-	newCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
-	defer cancel()
-
-	err := f.col(models.FeesCollectionName).FindOne(newCtx, filter).Decode(fee)
+	result, err := f.col(models.FeesCollectionName).UpdateOne(ctx, filter, update)
 	if err != nil {
-		return nil, err
+		return errs.Body(errs.DatabaseError, err)
+	}
+	if result.MatchedCount == 0 {
+		return errs.Body(errs.FeeScheduleConflictError, fmt.Errorf("fee %s was cancelled or took effect", id))
 	}
 
-	return fee, nil
+	return nil
 }
 
-func (f *feeStoreHandler) Fees(ctx context.Context, request query.ResultSelector) ([]models.Fee, uint64, error) {
+func (f *feeStoreHandler) Fees(ctx context.Context, request query.ResultSelector, at int64) ([]models.Fee, uint64, error) {
 	feesFilterMapping := map[string]string{
 		"lga": "lga.lga",
 	}
 	var filterQuery bson.M
 	if request.Filter != nil {
-		filterQuery = database.BuildMongoFilterQuery(request.Filter, feesFilterMapping)
+		// the status of a fee depends on the time its window is compared to, so it is not matched against the stored status
+		requestFilter := *request.Filter
+		requestFilter.Fields = nil
+		var statusQueries []bson.M
+		for _, field := range request.Filter.Fields {
+			if field.Name != "status" {
+				requestFilter.Fields = append(requestFilter.Fields, field)
+				continue
+			}
+			statusQueries = append(statusQueries, statusAt(field.Value, at))
+		}
+
+		filterQuery = database.BuildMongoFilterQuery(&requestFilter, feesFilterMapping)
+		switch {
+		case len(statusQueries) == 0:
+		case request.Filter.Operator == "or":
+			if len(requestFilter.Fields) > 0 {
+				statusQueries = append(statusQueries, filterQuery)
+			}
+			filterQuery = bson.M{"$or": statusQueries}
+		default:
+			filterQuery = bson.M{"$and": append(statusQueries, filterQuery)}
+		}
 	}
 
 	cursor, err := f.col(models.FeesCollectionName).Find(ctx, filterQuery)
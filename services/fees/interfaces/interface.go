@@ -2,10 +2,13 @@ package interfaces
 
 import (
 	"encoding/json"
+	"fmt"
+	"github.com/go-chi/chi/v5"
 	"github.com/greenbone/opensight-golang-libraries/pkg/query"
 	"github.com/greenbone/opensight-golang-libraries/pkg/query/filter"
 	_ "github.com/leetatech/leeta_backend/pkg"
 	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/pkg/helpers"
 	"github.com/leetatech/leeta_backend/pkg/jwtmiddleware"
 	"github.com/leetatech/leeta_backend/services/fees/application"
 	"github.com/leetatech/leeta_backend/services/fees/domain"
@@ -13,6 +16,7 @@ import (
 	"github.com/leetatech/leeta_backend/services/web"
 	"github.com/samber/lo"
 	"net/http"
+	"strconv"
 )
 
 type FeesHttpHandler struct {
@@ -58,9 +62,108 @@ func (handler *FeesHttpHandler) CreateFeeHandler(w http.ResponseWriter, r *http.
 	jwtmiddleware.WriteJSONResponse(w, response, http.StatusOK)
 }
 
+// ScheduleFeeHandler godoc
+// @Summary Schedule a fee
+// @Description The endpoint schedules a fee to take effect at effective_from, a unix time in the future. The fee in force is charged until then, and the new fee until the next scheduled fee takes effect
+// @Tags Fees
+// @Accept json
+// @produce json
+// @param domain.ScheduleFeeRequest body domain.ScheduleFeeRequest true "schedule fee request body"
+// @Security BearerToken
+// @success 200 {object} models.Fee
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Failure 409 {object} pkg.DefaultErrorResponse
+// @Router /fees/schedule [POST]
+func (handler *FeesHttpHandler) ScheduleFeeHandler(w http.ResponseWriter, r *http.Request) {
+	var request domain.ScheduleFeeRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		jwtmiddleware.WriteJSONResponse(w, errs.Body(errs.UnmarshalError, err), http.StatusBadRequest)
+		return
+	}
+	request.FeeQuotationRequest, err = request.FeeQuotationRequest.FeeTypeValidation()
+	if err != nil {
+		jwtmiddleware.WriteJSONErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := handler.FeesApplication.ScheduleFee(r.Context(), request)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, resp, http.StatusOK)
+}
+
+// CancelFeeHandler godoc
+// @Summary Cancel a scheduled fee
+// @Description The endpoint cancels a fee that has not taken effect yet. The fee it was to replace is charged in its place
+// @Tags Fees
+// @Accept json
+// @produce json
+// @Param			fee_id	path		string	true	"fee id"
+// @Security BearerToken
+// @success 200 {object} models.Fee
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Failure 409 {object} pkg.DefaultErrorResponse
+// @Router /fees/{fee_id}/cancel [POST]
+func (handler *FeesHttpHandler) CancelFeeHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := handler.FeesApplication.CancelFee(r.Context(), chi.URLParam(r, "fee_id"))
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, resp, http.StatusOK)
+}
+
+// FeeHistoryHandler godoc
+// @Summary List the history of a fee
+// @Description The endpoint lists the fees of a type, product and lga in the order they take effect, scheduled and cancelled fees included. Set at to get the fee in force at a unix time
+// @Tags Fees
+// @Accept json
+// @produce json
+// @Param			fee_type	query		string	true	"fee type"
+// @Param			product_id	query		string	false	"product id of product and deposit fees"
+// @Param			state		query		string	false	"state of delivery fees"
+// @Param			lga			query		string	false	"lga of delivery fees"
+// @Param			at			query		int		false	"unix time to get the fee in force at"
+// @Security BearerToken
+// @success 200 {object} []models.Fee
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Router /fees/history [GET]
+func (handler *FeesHttpHandler) FeeHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	request := domain.FeeHistoryRequest{
+		FeeType:   models.FeeType(params.Get("fee_type")),
+		ProductID: params.Get("product_id"),
+		LGA:       models.LGA{State: params.Get("state"), LGA: params.Get("lga")},
+	}
+	if at := params.Get("at"); at != "" {
+		var err error
+		request.At, err = strconv.ParseInt(at, 10, 64)
+		if err != nil {
+			jwtmiddleware.WriteJSONErrorResponse(w, http.StatusBadRequest, errs.Body(errs.InvalidRequestError, fmt.Errorf("invalid at %s: %w", at, err)))
+			return
+		}
+	}
+
+	resp, err := handler.FeesApplication.FeeHistory(r.Context(), request)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, resp, http.StatusOK)
+}
+
 // FetchFeesHandler is the endpoint to all fees
 // @Summary List fees.
-// @Description The endpoint to get all list fees. Use filter t filter by type
+// @Description The endpoint to get all list fees. Use filter t filter by type. Fees are filtered and listed with their status now: ACTIVE fees are in force, SCHEDULED fees take effect later and INACTIVE fees were replaced
 // @Tags Fees
 // @Accept json
 // @produce json
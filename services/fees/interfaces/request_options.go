@@ -67,16 +67,24 @@ var listFeesOptions = []filter.RequestOption{
 			string(models.DeliveryFee),
 			string(models.ServiceFee),
 			string(models.ProductFee),
+			string(models.CancellationFee),
+			string(models.DepositFee),
 		},
 	},
 	{
 		Name: statusRequestName,
 		Control: filter.RequestOptionType{
-			Type: filter.ControlTypeString,
+			Type: filter.ControlTypeEnum,
 		},
 		Operators: []filter.ReadableValue[filter.CompareOperator]{
 			operatorEqual,
 		},
+		Values: []string{
+			string(models.FeesActive),
+			string(models.FeesScheduled),
+			string(models.FeesInactive),
+			string(models.FeesCancelled),
+		},
 		MultiSelect: true,
 	},
 }
@@ -5,13 +5,19 @@ import (
 )
 
 type Cart struct {
-	ID         string       `json:"id" bson:"id"`
-	CustomerID string       `json:"customer_id" bson:"customer_id"`
-	CartItems  []CartItem   `json:"cart_items" bson:"cart_items"`
-	Total      float64      `json:"total" bson:"total"`
-	Status     CartStatuses `json:"status" bson:"status"`
-	StatusTs   int64        `json:"status_ts" bson:"status_ts"`
-	Ts         int64        `json:"ts" bson:"ts"`
+	ID         string `json:"id" bson:"id"`
+	CustomerID string `json:"customer_id" bson:"customer_id"`
+	// DeviceID is the device a guest built the cart on, it is only set on guest carts
+	DeviceID string `json:"device_id,omitempty" bson:"device_id,omitempty"`
+	// MergedInto is the customer cart the items of a guest cart were moved to when the guest signed in
+	MergedInto string `json:"merged_into,omitempty" bson:"merged_into,omitempty"`
+	// PromoCode is the code of the promotion applied to the cart, it is validated again when the cart is quoted and checked out
+	PromoCode string       `json:"promo_code,omitempty" bson:"promo_code,omitempty"`
+	CartItems []CartItem   `json:"cart_items" bson:"cart_items"`
+	Total     Money        `json:"total" bson:"total"`
+	Status    CartStatuses `json:"status" bson:"status"`
+	StatusTs  int64        `json:"status_ts" bson:"status_ts"`
+	Ts        int64        `json:"ts" bson:"ts"`
 }
 
 type CartItem struct {
@@ -19,53 +25,132 @@ type CartItem struct {
 	ProductID       string          `json:"product_id" bson:"product_id"`
 	ProductCategory ProductCategory `json:"product_category" bson:"product_category"`
 	VendorID        string          `json:"vendor_id" bson:"vendor_id"`
-	Weight          float32         `json:"weight,omitempty" bson:"weight"`
+	Weight          float64         `json:"weight,omitempty" bson:"weight"`
 	Quantity        int             `json:"quantity,omitempty" bson:"quantity"`
-	Cost            float64         `json:"cost" bson:"cost"`
+	// Mode tells whether the customer swaps empty cylinders or buys new ones, items without a mode are exchanges
+	Mode CylinderMode `json:"mode,omitempty" bson:"mode,omitempty"`
+	// Deposit is the refundable cylinder deposit included in the cost of a new cylinder purchase
+	Deposit Money `json:"deposit,omitempty" bson:"deposit,omitempty"`
+	Cost    Money `json:"cost" bson:"cost"`
 }
 
-func (c *CartItem) CalculateCartItemFee(fee *Fee) (float64, error) {
-	var totalCost float64
+// CylinderMode is how a customer gets the gas of an LPG cart item
+type CylinderMode string
+
+const (
+	CylinderExchange CylinderMode = "EXCHANGE"     // the customer hands in empty cylinders and only pays for the gas
+	CylinderPurchase CylinderMode = "NEW_CYLINDER" // the customer buys new cylinders with the gas and pays a deposit on each
+)
+
+func IsValidCylinderMode(mode CylinderMode) bool {
+	return mode == CylinderExchange || mode == CylinderPurchase
+}
+
+// IsNewCylinder reports whether the item buys new cylinders, which carry a deposit
+func (c *CartItem) IsNewCylinder() bool {
+	return c.Mode == CylinderPurchase
+}
+
+// SameItem reports whether two cart items hold the same product at the same weight and mode, so they can be combined into one
+func (c *CartItem) SameItem(other CartItem) bool {
+	return c.ProductID == other.ProductID && c.Weight == other.Weight && c.IsNewCylinder() == other.IsNewCylinder()
+}
+
+// CalculateCartItemFee prices the item at the product fee. New cylinder purchases also pay the deposit fee on every cylinder,
+// which is kept on the item's Deposit. The deposit fee is not needed for exchanges and can be nil.
+// Weighed items are priced per kg of a cylinder and rounded once, before being multiplied by the number of cylinders.
+func (c *CartItem) CalculateCartItemFee(fee *Fee, depositFee *Fee) (Money, error) {
+	var totalCost Money
 
 	// Check if the product IDs match
 	if fee.ProductID != c.ProductID {
-		return 0, fmt.Errorf("cart product id: %s does not match fee's product id: %s", c.ProductID, fee.ProductID)
+		return totalCost, fmt.Errorf("cart product id: %s does not match fee's product id: %s", c.ProductID, fee.ProductID)
 	}
 
 	// check for quantity
 	if c.Quantity == 0 {
-		return 0, fmt.Errorf("invalid cart item, cart quantity cannot be zero %d", c.Quantity)
+		return totalCost, fmt.Errorf("invalid cart item, cart quantity cannot be zero %d", c.Quantity)
 	}
 
 	// Calculate cost based on weight or quantity
 	if c.Weight != 0 {
-		totalCost = float64(c.Weight) * fee.Cost.CostPerKG
+		totalCost = fee.Cost.CostPerKG.MulRate(c.Weight)
 	} else {
 		totalCost = fee.Cost.CostPerQt
 	}
 	// Multiply cost by quantity
-	totalCost *= float64(c.Quantity)
+	totalCost = totalCost.Times(c.Quantity)
 
-	if totalCost == 0 {
+	if !totalCost.IsPositive() {
 		return totalCost, fmt.Errorf("invalid cart total cost calculation, cart total cost cannot be zero %d", c.Quantity)
 	}
 
+	c.Deposit = Money{}
+	if c.IsNewCylinder() {
+		if depositFee == nil || depositFee.FeeType != DepositFee || depositFee.ProductID != c.ProductID {
+			return Money{}, fmt.Errorf("no cylinder deposit fee for product id: %s", c.ProductID)
+		}
+
+		// the deposit is charged per cylinder, bigger cylinders can carry a higher deposit through the cost per kg
+		perCylinder := depositFee.Cost.CostPerQt.Add(depositFee.Cost.CostPerKG.MulRate(c.Weight))
+		c.Deposit = perCylinder.Times(c.Quantity)
+		totalCost = totalCost.Add(c.Deposit)
+	}
+
 	return totalCost, nil
 }
 
-func (c *Cart) CalculateCartTotalFee() float64 {
-	var totalCost float64
+func (c *Cart) CalculateCartTotalFee() Money {
+	var totalCost Money
 
 	for _, cartItem := range c.CartItems {
-		totalCost += cartItem.Cost
+		totalCost = totalCost.Add(cartItem.Cost)
 	}
 
 	return totalCost
 }
 
+// VendorCartItems holds the cart items sold by a single vendor
+type VendorCartItems struct {
+	VendorID string
+	Items    []CartItem
+	Total    Money
+}
+
+// Weight is the total weight in kg of the items
+func (v *VendorCartItems) Weight() float64 {
+	var weight float64
+	for _, item := range v.Items {
+		weight += item.Weight * float64(item.Quantity)
+	}
+
+	return weight
+}
+
+// GroupItemsByVendor splits the cart items per vendor, keeping the order in which the vendors first appear in the cart
+func (c *Cart) GroupItemsByVendor() []VendorCartItems {
+	var groups []VendorCartItems
+	index := make(map[string]int)
+
+	for _, cartItem := range c.CartItems {
+		i, ok := index[cartItem.VendorID]
+		if !ok {
+			i = len(groups)
+			index[cartItem.VendorID] = i
+			groups = append(groups, VendorCartItems{VendorID: cartItem.VendorID})
+		}
+
+		groups[i].Items = append(groups[i].Items, cartItem)
+		groups[i].Total = groups[i].Total.Add(cartItem.Cost)
+	}
+
+	return groups
+}
+
 type CartStatuses string
 
 const (
 	CartActive     CartStatuses = "ACTIVE"      // cart has been created and active
 	CartCheckedOut CartStatuses = "CHECKED_OUT" // cart has been checkout out
+	CartMerged     CartStatuses = "MERGED"      // guest cart has been merged into a customer cart
 )
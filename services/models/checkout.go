@@ -0,0 +1,15 @@
+package models
+
+// Checkout is the parent record of a cart checkout. A cart holding items from several vendors
+// is split into one child order per vendor, all referencing the same checkout.
+type Checkout struct {
+	ID              string        `json:"id" bson:"id"`
+	CartID          string        `json:"cart_id" bson:"cart_id"`
+	CustomerID      string        `json:"customer_id" bson:"customer_id"`
+	OrderIDs        []string      `json:"order_ids" bson:"order_ids"`
+	DeliveryDetails ShippingInfo  `json:"delivery_details" bson:"delivery_details"`
+	PaymentMethod   PaymentMethod `json:"payment_method" bson:"payment_method"`
+	PaymentID       string        `json:"payment_id" bson:"payment_id"`
+	Total           Money         `json:"total" bson:"total"`
+	Ts              int64         `json:"ts" bson:"ts"`
+} // @name Checkout
@@ -7,9 +7,29 @@ const (
 	BusinessCollectionName      = "businesses"
 	ProductCollectionName       = "products"
 	OrderCollectionName         = "orders"
+	CheckoutsCollectionName     = "checkouts"
+	PaymentsCollectionName      = "payments"
 	UsersCollectionName         = "users"
 	CartsCollectionName         = "carts"
 	FeesCollectionName          = "fees"
 	GuestsCollectionName        = "guests"
 	NGNStatesCollectionName     = "ngn-states"
+	IdempotencyCollectionName   = "idempotency_keys"
+	SubscriptionsCollectionName = "subscriptions"
+	RefundsCollectionName       = "refunds"
+	InvoicesCollectionName      = "invoices"
+	CountersCollectionName      = "counters"
+	ExportJobsCollectionName    = "export_jobs"
+	SlotsCollectionName         = "delivery_slots"
+	ReviewsCollectionName       = "reviews"
+	TicketsCollectionName       = "tickets"
+	PromotionsCollectionName    = "promotions"
+	// PromotionRedemptionsCollectionName holds the checkouts promotions were redeemed on
+	PromotionRedemptionsCollectionName = "promotion_redemptions"
+	// MigrationsCollectionName records the data migrations that ran on the database
+	MigrationsCollectionName = "migrations"
+	// ExportFilesBucketName is the GridFS bucket the files of export jobs are stored in
+	ExportFilesBucketName = "exports"
+	// DeliveryPhotosBucketName is the GridFS bucket the photos taken on delivery are stored in
+	DeliveryPhotosBucketName = "delivery_photos"
 )
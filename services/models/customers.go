@@ -54,9 +54,19 @@ type TimeStamps struct {
 type Vendor struct {
 	User
 	AdminID string `json:"admin_id" bson:"admin_id"`
+	// Rating aggregates the vendor ratings of the visible reviews of the vendor's orders
+	Rating *RatingSummary `json:"rating,omitempty" bson:"rating,omitempty"`
 	TimeStamps
 } // @name Vendor
 
+// Rider delivers orders to customers once a vendor or an admin assigns them
+type Rider struct {
+	User
+	// DeliveryRating aggregates the delivery ratings of the visible reviews of the orders the rider delivered
+	DeliveryRating *RatingSummary `json:"delivery_rating,omitempty" bson:"delivery_rating,omitempty"`
+	TimeStamps
+} // @name Rider
+
 type Admin struct {
 	User
 	Department string `json:"department"`
@@ -118,6 +128,18 @@ type Business struct {
 	Timestamp       int64            `json:"ts" bson:"ts"`
 } // @name Business
 
+// Location returns the coordinates of the first business address that has them, deliveries of the vendor leave from there.
+// It is zero when no address has coordinates.
+func (b *Business) Location() Coordinates {
+	for _, address := range b.Address {
+		if address.Coordinates != (Coordinates{}) {
+			return address.Coordinates
+		}
+	}
+
+	return Coordinates{}
+}
+
 /*
 **constants/enums
  */
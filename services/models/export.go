@@ -0,0 +1,87 @@
+package models
+
+import (
+	"errors"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query/filter"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query/sorting"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+)
+
+// ExportJob exports a dataset to a file in the background, for exports too long to stream in a single request
+type ExportJob struct {
+	ID          string           `json:"id" bson:"id"`
+	Dataset     ExportDataset    `json:"dataset" bson:"dataset"`
+	Format      ExportFormat     `json:"format" bson:"format"`
+	Filter      *filter.Request  `json:"filter,omitempty" bson:"filter,omitempty"`
+	Sorting     *sorting.Request `json:"sorting,omitempty" bson:"sorting,omitempty"`
+	RequestedBy string           `json:"requested_by" bson:"requested_by"`
+	Status      ExportJobStatus  `json:"status" bson:"status"`
+	Filename    string           `json:"filename" bson:"filename"`
+	RowCount    int64            `json:"row_count" bson:"row_count"`
+	Error       string           `json:"error,omitempty" bson:"error,omitempty"`
+	// DownloadURL is set once the job completed
+	DownloadURL string `json:"download_url,omitempty" bson:"-"`
+	Attempts    int    `json:"-" bson:"attempts"`
+	// LockedUntil keeps other workers from running the job while it runs
+	LockedUntil int64 `json:"-" bson:"locked_until"`
+	StatusTs    int64 `json:"status_ts" bson:"status_ts"`
+	Ts          int64 `json:"ts" bson:"ts"`
+} // @name ExportJob
+
+type ExportJobStatus string
+
+const (
+	ExportJobPending   ExportJobStatus = "PENDING"   // the job waits for a worker
+	ExportJobRunning   ExportJobStatus = "RUNNING"   // a worker is writing the file
+	ExportJobCompleted ExportJobStatus = "COMPLETED" // the file can be downloaded
+	ExportJobFailed    ExportJobStatus = "FAILED"    // the file could not be written
+)
+
+type ExportDataset string
+
+const (
+	ExportOrders    ExportDataset = "orders"
+	ExportCustomers ExportDataset = "customers"
+	ExportFees      ExportDataset = "fees"
+)
+
+func IsValidExportDataset(dataset ExportDataset) bool {
+	return dataset == ExportOrders || dataset == ExportCustomers || dataset == ExportFees
+}
+
+func SetExportDataset(dataset ExportDataset) (ExportDataset, error) {
+	switch IsValidExportDataset(dataset) {
+	case true:
+		return dataset, nil
+	default:
+		return "", errs.Body(errs.InvalidRequestError, errors.New("invalid export dataset, expected one of orders, customers or fees"))
+	}
+}
+
+type ExportFormat string
+
+const (
+	ExportCSV    ExportFormat = "csv"
+	ExportNDJSON ExportFormat = "ndjson"
+)
+
+func IsValidExportFormat(format ExportFormat) bool {
+	return format == ExportCSV || format == ExportNDJSON
+}
+
+func SetExportFormat(format ExportFormat) (ExportFormat, error) {
+	switch IsValidExportFormat(format) {
+	case true:
+		return format, nil
+	default:
+		return "", errs.Body(errs.InvalidRequestError, errors.New("invalid export format, expected csv or ndjson"))
+	}
+}
+
+// ContentType is the media type files of the format are served as
+func (format ExportFormat) ContentType() string {
+	if format == ExportNDJSON {
+		return "application/x-ndjson"
+	}
+	return "text/csv"
+}
@@ -1,5 +1,12 @@
 package models
 
+import (
+	"errors"
+	"fmt"
+)
+
+// Fee is charged from its effective time until the effective time of the next fee of the same type, product and lga.
+// The fees of a type, product and lga make up its price history, with at most one of them in force at a time.
 type Fee struct {
 	ID        string       `json:"id" bson:"id"`
 	ProductID string       `json:"product_id" bson:"product_id"`
@@ -7,15 +14,58 @@ type Fee struct {
 	LGA       LGA          `json:"lga" bson:"lga"`
 	Cost      Cost         `json:"cost" bson:"cost"`
 	Status    FeesStatuses `json:"status" bson:"status"`
-	StatusTs  int64        `json:"status_ts" bson:"status_ts"`
-	Ts        int64        `json:"ts" bson:"ts"`
+	// Bands price the deliveries of a delivery fee by distance and weight, the cost per type is charged where they cannot be
+	Bands *DeliveryBands `json:"bands,omitempty" bson:"bands,omitempty"`
+	// EffectiveFrom is the unix time the fee is charged from
+	EffectiveFrom int64 `json:"effective_from" bson:"effective_from"`
+	// EffectiveTo is the unix time the next fee replaces it, the fee is charged until further notice when it is zero
+	EffectiveTo int64 `json:"effective_to,omitempty" bson:"effective_to,omitempty"`
+	StatusTs    int64 `json:"status_ts" bson:"status_ts"`
+	Ts          int64 `json:"ts" bson:"ts"`
 } // @name Fee
 
+// InForce reports whether the fee is charged at the unix time
+func (f *Fee) InForce(ts int64) bool {
+	return f.Status != FeesCancelled && f.EffectiveFrom <= ts && (f.EffectiveTo == 0 || ts < f.EffectiveTo)
+}
+
+// CheckCurrency checks the costs of the fee can be charged in the currency. Amounts of different currencies cannot be
+// combined, so a fee stored in another currency is refused where it is read rather than priced into a cart.
+func (f *Fee) CheckCurrency(currency string) error {
+	_, err := f.Cost.InCurrency(currency)
+	if err == nil && f.Bands != nil {
+		_, err = f.Bands.InCurrency(currency)
+	}
+	if err != nil {
+		return fmt.Errorf("fee %s cannot be charged: %w", f.ID, err)
+	}
+
+	return nil
+}
+
+// StatusAt is the status of the fee at the unix time. Fees are stored active for their whole timeline, so an active fee is
+// scheduled before its effective window and inactive after it.
+func (f *Fee) StatusAt(ts int64) FeesStatuses {
+	switch {
+	case f.Status != FeesActive:
+		return f.Status
+	case ts < f.EffectiveFrom:
+		return FeesScheduled
+	case f.EffectiveTo != 0 && ts >= f.EffectiveTo:
+		return FeesInactive
+	}
+	return FeesActive
+}
+
 type FeesStatuses string
 
 const (
-	FeesActive   FeesStatuses = "ACTIVE"   // fees has been created and active
-	FeesInactive FeesStatuses = "INACTIVE" // fees has been inactivated
+	FeesActive   FeesStatuses = "ACTIVE"   // fees is in force, it is charged until its effective window closes
+	FeesInactive FeesStatuses = "INACTIVE" // fees has been replaced, its effective window closed
+	// FeesScheduled fees take effect later, they are listed with this status but stored as active
+	FeesScheduled FeesStatuses = "SCHEDULED"
+	// FeesCancelled fees were scheduled and cancelled before they took effect, they are never charged
+	FeesCancelled FeesStatuses = "CANCELLED"
 )
 
 type FeeType string
@@ -24,15 +74,113 @@ const (
 	ServiceFee  FeeType = "SERVICE_FEE"
 	ProductFee  FeeType = "PRODUCT_FEE"
 	DeliveryFee FeeType = "DELIVERY_FEE"
+	// CancellationFee is kept from the refund of an order the customer cancels after the vendor approved it
+	CancellationFee FeeType = "CANCELLATION_FEE"
+	// DepositFee is charged per cylinder bought with a new cylinder purchase, and refunded when the cylinder is returned
+	DepositFee FeeType = "DEPOSIT_FEE"
 )
 
 type Cost struct {
-	CostPerKG   float64 `json:"cost_per_kg" bson:"cost_per_kg"`
-	CostPerQt   float64 `json:"cost_per_qty" bson:"cost_per_qty"`
-	CostPerType float64 `json:"cost_per_type" bson:"cost_per_type"`
+	CostPerKG   Money `json:"cost_per_kg" bson:"cost_per_kg"`
+	CostPerQt   Money `json:"cost_per_qty" bson:"cost_per_qty"`
+	CostPerType Money `json:"cost_per_type" bson:"cost_per_type"`
+}
+
+// InCurrency sets the currency of costs given without one. Fees are charged in a single currency, so costs in another fail.
+func (c Cost) InCurrency(currency string) (Cost, error) {
+	var err error
+	if c.CostPerKG, err = c.CostPerKG.In(currency); err != nil {
+		return c, err
+	}
+	if c.CostPerQt, err = c.CostPerQt.In(currency); err != nil {
+		return c, err
+	}
+	if c.CostPerType, err = c.CostPerType.In(currency); err != nil {
+		return c, err
+	}
+
+	return c, nil
 }
 
 type LGA struct {
 	State string `json:"state" bson:"state"`
 	LGA   string `json:"lga" bson:"lga"`
 }
+
+// DeliveryBands price a delivery by the distance from the vendor to the customer and the weight delivered. The cost of
+// the distance band is added to the cost of the weight band. A distance or weight beyond the last band is charged the
+// last band.
+type DeliveryBands struct {
+	// Distance bands are in km, ordered by their upper limit
+	Distance []DeliveryBand `json:"distance" bson:"distance"`
+	// Weight bands are in kg, ordered by their upper limit. Deliveries are only priced by distance without them.
+	Weight []DeliveryBand `json:"weight,omitempty" bson:"weight,omitempty"`
+} // @name DeliveryBands
+
+// DeliveryBand charges its cost for a distance or weight up to and including UpTo
+type DeliveryBand struct {
+	UpTo float64 `json:"up_to" bson:"up_to"`
+	Cost Money   `json:"cost" bson:"cost"`
+} // @name DeliveryBand
+
+// Validate checks there is a distance band, and the bands are ordered and not negative
+func (b *DeliveryBands) Validate() error {
+	if len(b.Distance) == 0 {
+		return errors.New("at least one distance band is required")
+	}
+
+	for name, bands := range map[string][]DeliveryBand{"distance": b.Distance, "weight": b.Weight} {
+		for i, band := range bands {
+			if band.UpTo <= 0 || band.Cost.IsNegative() {
+				return fmt.Errorf("%s band %d must have an upper limit above 0 and a cost of at least 0", name, i+1)
+			}
+			if i > 0 && band.UpTo <= bands[i-1].UpTo {
+				return fmt.Errorf("%s bands must be ordered by their upper limit", name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// InCurrency sets the currency of band costs given without one, like Cost.InCurrency
+func (b *DeliveryBands) InCurrency(currency string) (*DeliveryBands, error) {
+	converted := &DeliveryBands{
+		Distance: make([]DeliveryBand, len(b.Distance)),
+		Weight:   make([]DeliveryBand, len(b.Weight)),
+	}
+
+	var err error
+	for i, band := range b.Distance {
+		converted.Distance[i] = band
+		if converted.Distance[i].Cost, err = band.Cost.In(currency); err != nil {
+			return nil, err
+		}
+	}
+	for i, band := range b.Weight {
+		converted.Weight[i] = band
+		if converted.Weight[i].Cost, err = band.Cost.In(currency); err != nil {
+			return nil, err
+		}
+	}
+
+	return converted, nil
+}
+
+// Cost prices a delivery of the weight in kg over the distance in km
+func (b *DeliveryBands) Cost(distanceKm, weightKg float64) Money {
+	return bandCost(b.Distance, distanceKm).Add(bandCost(b.Weight, weightKg))
+}
+
+func bandCost(bands []DeliveryBand, value float64) Money {
+	if len(bands) == 0 {
+		return Money{}
+	}
+
+	for _, band := range bands {
+		if value <= band.UpTo {
+			return band.Cost
+		}
+	}
+	return bands[len(bands)-1].Cost
+}
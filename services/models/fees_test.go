@@ -0,0 +1,178 @@
+package models
+
+import (
+	"testing"
+)
+
+func testBands() *DeliveryBands {
+	return &DeliveryBands{
+		Distance: []DeliveryBand{
+			{UpTo: 5, Cost: NewMoney(100000, "NGN")},
+			{UpTo: 10, Cost: NewMoney(150000, "NGN")},
+			{UpTo: 20, Cost: NewMoney(250000, "NGN")},
+		},
+		Weight: []DeliveryBand{
+			{UpTo: 12.5, Cost: NewMoney(0, "NGN")},
+			{UpTo: 50, Cost: NewMoney(50000, "NGN")},
+		},
+	}
+}
+
+func TestDeliveryBandsCost(t *testing.T) {
+	tests := []struct {
+		name       string
+		distanceKm float64
+		weightKg   float64
+		want       int64
+	}{
+		{name: "first bands", distanceKm: 2, weightKg: 6, want: 100000},
+		{name: "upper limit is in the band", distanceKm: 5, weightKg: 12.5, want: 100000},
+		{name: "just above the upper limit is in the next band", distanceKm: 5.01, weightKg: 12.6, want: 200000},
+		{name: "zero distance and weight are in the first bands", distanceKm: 0, weightKg: 0, want: 100000},
+		{name: "beyond the last bands is charged the last bands", distanceKm: 80, weightKg: 200, want: 300000},
+	}
+
+	bands := testBands()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := bands.Cost(tt.distanceKm, tt.weightKg)
+			if got.Amount != tt.want || got.Currency != "NGN" {
+				t.Errorf("Cost(%v, %v) = %v, want %d NGN", tt.distanceKm, tt.weightKg, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDeliveryBandsCostWithoutWeightBands(t *testing.T) {
+	bands := testBands()
+	bands.Weight = nil
+
+	got := bands.Cost(12, 500)
+	if got.Amount != 250000 || got.Currency != "NGN" {
+		t.Errorf("Cost(12, 500) = %v, want 250000 NGN", got)
+	}
+}
+
+func TestDeliveryBandsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		bands   func(*DeliveryBands)
+		wantErr bool
+	}{
+		{name: "valid", bands: func(*DeliveryBands) {}},
+		{name: "without weight bands", bands: func(b *DeliveryBands) { b.Weight = nil }},
+		{name: "free band", bands: func(b *DeliveryBands) { b.Distance[0].Cost = Money{} }},
+		{name: "without distance bands", bands: func(b *DeliveryBands) { b.Distance = nil }, wantErr: true},
+		{name: "zero upper limit", bands: func(b *DeliveryBands) { b.Distance[0].UpTo = 0 }, wantErr: true},
+		{name: "negative cost", bands: func(b *DeliveryBands) { b.Weight[1].Cost = NewMoney(-1, "NGN") }, wantErr: true},
+		{name: "unordered distance bands", bands: func(b *DeliveryBands) { b.Distance[2].UpTo = 8 }, wantErr: true},
+		{name: "repeated weight upper limit", bands: func(b *DeliveryBands) { b.Weight[1].UpTo = 12.5 }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bands := testBands()
+			tt.bands(bands)
+
+			err := bands.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDeliveryBandsInCurrency(t *testing.T) {
+	bands := &DeliveryBands{
+		Distance: []DeliveryBand{{UpTo: 5, Cost: Money{Amount: 100000}}},
+		Weight:   []DeliveryBand{{UpTo: 50, Cost: Money{Amount: 50000}}},
+	}
+
+	converted, err := bands.InCurrency("NGN")
+	if err != nil {
+		t.Fatalf("InCurrency() error = %v", err)
+	}
+	if converted.Distance[0].Cost.Currency != "NGN" || converted.Weight[0].Cost.Currency != "NGN" {
+		t.Errorf("InCurrency() = %+v, want band costs in NGN", converted)
+	}
+	if bands.Distance[0].Cost.Currency != "" {
+		t.Errorf("InCurrency() changed the bands it converted")
+	}
+
+	_, err = testBands().InCurrency("USD")
+	if err == nil {
+		t.Errorf("InCurrency() of NGN bands to USD returned no error")
+	}
+}
+
+func TestFeeInForce(t *testing.T) {
+	tests := []struct {
+		name string
+		fee  Fee
+		ts   int64
+		want bool
+	}{
+		{name: "before the effective window", fee: Fee{Status: FeesActive, EffectiveFrom: 100, EffectiveTo: 200}, ts: 99, want: false},
+		{name: "from the start of the effective window", fee: Fee{Status: FeesActive, EffectiveFrom: 100, EffectiveTo: 200}, ts: 100, want: true},
+		{name: "until the end of the effective window", fee: Fee{Status: FeesActive, EffectiveFrom: 100, EffectiveTo: 200}, ts: 199, want: true},
+		{name: "not when replaced", fee: Fee{Status: FeesActive, EffectiveFrom: 100, EffectiveTo: 200}, ts: 200, want: false},
+		{name: "until further notice", fee: Fee{Status: FeesActive, EffectiveFrom: 100}, ts: 1 << 40, want: true},
+		{name: "not when cancelled", fee: Fee{Status: FeesCancelled, EffectiveFrom: 100}, ts: 150, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.fee.InForce(tt.ts); got != tt.want {
+				t.Errorf("InForce(%d) = %v, want %v", tt.ts, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFeeStatusAt(t *testing.T) {
+	tests := []struct {
+		name string
+		fee  Fee
+		ts   int64
+		want FeesStatuses
+	}{
+		{name: "scheduled before the effective window", fee: Fee{Status: FeesActive, EffectiveFrom: 100, EffectiveTo: 200}, ts: 99, want: FeesScheduled},
+		{name: "active from the start of the effective window", fee: Fee{Status: FeesActive, EffectiveFrom: 100, EffectiveTo: 200}, ts: 100, want: FeesActive},
+		{name: "inactive once replaced", fee: Fee{Status: FeesActive, EffectiveFrom: 100, EffectiveTo: 200}, ts: 200, want: FeesInactive},
+		{name: "active until further notice", fee: Fee{Status: FeesActive, EffectiveFrom: 100}, ts: 1 << 40, want: FeesActive},
+		{name: "cancelled stays cancelled", fee: Fee{Status: FeesCancelled, EffectiveFrom: 100}, ts: 150, want: FeesCancelled},
+		{name: "stored inactive stays inactive", fee: Fee{Status: FeesInactive, EffectiveFrom: 100}, ts: 150, want: FeesInactive},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.fee.StatusAt(tt.ts); got != tt.want {
+				t.Errorf("StatusAt(%d) = %v, want %v", tt.ts, got, tt.want)
+			}
+		})
+	}
+}
+
+// the fee in force and the fee listed as active must agree at every time
+func TestFeeStatusAtAgreesWithInForce(t *testing.T) {
+	fees := []Fee{
+		{Status: FeesActive, EffectiveFrom: 100, EffectiveTo: 200},
+		{Status: FeesActive, EffectiveFrom: 200},
+		{Status: FeesCancelled, EffectiveFrom: 150},
+	}
+
+	for _, ts := range []int64{0, 99, 100, 150, 199, 200, 1 << 40} {
+		inForce := 0
+		for _, fee := range fees {
+			if fee.InForce(ts) != (fee.StatusAt(ts) == FeesActive) {
+				t.Errorf("fee %+v at %d: InForce = %v, StatusAt = %v", fee, ts, fee.InForce(ts), fee.StatusAt(ts))
+			}
+			if fee.InForce(ts) {
+				inForce++
+			}
+		}
+		if ts >= 100 && inForce != 1 {
+			t.Errorf("%d fees in force at %d, want 1", inForce, ts)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package models
+
+import "fmt"
+
+// Invoice is the receipt of an order. Invoices are numbered sequentially in the order they are first issued.
+// Order amounts include VAT, which the invoice shows separately.
+type Invoice struct {
+	ID          string        `json:"id" bson:"id"`
+	Number      string        `json:"number" bson:"number"`
+	Sequence    int64         `json:"sequence" bson:"sequence"`
+	OrderID     string        `json:"order_id" bson:"order_id"`
+	CheckoutID  string        `json:"checkout_id" bson:"checkout_id"`
+	CustomerID  string        `json:"customer_id" bson:"customer_id"`
+	VendorID    string        `json:"vendor_id" bson:"vendor_id"`
+	VendorName  string        `json:"vendor_name,omitempty" bson:"vendor_name"`
+	BillTo      ShippingInfo  `json:"bill_to" bson:"bill_to"`
+	Lines       []InvoiceLine `json:"lines" bson:"lines"`
+	ItemsTotal  Money         `json:"items_total" bson:"items_total"`
+	DeliveryFee Money         `json:"delivery_fee" bson:"delivery_fee"`
+	ServiceFee  Money         `json:"service_fee" bson:"service_fee"`
+	// Discount is taken off by the promotion applied to the order
+	Discount Money `json:"discount,omitempty" bson:"discount,omitempty"`
+	// Subtotal is the total before VAT
+	Subtotal Money   `json:"subtotal" bson:"subtotal"`
+	VATRate  float64 `json:"vat_rate" bson:"vat_rate"`
+	VAT      Money   `json:"vat" bson:"vat"`
+	Total    Money   `json:"total" bson:"total"`
+	OrderTs  int64   `json:"order_ts" bson:"order_ts"`
+	Ts       int64   `json:"ts" bson:"ts"`
+} // @name Invoice
+
+type InvoiceLine struct {
+	Description string  `json:"description" bson:"description"`
+	Weight      float64 `json:"weight,omitempty" bson:"weight"`
+	Quantity    int     `json:"quantity" bson:"quantity"`
+	// UnitPrice is the price per kg of weighed items, and per item otherwise
+	UnitPrice Money `json:"unit_price" bson:"unit_price"`
+	Amount    Money `json:"amount" bson:"amount"`
+} // @name InvoiceLine
+
+// InvoiceNumber formats the sequence of an invoice as its number
+func InvoiceNumber(sequence int64) string {
+	return fmt.Sprintf("INV-%06d", sequence)
+}
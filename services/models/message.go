@@ -12,5 +12,13 @@ type Message struct {
 	Body          string            `json:"body" bson:"body"`
 	TemplateID    string            `json:"template_id" bson:"template_id"`
 	DataMap       map[string]string `json:"data_map" bson:"data_map"`
+	Attachments   []Attachment      `json:"-" bson:"-"`
 	Ts            int64             `json:"ts" bson:"ts"`
 }
+
+// Attachment is a file sent along with an email
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
@@ -0,0 +1,177 @@
+package models
+
+import (
+	"fmt"
+	"math"
+)
+
+// minorUnits is the number of minor units in a major unit, kobo in a naira. Every currency the platform charges in has 100.
+const minorUnits = 100
+
+// Money is an amount of a currency counted in its minor unit, kobo for naira. Amounts are whole numbers of the minor unit,
+// so adding and subtracting them is exact and amounts only round where a rule below says so:
+//   - amounts given in major units, such as documents written before amounts were kept in minor units, are rounded
+//     half away from zero to the minor unit
+//   - an amount multiplied by a rate, such as a price per kg by the weight of a cylinder, is rounded half away from zero
+//     before it is multiplied by a quantity or added to a total, which never round
+//   - a percentage of an amount, such as a percentage discount, is rounded down so it never exceeds the percentage
+//   - an amount split into parts is shared by largest remainder, so the parts always add up to the amount
+//
+// Amounts of different currencies are never added together, fees are set in the payment currency.
+type Money struct {
+	// Amount is counted in the minor unit of the currency
+	Amount   int64  `json:"amount" bson:"amount"`
+	Currency string `json:"currency" bson:"currency"`
+} // @name Money
+
+// NewMoney returns the amount of minor units of the currency
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// MoneyFromMajor converts an amount given in major units, rounding it half away from zero to the minor unit
+func MoneyFromMajor(amount float64, currency string) Money {
+	return Money{Amount: int64(math.Round(amount * minorUnits)), Currency: currency}
+}
+
+// In sets the currency of an amount given without one. It fails when the amount is in another currency.
+func (m Money) In(currency string) (Money, error) {
+	if m.Currency != "" && m.Currency != currency {
+		return m, fmt.Errorf("amount is in %s, expected %s", m.Currency, currency)
+	}
+
+	return Money{Amount: m.Amount, Currency: currency}, nil
+}
+
+// Major returns the amount in major units, for display and for providers charging in major units
+func (m Money) Major() float64 {
+	return float64(m.Amount) / minorUnits
+}
+
+// IsZero reports whether the amount is zero. It lets omitempty leave zero amounts out of documents.
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Add returns the sum of the amounts. A zero value takes the currency of the amount added to it.
+// It panics when the amounts are in different currencies.
+func (m Money) Add(other Money) Money {
+	return Money{Amount: m.Amount + other.Amount, Currency: m.currencyOr(other)}
+}
+
+// Sub returns the amount less the other amount. It panics when the amounts are in different currencies.
+func (m Money) Sub(other Money) Money {
+	return Money{Amount: m.Amount - other.Amount, Currency: m.currencyOr(other)}
+}
+
+// Times multiplies the amount by a whole quantity, which never rounds
+func (m Money) Times(quantity int) Money {
+	return Money{Amount: m.Amount * int64(quantity), Currency: m.Currency}
+}
+
+// MulRate multiplies the amount by a rate such as a weight, rounding half away from zero to the minor unit
+func (m Money) MulRate(rate float64) Money {
+	return Money{Amount: int64(math.Round(float64(m.Amount) * rate)), Currency: m.Currency}
+}
+
+// Percent returns the percentage of the amount, rounded down to the minor unit
+func (m Money) Percent(percentage float64) Money {
+	return Money{Amount: int64(math.Floor(float64(m.Amount) * percentage / 100)), Currency: m.Currency}
+}
+
+// Min returns the smaller of the amounts. It panics when the amounts are in different currencies.
+func (m Money) Min(other Money) Money {
+	currency := m.currencyOr(other)
+	if other.Amount < m.Amount {
+		return Money{Amount: other.Amount, Currency: currency}
+	}
+	return Money{Amount: m.Amount, Currency: currency}
+}
+
+// Allocate splits the amount in proportion to the weights by largest remainder, so the parts add up to the amount.
+// Every part is zero when the weights add up to zero. Weights must not be negative.
+func (m Money) Allocate(weights []int64) []Money {
+	if m.Amount < 0 {
+		parts := Money{Amount: -m.Amount, Currency: m.Currency}.Allocate(weights)
+		for i := range parts {
+			parts[i].Amount = -parts[i].Amount
+		}
+		return parts
+	}
+
+	parts := make([]Money, len(weights))
+	var totalWeight int64
+	for i, weight := range weights {
+		parts[i].Currency = m.Currency
+		totalWeight += weight
+	}
+	if totalWeight == 0 {
+		return parts
+	}
+
+	remainders := make([]int64, len(weights))
+	allocated := int64(0)
+	for i, weight := range weights {
+		parts[i].Amount = m.Amount * weight / totalWeight
+		remainders[i] = m.Amount * weight % totalWeight
+		allocated += parts[i].Amount
+	}
+
+	// the minor units left over go one by one to the parts with the largest remainders, the first part winning ties
+	for left := m.Amount - allocated; left > 0; left-- {
+		largest := 0
+		for i := range remainders {
+			if remainders[i] > remainders[largest] {
+				largest = i
+			}
+		}
+		parts[largest].Amount++
+		remainders[largest] = -1
+	}
+
+	return parts
+}
+
+// String formats the amount in major units with its currency, such as NGN 1,500.00
+func (m Money) String() string {
+	return m.Currency + " " + m.Digits()
+}
+
+// Digits formats the amount in major units with two decimals and thousands separators, without its currency
+func (m Money) Digits() string {
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	major := fmt.Sprintf("%d", amount/minorUnits)
+	for i := len(major) - 3; i > 0; i -= 3 {
+		major = major[:i] + "," + major[i:]
+	}
+
+	return fmt.Sprintf("%s%s.%02d", sign, major, amount%minorUnits)
+}
+
+// currencyOr returns the currency of the amounts. An amount without a currency, such as the zero value a total starts
+// from, takes the currency of the other. Amounts are put in the payment currency with In where they enter the platform,
+// and fees are checked to be in it where they are read, so amounts of different currencies meeting is a bug. It panics
+// rather than return an amount in the wrong currency, the router recovers the request it happens in.
+func (m Money) currencyOr(other Money) string {
+	if m.Currency == "" {
+		return other.Currency
+	}
+	if other.Currency != "" && other.Currency != m.Currency {
+		panic(fmt.Sprintf("cannot combine amounts in %s and %s", m.Currency, other.Currency))
+	}
+	return m.Currency
+}
@@ -0,0 +1,224 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestMoneyFromMajor(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount float64
+		want   int64
+	}{
+		{name: "whole", amount: 1500, want: 150000},
+		{name: "minor units", amount: 12.34, want: 1234},
+		{name: "half unit rounds up", amount: 0.125, want: 13},
+		{name: "negative half unit rounds away from zero", amount: -0.125, want: -13},
+		{name: "below half unit rounds down", amount: 0.124, want: 12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MoneyFromMajor(tt.amount, "NGN")
+			if got.Amount != tt.want || got.Currency != "NGN" {
+				t.Errorf("MoneyFromMajor(%v) = %v, want %d NGN", tt.amount, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyMulRate(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount int64
+		rate   float64
+		want   int64
+	}{
+		{name: "whole rate", amount: 1000, rate: 3, want: 3000},
+		{name: "exact fraction", amount: 1000, rate: 12.5, want: 12500},
+		{name: "half unit rounds up", amount: 5, rate: 0.5, want: 3},
+		{name: "below half unit rounds down", amount: 7, rate: 0.3, want: 2},
+		{name: "above half unit rounds up", amount: 7, rate: 0.5, want: 4},
+		{name: "negative half unit rounds away from zero", amount: -5, rate: 0.5, want: -3},
+		{name: "zero rate", amount: 1000, rate: 0, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewMoney(tt.amount, "NGN").MulRate(tt.rate)
+			if got.Amount != tt.want || got.Currency != "NGN" {
+				t.Errorf("MulRate(%d, %v) = %v, want %d NGN", tt.amount, tt.rate, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyPercent(t *testing.T) {
+	tests := []struct {
+		name       string
+		amount     int64
+		percentage float64
+		want       int64
+	}{
+		{name: "exact", amount: 10000, percentage: 10, want: 1000},
+		{name: "half unit rounds down", amount: 5, percentage: 50, want: 2},
+		{name: "above half unit rounds down", amount: 999, percentage: 10, want: 99},
+		{name: "fractional percentage", amount: 10000, percentage: 7.5, want: 750},
+		{name: "whole amount", amount: 1234, percentage: 100, want: 1234},
+		{name: "zero percentage", amount: 1234, percentage: 0, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewMoney(tt.amount, "NGN").Percent(tt.percentage)
+			if got.Amount != tt.want || got.Currency != "NGN" {
+				t.Errorf("Percent(%d, %v) = %v, want %d NGN", tt.amount, tt.percentage, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyAllocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  int64
+		weights []int64
+		want    []int64
+	}{
+		{name: "even split", amount: 300, weights: []int64{1, 1, 1}, want: []int64{100, 100, 100}},
+		{name: "leftover to the first part on ties", amount: 100, weights: []int64{1, 1, 1}, want: []int64{34, 33, 33}},
+		{name: "leftover to the largest remainder", amount: 100, weights: []int64{1, 2}, want: []int64{33, 67}},
+		{name: "proportional", amount: 1000, weights: []int64{250, 750}, want: []int64{250, 750}},
+		{name: "half units", amount: 5, weights: []int64{1, 1}, want: []int64{3, 2}},
+		{name: "zero weight gets nothing", amount: 101, weights: []int64{0, 3, 1}, want: []int64{0, 76, 25}},
+		{name: "zero total weight", amount: 100, weights: []int64{0, 0}, want: []int64{0, 0}},
+		{name: "single part", amount: 12345, weights: []int64{7}, want: []int64{12345}},
+		{name: "negative amount", amount: -100, weights: []int64{1, 1, 1}, want: []int64{-34, -33, -33}},
+		{name: "zero amount", amount: 0, weights: []int64{1, 2}, want: []int64{0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts := NewMoney(tt.amount, "NGN").Allocate(tt.weights)
+			if len(parts) != len(tt.want) {
+				t.Fatalf("Allocate(%d, %v) returned %d parts, want %d", tt.amount, tt.weights, len(parts), len(tt.want))
+			}
+
+			var sum int64
+			for i, part := range parts {
+				if part.Amount != tt.want[i] || part.Currency != "NGN" {
+					t.Errorf("part %d = %v, want %d NGN", i, part, tt.want[i])
+				}
+				sum += part.Amount
+			}
+
+			totalWeight := int64(0)
+			for _, weight := range tt.weights {
+				totalWeight += weight
+			}
+			if totalWeight != 0 && sum != tt.amount {
+				t.Errorf("parts add up to %d, want %d", sum, tt.amount)
+			}
+		})
+	}
+}
+
+func TestMoneyAllocateAddsUp(t *testing.T) {
+	weights := [][]int64{{1, 1, 1}, {3, 7}, {1, 2, 3, 4, 5, 6}, {999, 1}, {13, 17, 19}}
+	for amount := int64(-250); amount <= 250; amount++ {
+		for _, w := range weights {
+			var sum int64
+			for _, part := range NewMoney(amount, "NGN").Allocate(w) {
+				sum += part.Amount
+			}
+			if sum != amount {
+				t.Fatalf("Allocate(%d, %v) parts add up to %d", amount, w, sum)
+			}
+		}
+	}
+}
+
+func TestMoneyIn(t *testing.T) {
+	tests := []struct {
+		name    string
+		money   Money
+		want    Money
+		wantErr bool
+	}{
+		{name: "without currency", money: Money{Amount: 150}, want: NewMoney(150, "NGN")},
+		{name: "same currency", money: NewMoney(150, "NGN"), want: NewMoney(150, "NGN")},
+		{name: "other currency", money: NewMoney(150, "USD"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.money.In("NGN")
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("In(NGN) of %v succeeded, want an error", tt.money)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("In(NGN) of %v failed: %v", tt.money, err)
+			}
+			if got != tt.want {
+				t.Errorf("In(NGN) of %v = %v, want %v", tt.money, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyAddSub(t *testing.T) {
+	var total Money
+	total = total.Add(NewMoney(1050, "NGN"))
+	if total != NewMoney(1050, "NGN") {
+		t.Errorf("zero value plus 1050 NGN = %v", total)
+	}
+
+	total = total.Add(NewMoney(25, "NGN")).Sub(NewMoney(75, "NGN"))
+	if total != NewMoney(1000, "NGN") {
+		t.Errorf("1050 + 25 - 75 NGN = %v, want 1000 NGN", total)
+	}
+
+	if got := total.Sub(Money{Amount: 1}); got != NewMoney(999, "NGN") {
+		t.Errorf("1000 NGN less 1 without currency = %v", got)
+	}
+}
+
+func TestMoneyMismatchedCurrenciesPanic(t *testing.T) {
+	operations := map[string]func(a, b Money) Money{
+		"Add": Money.Add,
+		"Sub": Money.Sub,
+		"Min": Money.Min,
+	}
+
+	for name, operation := range operations {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s of NGN and USD did not panic", name)
+				}
+			}()
+			operation(NewMoney(100, "NGN"), NewMoney(100, "USD"))
+		})
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{money: NewMoney(150000, "NGN"), want: "NGN 1,500.00"},
+		{money: NewMoney(5, "NGN"), want: "NGN 0.05"},
+		{money: NewMoney(-123456789, "NGN"), want: "NGN -1,234,567.89"},
+		{money: NewMoney(0, "NGN"), want: "NGN 0.00"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.money.String(); got != tt.want {
+			t.Errorf("String() of %d = %q, want %q", tt.money.Amount, got, tt.want)
+		}
+	}
+}
@@ -2,25 +2,58 @@ package models
 
 import (
 	"errors"
+	"fmt"
 	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/samber/lo"
 )
 
+// Order holds the part of a checkout fulfilled by a single vendor
 type Order struct {
-	ID              string       `json:"id" bson:"id"`
-	Orders          []CartItem   `json:"orders" bson:"orders"`
-	CustomerID      string       `json:"customer_id" bson:"customer_id"`
-	DeliveryDetails ShippingInfo `json:"delivery_details" bson:"delivery_details"`
-	PaymentMethod   string       `json:"payment_method" bson:"payment_method"`
-	//VendorID        string        `json:"vendor_id" bson:"vendor_id"` // uncomment vendor id when sure how vendors affects individual orders
-	DeliveryFee   float64         `json:"delivery_fee" bson:"delivery_fee"`
-	ServiceFee    float64         `json:"service_fee" bson:"service_fee"`
-	Total         float64         `json:"total" bson:"total"`
+	ID              string        `json:"id" bson:"id"`
+	CheckoutID      string        `json:"checkout_id" bson:"checkout_id"`
+	Orders          []CartItem    `json:"orders" bson:"orders"`
+	CustomerID      string        `json:"customer_id" bson:"customer_id"`
+	VendorID        string        `json:"vendor_id" bson:"vendor_id"`
+	DeliveryDetails ShippingInfo  `json:"delivery_details" bson:"delivery_details"`
+	PaymentMethod   PaymentMethod `json:"payment_method" bson:"payment_method"`
+	DeliveryFee     Money         `json:"delivery_fee" bson:"delivery_fee"`
+	ServiceFee      Money         `json:"service_fee" bson:"service_fee"`
+	Total           Money         `json:"total" bson:"total"`
+	// Discount is the promotion applied to the order, the total is after the discount
+	Discount *OrderDiscount `json:"discount,omitempty" bson:"discount,omitempty"`
+	// CancellationFee is kept from the refund when the customer cancels the order after it was approved
+	CancellationFee Money `json:"cancellation_fee,omitempty" bson:"cancellation_fee,omitempty"`
+	// DepositReturns records the cylinders bought with the order that were returned for their deposit
+	DepositReturns []DepositReturn `json:"deposit_returns,omitempty" bson:"deposit_returns,omitempty"`
+	Status         OrderStatuses   `json:"status" bson:"status"`
+	Delivery       *Delivery       `json:"delivery,omitempty" bson:"delivery,omitempty"`
+	// DeliverySlot is the window the customer chose for delivery. Orders of subscriptions have none.
+	DeliverySlot *OrderSlot `json:"delivery_slot,omitempty" bson:"delivery_slot,omitempty"`
+	// ReplacesOrderID is the order this order delivers again at no cost, after a support ticket about it was resolved
+	ReplacesOrderID string `json:"replaces_order_id,omitempty" bson:"replaces_order_id,omitempty"`
+	// HandoverCode is given by the customer to the rider on delivery, it is generated when the order ships
+	HandoverCode string `json:"-" bson:"handover_code,omitempty"`
+	// HandoverAttempts counts the wrong handover codes submitted for the order
+	HandoverAttempts int `json:"-" bson:"handover_attempts,omitempty"`
+	// InvoiceNumber is the number of the invoice issued for the order, it is set once the order is completed and invoiced
+	InvoiceNumber string          `json:"invoice_number,omitempty" bson:"invoice_number,omitempty"`
 	StatusHistory []StatusHistory `json:"status_history" bson:"status_history"`
 	Reason        string          `json:"reason" bson:"reason"`
 	StatusTs      int64           `json:"status_ts" bson:"status_ts"`
 	Ts            int64           `json:"ts" bson:"ts"`
 } // @name Order
 
+// DepositReturn records cylinders of a new cylinder purchase returned by the customer, and the deposit given back for them
+type DepositReturn struct {
+	CartItemID string `json:"cart_item_id" bson:"cart_item_id"`
+	Quantity   int    `json:"quantity" bson:"quantity"`
+	Amount     Money  `json:"amount" bson:"amount"`
+	// RefundID is the refund of the deposit for orders paid online, the deposit of orders paid on delivery is given back in cash
+	RefundID   string `json:"refund_id,omitempty" bson:"refund_id,omitempty"`
+	ReturnedBy string `json:"returned_by" bson:"returned_by"`
+	Ts         int64  `json:"ts" bson:"ts"`
+} // @name DepositReturn
+
 // ShippingInfo is the object required for shipping details of an order
 type ShippingInfo struct {
 	Name    string  `json:"name,omitempty" bson:"name"`
@@ -29,26 +62,145 @@ type ShippingInfo struct {
 	Address Address `json:"address,omitempty" bson:"address"`
 } // @name ShippingInfo
 
+// Delivery holds the rider assigned to deliver an order and when the order was picked up and delivered
+type Delivery struct {
+	RiderID     string `json:"rider_id" bson:"rider_id"`
+	AssignedBy  string `json:"assigned_by" bson:"assigned_by"`
+	AssignedTs  int64  `json:"assigned_ts" bson:"assigned_ts"`
+	PickedUpTs  int64  `json:"picked_up_ts,omitempty" bson:"picked_up_ts,omitempty"`
+	DeliveredTs int64  `json:"delivered_ts,omitempty" bson:"delivered_ts,omitempty"`
+	// Location is the last location the rider reported while delivering the order
+	Location   *Coordinates `json:"location,omitempty" bson:"location,omitempty"`
+	LocationTs int64        `json:"location_ts,omitempty" bson:"location_ts,omitempty"`
+} // @name Delivery
+
 // OrderStatuses type
 type OrderStatuses string
 
 const (
-	OrderPending   OrderStatuses = "PENDING"   // @name PENDING    // order has been created and processing
-	OrderApproved  OrderStatuses = "APPROVED"  // @name APPROVED  // order has been approved
-	OrderShipped   OrderStatuses = "SHIPPED"   // @name SHIPPED  // order has been shipped
-	OrderCompleted OrderStatuses = "COMPLETED" // @name COMPLETED // order has been processed and delivered, and verified by the customer
-	OrderCancelled OrderStatuses = "CANCELLED" // @name CANCELLED // order has been cancelled by vendor or customer
-	OrderRejected  OrderStatuses = "REJECTED"  // @name REJECTED // order was rejected by vendor or customer
+	OrderAwaitingPayment OrderStatuses = "AWAITING_PAYMENT" // @name AWAITING_PAYMENT // order is waiting for its payment to be confirmed
+	OrderPending         OrderStatuses = "PENDING"          // @name PENDING    // order has been created and processing
+	OrderApproved        OrderStatuses = "APPROVED"         // @name APPROVED  // order has been approved
+	OrderShipped         OrderStatuses = "SHIPPED"          // @name SHIPPED  // order has been shipped
+	OrderCompleted       OrderStatuses = "COMPLETED"        // @name COMPLETED // order has been processed and delivered, and verified by the customer
+	OrderCancelled       OrderStatuses = "CANCELLED"        // @name CANCELLED // order has been cancelled by vendor or customer
+	OrderRejected        OrderStatuses = "REJECTED"         // @name REJECTED // order was rejected by vendor or customer
 )
 
 type StatusHistory struct {
-	Status   OrderStatuses `json:"status" bson:"status"`
-	Reason   string        `json:"reason" bson:"reason"`
-	StatusTs int64         `json:"status_ts" bson:"status_ts"`
+	Status    OrderStatuses `json:"status" bson:"status"`
+	Reason    string        `json:"reason" bson:"reason"`
+	UpdatedBy string        `json:"updated_by,omitempty" bson:"updated_by,omitempty"`
+	Role      UserCategory  `json:"role,omitempty" bson:"role,omitempty"`
+	// Proof is recorded when the order is completed on delivery
+	Proof    *DeliveryProof `json:"proof,omitempty" bson:"proof,omitempty"`
+	StatusTs int64          `json:"status_ts" bson:"status_ts"`
+}
+
+// DeliveryProof is what the order was completed on, to settle disputes about its delivery
+type DeliveryProof struct {
+	HandoverCodeVerified bool `json:"handover_code_verified" bson:"handover_code_verified"`
+	// PhotoID identifies the photo of the delivery, which is downloaded from the order
+	PhotoID  string       `json:"photo_id,omitempty" bson:"photo_id,omitempty"`
+	Location *Coordinates `json:"location,omitempty" bson:"location,omitempty"`
+} // @name DeliveryProof
+
+// orderStatusTransitions holds, per user category, the statuses an order may move to from its current status.
+// Statuses missing from a category's table cannot be moved from by that category.
+// Orders only leave AWAITING_PAYMENT for PENDING once the payment provider confirms the payment.
+// No one can cancel an order once it has shipped.
+var orderStatusTransitions = map[UserCategory]map[OrderStatuses][]OrderStatuses{
+	CustomerCategory: {
+		OrderAwaitingPayment: {OrderCancelled},
+		OrderPending:         {OrderCancelled},
+		OrderApproved:        {OrderCancelled},
+		OrderShipped:         {OrderCompleted},
+	},
+	VendorCategory: {
+		OrderPending:  {OrderApproved, OrderRejected, OrderCancelled},
+		OrderApproved: {OrderShipped, OrderCancelled},
+		OrderShipped:  {OrderCompleted},
+	},
+	// riders move the orders assigned to them when they pick them up and deliver them
+	RiderCategory: {
+		OrderApproved: {OrderShipped},
+		OrderShipped:  {OrderCompleted},
+	},
+	AdminCategory: {
+		OrderAwaitingPayment: {OrderCancelled},
+		OrderPending:         {OrderApproved, OrderRejected, OrderCancelled},
+		OrderApproved:        {OrderShipped, OrderCancelled},
+		OrderShipped:         {OrderCompleted},
+	},
+}
+
+// IsTerminalOrderStatus reports whether an order in this status can no longer change status
+func IsTerminalOrderStatus(status OrderStatuses) bool {
+	return status == OrderCompleted || status == OrderCancelled || status == OrderRejected
+}
+
+// ValidateOrderStatusTransition checks that a user of the given category may move an order from one status to another
+func ValidateOrderStatusTransition(role UserCategory, from, to OrderStatuses) error {
+	if IsTerminalOrderStatus(from) {
+		return errs.Body(errs.OrderStatusTransitionError, fmt.Errorf("order is already %s and cannot be moved to %s", from, to))
+	}
+
+	if !lo.Contains(orderStatusTransitions[role][from], to) {
+		return errs.Body(errs.OrderStatusTransitionError, fmt.Errorf("%s cannot move order from %s to %s", role, from, to))
+	}
+
+	return nil
+}
+
+// IsCancellationCharged reports whether cancelling an order in this status costs the cancellation fee.
+// Cancelling is free until the vendor approves the order, and vendors and admins never charge the customer for cancelling.
+func IsCancellationCharged(role UserCategory, status OrderStatuses) bool {
+	return role == CustomerCategory && status == OrderApproved
+}
+
+// ReturnableCylinders returns the order item bought as new cylinders and how many of its cylinders were not returned yet
+func (o *Order) ReturnableCylinders(cartItemID string) (CartItem, int, error) {
+	item, ok := lo.Find(o.Orders, func(item CartItem) bool {
+		return item.ID == cartItemID
+	})
+	if !ok {
+		return item, 0, fmt.Errorf("order %s has no item %s", o.ID, cartItemID)
+	}
+
+	if !item.IsNewCylinder() {
+		return item, 0, fmt.Errorf("item %s exchanged cylinders, it has no deposit to return", cartItemID)
+	}
+
+	returned := 0
+	for _, depositReturn := range o.DepositReturns {
+		if depositReturn.CartItemID == cartItemID {
+			returned += depositReturn.Quantity
+		}
+	}
+
+	return item, item.Quantity - returned, nil
+}
+
+// IsAssignedTo reports whether the rider is assigned to deliver the order
+func (o *Order) IsAssignedTo(riderID string) bool {
+	return o.Delivery != nil && o.Delivery.RiderID == riderID
+}
+
+// CurrentStatus returns the order status, falling back to the status history for orders persisted before the status field was stored
+func (o *Order) CurrentStatus() OrderStatuses {
+	if o.Status != "" {
+		return o.Status
+	}
+
+	if len(o.StatusHistory) > 0 {
+		return o.StatusHistory[len(o.StatusHistory)-1].Status
+	}
+
+	return OrderPending
 }
 
 func IsValidOrderStatus(status OrderStatuses) bool {
-	return status == OrderPending || status == OrderCancelled || status == OrderRejected || status == OrderCompleted || status == OrderApproved || status == OrderShipped
+	return status == OrderAwaitingPayment || status == OrderPending || status == OrderCancelled || status == OrderRejected || status == OrderCompleted || status == OrderApproved || status == OrderShipped
 }
 
 func SetOrderStatus(status OrderStatuses) (OrderStatuses, error) {
@@ -47,6 +47,7 @@ const (
 	CustomerCategory UserCategory = "customer"
 	AdminCategory    UserCategory = "admin_leeta"
 	GuestCategory    UserCategory = "guest"
+	RiderCategory    UserCategory = "rider"
 )
 
 func IsValidCredentialType(credentialType CredentialType) bool {
@@ -74,7 +75,7 @@ func SetCredentialStatus(status CredentialStatus) (CredentialStatus, error) {
 }
 
 func IsValidUserCategory(category UserCategory) bool {
-	return category == VendorCategory || category == CustomerCategory || category == AdminCategory || category == GuestCategory
+	return category == VendorCategory || category == CustomerCategory || category == AdminCategory || category == GuestCategory || category == RiderCategory
 }
 func SetUserCategory(category UserCategory) (UserCategory, error) {
 	switch IsValidUserCategory(category) {
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"errors"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+)
+
+// PaymentMethod is the way a customer pays for a checkout
+type PaymentMethod string
+
+const (
+	PaymentMethodCard           PaymentMethod = "CARD"             // paid online through the payment provider
+	PaymentMethodBankTransfer   PaymentMethod = "BANK_TRANSFER"    // paid online through the payment provider
+	PaymentMethodCashOnDelivery PaymentMethod = "CASH_ON_DELIVERY" // paid to the rider when the order is delivered
+)
+
+func IsValidPaymentMethod(method PaymentMethod) bool {
+	return method == PaymentMethodCard || method == PaymentMethodBankTransfer || method == PaymentMethodCashOnDelivery
+}
+
+func SetPaymentMethod(method PaymentMethod) (PaymentMethod, error) {
+	switch IsValidPaymentMethod(method) {
+	case true:
+		return method, nil
+	default:
+		return "", errs.Body(errs.PaymentMethodError, errors.New("invalid payment method"))
+	}
+}
+
+// IsPaidOnDelivery reports whether orders paid with this method can be processed before any payment is confirmed
+func (method PaymentMethod) IsPaidOnDelivery() bool {
+	return method == PaymentMethodCashOnDelivery
+}
+
+type PaymentStatus string
+
+const (
+	PaymentPending    PaymentStatus = "PENDING"    // payment has been initialized and is waiting for the customer
+	PaymentSuccessful PaymentStatus = "SUCCESSFUL" // payment has been confirmed by the provider
+	PaymentFailed     PaymentStatus = "FAILED"     // payment was declined or abandoned
+	PaymentRefunded   PaymentStatus = "REFUNDED"   // payment has been refunded to the customer
+)
+
+// Payment records the charge made for a checkout through a payment provider
+type Payment struct {
+	ID               string        `json:"id" bson:"id"`
+	CheckoutID       string        `json:"checkout_id" bson:"checkout_id"`
+	CustomerID       string        `json:"customer_id" bson:"customer_id"`
+	Method           PaymentMethod `json:"method" bson:"method"`
+	Provider         string        `json:"provider" bson:"provider"`
+	Reference        string        `json:"reference" bson:"reference"`
+	Amount           Money         `json:"amount" bson:"amount"`
+	AuthorizationURL string        `json:"authorization_url,omitempty" bson:"authorization_url"`
+	Status           PaymentStatus `json:"status" bson:"status"`
+	StatusTs         int64         `json:"status_ts" bson:"status_ts"`
+	Ts               int64         `json:"ts" bson:"ts"`
+} // @name Payment
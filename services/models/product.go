@@ -14,12 +14,12 @@ type Product struct {
 	Name                string             `json:"name,omitempty" bson:"name"`
 	Weight              string             `json:"weight,omitempty" bson:"weight"`
 	Description         string             `json:"description,omitempty" bson:"description"`
-	OriginalPrice       float64            `json:"original_price,omitempty" bson:"original_price"`
-	Vat                 float64            `json:"vat,omitempty" bson:"vat"`
-	OriginalPriceAndVat float64            `json:"original_price_and_vat,omitempty" bson:"original_price_and_vat"`
+	OriginalPrice       Money              `json:"original_price,omitempty" bson:"original_price"`
+	Vat                 Money              `json:"vat,omitempty" bson:"vat"`
+	OriginalPriceAndVat Money              `json:"original_price_and_vat,omitempty" bson:"original_price_and_vat"`
 	Discount            bool               `json:"discount,omitempty" bson:"discount"`
-	DiscountPrice       float64            `json:"discount_price,omitempty" bson:"discount_price"`
-	FinalPrice          float64            `json:"final_price,omitempty" bson:"final_price"`
+	DiscountPrice       Money              `json:"discount_price,omitempty" bson:"discount_price"`
+	FinalPrice          Money              `json:"final_price,omitempty" bson:"final_price"`
 	Status              ProductStatus      `json:"status" bson:"status"`
 	StatusTs            int64              `json:"status_ts" bson:"status_ts"`
 	Ts                  int64              `json:"ts" bson:"ts"`
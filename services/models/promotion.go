@@ -0,0 +1,190 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"github.com/samber/lo"
+	"strings"
+)
+
+// Promotion gives a discount to carts checked out with its code while its rules are met
+type Promotion struct {
+	ID string `json:"id" bson:"id"`
+	// Code is what customers enter on the cart, it is stored in upper case
+	Code        string        `json:"code" bson:"code"`
+	Description string        `json:"description,omitempty" bson:"description,omitempty"`
+	Type        PromotionType `json:"type" bson:"type"`
+	// Value is the percentage taken off the eligible items for percentage promotions
+	Value float64 `json:"value,omitempty" bson:"value,omitempty"`
+	// Amount is taken off the eligible items for fixed amount promotions
+	Amount Money `json:"amount,omitempty" bson:"amount,omitempty"`
+	// MaxDiscount caps the discount of a percentage promotion, no cap is applied when it is zero
+	MaxDiscount Money `json:"max_discount,omitempty" bson:"max_discount,omitempty"`
+	// MinWeight is the total weight in kg of the eligible items the cart must hold
+	MinWeight float64 `json:"min_weight,omitempty" bson:"min_weight,omitempty"`
+	// FirstOrderOnly limits the promotion to customers who never placed an order
+	FirstOrderOnly bool           `json:"first_order_only" bson:"first_order_only"`
+	Scope          PromotionScope `json:"scope" bson:"scope"`
+	// UsageLimit is the number of checkouts the promotion can be redeemed on, it is unlimited when zero
+	UsageLimit int `json:"usage_limit,omitempty" bson:"usage_limit,omitempty"`
+	// PerCustomerLimit is the number of checkouts a customer can redeem the promotion on, it is unlimited when zero
+	PerCustomerLimit int             `json:"per_customer_limit,omitempty" bson:"per_customer_limit,omitempty"`
+	UsageCount       int             `json:"usage_count" bson:"usage_count"`
+	StartsAt         int64           `json:"starts_at" bson:"starts_at"`
+	EndsAt           int64           `json:"ends_at" bson:"ends_at"`
+	Status           PromotionStatus `json:"status" bson:"status"`
+	CreatedBy        string          `json:"created_by" bson:"created_by"`
+	StatusTs         int64           `json:"status_ts" bson:"status_ts"`
+	Ts               int64           `json:"ts" bson:"ts"`
+} // @name Promotion
+
+// PromotionScope limits a promotion to products, delivery lgas and customer segments. An empty list does not limit the promotion.
+type PromotionScope struct {
+	ProductIDs []string      `json:"product_ids,omitempty" bson:"product_ids,omitempty"`
+	LGAs       []LGA         `json:"lgas,omitempty" bson:"lgas,omitempty"`
+	Segments   []UserSegment `json:"segments,omitempty" bson:"segments,omitempty"`
+} // @name PromotionScope
+
+type PromotionType string
+
+const (
+	PromotionPercentage   PromotionType = "PERCENTAGE"    // a percentage of the eligible items is taken off
+	PromotionFixedAmount  PromotionType = "FIXED_AMOUNT"  // a fixed amount is taken off the eligible items
+	PromotionFreeDelivery PromotionType = "FREE_DELIVERY" // the delivery fee of the orders holding eligible items is waived
+)
+
+func IsValidPromotionType(promotionType PromotionType) bool {
+	return promotionType == PromotionPercentage || promotionType == PromotionFixedAmount || promotionType == PromotionFreeDelivery
+}
+
+type PromotionStatus string
+
+const (
+	PromotionActive   PromotionStatus = "ACTIVE"
+	PromotionInactive PromotionStatus = "INACTIVE"
+)
+
+func IsValidPromotionStatus(status PromotionStatus) bool {
+	return status == PromotionActive || status == PromotionInactive
+}
+
+// UserSegment groups customers a promotion can be limited to
+type UserSegment string
+
+const (
+	SegmentGuest             UserSegment = "GUEST"              // guests checking out without an account
+	SegmentNewCustomer       UserSegment = "NEW_CUSTOMER"       // customers who never placed an order
+	SegmentReturningCustomer UserSegment = "RETURNING_CUSTOMER" // customers who placed an order before
+)
+
+func IsValidUserSegment(segment UserSegment) bool {
+	return segment == SegmentGuest || segment == SegmentNewCustomer || segment == SegmentReturningCustomer
+}
+
+// NormalizePromoCode is the form promo codes are stored and looked up in
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Validate checks the rules of a promotion are consistent before it is created
+func (p *Promotion) Validate() error {
+	if p.Code == "" {
+		return errors.New("code is required")
+	}
+
+	switch p.Type {
+	case PromotionPercentage:
+		if p.Value <= 0 || p.Value > 100 {
+			return errors.New("percentage must be more than 0 and at most 100")
+		}
+		p.Amount = Money{}
+	case PromotionFixedAmount:
+		if !p.Amount.IsPositive() {
+			return errors.New("amount must be more than 0")
+		}
+		p.Value = 0
+		p.MaxDiscount = Money{}
+	case PromotionFreeDelivery:
+		p.Value = 0
+		p.Amount = Money{}
+		p.MaxDiscount = Money{}
+	default:
+		return fmt.Errorf("invalid promotion type %s", p.Type)
+	}
+
+	if p.MaxDiscount.IsNegative() || p.MinWeight < 0 || p.UsageLimit < 0 || p.PerCustomerLimit < 0 {
+		return errors.New("limits cannot be negative")
+	}
+
+	if p.EndsAt <= p.StartsAt {
+		return errors.New("promotion must end after it starts")
+	}
+
+	for _, segment := range p.Scope.Segments {
+		if !IsValidUserSegment(segment) {
+			return fmt.Errorf("invalid user segment %s", segment)
+		}
+	}
+
+	return nil
+}
+
+// CheckRedeemable checks the promotion can be redeemed at the unix time by a customer in the segment
+func (p *Promotion) CheckRedeemable(ts int64, segment UserSegment) error {
+	switch {
+	case p.Status != PromotionActive:
+		return errors.New("promo code is not active")
+	case ts < p.StartsAt:
+		return errors.New("promo code is not valid yet")
+	case ts >= p.EndsAt:
+		return errors.New("promo code has expired")
+	case p.UsageLimit > 0 && p.UsageCount >= p.UsageLimit:
+		return errors.New("promo code has been used up")
+	case p.FirstOrderOnly && segment != SegmentNewCustomer:
+		return errors.New("promo code is only valid on a first order")
+	case len(p.Scope.Segments) > 0 && !lo.Contains(p.Scope.Segments, segment):
+		return errors.New("promo code is not available to you")
+	}
+
+	return nil
+}
+
+// AppliesToProduct reports whether the cart items of the product are eligible for the promotion
+func (p *Promotion) AppliesToProduct(productID string) bool {
+	return len(p.Scope.ProductIDs) == 0 || lo.Contains(p.Scope.ProductIDs, productID)
+}
+
+// AppliesToLGA reports whether carts delivered in the lga are eligible for the promotion
+func (p *Promotion) AppliesToLGA(lga LGA) bool {
+	if len(p.Scope.LGAs) == 0 {
+		return true
+	}
+
+	return lo.ContainsBy(p.Scope.LGAs, func(scoped LGA) bool {
+		return strings.EqualFold(scoped.LGA, lga.LGA) && strings.EqualFold(scoped.State, lga.State)
+	})
+}
+
+// OrderDiscount is the part of a promotion's discount applied to an order, kept on the order for reporting
+type OrderDiscount struct {
+	PromotionID      string        `json:"promotion_id" bson:"promotion_id"`
+	Code             string        `json:"code" bson:"code"`
+	Type             PromotionType `json:"type" bson:"type"`
+	ItemsDiscount    Money         `json:"items_discount" bson:"items_discount"`
+	DeliveryDiscount Money         `json:"delivery_discount" bson:"delivery_discount"`
+	Total            Money         `json:"total" bson:"total"`
+} // @name OrderDiscount
+
+// PromotionRedemption records a promotion redeemed on a checkout
+type PromotionRedemption struct {
+	ID          string   `json:"id" bson:"id"`
+	PromotionID string   `json:"promotion_id" bson:"promotion_id"`
+	Code        string   `json:"code" bson:"code"`
+	CustomerID  string   `json:"customer_id" bson:"customer_id"`
+	CheckoutID  string   `json:"checkout_id" bson:"checkout_id"`
+	OrderIDs    []string `json:"order_ids" bson:"order_ids"`
+	Discount    Money    `json:"discount" bson:"discount"`
+	// Sequence numbers the redemptions of a promotion limited per customer by the customer, from one to the limit
+	Sequence int   `json:"sequence,omitempty" bson:"sequence,omitempty"`
+	Ts       int64 `json:"ts" bson:"ts"`
+} // @name PromotionRedemption
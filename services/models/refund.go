@@ -0,0 +1,61 @@
+package models
+
+import (
+	"fmt"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/samber/lo"
+)
+
+// Refund returns what the customer paid for a cancelled order, less the cancellation fee
+type Refund struct {
+	ID         string `json:"id" bson:"id"`
+	OrderID    string `json:"order_id" bson:"order_id"`
+	CheckoutID string `json:"checkout_id" bson:"checkout_id"`
+	PaymentID  string `json:"payment_id" bson:"payment_id"`
+	// PaymentReference identifies the payment with the provider the refund is paid through
+	PaymentReference string                `json:"payment_reference" bson:"payment_reference"`
+	CustomerID       string                `json:"customer_id" bson:"customer_id"`
+	VendorID         string                `json:"vendor_id" bson:"vendor_id"`
+	OrderTotal       Money                 `json:"order_total" bson:"order_total"`
+	CancellationFee  Money                 `json:"cancellation_fee" bson:"cancellation_fee"`
+	Amount           Money                 `json:"amount" bson:"amount"`
+	Reason           string                `json:"reason" bson:"reason"`
+	Status           RefundStatus          `json:"status" bson:"status"`
+	StatusHistory    []RefundStatusHistory `json:"status_history" bson:"status_history"`
+	StatusTs         int64                 `json:"status_ts" bson:"status_ts"`
+	Ts               int64                 `json:"ts" bson:"ts"`
+} // @name Refund
+
+type RefundStatus string
+
+const (
+	RefundRequested RefundStatus = "REQUESTED" // the order was cancelled and the refund awaits an admin
+	RefundApproved  RefundStatus = "APPROVED"  // an admin approved the refund and it can be paid
+	RefundPaying    RefundStatus = "PAYING"    // the refund is being paid through the payment provider
+	RefundRejected  RefundStatus = "REJECTED"  // an admin rejected the refund
+	RefundPaid      RefundStatus = "PAID"      // the refund was paid back through the payment provider
+)
+
+type RefundStatusHistory struct {
+	Status    RefundStatus `json:"status" bson:"status"`
+	Reason    string       `json:"reason" bson:"reason"`
+	UpdatedBy string       `json:"updated_by,omitempty" bson:"updated_by,omitempty"`
+	StatusTs  int64        `json:"status_ts" bson:"status_ts"`
+}
+
+// refundStatusTransitions holds the statuses a refund may move to from its current status
+var refundStatusTransitions = map[RefundStatus][]RefundStatus{
+	RefundRequested: {RefundApproved, RefundRejected},
+	RefundApproved:  {RefundPaying},
+	// a refund the provider failed to pay is approved again, so it can be paid once the problem is solved
+	RefundPaying: {RefundPaid, RefundApproved},
+}
+
+// ValidateRefundStatusTransition checks that a refund may move from one status to another
+func ValidateRefundStatusTransition(from, to RefundStatus) error {
+	if !lo.Contains(refundStatusTransitions[from], to) {
+		return errs.Body(errs.RefundStatusTransitionError, fmt.Errorf("refund cannot move from %s to %s", from, to))
+	}
+
+	return nil
+}
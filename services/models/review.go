@@ -0,0 +1,48 @@
+package models
+
+const (
+	MinRating = 1
+	MaxRating = 5
+)
+
+// Review is the customer's rating of the vendor and the delivery of a completed order. An order is reviewed once.
+type Review struct {
+	ID         string `json:"id" bson:"id"`
+	OrderID    string `json:"order_id" bson:"order_id"`
+	CustomerID string `json:"customer_id" bson:"customer_id"`
+	VendorID   string `json:"vendor_id" bson:"vendor_id"`
+	// RiderID is empty for orders that were not delivered by a rider, which have no delivery rating
+	RiderID         string       `json:"rider_id,omitempty" bson:"rider_id,omitempty"`
+	VendorRating    int          `json:"vendor_rating" bson:"vendor_rating"`
+	VendorComment   string       `json:"vendor_comment,omitempty" bson:"vendor_comment,omitempty"`
+	DeliveryRating  int          `json:"delivery_rating,omitempty" bson:"delivery_rating,omitempty"`
+	DeliveryComment string       `json:"delivery_comment,omitempty" bson:"delivery_comment,omitempty"`
+	Status          ReviewStatus `json:"status" bson:"status"`
+	// Flagged marks the review for an admin to look at, flagging alone does not hide it
+	Flagged          bool   `json:"flagged" bson:"flagged"`
+	ModerationReason string `json:"moderation_reason,omitempty" bson:"moderation_reason,omitempty"`
+	ModeratedBy      string `json:"moderated_by,omitempty" bson:"moderated_by,omitempty"`
+	StatusTs         int64  `json:"status_ts" bson:"status_ts"`
+	Ts               int64  `json:"ts" bson:"ts"`
+} // @name Review
+
+type ReviewStatus string
+
+const (
+	ReviewVisible ReviewStatus = "VISIBLE" // the review is shown and counts towards the ratings
+	ReviewHidden  ReviewStatus = "HIDDEN"  // an admin hid the review, it no longer counts towards the ratings
+)
+
+func IsValidReviewStatus(status ReviewStatus) bool {
+	return status == ReviewVisible || status == ReviewHidden
+}
+
+func IsValidRating(rating int) bool {
+	return rating >= MinRating && rating <= MaxRating
+}
+
+// RatingSummary is the average of the ratings of a vendor or a rider
+type RatingSummary struct {
+	Average float64 `json:"average" bson:"average"`
+	Count   int     `json:"count" bson:"count"`
+} // @name RatingSummary
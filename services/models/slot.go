@@ -0,0 +1,36 @@
+package models
+
+// DeliverySlot is a window orders in an LGA can be delivered in. Capacity bounds how many orders are delivered in the window.
+type DeliverySlot struct {
+	ID       string     `json:"id" bson:"id"`
+	LGA      LGA        `json:"lga" bson:"lga"`
+	StartTs  int64      `json:"start_ts" bson:"start_ts"`
+	EndTs    int64      `json:"end_ts" bson:"end_ts"`
+	Capacity int        `json:"capacity" bson:"capacity"`
+	Reserved int        `json:"reserved" bson:"reserved"`
+	Status   SlotStatus `json:"status" bson:"status"`
+	StatusTs int64      `json:"status_ts" bson:"status_ts"`
+	Ts       int64      `json:"ts" bson:"ts"`
+} // @name DeliverySlot
+
+// Available is how many more orders can be delivered in the slot
+func (slot DeliverySlot) Available() int {
+	if slot.Reserved >= slot.Capacity {
+		return 0
+	}
+	return slot.Capacity - slot.Reserved
+}
+
+type SlotStatus string
+
+const (
+	SlotActive   SlotStatus = "ACTIVE"   // orders can be booked in the slot
+	SlotInactive SlotStatus = "INACTIVE" // the slot no longer takes orders, booked orders are kept
+)
+
+// OrderSlot is the delivery slot an order was booked in
+type OrderSlot struct {
+	ID      string `json:"id" bson:"id"`
+	StartTs int64  `json:"start_ts" bson:"start_ts"`
+	EndTs   int64  `json:"end_ts" bson:"end_ts"`
+} // @name OrderSlot
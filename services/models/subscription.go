@@ -0,0 +1,81 @@
+package models
+
+import (
+	"errors"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"time"
+)
+
+// Subscription refills the same product for a customer on a recurring cadence
+type Subscription struct {
+	ID              string              `json:"id" bson:"id"`
+	CustomerID      string              `json:"customer_id" bson:"customer_id"`
+	ProductID       string              `json:"product_id" bson:"product_id"`
+	VendorID        string              `json:"vendor_id" bson:"vendor_id"`
+	Weight          float64             `json:"weight,omitempty" bson:"weight"`
+	Quantity        int                 `json:"quantity" bson:"quantity"`
+	DeliveryDetails ShippingInfo        `json:"delivery_details" bson:"delivery_details"`
+	PaymentMethod   PaymentMethod       `json:"payment_method" bson:"payment_method"`
+	Cadence         SubscriptionCadence `json:"cadence" bson:"cadence"`
+	// NextRunTs is when the next order of the subscription is created
+	NextRunTs int64 `json:"next_run_ts" bson:"next_run_ts"`
+	// RemindedRunTs is the run the customer was last reminded of
+	RemindedRunTs int64  `json:"reminded_run_ts,omitempty" bson:"reminded_run_ts"`
+	LastOrderID   string `json:"last_order_id,omitempty" bson:"last_order_id"`
+	// FailedRuns counts the failed attempts at the order of the next run, the subscription is paused when they run out
+	FailedRuns int `json:"failed_runs,omitempty" bson:"failed_runs"`
+	// LockedUntil keeps other schedulers from creating the order of a run while it is being processed
+	LockedUntil int64              `json:"-" bson:"locked_until"`
+	Status      SubscriptionStatus `json:"status" bson:"status"`
+	StatusTs    int64              `json:"status_ts" bson:"status_ts"`
+	Ts          int64              `json:"ts" bson:"ts"`
+} // @name Subscription
+
+type SubscriptionCadence string
+
+const (
+	SubscriptionWeekly   SubscriptionCadence = "WEEKLY"
+	SubscriptionBiweekly SubscriptionCadence = "BIWEEKLY"
+	SubscriptionMonthly  SubscriptionCadence = "MONTHLY"
+)
+
+type SubscriptionStatus string
+
+const (
+	SubscriptionActive    SubscriptionStatus = "ACTIVE"    // orders are created on every run
+	SubscriptionPaused    SubscriptionStatus = "PAUSED"    // no orders are created until the subscription is resumed
+	SubscriptionCancelled SubscriptionStatus = "CANCELLED" // the subscription ended
+)
+
+func IsValidSubscriptionCadence(cadence SubscriptionCadence) bool {
+	return cadence == SubscriptionWeekly || cadence == SubscriptionBiweekly || cadence == SubscriptionMonthly
+}
+
+func SetSubscriptionCadence(cadence SubscriptionCadence) (SubscriptionCadence, error) {
+	switch IsValidSubscriptionCadence(cadence) {
+	case true:
+		return cadence, nil
+	default:
+		return "", errs.Body(errs.InvalidRequestError, errors.New("invalid subscription cadence"))
+	}
+}
+
+// Next returns the run following the given one
+func (c SubscriptionCadence) Next(from time.Time) time.Time {
+	switch c {
+	case SubscriptionWeekly:
+		return from.AddDate(0, 0, 7)
+	case SubscriptionBiweekly:
+		return from.AddDate(0, 0, 14)
+	default:
+		return from.AddDate(0, 1, 0)
+	}
+}
+
+// NextAfter returns the first run of the cadence, counted from the given run, that is not before now
+func (c SubscriptionCadence) NextAfter(from, now time.Time) time.Time {
+	for from.Before(now) {
+		from = c.Next(from)
+	}
+	return from
+}
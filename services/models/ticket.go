@@ -0,0 +1,133 @@
+package models
+
+import (
+	"fmt"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/samber/lo"
+)
+
+// Ticket is a customer's support request about an order, such as a wrong cylinder size, a short weight or a late delivery
+type Ticket struct {
+	ID         string         `json:"id" bson:"id"`
+	OrderID    string         `json:"order_id" bson:"order_id"`
+	CustomerID string         `json:"customer_id" bson:"customer_id"`
+	VendorID   string         `json:"vendor_id" bson:"vendor_id"`
+	Category   TicketCategory `json:"category" bson:"category"`
+	Subject    string         `json:"subject" bson:"subject"`
+	Priority   TicketPriority `json:"priority" bson:"priority"`
+	// AssignedTo is the support admin handling the ticket
+	AssignedTo    string                `json:"assigned_to,omitempty" bson:"assigned_to,omitempty"`
+	Messages      []TicketMessage       `json:"messages" bson:"messages"`
+	Resolution    *TicketResolution     `json:"resolution,omitempty" bson:"resolution,omitempty"`
+	SLA           TicketSLA             `json:"sla" bson:"sla"`
+	Status        TicketStatus          `json:"status" bson:"status"`
+	StatusHistory []TicketStatusHistory `json:"status_history" bson:"status_history"`
+	StatusTs      int64                 `json:"status_ts" bson:"status_ts"`
+	Ts            int64                 `json:"ts" bson:"ts"`
+} // @name Ticket
+
+type TicketMessage struct {
+	ID       string       `json:"id" bson:"id"`
+	AuthorID string       `json:"author_id" bson:"author_id"`
+	Role     UserCategory `json:"role" bson:"role"`
+	Body     string       `json:"body" bson:"body"`
+	Ts       int64        `json:"ts" bson:"ts"`
+} // @name TicketMessage
+
+// TicketSLA holds when support is due to first reply to and resolve the ticket, and when they did
+type TicketSLA struct {
+	FirstResponseDueTs int64 `json:"first_response_due_ts" bson:"first_response_due_ts"`
+	ResolutionDueTs    int64 `json:"resolution_due_ts" bson:"resolution_due_ts"`
+	FirstResponseTs    int64 `json:"first_response_ts,omitempty" bson:"first_response_ts,omitempty"`
+	ResolvedTs         int64 `json:"resolved_ts,omitempty" bson:"resolved_ts,omitempty"`
+	ClosedTs           int64 `json:"closed_ts,omitempty" bson:"closed_ts,omitempty"`
+} // @name TicketSLA
+
+// TicketResolution is how support settled the ticket, and the refund or replacement order it was settled with
+type TicketResolution struct {
+	Action             TicketResolutionAction `json:"action" bson:"action"`
+	Note               string                 `json:"note" bson:"note"`
+	RefundID           string                 `json:"refund_id,omitempty" bson:"refund_id,omitempty"`
+	RefundAmount       Money                  `json:"refund_amount,omitempty" bson:"refund_amount,omitempty"`
+	ReplacementOrderID string                 `json:"replacement_order_id,omitempty" bson:"replacement_order_id,omitempty"`
+	ResolvedBy         string                 `json:"resolved_by" bson:"resolved_by"`
+	Ts                 int64                  `json:"ts" bson:"ts"`
+} // @name TicketResolution
+
+type TicketStatusHistory struct {
+	Status    TicketStatus `json:"status" bson:"status"`
+	Reason    string       `json:"reason" bson:"reason"`
+	UpdatedBy string       `json:"updated_by,omitempty" bson:"updated_by,omitempty"`
+	StatusTs  int64        `json:"status_ts" bson:"status_ts"`
+}
+
+type TicketCategory string
+
+const (
+	TicketWrongSize    TicketCategory = "WRONG_SIZE"    // the cylinder delivered is not the size ordered
+	TicketShortWeight  TicketCategory = "SHORT_WEIGHT"  // the cylinder was filled with less gas than ordered
+	TicketLateDelivery TicketCategory = "LATE_DELIVERY" // the order was delivered late or not at all
+	TicketDamaged      TicketCategory = "DAMAGED"       // the cylinder or its valve was damaged
+	TicketOther        TicketCategory = "OTHER"
+)
+
+func IsValidTicketCategory(category TicketCategory) bool {
+	return category == TicketWrongSize || category == TicketShortWeight || category == TicketLateDelivery || category == TicketDamaged || category == TicketOther
+}
+
+type TicketPriority string
+
+const (
+	TicketPriorityLow    TicketPriority = "LOW"
+	TicketPriorityNormal TicketPriority = "NORMAL"
+	TicketPriorityHigh   TicketPriority = "HIGH"
+)
+
+func IsValidTicketPriority(priority TicketPriority) bool {
+	return priority == TicketPriorityLow || priority == TicketPriorityNormal || priority == TicketPriorityHigh
+}
+
+type TicketStatus string
+
+const (
+	TicketOpen             TicketStatus = "OPEN"              // the customer opened the ticket and support has not replied yet
+	TicketInProgress       TicketStatus = "IN_PROGRESS"       // support is handling the ticket
+	TicketAwaitingCustomer TicketStatus = "AWAITING_CUSTOMER" // support is waiting for the customer to reply
+	TicketResolved         TicketStatus = "RESOLVED"          // support settled the ticket
+	TicketClosed           TicketStatus = "CLOSED"            // the ticket is closed, no more messages can be added
+)
+
+type TicketResolutionAction string
+
+const (
+	TicketNoAction   TicketResolutionAction = "NONE"       // the ticket is settled without compensating the customer
+	TicketRefund     TicketResolutionAction = "REFUND"     // part or all of what the customer paid is refunded
+	TicketRedelivery TicketResolutionAction = "REDELIVERY" // the order is delivered again at no cost
+)
+
+func IsValidTicketResolutionAction(action TicketResolutionAction) bool {
+	return action == TicketNoAction || action == TicketRefund || action == TicketRedelivery
+}
+
+// ticketStatusTransitions holds the statuses support may move a ticket to from its current status.
+// Tickets are resolved through their resolution, and replies move them between in progress and awaiting customer.
+var ticketStatusTransitions = map[TicketStatus][]TicketStatus{
+	TicketOpen:             {TicketInProgress, TicketAwaitingCustomer, TicketResolved, TicketClosed},
+	TicketInProgress:       {TicketAwaitingCustomer, TicketResolved, TicketClosed},
+	TicketAwaitingCustomer: {TicketInProgress, TicketResolved, TicketClosed},
+	TicketResolved:         {TicketClosed},
+}
+
+// ValidateTicketStatusTransition checks that a ticket may move from one status to another
+func ValidateTicketStatusTransition(from, to TicketStatus) error {
+	if !lo.Contains(ticketStatusTransitions[from], to) {
+		return errs.Body(errs.TicketStatusTransitionError, fmt.Errorf("ticket cannot move from %s to %s", from, to))
+	}
+
+	return nil
+}
+
+// IsTicketOpenForMessages reports whether messages can still be added to a ticket in this status
+func IsTicketOpenForMessages(status TicketStatus) bool {
+	return status != TicketResolved && status != TicketClosed
+}
@@ -0,0 +1,25 @@
+package models
+
+type TrackingEventType string
+
+const (
+	TrackingSnapshot TrackingEventType = "snapshot" // current state of the order, sent when subscribing
+	TrackingLocation TrackingEventType = "location" // rider location ping
+	TrackingStatus   TrackingEventType = "status"   // order status change
+)
+
+// TrackingEvent is streamed to the users following an order delivery
+type TrackingEvent struct {
+	Type     TrackingEventType `json:"type"`
+	OrderID  string            `json:"order_id"`
+	Status   OrderStatuses     `json:"status"`
+	Location *Coordinates      `json:"location,omitempty"`
+	// ETA is the estimated number of seconds until the rider reaches the delivery address
+	ETA int64 `json:"eta,omitempty"`
+	Ts  int64 `json:"ts"`
+} // @name TrackingEvent
+
+// OrderTrackingTopic is the pub/sub topic tracking events of an order are published on
+func OrderTrackingTopic(orderID string) string {
+	return "order_tracking:" + orderID
+}
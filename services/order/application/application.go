@@ -3,16 +3,21 @@ package application
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/greenbone/opensight-golang-libraries/pkg/query"
 	"github.com/leetatech/leeta_backend/pkg"
+	"github.com/leetatech/leeta_backend/pkg/config"
 	"github.com/leetatech/leeta_backend/pkg/encrypto"
 	"github.com/leetatech/leeta_backend/pkg/errs"
 	"github.com/leetatech/leeta_backend/pkg/idgenerator"
 	"github.com/leetatech/leeta_backend/pkg/jwtmiddleware"
 	mailer "github.com/leetatech/leeta_backend/pkg/notification/mailer/aws"
 	"github.com/leetatech/leeta_backend/pkg/otp"
+	"github.com/leetatech/leeta_backend/pkg/pubsub"
 	"github.com/leetatech/leeta_backend/services/models"
 	"github.com/leetatech/leeta_backend/services/order/domain"
+	"github.com/rs/zerolog/log"
+	"github.com/samber/lo"
 	"time"
 )
 
@@ -23,6 +28,10 @@ type orderAppHandler struct {
 	otpGenerator  otp.Generator
 	EmailClient   mailer.Client
 	allRepository pkg.RepositoryManager
+	trackingHub   pubsub.Hub
+	mailerConfig  config.NotificationConfig
+	paymentConfig config.PaymentConfig
+	invoiceConfig config.InvoiceConfig
 }
 
 type Order interface {
@@ -31,6 +40,26 @@ type Order interface {
 	GetCustomerOrdersByStatus(ctx context.Context, request domain.GetCustomerOrdersRequest) ([]domain.Response, error)
 	ListOrders(ctx context.Context, request query.ResultSelector) ([]models.Order, uint64, error)
 	ListOrderStatusHistory(ctx context.Context, orderId string) ([]models.StatusHistory, error)
+	GetCheckout(ctx context.Context, checkoutID string) (*domain.CheckoutResponse, error)
+	AssignRider(ctx context.Context, orderID string, request domain.AssignRiderRequest) (*pkg.DefaultResponse, error)
+	ListRiderJobs(ctx context.Context, statuses []models.OrderStatuses) ([]models.Order, error)
+	PickUpOrder(ctx context.Context, orderID string) (*pkg.DefaultResponse, error)
+	DeliverOrder(ctx context.Context, orderID string, request domain.DeliverOrderRequest) (*pkg.DefaultResponse, error)
+	// HandoverCode returns the code the customer gives the rider to complete a shipped order
+	HandoverCode(ctx context.Context, orderID string) (*domain.HandoverCodeResponse, error)
+	DeliveryPhoto(ctx context.Context, orderID string) (*models.Attachment, error)
+	UpdateRiderLocation(ctx context.Context, orderID string, location models.Coordinates) (*models.TrackingEvent, error)
+	TrackOrder(ctx context.Context, orderID string) (*models.TrackingEvent, *pubsub.Subscription, error)
+	VendorInbox(ctx context.Context, request query.ResultSelector) ([]models.Order, uint64, error)
+	VendorInboxCounts(ctx context.Context) ([]domain.StatusCount, error)
+	AcceptOrder(ctx context.Context, orderID string) (*pkg.DefaultResponse, error)
+	RejectOrder(ctx context.Context, orderID string, request domain.RejectOrderRequest) (*pkg.DefaultResponse, error)
+	// Invoice returns the invoice of the order and its PDF document
+	Invoice(ctx context.Context, orderID string) (*models.Invoice, []byte, error)
+	// Reorder copies the items of a previous order into the active cart at the current prices
+	Reorder(ctx context.Context, orderID string) (*domain.ReorderResponse, error)
+	// ReturnDeposit records returned cylinders of a new cylinder purchase and refunds their deposit
+	ReturnDeposit(ctx context.Context, orderID string, request domain.ReturnDepositRequest) (*models.DepositReturn, error)
 }
 
 func New(request pkg.ApplicationContext) Order {
@@ -41,6 +70,10 @@ func New(request pkg.ApplicationContext) Order {
 		otpGenerator:  otp.New(),
 		EmailClient:   request.MailClient,
 		allRepository: request.RepositoryManager,
+		trackingHub:   request.TrackingHub,
+		mailerConfig:  request.Config.Notification,
+		paymentConfig: request.Config.Payment,
+		invoiceConfig: request.Config.Invoice,
 	}
 }
 
@@ -54,43 +87,137 @@ func (o *orderAppHandler) UpdateOrderStatus(ctx context.Context, request domain.
 		return nil, errs.Body(errs.InvalidRequestError, errors.New("reason is required"))
 	}
 
-	persistUpdate := domain.PersistOrderUpdate{
-		UpdateStatusRequest: request,
-		StatusHistory: models.StatusHistory{
-			Status:   request.OrderStatus,
-			Reason:   request.Reason,
-			StatusTs: time.Now().Unix(),
-		},
+	status, err := models.SetOrderStatus(request.OrderStatus)
+	if err != nil {
+		return nil, err
 	}
 
-	status, err := models.SetOrderStatus(request.OrderStatus)
+	order, err := o.allRepository.OrderRepository.OrderByID(ctx, request.OrderId)
 	if err != nil {
 		return nil, err
 	}
 
-	if claims.Role == models.VendorCategory || claims.Role == models.AdminCategory {
-		err = o.allRepository.OrderRepository.UpdateStatus(ctx, persistUpdate)
+	if !canAccessOrder(claims, order) {
+		return nil, errs.Body(errs.ErrorUnauthorized, errors.New("you cannot update this order"))
+	}
+
+	// the customer holds the handover code, everyone else completes the order with it
+	var proof *models.DeliveryProof
+	if status == models.OrderCompleted && claims.Role != models.CustomerCategory {
+		proof, err = o.verifyHandover(ctx, claims, order, request.HandoverCode)
 		if err != nil {
-			return nil, errs.Body(errs.DatabaseError, err)
+			return nil, err
 		}
+	}
 
-		return &pkg.DefaultResponse{Success: "success", Message: "Order status updated successfully"}, nil
+	err = o.updateStatus(ctx, claims, order, status, request.Reason, proof)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pkg.DefaultResponse{Success: "success", Message: "Order status updated successfully"}, nil
+}
+
+// updateStatus moves the order to the new status if the user's role allows it, recording who made the change in the status history.
+// Riders also record when they picked up and delivered the order.
+// Orders shipped with a handover code are only completed by their customer or with the proof the code was verified.
+func (o *orderAppHandler) updateStatus(ctx context.Context, claims *jwtmiddleware.UserClaims, order *models.Order, status models.OrderStatuses, reason string, proof *models.DeliveryProof) error {
+	currentStatus := order.CurrentStatus()
+	err := models.ValidateOrderStatusTransition(claims.Role, currentStatus, status)
+	if err != nil {
+		return err
 	}
 
-	if status != models.OrderCancelled {
-		return nil, errs.Body(errs.ErrorUnauthorized, errors.New("you cannot update this status"))
+	if status == models.OrderCompleted && order.HandoverCode != "" && claims.Role != models.CustomerCategory && (proof == nil || !proof.HandoverCodeVerified) {
+		return errs.Body(errs.HandoverCodeError, errors.New("handover code is required to complete the order"))
 	}
 
-	err = o.allRepository.OrderRepository.UpdateStatus(ctx, persistUpdate)
+	now := time.Now().Unix()
+	persistUpdate := domain.PersistOrderUpdate{
+		UpdateStatusRequest: domain.UpdateStatusRequest{
+			OrderId:     order.ID,
+			OrderStatus: status,
+			Reason:      reason,
+		},
+		CurrentStatus: currentStatus,
+		StatusHistory: models.StatusHistory{
+			Status:    status,
+			Reason:    reason,
+			UpdatedBy: claims.UserID,
+			Role:      claims.Role,
+			Proof:     proof,
+			StatusTs:  now,
+		},
+	}
+
+	if status == models.OrderShipped {
+		persistUpdate.HandoverCode = o.otpGenerator.Generate()
+	}
+
+	if claims.Role == models.RiderCategory {
+		delivery := *order.Delivery
+		switch status {
+		case models.OrderShipped:
+			delivery.PickedUpTs = now
+		case models.OrderCompleted:
+			delivery.DeliveredTs = now
+		}
+		persistUpdate.Delivery = &delivery
+	}
+
+	var refund *models.Refund
+	if status == models.OrderCancelled {
+		refund, persistUpdate.CancellationFee, err = o.cancellationRefund(ctx, claims, order, currentStatus, reason, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the refund of a cancelled order is requested together with the cancellation, so a paid order is never cancelled without one.
+	// An order that will not be delivered gives its room in the delivery slot back in the same way.
+	err = o.allRepository.Transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		err := o.allRepository.OrderRepository.UpdateStatus(ctx, persistUpdate)
+		if err != nil {
+			return err
+		}
+
+		if order.DeliverySlot != nil && (status == models.OrderCancelled || status == models.OrderRejected) {
+			err = o.allRepository.SlotRepository.Release(ctx, order.DeliverySlot.ID, 1)
+			if err != nil {
+				return err
+			}
+		}
+
+		if refund == nil {
+			return nil
+		}
+		return o.allRepository.RefundRepository.Create(ctx, *refund)
+	})
 	if err != nil {
-		return nil, errs.Body(errs.DatabaseError, err)
+		return err
 	}
 
-	return &pkg.DefaultResponse{Success: "success", Message: "Order status updated successfully"}, nil
+	switch status {
+	case models.OrderShipped:
+		o.sendHandoverCode(order, persistUpdate.HandoverCode)
+	case models.OrderCancelled:
+		o.notifyCancellation(claims, order, reason, persistUpdate.CancellationFee, refund)
+	case models.OrderCompleted:
+		o.sendCompletionEmail(ctx, order)
+	}
+
+	o.publishTrackingEvent(models.TrackingEvent{
+		Type:    models.TrackingStatus,
+		OrderID: order.ID,
+		Status:  status,
+		Ts:      now,
+	})
+
+	return nil
 }
 
 func (o *orderAppHandler) GetOrderByID(ctx context.Context, id string) (*models.Order, error) {
-	_, err := o.jwtManager.ExtractUserClaims(ctx)
+	claims, err := o.jwtManager.ExtractUserClaims(ctx)
 	if err != nil {
 		return nil, errs.Body(errs.ErrorUnauthorized, err)
 	}
@@ -100,6 +227,10 @@ func (o *orderAppHandler) GetOrderByID(ctx context.Context, id string) (*models.
 		return nil, err
 	}
 
+	if !canAccessOrder(claims, order) {
+		return nil, errs.Body(errs.ErrorUnauthorized, errors.New("you cannot view this order"))
+	}
+
 	return order, nil
 }
 
@@ -123,7 +254,7 @@ func (o *orderAppHandler) ListOrders(ctx context.Context, request query.ResultSe
 		return nil, 0, errs.Body(errs.ErrorUnauthorized, err)
 	}
 
-	orders, totalRecord, err := o.allRepository.OrderRepository.Orders(ctx, request, claims.UserID)
+	orders, totalRecord, err := o.allRepository.OrderRepository.Orders(ctx, request, orderScope(claims))
 	if err != nil {
 		return nil, 0, err
 	}
@@ -131,16 +262,228 @@ func (o *orderAppHandler) ListOrders(ctx context.Context, request query.ResultSe
 	return orders, totalRecord, nil
 }
 
+// orderScope limits the orders a user lists to the ones they placed, fulfil or deliver. Admins list every order.
+func orderScope(claims *jwtmiddleware.UserClaims) domain.OrderScope {
+	switch claims.Role {
+	case models.AdminCategory:
+		return domain.OrderScope{}
+	case models.VendorCategory:
+		return domain.OrderScope{VendorID: claims.UserID}
+	case models.RiderCategory:
+		return domain.OrderScope{RiderID: claims.UserID}
+	default:
+		return domain.OrderScope{CustomerID: claims.UserID}
+	}
+}
+
 func (o *orderAppHandler) ListOrderStatusHistory(ctx context.Context, orderId string) ([]models.StatusHistory, error) {
-	_, err := o.jwtManager.ExtractUserClaims(ctx)
+	order, err := o.GetOrderByID(ctx, orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	return order.StatusHistory, nil
+}
+
+func (o *orderAppHandler) GetCheckout(ctx context.Context, checkoutID string) (*domain.CheckoutResponse, error) {
+	claims, err := o.jwtManager.ExtractUserClaims(ctx)
 	if err != nil {
 		return nil, errs.Body(errs.ErrorUnauthorized, err)
 	}
 
-	statusHistory, err := o.allRepository.OrderRepository.OrderStatusHistory(ctx, orderId)
+	checkout, err := o.allRepository.OrderRepository.CheckoutByID(ctx, checkoutID)
 	if err != nil {
 		return nil, err
 	}
 
-	return statusHistory, nil
+	orders, err := o.allRepository.OrderRepository.OrdersByCheckoutID(ctx, checkoutID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch claims.Role {
+	case models.AdminCategory:
+	case models.VendorCategory:
+		// vendors only see their own part of the basket
+		orders = lo.Filter(orders, func(order models.Order, _ int) bool {
+			return order.VendorID == claims.UserID
+		})
+		if len(orders) == 0 {
+			return nil, errs.Body(errs.ErrorUnauthorized, errors.New("you cannot view this checkout"))
+		}
+	default:
+		if checkout.CustomerID != claims.UserID {
+			return nil, errs.Body(errs.ErrorUnauthorized, errors.New("you cannot view this checkout"))
+		}
+	}
+
+	return &domain.CheckoutResponse{Checkout: *checkout, Orders: orders}, nil
+}
+
+func (o *orderAppHandler) AssignRider(ctx context.Context, orderID string, request domain.AssignRiderRequest) (*pkg.DefaultResponse, error) {
+	claims, err := o.jwtManager.ExtractUserClaims(ctx)
+	if err != nil {
+		return nil, errs.Body(errs.ErrorUnauthorized, err)
+	}
+
+	if claims.Role != models.VendorCategory && claims.Role != models.AdminCategory {
+		return nil, errs.Body(errs.ErrorUnauthorized, errors.New("only vendors and admins can assign riders"))
+	}
+
+	if request.RiderID == "" {
+		return nil, errs.Body(errs.InvalidRequestError, errors.New("rider id is required"))
+	}
+
+	order, err := o.allRepository.OrderRepository.OrderByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !canAccessOrder(claims, order) {
+		return nil, errs.Body(errs.ErrorUnauthorized, errors.New("you cannot assign a rider to this order"))
+	}
+
+	if order.CurrentStatus() != models.OrderApproved {
+		return nil, errs.Body(errs.OrderStatusTransitionError, fmt.Errorf("riders can only be assigned to %s orders, order is %s", models.OrderApproved, order.CurrentStatus()))
+	}
+
+	identity, err := o.allRepository.AuthRepository.IdentityByUserID(ctx, request.RiderID)
+	if err != nil || identity.Role != models.RiderCategory {
+		return nil, errs.Body(errs.InvalidRequestError, fmt.Errorf("user %s is not a rider", request.RiderID))
+	}
+
+	now := time.Now().Unix()
+	delivery := models.Delivery{
+		RiderID:    request.RiderID,
+		AssignedBy: claims.UserID,
+		AssignedTs: now,
+	}
+	history := models.StatusHistory{
+		Status:    models.OrderApproved,
+		Reason:    fmt.Sprintf("rider %s assigned", request.RiderID),
+		UpdatedBy: claims.UserID,
+		Role:      claims.Role,
+		StatusTs:  now,
+	}
+
+	err = o.allRepository.OrderRepository.AssignRider(ctx, orderID, delivery, history)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pkg.DefaultResponse{Success: "success", Message: "Rider assigned successfully"}, nil
+}
+
+func (o *orderAppHandler) ListRiderJobs(ctx context.Context, statuses []models.OrderStatuses) ([]models.Order, error) {
+	claims, err := o.riderClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, status := range statuses {
+		if !models.IsValidOrderStatus(status) {
+			return nil, errs.Body(errs.OrderStatusesError, fmt.Errorf("invalid order status %s", status))
+		}
+	}
+
+	return o.allRepository.OrderRepository.OrdersByRiderID(ctx, claims.UserID, statuses)
+}
+
+func (o *orderAppHandler) PickUpOrder(ctx context.Context, orderID string) (*pkg.DefaultResponse, error) {
+	claims, order, err := o.riderOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = o.updateStatus(ctx, claims, order, models.OrderShipped, "picked up by rider", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pkg.DefaultResponse{Success: "success", Message: "Order picked up"}, nil
+}
+
+// DeliverOrder completes the order once the rider submits the handover code the customer gave them
+func (o *orderAppHandler) DeliverOrder(ctx context.Context, orderID string, request domain.DeliverOrderRequest) (*pkg.DefaultResponse, error) {
+	claims, order, err := o.riderOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Location != nil && !isValidLocation(*request.Location) {
+		return nil, errs.Body(errs.InvalidRequestError, errors.New("invalid delivery location"))
+	}
+
+	proof, err := o.verifyHandover(ctx, claims, order, request.HandoverCode)
+	if err != nil {
+		return nil, err
+	}
+	proof.Location = request.Location
+
+	if request.Photo != nil {
+		proof.PhotoID = order.ID
+	}
+
+	err = o.updateStatus(ctx, claims, order, models.OrderCompleted, "delivered by rider", proof)
+	if err != nil {
+		return nil, err
+	}
+
+	// the photo is only stored once the delivery is completed, so a refused attempt leaves no proof of a delivery behind
+	// and cannot replace the photo of the attempt that completed it
+	if request.Photo != nil {
+		err = o.allRepository.OrderRepository.SaveDeliveryPhoto(ctx, order.ID, *request.Photo)
+		if err != nil {
+			log.Error().Err(err).Msgf("error saving delivery photo of completed order %s", order.ID)
+		}
+	}
+
+	return &pkg.DefaultResponse{Success: "success", Message: "Order delivered"}, nil
+}
+
+// riderOrder returns the order if it is assigned to the signed in rider
+func (o *orderAppHandler) riderOrder(ctx context.Context, orderID string) (*jwtmiddleware.UserClaims, *models.Order, error) {
+	claims, err := o.riderClaims(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	order, err := o.allRepository.OrderRepository.OrderByID(ctx, orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !canAccessOrder(claims, order) {
+		return nil, nil, errs.Body(errs.ErrorUnauthorized, errors.New("order is not assigned to you"))
+	}
+
+	return claims, order, nil
+}
+
+func (o *orderAppHandler) riderClaims(ctx context.Context) (*jwtmiddleware.UserClaims, error) {
+	claims, err := o.jwtManager.ExtractUserClaims(ctx)
+	if err != nil {
+		return nil, errs.Body(errs.ErrorUnauthorized, err)
+	}
+
+	if claims.Role != models.RiderCategory {
+		return nil, errs.Body(errs.ErrorUnauthorized, errors.New("only riders have delivery jobs"))
+	}
+
+	return claims, nil
+}
+
+// canAccessOrder reports whether the user may view or act on the order. Vendors only see the orders they fulfil
+// and riders the orders assigned to them.
+func canAccessOrder(claims *jwtmiddleware.UserClaims, order *models.Order) bool {
+	switch claims.Role {
+	case models.AdminCategory:
+		return true
+	case models.VendorCategory:
+		return order.VendorID == claims.UserID
+	case models.RiderCategory:
+		return order.IsAssignedTo(claims.UserID)
+	default:
+		return order.CustomerID == claims.UserID
+	}
 }
@@ -0,0 +1,119 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/leetatech/leeta_backend/pkg"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/pkg/jwtmiddleware"
+	"github.com/leetatech/leeta_backend/services/models"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/mongo"
+	"time"
+)
+
+// cancellationRefund applies the cancellation policy to the order. It returns the cancellation fee and, when the order
+// was paid online, the refund of what was paid less the fee. Orders that were not paid yet have nothing to refund.
+func (o *orderAppHandler) cancellationRefund(ctx context.Context, claims *jwtmiddleware.UserClaims, order *models.Order, currentStatus models.OrderStatuses, reason string, now int64) (*models.Refund, models.Money, error) {
+	var cancellationFee models.Money
+	if order.PaymentMethod.IsPaidOnDelivery() || order.CheckoutID == "" {
+		return nil, cancellationFee, nil
+	}
+
+	payment, err := o.allRepository.PaymentRepository.ByCheckoutID(ctx, order.CheckoutID)
+	if err != nil {
+		return nil, cancellationFee, err
+	}
+
+	if payment.Status != models.PaymentSuccessful {
+		return nil, cancellationFee, nil
+	}
+
+	if models.IsCancellationCharged(claims.Role, currentStatus) {
+		fee, err := o.allRepository.FeesRepository.ByFeeType(ctx, models.CancellationFee, models.LGA{}, time.Now().Unix())
+		switch {
+		case err == nil:
+			cancellationFee = fee.Cost.CostPerType.Min(order.Total)
+		case !errors.Is(err, mongo.ErrNoDocuments):
+			return nil, cancellationFee, errs.Body(errs.FeesError, fmt.Errorf("error getting cancellation fee: %w", err))
+		}
+	}
+
+	amount := order.Total.Sub(cancellationFee)
+	if !amount.IsPositive() {
+		return nil, cancellationFee, nil
+	}
+
+	return &models.Refund{
+		ID:               o.idGenerator.Generate(),
+		OrderID:          order.ID,
+		CheckoutID:       order.CheckoutID,
+		PaymentID:        payment.ID,
+		PaymentReference: payment.Reference,
+		CustomerID:       order.CustomerID,
+		VendorID:         order.VendorID,
+		OrderTotal:       order.Total,
+		CancellationFee:  cancellationFee,
+		Amount:           amount,
+		Reason:           reason,
+		Status:           models.RefundRequested,
+		StatusHistory: []models.RefundStatusHistory{
+			{
+				Status:    models.RefundRequested,
+				Reason:    reason,
+				UpdatedBy: claims.UserID,
+				StatusTs:  now,
+			},
+		},
+		StatusTs: now,
+		Ts:       now,
+	}, cancellationFee, nil
+}
+
+// notifyCancellation lets the customer and the vendor know the order was cancelled. Failing to notify them does not undo the cancellation.
+func (o *orderAppHandler) notifyCancellation(claims *jwtmiddleware.UserClaims, order *models.Order, reason string, cancellationFee models.Money, refund *models.Refund) {
+	dataMap := map[string]string{
+		"OrderID":     order.ID,
+		"CancelledBy": string(claims.Role),
+		"Reason":      reason,
+	}
+	if refund != nil {
+		dataMap["RefundAmount"] = refund.Amount.String()
+	}
+	if cancellationFee.IsPositive() {
+		dataMap["CancellationFee"] = cancellationFee.String()
+	}
+
+	recipients := map[string]string{}
+	if order.DeliveryDetails.Email != "" {
+		recipients[order.DeliveryDetails.Email] = order.DeliveryDetails.Name
+	}
+	vendor, err := o.allRepository.UserRepository.GetVendorByID(order.VendorID)
+	if err != nil {
+		log.Error().Err(err).Msgf("error getting vendor %s to notify of the cancellation of order %s", order.VendorID, order.ID)
+	} else if vendor.Email.Address != "" {
+		recipients[vendor.Email.Address] = vendor.FirstName
+	}
+
+	for email, name := range recipients {
+		data := make(map[string]string, len(dataMap)+1)
+		for key, value := range dataMap {
+			data[key] = value
+		}
+		data["Name"] = name
+
+		err := o.EmailClient.Send(pkg.OrderCancelledTemplatePath, models.Message{
+			ID:         o.idGenerator.Generate(),
+			TemplateID: pkg.OrderCancelledTemplatePath,
+			Title:      "Order cancelled",
+			Sender:     o.mailerConfig.VerificationEmail,
+			DataMap:    data,
+			Recipients: []string{email},
+			Ts:         time.Now().Unix(),
+		})
+		if err != nil {
+			log.Error().Err(err).Msgf("error notifying %s of the cancellation of order %s", email, order.ID)
+		}
+	}
+}
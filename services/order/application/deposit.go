@@ -0,0 +1,129 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/pkg/jwtmiddleware"
+	"github.com/leetatech/leeta_backend/services/models"
+	"github.com/leetatech/leeta_backend/services/order/domain"
+	"time"
+)
+
+// ReturnDeposit records cylinders of a completed new cylinder purchase the customer brought back. The deposit paid on them
+// is refunded through the payment provider when the order was paid online, and given back in cash otherwise.
+func (o *orderAppHandler) ReturnDeposit(ctx context.Context, orderID string, request domain.ReturnDepositRequest) (*models.DepositReturn, error) {
+	claims, err := o.jwtManager.ExtractUserClaims(ctx)
+	if err != nil {
+		return nil, errs.Body(errs.ErrorUnauthorized, err)
+	}
+
+	if claims.Role != models.VendorCategory && claims.Role != models.AdminCategory {
+		return nil, errs.Body(errs.ErrorUnauthorized, errors.New("only vendors and admins can record returned cylinders"))
+	}
+
+	if request.CartItemID == "" || request.Quantity <= 0 {
+		return nil, errs.Body(errs.InvalidRequestError, errors.New("cart item id and a quantity greater than zero are required"))
+	}
+
+	order, err := o.allRepository.OrderRepository.OrderByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Role == models.VendorCategory && order.VendorID != claims.UserID {
+		return nil, errs.Body(errs.ErrorUnauthorized, errors.New("order belongs to another vendor"))
+	}
+
+	if order.CurrentStatus() != models.OrderCompleted {
+		return nil, errs.Body(errs.InvalidRequestError, fmt.Errorf("cylinders can only be returned on %s orders, order is %s", models.OrderCompleted, order.CurrentStatus()))
+	}
+
+	item, returnable, err := order.ReturnableCylinders(request.CartItemID)
+	if err != nil {
+		return nil, errs.Body(errs.InvalidRequestError, err)
+	}
+
+	if request.Quantity > returnable {
+		return nil, errs.Body(errs.InvalidRequestError, fmt.Errorf("only %d cylinders of item %s are left to return", returnable, item.ID))
+	}
+
+	now := time.Now().Unix()
+	depositReturn := models.DepositReturn{
+		CartItemID: item.ID,
+		Quantity:   request.Quantity,
+		// the deposit is the same on every cylinder of the item, so the share returned is exact
+		Amount:     models.NewMoney(item.Deposit.Amount*int64(request.Quantity)/int64(item.Quantity), item.Deposit.Currency),
+		ReturnedBy: claims.UserID,
+		Ts:         now,
+	}
+
+	refund, err := o.depositRefund(ctx, claims, order, depositReturn.Amount, now)
+	if err != nil {
+		return nil, err
+	}
+	if refund != nil {
+		depositReturn.RefundID = refund.ID
+	}
+
+	// the refund is requested with the return, so a returned cylinder always has its deposit accounted for
+	err = o.allRepository.Transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		err := o.allRepository.OrderRepository.AddDepositReturn(ctx, order.ID, len(order.DepositReturns), depositReturn)
+		if err != nil {
+			return err
+		}
+
+		if refund != nil {
+			return o.allRepository.RefundRepository.Create(ctx, *refund)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &depositReturn, nil
+}
+
+// depositRefund requests the refund of a returned cylinder deposit when the order was paid online. Deposits of orders
+// paid on delivery are given back in cash when the cylinders are returned, so they have no refund.
+func (o *orderAppHandler) depositRefund(ctx context.Context, claims *jwtmiddleware.UserClaims, order *models.Order, amount models.Money, now int64) (*models.Refund, error) {
+	if !amount.IsPositive() || order.PaymentMethod.IsPaidOnDelivery() || order.CheckoutID == "" {
+		return nil, nil
+	}
+
+	payment, err := o.allRepository.PaymentRepository.ByCheckoutID(ctx, order.CheckoutID)
+	if err != nil {
+		return nil, err
+	}
+
+	if payment.Status != models.PaymentSuccessful {
+		return nil, nil
+	}
+
+	reason := "cylinder deposit returned"
+	return &models.Refund{
+		ID:               o.idGenerator.Generate(),
+		OrderID:          order.ID,
+		CheckoutID:       order.CheckoutID,
+		PaymentID:        payment.ID,
+		PaymentReference: payment.Reference,
+		CustomerID:       order.CustomerID,
+		VendorID:         order.VendorID,
+		OrderTotal:       order.Total,
+		Amount:           amount,
+		Reason:           reason,
+		Status:           models.RefundRequested,
+		StatusHistory: []models.RefundStatusHistory{
+			{
+				Status:    models.RefundRequested,
+				Reason:    reason,
+				UpdatedBy: claims.UserID,
+				StatusTs:  now,
+			},
+		},
+		StatusTs: now,
+		Ts:       now,
+	}, nil
+}
@@ -0,0 +1,109 @@
+package application
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"github.com/leetatech/leeta_backend/pkg"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/pkg/jwtmiddleware"
+	"github.com/leetatech/leeta_backend/services/models"
+	"github.com/leetatech/leeta_backend/services/order/domain"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+// maxHandoverAttempts is how many wrong handover codes are accepted before only an admin can complete the order
+const maxHandoverAttempts = 5
+
+// verifyHandover checks the handover code the customer gave for the order, counting wrong codes against the order.
+// Orders shipped before handover codes were issued have none to check.
+func (o *orderAppHandler) verifyHandover(ctx context.Context, claims *jwtmiddleware.UserClaims, order *models.Order, code string) (*models.DeliveryProof, error) {
+	err := models.ValidateOrderStatusTransition(claims.Role, order.CurrentStatus(), models.OrderCompleted)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.HandoverCode == "" {
+		return &models.DeliveryProof{}, nil
+	}
+
+	if code == "" {
+		return nil, errs.Body(errs.HandoverCodeError, errors.New("handover code is required"))
+	}
+
+	if claims.Role != models.AdminCategory && order.HandoverAttempts >= maxHandoverAttempts {
+		return nil, errs.Body(errs.HandoverCodeError, errors.New("too many wrong handover codes, contact support to complete the order"))
+	}
+
+	if subtle.ConstantTimeCompare([]byte(code), []byte(order.HandoverCode)) != 1 {
+		err = o.allRepository.OrderRepository.RecordHandoverAttempt(ctx, order.ID)
+		if err != nil {
+			log.Error().Err(err).Msgf("error recording handover attempt of order %s", order.ID)
+		}
+		return nil, errs.Body(errs.HandoverCodeError, errors.New("handover code does not match"))
+	}
+
+	return &models.DeliveryProof{HandoverCodeVerified: true}, nil
+}
+
+func (o *orderAppHandler) HandoverCode(ctx context.Context, orderID string) (*domain.HandoverCodeResponse, error) {
+	claims, err := o.jwtManager.ExtractUserClaims(ctx)
+	if err != nil {
+		return nil, errs.Body(errs.ErrorUnauthorized, err)
+	}
+
+	if claims.Role != models.CustomerCategory && claims.Role != models.AdminCategory {
+		return nil, errs.Body(errs.ErrorUnauthorized, errors.New("only the customer can view the handover code"))
+	}
+
+	order, err := o.allRepository.OrderRepository.OrderByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !canAccessOrder(claims, order) {
+		return nil, errs.Body(errs.ErrorUnauthorized, errors.New("you cannot view this order"))
+	}
+
+	if order.CurrentStatus() != models.OrderShipped || order.HandoverCode == "" {
+		return nil, errs.Body(errs.InvalidRequestError, errors.New("order has no handover code, it is issued when the order ships"))
+	}
+
+	return &domain.HandoverCodeResponse{HandoverCode: order.HandoverCode}, nil
+}
+
+func (o *orderAppHandler) DeliveryPhoto(ctx context.Context, orderID string) (*models.Attachment, error) {
+	order, err := o.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.allRepository.OrderRepository.DeliveryPhoto(ctx, order.ID)
+}
+
+// sendHandoverCode emails the customer the code to give the rider. Failing to send it does not undo the shipping,
+// the customer can still view the code on the order.
+func (o *orderAppHandler) sendHandoverCode(order *models.Order, code string) {
+	if order.DeliveryDetails.Email == "" {
+		return
+	}
+
+	err := o.EmailClient.Send(pkg.OrderShippedTemplatePath, models.Message{
+		ID:         o.idGenerator.Generate(),
+		UserID:     order.CustomerID,
+		TemplateID: pkg.OrderShippedTemplatePath,
+		Title:      "Your order is on its way",
+		Sender:     o.mailerConfig.VerificationEmail,
+		DataMap: map[string]string{
+			"Name":         order.DeliveryDetails.Name,
+			"OrderID":      order.ID,
+			"HandoverCode": code,
+		},
+		Recipients: []string{order.DeliveryDetails.Email},
+		Ts:         time.Now().Unix(),
+	})
+	if err != nil {
+		log.Error().Err(err).Msgf("error sending handover code of order %s", order.ID)
+	}
+}
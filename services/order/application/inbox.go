@@ -0,0 +1,85 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query"
+	"github.com/leetatech/leeta_backend/pkg"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/pkg/jwtmiddleware"
+	"github.com/leetatech/leeta_backend/services/models"
+	"github.com/leetatech/leeta_backend/services/order/domain"
+)
+
+// VendorInbox lists the orders of the signed in vendor
+func (o *orderAppHandler) VendorInbox(ctx context.Context, request query.ResultSelector) ([]models.Order, uint64, error) {
+	claims, err := o.vendorClaims(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return o.allRepository.OrderRepository.Orders(ctx, request, domain.OrderScope{VendorID: claims.UserID})
+}
+
+// VendorInboxCounts counts the orders of the signed in vendor in each status
+func (o *orderAppHandler) VendorInboxCounts(ctx context.Context) ([]domain.StatusCount, error) {
+	claims, err := o.vendorClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.allRepository.OrderRepository.CountByStatus(ctx, domain.OrderScope{VendorID: claims.UserID})
+}
+
+func (o *orderAppHandler) AcceptOrder(ctx context.Context, orderID string) (*pkg.DefaultResponse, error) {
+	err := o.vendorUpdateStatus(ctx, orderID, models.OrderApproved, "accepted by vendor")
+	if err != nil {
+		return nil, err
+	}
+
+	return &pkg.DefaultResponse{Success: "success", Message: "Order accepted successfully"}, nil
+}
+
+func (o *orderAppHandler) RejectOrder(ctx context.Context, orderID string, request domain.RejectOrderRequest) (*pkg.DefaultResponse, error) {
+	if request.Reason == "" {
+		return nil, errs.Body(errs.InvalidRequestError, errors.New("reason is required"))
+	}
+
+	err := o.vendorUpdateStatus(ctx, orderID, models.OrderRejected, request.Reason)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pkg.DefaultResponse{Success: "success", Message: "Order rejected successfully"}, nil
+}
+
+func (o *orderAppHandler) vendorUpdateStatus(ctx context.Context, orderID string, status models.OrderStatuses, reason string) error {
+	claims, err := o.vendorClaims(ctx)
+	if err != nil {
+		return err
+	}
+
+	order, err := o.allRepository.OrderRepository.OrderByID(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	if !canAccessOrder(claims, order) {
+		return errs.Body(errs.ErrorUnauthorized, errors.New("order does not belong to you"))
+	}
+
+	return o.updateStatus(ctx, claims, order, status, reason, nil)
+}
+
+func (o *orderAppHandler) vendorClaims(ctx context.Context) (*jwtmiddleware.UserClaims, error) {
+	claims, err := o.jwtManager.ExtractUserClaims(ctx)
+	if err != nil {
+		return nil, errs.Body(errs.ErrorUnauthorized, err)
+	}
+
+	if claims.Role != models.VendorCategory {
+		return nil, errs.Body(errs.ErrorUnauthorized, errors.New("only vendors have an order inbox"))
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,301 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"github.com/leetatech/leeta_backend/pkg"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/pkg/pdf"
+	"github.com/leetatech/leeta_backend/services/models"
+	"github.com/rs/zerolog/log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	invoiceMargin     = 50.0
+	invoiceLineHeight = 16.0
+)
+
+func (o *orderAppHandler) Invoice(ctx context.Context, orderID string) (*models.Invoice, []byte, error) {
+	order, err := o.GetOrderByID(ctx, orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if status := order.CurrentStatus(); status != models.OrderCompleted {
+		return nil, nil, errs.Body(errs.InvalidRequestError, fmt.Errorf("order is %s, invoices are only issued for completed orders", status))
+	}
+
+	invoice, err := o.invoice(ctx, order)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	document, err := renderInvoice(invoice)
+	if err != nil {
+		return nil, nil, errs.Body(errs.InternalError, fmt.Errorf("error rendering invoice %s: %w", invoice.Number, err))
+	}
+
+	return invoice, document, nil
+}
+
+// invoice returns the invoice of the order, issuing it with the next invoice number the first time it is requested. The
+// number is stored on the order in the same transaction, so an order is numbered once and later requests reuse its invoice.
+func (o *orderAppHandler) invoice(ctx context.Context, order *models.Order) (*models.Invoice, error) {
+	if order.InvoiceNumber != "" {
+		return o.allRepository.OrderRepository.InvoiceByOrderID(ctx, order.ID)
+	}
+
+	invoice, err := o.buildInvoice(ctx, order)
+	if err != nil {
+		return nil, err
+	}
+
+	err = o.allRepository.Transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		sequence, err := o.allRepository.OrderRepository.NextInvoiceSequence(ctx)
+		if err != nil {
+			return err
+		}
+		invoice.Sequence = sequence
+		invoice.Number = models.InvoiceNumber(sequence)
+
+		err = o.allRepository.OrderRepository.SetInvoiceNumber(ctx, order.ID, invoice.Number)
+		if err != nil {
+			return err
+		}
+
+		return o.allRepository.OrderRepository.CreateInvoice(ctx, *invoice)
+	})
+	if err != nil {
+		// the invoice may have been issued by a concurrent request, which rolled this one back
+		existing, findErr := o.allRepository.OrderRepository.InvoiceByOrderID(ctx, order.ID)
+		if findErr == nil {
+			return existing, nil
+		}
+		return nil, err
+	}
+
+	return invoice, nil
+}
+
+func (o *orderAppHandler) buildInvoice(ctx context.Context, order *models.Order) (*models.Invoice, error) {
+	invoice := &models.Invoice{
+		ID:          o.idGenerator.Generate(),
+		OrderID:     order.ID,
+		CheckoutID:  order.CheckoutID,
+		CustomerID:  order.CustomerID,
+		VendorID:    order.VendorID,
+		BillTo:      order.DeliveryDetails,
+		DeliveryFee: order.DeliveryFee,
+		ServiceFee:  order.ServiceFee,
+		VATRate:     o.invoiceConfig.VATRate,
+		Total:       order.Total,
+		OrderTs:     order.Ts,
+		Ts:          time.Now().Unix(),
+	}
+	if order.Discount != nil {
+		invoice.Discount = order.Discount.Total
+	}
+
+	vendor, err := o.allRepository.UserRepository.GetVendorByID(order.VendorID)
+	if err == nil {
+		invoice.VendorName = strings.TrimSpace(vendor.FirstName + " " + vendor.LastName)
+	}
+
+	for _, item := range order.Orders {
+		// the refundable cylinder deposit of new cylinder purchases is invoiced on its own line
+		gasCost := item.Cost.Sub(item.Deposit)
+		line := models.InvoiceLine{
+			Description: string(item.ProductCategory),
+			Weight:      item.Weight,
+			Quantity:    item.Quantity,
+			Amount:      gasCost,
+		}
+
+		product, err := o.allRepository.ProductRepository.Product(ctx, item.ProductID)
+		if err == nil && product.Name != "" {
+			line.Description = product.Name
+		}
+
+		units := float64(item.Quantity)
+		if item.Weight > 0 {
+			units *= item.Weight
+		}
+		if units > 0 {
+			line.UnitPrice = gasCost.MulRate(1 / units)
+		}
+
+		invoice.Lines = append(invoice.Lines, line)
+		if item.Deposit.IsPositive() {
+			invoice.Lines = append(invoice.Lines, models.InvoiceLine{
+				Description: line.Description + " cylinder deposit",
+				Quantity:    item.Quantity,
+				UnitPrice:   models.NewMoney(item.Deposit.Amount/int64(item.Quantity), item.Deposit.Currency),
+				Amount:      item.Deposit,
+			})
+		}
+		invoice.ItemsTotal = invoice.ItemsTotal.Add(item.Cost)
+	}
+
+	// order amounts include VAT, so it is the part of the total above the net amount
+	invoice.VAT = invoice.Total.MulRate(invoice.VATRate / (100 + invoice.VATRate))
+	invoice.Subtotal = invoice.Total.Sub(invoice.VAT)
+
+	return invoice, nil
+}
+
+// sendCompletionEmail sends the customer the invoice of a delivered order. Failing to send it does not undo the completion.
+func (o *orderAppHandler) sendCompletionEmail(ctx context.Context, order *models.Order) {
+	if order.DeliveryDetails.Email == "" {
+		return
+	}
+
+	invoice, err := o.invoice(ctx, order)
+	if err != nil {
+		log.Error().Err(err).Msgf("error issuing invoice of order %s", order.ID)
+		return
+	}
+
+	document, err := renderInvoice(invoice)
+	if err != nil {
+		log.Error().Err(err).Msgf("error rendering invoice %s", invoice.Number)
+		return
+	}
+
+	err = o.EmailClient.Send(pkg.OrderCompletedTemplatePath, models.Message{
+		ID:         o.idGenerator.Generate(),
+		UserID:     order.CustomerID,
+		TemplateID: pkg.OrderCompletedTemplatePath,
+		Title:      fmt.Sprintf("Your Leeta invoice %s", invoice.Number),
+		Sender:     o.mailerConfig.VerificationEmail,
+		DataMap: map[string]string{
+			"Name":          order.DeliveryDetails.Name,
+			"OrderID":       order.ID,
+			"InvoiceNumber": invoice.Number,
+			"Total":         invoice.Total.String(),
+		},
+		Recipients: []string{order.DeliveryDetails.Email},
+		Attachments: []models.Attachment{
+			{
+				Filename:    InvoiceFilename(invoice),
+				ContentType: "application/pdf",
+				Data:        document,
+			},
+		},
+		Ts: time.Now().Unix(),
+	})
+	if err != nil {
+		log.Error().Err(err).Msgf("error sending invoice %s of order %s", invoice.Number, order.ID)
+	}
+}
+
+// InvoiceFilename is the name the invoice document is downloaded and attached as
+func InvoiceFilename(invoice *models.Invoice) string {
+	return invoice.Number + ".pdf"
+}
+
+func renderInvoice(invoice *models.Invoice) ([]byte, error) {
+	doc := pdf.New()
+	right := pdf.PageWidth - invoiceMargin
+	y := pdf.PageHeight - invoiceMargin - 10
+
+	doc.Text(invoiceMargin, y, 22, true, "INVOICE")
+	doc.TextRight(right, y, 12, true, "Leeta Technologies")
+	y -= 2 * invoiceLineHeight
+
+	for _, field := range [][2]string{
+		{"Invoice number", invoice.Number},
+		{"Invoice date", time.Unix(invoice.Ts, 0).Format("2 January 2006")},
+		{"Order", invoice.OrderID},
+		{"Order date", time.Unix(invoice.OrderTs, 0).Format("2 January 2006")},
+		{"Vendor", invoice.VendorName},
+	} {
+		if field[1] == "" {
+			continue
+		}
+		doc.Text(invoiceMargin, y, 10, true, field[0])
+		doc.Text(invoiceMargin+100, y, 10, false, field[1])
+		y -= invoiceLineHeight
+	}
+	y -= invoiceLineHeight
+
+	doc.Text(invoiceMargin, y, 10, true, "Bill to")
+	y -= invoiceLineHeight
+	address := invoice.BillTo.Address
+	for _, line := range []string{
+		invoice.BillTo.Name,
+		invoice.BillTo.Phone,
+		invoice.BillTo.Email,
+		address.FullAddress,
+		strings.Trim(strings.Join([]string{address.LGA, address.State}, ", "), ", "),
+	} {
+		if line == "" {
+			continue
+		}
+		doc.Text(invoiceMargin, y, 10, false, line)
+		y -= invoiceLineHeight
+	}
+	y -= invoiceLineHeight
+
+	columns := []float64{invoiceMargin, 300, 370, 460, right}
+	tableHeader := func() {
+		doc.Text(columns[0], y, 10, true, "Description")
+		doc.TextRight(columns[1]+40, y, 10, true, "Weight (kg)")
+		doc.TextRight(columns[2]+40, y, 10, true, "Qty")
+		doc.TextRight(columns[3]+30, y, 10, true, "Unit price")
+		doc.TextRight(columns[4], y, 10, true, "Amount")
+		y -= 6
+		doc.Line(invoiceMargin, y, right, y)
+		y -= invoiceLineHeight
+	}
+	tableHeader()
+
+	for _, line := range invoice.Lines {
+		if y < invoiceMargin+8*invoiceLineHeight {
+			doc.AddPage()
+			y = pdf.PageHeight - invoiceMargin
+			tableHeader()
+		}
+
+		weight := ""
+		if line.Weight > 0 {
+			weight = strconv.FormatFloat(line.Weight, 'f', -1, 64)
+		}
+		doc.Text(columns[0], y, 10, false, line.Description)
+		doc.TextRight(columns[1]+40, y, 10, false, weight)
+		doc.TextRight(columns[2]+40, y, 10, false, strconv.Itoa(line.Quantity))
+		doc.TextRight(columns[3]+30, y, 10, false, line.UnitPrice.Digits())
+		doc.TextRight(columns[4], y, 10, false, line.Amount.Digits())
+		y -= invoiceLineHeight
+	}
+
+	y += invoiceLineHeight - 6
+	doc.Line(invoiceMargin, y, right, y)
+	y -= invoiceLineHeight
+
+	totals := [][2]string{
+		{"Items", invoice.ItemsTotal.String()},
+		{"Delivery fee", invoice.DeliveryFee.String()},
+		{"Service fee", invoice.ServiceFee.String()},
+	}
+	if invoice.Discount.IsPositive() {
+		totals = append(totals, [2]string{"Discount", "-" + invoice.Discount.String()})
+	}
+	totals = append(totals,
+		[2]string{"Subtotal excl. VAT", invoice.Subtotal.String()},
+		[2]string{fmt.Sprintf("VAT (%s%%)", strconv.FormatFloat(invoice.VATRate, 'f', -1, 64)), invoice.VAT.String()},
+	)
+	for _, total := range totals {
+		doc.Text(columns[2], y, 10, false, total[0])
+		doc.TextRight(right, y, 10, false, total[1])
+		y -= invoiceLineHeight
+	}
+	doc.Text(columns[2], y, 12, true, "Total")
+	doc.TextRight(right, y, 12, true, invoice.Total.String())
+
+	doc.Text(invoiceMargin, invoiceMargin, 8, false, "All amounts include VAT. Thank you for ordering with Leeta.")
+
+	return doc.Bytes()
+}
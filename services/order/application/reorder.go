@@ -0,0 +1,178 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/pkg/jwtmiddleware"
+	"github.com/leetatech/leeta_backend/services/models"
+	"github.com/leetatech/leeta_backend/services/order/domain"
+	"go.mongodb.org/mongo-driver/mongo"
+	"time"
+)
+
+// Reorder copies the items of a previous order into the customer's active cart, priced at the current product fees.
+// Items whose product is gone, out of stock or has no active fee are reported back instead of failing the reorder,
+// and items already in the cart are combined with the reordered ones.
+func (o *orderAppHandler) Reorder(ctx context.Context, orderID string) (*domain.ReorderResponse, error) {
+	claims, err := o.jwtManager.ExtractUserClaims(ctx)
+	if err != nil {
+		return nil, errs.Body(errs.ErrorUnauthorized, err)
+	}
+
+	order, err := o.allRepository.OrderRepository.OrderByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.CustomerID != claims.UserID {
+		return nil, errs.Body(errs.ErrorUnauthorized, errors.New("only the customer of the order can reorder it"))
+	}
+
+	now := time.Now().Unix()
+	isNewCart := false
+	cart, err := o.reorderCart(ctx, claims)
+	if err != nil {
+		if !errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errs.Body(errs.DatabaseError, fmt.Errorf("error getting active cart: %w", err))
+		}
+		isNewCart = true
+		cart = models.Cart{
+			ID:         o.idGenerator.Generate(),
+			CustomerID: claims.UserID,
+			Status:     models.CartActive,
+			Ts:         now,
+		}
+		if claims.Role == models.GuestCategory {
+			cart.DeviceID = claims.DeviceID
+		}
+	}
+
+	response := &domain.ReorderResponse{
+		Added:   []models.CartItem{},
+		Skipped: []domain.ReorderSkippedItem{},
+	}
+	for _, orderItem := range order.Orders {
+		reason, err := o.reorderItem(ctx, &cart, orderItem)
+		if err != nil {
+			return nil, err
+		}
+
+		if reason != "" {
+			response.Skipped = append(response.Skipped, domain.ReorderSkippedItem{
+				ProductID: orderItem.ProductID,
+				Weight:    orderItem.Weight,
+				Quantity:  orderItem.Quantity,
+				Reason:    reason,
+			})
+			continue
+		}
+		response.Added = append(response.Added, orderItem)
+	}
+
+	if len(response.Added) > 0 {
+		cart.Total = cart.CalculateCartTotalFee()
+		cart.StatusTs = now
+
+		if isNewCart {
+			err = o.allRepository.CartRepository.AddToCart(ctx, cart)
+		} else {
+			err = o.allRepository.CartRepository.UpdateCart(ctx, cart)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the added items are reported as they are in the cart, with their current price
+	for i, added := range response.Added {
+		for _, item := range cart.CartItems {
+			if item.SameItem(added) {
+				response.Added[i] = item
+				break
+			}
+		}
+	}
+	response.Cart = cart
+
+	return response, nil
+}
+
+// reorderCart returns the active cart items are reordered into. Guests reorder into the cart of their device.
+func (o *orderAppHandler) reorderCart(ctx context.Context, claims *jwtmiddleware.UserClaims) (models.Cart, error) {
+	if claims.Role == models.GuestCategory {
+		return o.allRepository.CartRepository.GetCartByDeviceID(ctx, claims.DeviceID)
+	}
+
+	return o.allRepository.CartRepository.GetActiveCartByCustomerID(ctx, claims.UserID)
+}
+
+// reorderItem adds an item of a previous order to the cart at the current product fee, or returns why it cannot be bought anymore
+func (o *orderAppHandler) reorderItem(ctx context.Context, cart *models.Cart, orderItem models.CartItem) (domain.ReorderSkipReason, error) {
+	product, err := o.allRepository.ProductRepository.Product(ctx, orderItem.ProductID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return domain.ReorderProductNotFound, nil
+		}
+		return "", errs.Body(errs.DatabaseError, fmt.Errorf("error getting product %s: %w", orderItem.ProductID, err))
+	}
+
+	if product.Status == models.OutOfStock {
+		return domain.ReorderOutOfStock, nil
+	}
+
+	fee, err := o.allRepository.FeesRepository.ByProductID(ctx, product.ID, models.ProductFee, time.Now().Unix())
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return domain.ReorderNoActiveFee, nil
+		}
+		return "", errs.Body(errs.FeesError, fmt.Errorf("error getting fee of product %s: %w", product.ID, err))
+	}
+
+	var depositFee *models.Fee
+	if orderItem.IsNewCylinder() {
+		depositFee, err = o.allRepository.FeesRepository.ByProductID(ctx, product.ID, models.DepositFee, time.Now().Unix())
+		if err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				return domain.ReorderNoActiveFee, nil
+			}
+			return "", errs.Body(errs.FeesError, fmt.Errorf("error getting cylinder deposit fee of product %s: %w", product.ID, err))
+		}
+	}
+
+	index := -1
+	for i, item := range cart.CartItems {
+		if item.SameItem(orderItem) {
+			index = i
+			break
+		}
+	}
+
+	item := models.CartItem{
+		ID:              o.idGenerator.Generate(),
+		ProductID:       product.ID,
+		ProductCategory: product.ParentCategory,
+		VendorID:        product.VendorID,
+		Weight:          orderItem.Weight,
+		Quantity:        orderItem.Quantity,
+		Mode:            orderItem.Mode,
+	}
+	if index >= 0 {
+		item = cart.CartItems[index]
+		item.Quantity += orderItem.Quantity
+	}
+
+	item.Cost, err = item.CalculateCartItemFee(fee, depositFee)
+	if err != nil {
+		return "", errs.Body(errs.FeesError, fmt.Errorf("error pricing reordered product %s: %w", product.ID, err))
+	}
+
+	if index >= 0 {
+		cart.CartItems[index] = item
+	} else {
+		cart.CartItems = append(cart.CartItems, item)
+	}
+
+	return "", nil
+}
@@ -0,0 +1,120 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/pkg/helpers"
+	"github.com/leetatech/leeta_backend/pkg/pubsub"
+	"github.com/leetatech/leeta_backend/services/models"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+// riderAverageSpeedKmh is used to estimate how long a rider takes to reach the delivery address
+const riderAverageSpeedKmh = 25.0
+
+func (o *orderAppHandler) UpdateRiderLocation(ctx context.Context, orderID string, location models.Coordinates) (*models.TrackingEvent, error) {
+	claims, err := o.riderClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isValidLocation(location) {
+		return nil, errs.Body(errs.InvalidRequestError, errors.New("invalid coordinates"))
+	}
+
+	order, err := o.allRepository.OrderRepository.OrderByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !canAccessOrder(claims, order) {
+		return nil, errs.Body(errs.ErrorUnauthorized, errors.New("order is not assigned to you"))
+	}
+
+	// the rider is only tracked while the order is on its way, between pickup and delivery
+	if order.CurrentStatus() != models.OrderShipped {
+		return nil, errs.Body(errs.InvalidRequestError, fmt.Errorf("rider location is only tracked on %s orders, order is %s", models.OrderShipped, order.CurrentStatus()))
+	}
+
+	now := time.Now().Unix()
+	err = o.allRepository.OrderRepository.UpdateRiderLocation(ctx, orderID, claims.UserID, location, now)
+	if err != nil {
+		return nil, err
+	}
+
+	event := models.TrackingEvent{
+		Type:     models.TrackingLocation,
+		OrderID:  orderID,
+		Status:   order.CurrentStatus(),
+		Location: &location,
+		ETA:      estimateArrival(location, order.DeliveryDetails.Address.Coordinates),
+		Ts:       now,
+	}
+	o.publishTrackingEvent(event)
+
+	return &event, nil
+}
+
+// TrackOrder returns the current tracking state of the order and subscribes to its tracking events.
+// The caller must close the subscription.
+func (o *orderAppHandler) TrackOrder(ctx context.Context, orderID string) (*models.TrackingEvent, *pubsub.Subscription, error) {
+	claims, err := o.jwtManager.ExtractUserClaims(ctx)
+	if err != nil {
+		return nil, nil, errs.Body(errs.ErrorUnauthorized, err)
+	}
+
+	// subscribe before reading the order, so no event published in between is missed
+	subscription := o.trackingHub.Subscribe(models.OrderTrackingTopic(orderID))
+
+	order, err := o.allRepository.OrderRepository.OrderByID(ctx, orderID)
+	if err != nil {
+		subscription.Close()
+		return nil, nil, err
+	}
+
+	if !canAccessOrder(claims, order) {
+		subscription.Close()
+		return nil, nil, errs.Body(errs.ErrorUnauthorized, errors.New("you cannot track this order"))
+	}
+
+	snapshot := &models.TrackingEvent{
+		Type:    models.TrackingSnapshot,
+		OrderID: order.ID,
+		Status:  order.CurrentStatus(),
+		Ts:      time.Now().Unix(),
+	}
+	if order.Delivery != nil && order.Delivery.Location != nil {
+		snapshot.Location = order.Delivery.Location
+		snapshot.ETA = estimateArrival(*order.Delivery.Location, order.DeliveryDetails.Address.Coordinates)
+	}
+
+	return snapshot, subscription, nil
+}
+
+func (o *orderAppHandler) publishTrackingEvent(event models.TrackingEvent) {
+	message, err := json.Marshal(event)
+	if err != nil {
+		log.Error().Msgf("error encoding tracking event for order %s: %v", event.OrderID, err)
+		return
+	}
+
+	o.trackingHub.Publish(models.OrderTrackingTopic(event.OrderID), message)
+}
+
+// estimateArrival returns the seconds the rider needs to reach the destination, or 0 when the destination has no coordinates
+func estimateArrival(from, to models.Coordinates) int64 {
+	if to == (models.Coordinates{}) {
+		return 0
+	}
+
+	distanceKm := helpers.HaversineDistanceKm(from.Latitude, from.Longitude, to.Latitude, to.Longitude)
+	return int64(distanceKm / riderAverageSpeedKmh * time.Hour.Seconds())
+}
+
+func isValidLocation(location models.Coordinates) bool {
+	return location.Latitude >= -90 && location.Latitude <= 90 && location.Longitude >= -180 && location.Longitude <= 180
+}
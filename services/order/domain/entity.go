@@ -11,9 +11,9 @@ type Response struct {
 	ProductID       string               `json:"product_id" bson:"product_id"`
 	CustomerID      string               `json:"customer_id" bson:"customer_id"`
 	VendorID        string               `json:"vendor_id" bson:"vendor_id"`
-	VAT             float64              `json:"vat" bson:"vat"`
-	DeliveryFee     float64              `json:"delivery_fee" bson:"delivery_fee"`
-	Total           float64              `json:"total" bson:"total"`
+	VAT             models.Money         `json:"vat" bson:"vat"`
+	DeliveryFee     models.Money         `json:"delivery_fee" bson:"delivery_fee"`
+	Total           models.Money         `json:"total" bson:"total"`
 	Status          models.OrderStatuses `json:"status" bson:"status"`
 	StatusTs        int64                `json:"status_ts" bson:"status_ts"`
 	Ts              int64                `json:"ts" bson:"ts"`
@@ -25,13 +25,56 @@ type UpdateStatusRequest struct {
 	OrderId     string               `json:"order_id" bson:"order_id"`
 	OrderStatus models.OrderStatuses `json:"order_status" bson:"order_status"`
 	Reason      string               `json:"reason" bson:"reason"`
+	// HandoverCode is required from vendors, riders and admins to complete a shipped order
+	HandoverCode string `json:"handover_code,omitempty" bson:"-"`
 } // @name UpdateStatusRequest
 
 type PersistOrderUpdate struct {
 	UpdateStatusRequest
+	// CurrentStatus is the status the order is expected to be in; the update is only applied if it still is
+	CurrentStatus models.OrderStatuses `json:"current_status" bson:"current_status"`
 	StatusHistory models.StatusHistory `json:"status_history" bson:"status_history"`
+	// Delivery replaces the order delivery when set. The update is only applied if the order is still assigned to its rider
+	Delivery *models.Delivery `json:"delivery,omitempty" bson:"delivery,omitempty"`
+	// CancellationFee is recorded on the order when it is cancelled for a fee
+	CancellationFee models.Money `json:"cancellation_fee,omitempty" bson:"cancellation_fee,omitempty"`
+	// HandoverCode is recorded on the order when it ships
+	HandoverCode string `json:"-" bson:"-"`
 }
 
+// DeliverOrderRequest completes a shipped order with the handover code the customer gave the rider,
+// and optionally a photo and the location of the delivery
+type DeliverOrderRequest struct {
+	HandoverCode string
+	Location     *models.Coordinates
+	Photo        *models.Attachment
+}
+
+type HandoverCodeResponse struct {
+	HandoverCode string `json:"handover_code"`
+} // @name HandoverCodeResponse
+
+// OrderScope limits orders to those of a customer, a vendor or a rider. An empty scope matches every order.
+type OrderScope struct {
+	CustomerID string
+	VendorID   string
+	RiderID    string
+}
+
+type RejectOrderRequest struct {
+	Reason string `json:"reason" bson:"reason"`
+} // @name RejectOrderRequest
+
+// StatusCount is the number of orders in a status
+type StatusCount struct {
+	Status models.OrderStatuses `json:"status" bson:"_id"`
+	Count  int64                `json:"count" bson:"count"`
+} // @name StatusCount
+
+type AssignRiderRequest struct {
+	RiderID string `json:"rider_id" bson:"rider_id"`
+} // @name AssignRiderRequest
+
 type GetCustomerOrders struct {
 	UserId string `json:"user_id" bson:"user_id"`
 	GetCustomerOrdersRequest
@@ -42,3 +85,38 @@ type GetCustomerOrdersRequest struct {
 	Limit       int64                  `json:"limit" bson:"limit"`
 	Page        int64                  `json:"page" bson:"page"`
 } // @name GetCustomerOrdersRequest
+
+type CheckoutResponse struct {
+	Checkout models.Checkout `json:"checkout"`
+	Orders   []models.Order  `json:"orders"`
+} // @name CheckoutResponse
+
+// ReorderSkipReason tells why an item of a previous order was not added to the cart on reorder
+type ReorderSkipReason string
+
+const (
+	ReorderProductNotFound ReorderSkipReason = "PRODUCT_NOT_FOUND"
+	ReorderOutOfStock      ReorderSkipReason = "OUT_OF_STOCK"
+	ReorderNoActiveFee     ReorderSkipReason = "NO_ACTIVE_FEE"
+)
+
+// ReorderSkippedItem is an item of the previous order that could not be added to the cart
+type ReorderSkippedItem struct {
+	ProductID string            `json:"product_id"`
+	Weight    float64           `json:"weight,omitempty"`
+	Quantity  int               `json:"quantity"`
+	Reason    ReorderSkipReason `json:"reason"`
+} // @name ReorderSkippedItem
+
+type ReorderResponse struct {
+	// Cart is the active cart holding the reordered items at the current prices
+	Cart    models.Cart          `json:"cart"`
+	Added   []models.CartItem    `json:"added"`
+	Skipped []ReorderSkippedItem `json:"skipped"`
+} // @name ReorderResponse
+
+// ReturnDepositRequest records cylinders of a new cylinder purchase the customer returned
+type ReturnDepositRequest struct {
+	CartItemID string `json:"cart_item_id"`
+	Quantity   int    `json:"quantity"`
+} // @name ReturnDepositRequest
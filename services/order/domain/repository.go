@@ -8,9 +8,31 @@ import (
 
 type OrderRepository interface {
 	Create(ctx context.Context, request models.Order) error
+	CreateCheckout(ctx context.Context, checkout models.Checkout, orders []models.Order) error
+	CheckoutByID(ctx context.Context, id string) (*models.Checkout, error)
+	OrdersByCheckoutID(ctx context.Context, checkoutID string) ([]models.Order, error)
 	UpdateStatus(ctx context.Context, request PersistOrderUpdate) error
+	UpdateStatusByCheckoutID(ctx context.Context, checkoutID string, currentStatus models.OrderStatuses, history models.StatusHistory) error
 	OrderByID(ctx context.Context, id string) (*models.Order, error)
 	OrdersByStatus(ctx context.Context, request GetCustomerOrders) ([]Response, error)
-	Orders(ctx context.Context, request query.ResultSelector, userId string) (orders []models.Order, totalResults uint64, err error)
-	OrderStatusHistory(ctx context.Context, orderId string) ([]models.StatusHistory, error)
+	Orders(ctx context.Context, request query.ResultSelector, scope OrderScope) (orders []models.Order, totalResults uint64, err error)
+	CountByStatus(ctx context.Context, scope OrderScope) ([]StatusCount, error)
+	// CountPlacedOrders counts the orders of the customer that were paid for or are paid on delivery and not cancelled or rejected
+	CountPlacedOrders(ctx context.Context, customerID string) (int64, error)
+	AssignRider(ctx context.Context, orderID string, delivery models.Delivery, history models.StatusHistory) error
+	OrdersByRiderID(ctx context.Context, riderID string, statuses []models.OrderStatuses) ([]models.Order, error)
+	UpdateRiderLocation(ctx context.Context, orderID, riderID string, location models.Coordinates, ts int64) error
+	// NextInvoiceSequence reserves the next invoice number. Run it in the transaction that creates the invoice, so numbers have no gaps.
+	NextInvoiceSequence(ctx context.Context) (int64, error)
+	CreateInvoice(ctx context.Context, invoice models.Invoice) error
+	InvoiceByOrderID(ctx context.Context, orderID string) (*models.Invoice, error)
+	// SetInvoiceNumber records the invoice issued for the order. It fails if the order was already invoiced.
+	SetInvoiceNumber(ctx context.Context, orderID, invoiceNumber string) error
+	// RecordHandoverAttempt counts a wrong handover code submitted for the order
+	RecordHandoverAttempt(ctx context.Context, orderID string) error
+	// SaveDeliveryPhoto replaces the delivery photo of the order
+	SaveDeliveryPhoto(ctx context.Context, orderID string, photo models.Attachment) error
+	DeliveryPhoto(ctx context.Context, orderID string) (*models.Attachment, error)
+	// AddDepositReturn records returned cylinders on the order, as long as it still holds the number of returns it was read with
+	AddDepositReturn(ctx context.Context, orderID string, returns int, depositReturn models.DepositReturn) error
 }
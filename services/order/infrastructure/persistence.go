@@ -1,10 +1,12 @@
 package infrastructure
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"github.com/greenbone/opensight-golang-libraries/pkg/query"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query/sorting"
 	"github.com/leetatech/leeta_backend/pkg/database"
 	"github.com/leetatech/leeta_backend/pkg/errs"
 	"github.com/leetatech/leeta_backend/services/models"
@@ -12,7 +14,9 @@ import (
 	"github.com/rs/zerolog/log"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"io"
 	"time"
 )
 
@@ -41,25 +45,124 @@ func (o *orderStoreHandler) Create(ctx context.Context, request models.Order) er
 	return nil
 }
 
+func (o *orderStoreHandler) CreateCheckout(ctx context.Context, checkout models.Checkout, orders []models.Order) error {
+	updatedCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	_, err := o.col(models.CheckoutsCollectionName).InsertOne(updatedCtx, checkout)
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	documents := make([]any, 0, len(orders))
+	for _, order := range orders {
+		documents = append(documents, order)
+	}
+
+	_, err = o.col(models.OrderCollectionName).InsertMany(updatedCtx, documents)
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	return nil
+}
+
+func (o *orderStoreHandler) CheckoutByID(ctx context.Context, id string) (*models.Checkout, error) {
+	checkout := &models.Checkout{}
+	err := o.col(models.CheckoutsCollectionName).FindOne(ctx, bson.M{"id": id}).Decode(checkout)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errs.Body(errs.DatabaseNoRecordError, fmt.Errorf("checkout with id %s not found", id))
+		}
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	return checkout, nil
+}
+
+func (o *orderStoreHandler) OrdersByCheckoutID(ctx context.Context, checkoutID string) ([]models.Order, error) {
+	updatedCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	cursor, err := o.col(models.OrderCollectionName).Find(updatedCtx, bson.M{"checkout_id": checkoutID})
+	if err != nil {
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	orders := make([]models.Order, 0)
+	if err = cursor.All(updatedCtx, &orders); err != nil {
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	return orders, nil
+}
+
 func (o *orderStoreHandler) UpdateStatus(ctx context.Context, request domain.PersistOrderUpdate) error {
 	filter := bson.M{
-		"id": request.OrderId,
+		"id":     request.OrderId,
+		"status": request.CurrentStatus,
+	}
+	if request.CurrentStatus == models.OrderPending {
+		// orders that were never updated have no status field stored yet
+		filter["status"] = bson.M{"$in": []any{models.OrderPending, nil}}
+	}
+
+	set := bson.M{
+		"status":    request.OrderStatus,
+		"reason":    request.Reason,
+		"status_ts": time.Now().Unix(),
+	}
+	if request.Delivery != nil {
+		filter["delivery.rider_id"] = request.Delivery.RiderID
+		set["delivery"] = request.Delivery
+	}
+	if request.CancellationFee.IsPositive() {
+		set["cancellation_fee"] = request.CancellationFee
+	}
+	if request.HandoverCode != "" {
+		set["handover_code"] = request.HandoverCode
+	}
+
+	update := bson.M{
+		"$set": set,
+		"$push": bson.M{
+			"status_history": request.StatusHistory,
+		},
+	}
+
+	result, err := o.col(models.OrderCollectionName).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	if result.MatchedCount == 0 {
+		return errs.Body(errs.OrderStatusConflictError, fmt.Errorf("order %s is no longer %s", request.OrderId, request.CurrentStatus))
+	}
+
+	return nil
+}
+
+func (o *orderStoreHandler) UpdateStatusByCheckoutID(ctx context.Context, checkoutID string, currentStatus models.OrderStatuses, history models.StatusHistory) error {
+	filter := bson.M{
+		"checkout_id": checkoutID,
+		"status":      currentStatus,
 	}
 	update := bson.M{
 		"$set": bson.M{
-			"status":    request.OrderStatus,
-			"reason":    request.Reason,
-			"status_ts": time.Now().Unix(),
+			"status":    history.Status,
+			"reason":    history.Reason,
+			"status_ts": history.StatusTs,
 		},
 		"$push": bson.M{
-			"status_history": request.StatusHistory,
+			"status_history": history,
 		},
 	}
 
-	_, err := o.col(models.OrderCollectionName).UpdateOne(ctx, filter, update)
+	_, err := o.col(models.OrderCollectionName).UpdateMany(ctx, filter, update)
 	if err != nil {
 		return errs.Body(errs.DatabaseError, err)
 	}
+
 	return nil
 }
 
@@ -71,6 +174,9 @@ func (o *orderStoreHandler) OrderByID(ctx context.Context, id string) (*models.O
 
 	err := o.col(models.OrderCollectionName).FindOne(ctx, filter).Decode(order)
 	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errs.Body(errs.DatabaseNoRecordError, fmt.Errorf("order with id %s not found", id))
+		}
 		return nil, errs.Body(errs.DatabaseError, err)
 	}
 
@@ -174,16 +280,17 @@ func makeCustomerPipeline(filter bson.M, limit, page int64) []bson.M {
 	}
 }
 
-func (o *orderStoreHandler) Orders(ctx context.Context, request query.ResultSelector, userId string) (orders []models.Order, totalResults uint64, err error) {
+func (o *orderStoreHandler) Orders(ctx context.Context, request query.ResultSelector, scope domain.OrderScope) (orders []models.Order, totalResults uint64, err error) {
 	updatedCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	filter := bson.M{"customer_id": userId}
+	filter := bson.M{}
 	if request.Filter != nil {
-		userFilter := database.BuildMongoFilterQuery(request.Filter, nil)
-		for key, value := range userFilter {
-			filter[key] = value
-		}
+		filter = database.BuildMongoFilterQuery(request.Filter, nil)
+	}
+	// the scope is applied last, so a filter can never widen it
+	for key, value := range scopeFilter(scope) {
+		filter[key] = value
 	}
 
 	totalRecord, err := o.col(models.OrderCollectionName).CountDocuments(updatedCtx, filter)
@@ -199,6 +306,13 @@ func (o *orderStoreHandler) Orders(ctx context.Context, request query.ResultSele
 
 	limit := int64(request.Paging.PageSize)
 	pagingOptions := options.Find().SetSkip(skip).SetLimit(limit)
+	if request.Sorting != nil && request.Sorting.SortColumn != "" {
+		direction := 1
+		if request.Sorting.SortDirection == sorting.DirectionDescending {
+			direction = -1
+		}
+		pagingOptions.SetSort(bson.D{{Key: request.Sorting.SortColumn, Value: direction}})
+	}
 
 	extraDocumentCursor, err := o.col(models.OrderCollectionName).Find(updatedCtx, filter, options.Find().SetSkip(skip+limit).SetLimit(1))
 	if err != nil {
@@ -223,22 +337,292 @@ func (o *orderStoreHandler) Orders(ctx context.Context, request query.ResultSele
 	return orders, uint64(totalRecord), nil
 }
 
-func (o *orderStoreHandler) OrderStatusHistory(ctx context.Context, orderId string) ([]models.StatusHistory, error) {
+func (o *orderStoreHandler) CountByStatus(ctx context.Context, scope domain.OrderScope) ([]domain.StatusCount, error) {
 	updatedCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: scopeFilter(scope)}},
+		// orders that were never updated have no status field stored yet
+		{{Key: "$group", Value: bson.M{
+			"_id":   bson.M{"$ifNull": bson.A{"$status", models.OrderPending}},
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+
+	cursor, err := o.col(models.OrderCollectionName).Aggregate(updatedCtx, pipeline)
+	if err != nil {
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	counts := make([]domain.StatusCount, 0)
+	if err = cursor.All(updatedCtx, &counts); err != nil {
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	return counts, nil
+}
+
+func (o *orderStoreHandler) CountPlacedOrders(ctx context.Context, customerID string) (int64, error) {
 	filter := bson.M{
-		"id": orderId,
+		"customer_id": customerID,
+		"status":      bson.M{"$nin": bson.A{models.OrderAwaitingPayment, models.OrderCancelled, models.OrderRejected}},
 	}
 
-	order := &models.Order{}
-	err := o.col(models.OrderCollectionName).FindOne(updatedCtx, filter).Decode(order)
+	count, err := o.col(models.OrderCollectionName).CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, errs.Body(errs.DatabaseError, err)
+	}
+
+	return count, nil
+}
+
+func scopeFilter(scope domain.OrderScope) bson.M {
+	filter := bson.M{}
+	if scope.CustomerID != "" {
+		filter["customer_id"] = scope.CustomerID
+	}
+	if scope.VendorID != "" {
+		filter["vendor_id"] = scope.VendorID
+	}
+	if scope.RiderID != "" {
+		filter["delivery.rider_id"] = scope.RiderID
+	}
+	return filter
+}
+
+// AssignRider sets the rider delivering the order. Riders can only be assigned or replaced while the order is approved.
+func (o *orderStoreHandler) AssignRider(ctx context.Context, orderID string, delivery models.Delivery, history models.StatusHistory) error {
+	filter := bson.M{
+		"id":     orderID,
+		"status": models.OrderApproved,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"delivery": delivery,
+		},
+		"$push": bson.M{
+			"status_history": history,
+		},
+	}
+
+	result, err := o.col(models.OrderCollectionName).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	if result.MatchedCount == 0 {
+		return errs.Body(errs.OrderStatusConflictError, fmt.Errorf("order %s is no longer %s", orderID, models.OrderApproved))
+	}
+
+	return nil
+}
+
+func (o *orderStoreHandler) OrdersByRiderID(ctx context.Context, riderID string, statuses []models.OrderStatuses) ([]models.Order, error) {
+	filter := bson.M{"delivery.rider_id": riderID}
+	if len(statuses) > 0 {
+		filter["status"] = bson.M{"$in": statuses}
+	}
+
+	opts := options.Find().SetSort(bson.M{"ts": -1})
+	cursor, err := o.col(models.OrderCollectionName).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	orders := make([]models.Order, 0)
+	err = cursor.All(ctx, &orders)
+	if err != nil {
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	return orders, nil
+}
+
+// UpdateRiderLocation records the location of the rider delivering a shipped order
+func (o *orderStoreHandler) UpdateRiderLocation(ctx context.Context, orderID, riderID string, location models.Coordinates, ts int64) error {
+	filter := bson.M{
+		"id":                orderID,
+		"delivery.rider_id": riderID,
+		"status":            models.OrderShipped,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"delivery.location":    location,
+			"delivery.location_ts": ts,
+		},
+	}
+
+	result, err := o.col(models.OrderCollectionName).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	if result.MatchedCount == 0 {
+		return errs.Body(errs.OrderStatusConflictError, fmt.Errorf("order %s is not being delivered by rider %s", orderID, riderID))
+	}
+
+	return nil
+}
+
+func (o *orderStoreHandler) AddDepositReturn(ctx context.Context, orderID string, returns int, depositReturn models.DepositReturn) error {
+	// the return is only added when no other return was recorded since the order was read, so cylinders cannot be returned twice
+	filter := bson.M{
+		"id": orderID,
+		fmt.Sprintf("deposit_returns.%d", returns): bson.M{"$exists": false},
+	}
+	update := bson.M{"$push": bson.M{"deposit_returns": depositReturn}}
+
+	result, err := o.col(models.OrderCollectionName).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	if result.MatchedCount == 0 {
+		return errs.Body(errs.OrderStatusConflictError, fmt.Errorf("cylinders of order %s were returned meanwhile, try again", orderID))
+	}
+
+	return nil
+}
+
+// EnsureIndexes keeps a single invoice per order
+func EnsureIndexes(ctx context.Context, client *mongo.Client, databaseName string) error {
+	_, err := client.Database(databaseName).Collection(models.InvoicesCollectionName).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "order_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "sequence", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error creating invoice indexes: %w", err)
+	}
+
+	return nil
+}
+
+func (o *orderStoreHandler) NextInvoiceSequence(ctx context.Context) (int64, error) {
+	counter := struct {
+		Sequence int64 `bson:"sequence"`
+	}{}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	err := o.col(models.CountersCollectionName).FindOneAndUpdate(ctx, bson.M{"id": "invoice"}, bson.M{"$inc": bson.M{"sequence": 1}}, opts).Decode(&counter)
+	if err != nil {
+		return 0, errs.Body(errs.DatabaseError, err)
+	}
+
+	return counter.Sequence, nil
+}
+
+func (o *orderStoreHandler) CreateInvoice(ctx context.Context, invoice models.Invoice) error {
+	_, err := o.col(models.InvoicesCollectionName).InsertOne(ctx, invoice)
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	return nil
+}
+
+func (o *orderStoreHandler) InvoiceByOrderID(ctx context.Context, orderID string) (*models.Invoice, error) {
+	invoice := &models.Invoice{}
+	err := o.col(models.InvoicesCollectionName).FindOne(ctx, bson.M{"order_id": orderID}).Decode(invoice)
 	if err != nil {
 		if errors.Is(err, mongo.ErrNoDocuments) {
-			return nil, errs.Body(errs.DatabaseNoRecordError, fmt.Errorf("order with id %s not found", orderId))
+			return nil, errs.Body(errs.DatabaseNoRecordError, fmt.Errorf("invoice of order %s not found", orderID))
+		}
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	return invoice, nil
+}
+
+func (o *orderStoreHandler) SetInvoiceNumber(ctx context.Context, orderID, invoiceNumber string) error {
+	filter := bson.M{
+		"id":             orderID,
+		"invoice_number": bson.M{"$exists": false},
+	}
+
+	result, err := o.col(models.OrderCollectionName).UpdateOne(ctx, filter, bson.M{"$set": bson.M{"invoice_number": invoiceNumber}})
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	if result.MatchedCount == 0 {
+		return errs.Body(errs.InvalidRequestError, fmt.Errorf("order %s is already invoiced", orderID))
+	}
+
+	return nil
+}
+
+func (o *orderStoreHandler) RecordHandoverAttempt(ctx context.Context, orderID string) error {
+	_, err := o.col(models.OrderCollectionName).UpdateOne(ctx, bson.M{"id": orderID}, bson.M{"$inc": bson.M{"handover_attempts": 1}})
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	return nil
+}
+
+func (o *orderStoreHandler) photos() (*gridfs.Bucket, error) {
+	return gridfs.NewBucket(o.client.Database(o.databaseName), options.GridFSBucket().SetName(models.DeliveryPhotosBucketName))
+}
+
+func (o *orderStoreHandler) SaveDeliveryPhoto(ctx context.Context, orderID string, photo models.Attachment) error {
+	bucket, err := o.photos()
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	// a delivery the rider retries replaces the photo of the earlier attempt
+	err = bucket.DeleteContext(ctx, orderID)
+	if err != nil && !errors.Is(err, gridfs.ErrFileNotFound) {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	opts := options.GridFSUpload().SetMetadata(bson.M{"content_type": photo.ContentType})
+	err = bucket.UploadFromStreamWithID(orderID, photo.Filename, bytes.NewReader(photo.Data), opts)
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	return nil
+}
+
+func (o *orderStoreHandler) DeliveryPhoto(ctx context.Context, orderID string) (*models.Attachment, error) {
+	bucket, err := o.photos()
+	if err != nil {
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	download, err := bucket.OpenDownloadStream(orderID)
+	if err != nil {
+		if errors.Is(err, gridfs.ErrFileNotFound) {
+			return nil, errs.Body(errs.DatabaseNoRecordError, fmt.Errorf("delivery photo of order %s not found", orderID))
+		}
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+	defer func() {
+		if err := download.Close(); err != nil {
+			log.Debug().Msgf("error closing delivery photo download %v", err)
 		}
+	}()
+
+	data, err := io.ReadAll(download)
+	if err != nil {
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	metadata := struct {
+		ContentType string `bson:"content_type"`
+	}{}
+	file := download.GetFile()
+	if err = bson.Unmarshal(file.Metadata, &metadata); err != nil {
 		return nil, errs.Body(errs.DatabaseError, err)
 	}
 
-	return order.StatusHistory, nil
+	return &models.Attachment{Filename: file.Name, ContentType: metadata.ContentType, Data: data}, nil
 }
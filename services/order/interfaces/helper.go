@@ -0,0 +1,74 @@
+package interfaces
+
+import (
+	"errors"
+	"fmt"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/services/models"
+	"github.com/leetatech/leeta_backend/services/order/domain"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxDeliveryPhotoSize is the largest delivery photo a rider can upload
+const maxDeliveryPhotoSize = 5 << 20
+
+// checkDeliverOrderForm reads the handover code, and the optional photo and location of the delivery, from the multipart form
+func checkDeliverOrderForm(w http.ResponseWriter, r *http.Request) (*domain.DeliverOrderRequest, error) {
+	// leave room for the form fields next to the photo
+	r.Body = http.MaxBytesReader(w, r.Body, maxDeliveryPhotoSize+1<<20)
+	err := r.ParseMultipartForm(maxDeliveryPhotoSize)
+	if err != nil {
+		return nil, errs.Body(errs.FormParseError, errors.New("failed to parse multipart form"))
+	}
+
+	request := domain.DeliverOrderRequest{
+		HandoverCode: strings.TrimSpace(r.FormValue("handover_code")),
+	}
+
+	latitudeStr, longitudeStr := r.FormValue("latitude"), r.FormValue("longitude")
+	if latitudeStr != "" || longitudeStr != "" {
+		latitude, err := strconv.ParseFloat(latitudeStr, 64)
+		if err != nil {
+			return nil, errs.Body(errs.FormParseError, fmt.Errorf("invalid latitude %q", latitudeStr))
+		}
+		longitude, err := strconv.ParseFloat(longitudeStr, 64)
+		if err != nil {
+			return nil, errs.Body(errs.FormParseError, fmt.Errorf("invalid longitude %q", longitudeStr))
+		}
+		request.Location = &models.Coordinates{Latitude: latitude, Longitude: longitude}
+	}
+
+	file, header, err := r.FormFile("photo")
+	if errors.Is(err, http.ErrMissingFile) {
+		return &request, nil
+	}
+	if err != nil {
+		return nil, errs.Body(errs.FormParseError, errors.New("failed to get photo from the request"))
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxDeliveryPhotoSize+1))
+	if err != nil {
+		return nil, errs.Body(errs.FormParseError, errors.New("failed to read photo"))
+	}
+	if len(data) > maxDeliveryPhotoSize {
+		return nil, errs.Body(errs.FormParseError, fmt.Errorf("photo is larger than %d MB", maxDeliveryPhotoSize>>20))
+	}
+
+	// the declared content type of the part is not trusted
+	contentType := http.DetectContentType(data)
+	if !strings.HasPrefix(contentType, "image/") {
+		return nil, errs.Body(errs.FormParseError, fmt.Errorf("photo must be an image, got %s", contentType))
+	}
+
+	request.Photo = &models.Attachment{
+		Filename:    header.Filename,
+		ContentType: contentType,
+		Data:        data,
+	}
+
+	return &request, nil
+}
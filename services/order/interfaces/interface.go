@@ -2,6 +2,8 @@ package interfaces
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/go-chi/chi/v5"
 	"github.com/greenbone/opensight-golang-libraries/pkg/query"
 	"github.com/greenbone/opensight-golang-libraries/pkg/query/filter"
@@ -16,8 +18,12 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/samber/lo"
 	"net/http"
+	"time"
 )
 
+// trackingKeepAliveInterval keeps idle tracking streams from being closed by proxies
+const trackingKeepAliveInterval = 15 * time.Second
+
 type OrderHttpHandler struct {
 	OrderApplication application.Order
 }
@@ -31,7 +37,7 @@ func New(orderApplication application.Order) *OrderHttpHandler {
 
 // UpdateOrderStatusHandler godoc
 // @Summary Update Order Status
-// @Description The endpoint takes the order update request and updates the status of the order
+// @Description The endpoint takes the order update request and updates the status of the order. Cancelling is free until the order is approved, costs the cancellation fee after, and is not allowed once the order has shipped. Cancelling a paid order requests its refund. Vendors and admins complete a shipped order with the handover code the customer was given
 // @Tags Order
 // @Accept json
 // @Produce json
@@ -114,7 +120,7 @@ func (handler *OrderHttpHandler) GetCustomerOrdersByStatusHandler(w http.Respons
 
 // ListOrdersHandler is the endpoint to list all orders
 // @Summary List orders
-// @Description The endpoint to list all orders. List endpoint can be configured with the filters
+// @Description The endpoint to list orders. Customers list the orders they placed, vendors the orders they fulfil, riders the orders assigned to them and admins every order. List endpoint can be configured with the filters
 // @Tags Order
 // @Accept json
 // @produce json
@@ -183,6 +189,505 @@ func (handler *OrderHttpHandler) ListOrderStatusHistoryHandler(w http.ResponseWr
 	jwtmiddleware.WriteJSONResponse(w, statusHistory, http.StatusOK)
 }
 
+// GetCheckoutHandler godoc
+// @Summary Get checkout
+// @Description The endpoint takes the checkout id and returns the checkout with its per-vendor orders. Vendors only receive their own orders
+// @Tags Order
+// @Accept json
+// @produce json
+// @Param			checkout_id	path		string	true	"checkout id"
+// @Security BearerToken
+// @success 200 {object} domain.CheckoutResponse
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Router /order/checkout/{checkout_id} [get]
+func (handler *OrderHttpHandler) GetCheckoutHandler(w http.ResponseWriter, r *http.Request) {
+	checkoutID := chi.URLParam(r, "checkout_id")
+	checkout, err := handler.OrderApplication.GetCheckout(r.Context(), checkoutID)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, checkout, http.StatusOK)
+}
+
+// AssignRiderHandler godoc
+// @Summary Assign rider to order
+// @Description The endpoint assigns the rider who delivers an approved order. Only the order vendor or an admin can assign riders
+// @Tags Order
+// @Accept json
+// @produce json
+// @Param			order_id	path		string	true	"order id"
+// @Param domain.AssignRiderRequest body domain.AssignRiderRequest true "assign rider request body"
+// @Security BearerToken
+// @success 200 {object} pkg.DefaultResponse
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Failure 409 {object} pkg.DefaultErrorResponse
+// @Router /order/{order_id}/rider [put]
+func (handler *OrderHttpHandler) AssignRiderHandler(w http.ResponseWriter, r *http.Request) {
+	var request domain.AssignRiderRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		jwtmiddleware.WriteJSONErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := handler.OrderApplication.AssignRider(r.Context(), chi.URLParam(r, "order_id"), request)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, resp, http.StatusOK)
+}
+
+// ListRiderJobsHandler godoc
+// @Summary List rider jobs
+// @Description The endpoint returns the orders assigned to the signed in rider, optionally filtered by status
+// @Tags Order
+// @Accept json
+// @produce json
+// @Param			status	query		[]string	false	"order statuses"	collectionFormat(multi)
+// @Security BearerToken
+// @success 200 {object} []models.Order
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Router /order/rider/jobs [get]
+func (handler *OrderHttpHandler) ListRiderJobsHandler(w http.ResponseWriter, r *http.Request) {
+	statuses := lo.Map(r.URL.Query()["status"], func(status string, _ int) models.OrderStatuses {
+		return models.OrderStatuses(status)
+	})
+
+	orders, err := handler.OrderApplication.ListRiderJobs(r.Context(), statuses)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, orders, http.StatusOK)
+}
+
+// PickUpOrderHandler godoc
+// @Summary Pick up order
+// @Description The endpoint lets the assigned rider mark an approved order as picked up, which ships the order
+// @Tags Order
+// @Accept json
+// @produce json
+// @Param			order_id	path		string	true	"order id"
+// @Security BearerToken
+// @success 200 {object} pkg.DefaultResponse
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Failure 409 {object} pkg.DefaultErrorResponse
+// @Router /order/rider/jobs/{order_id}/pickup [put]
+func (handler *OrderHttpHandler) PickUpOrderHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := handler.OrderApplication.PickUpOrder(r.Context(), chi.URLParam(r, "order_id"))
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, resp, http.StatusOK)
+}
+
+// DeliverOrderHandler godoc
+// @Summary Deliver order
+// @Description The endpoint lets the assigned rider mark a shipped order as delivered with the handover code the customer gave them, which completes the order.
+// @Description A photo and the location of the delivery can be sent as proof. Riders cannot complete an order after 5 wrong codes.
+// @Tags Order
+// @Accept multipart/form-data
+// @produce json
+// @Param			order_id	path		string	true	"order id"
+// @Param			handover_code	formData	string	true	"handover code given by the customer"
+// @Param			latitude	formData	number	false	"latitude of the delivery"
+// @Param			longitude	formData	number	false	"longitude of the delivery"
+// @Param			photo	formData	file	false	"photo of the delivery, up to 5 MB"
+// @Security BearerToken
+// @success 200 {object} pkg.DefaultResponse
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Failure 409 {object} pkg.DefaultErrorResponse
+// @Router /order/rider/jobs/{order_id}/deliver [put]
+func (handler *OrderHttpHandler) DeliverOrderHandler(w http.ResponseWriter, r *http.Request) {
+	request, err := checkDeliverOrderForm(w, r)
+	if err != nil {
+		jwtmiddleware.WriteJSONErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := handler.OrderApplication.DeliverOrder(r.Context(), chi.URLParam(r, "order_id"), *request)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, resp, http.StatusOK)
+}
+
+// GetHandoverCodeHandler godoc
+// @Summary Get order handover code
+// @Description The endpoint returns the code the customer gives the rider to complete a shipped order. Only the customer of the order and admins can view it
+// @Tags Order
+// @Accept json
+// @produce json
+// @Param			order_id	path		string	true	"order id"
+// @Security BearerToken
+// @success 200 {object} domain.HandoverCodeResponse
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Router /order/{order_id}/handover-code [get]
+func (handler *OrderHttpHandler) GetHandoverCodeHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := handler.OrderApplication.HandoverCode(r.Context(), chi.URLParam(r, "order_id"))
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, resp, http.StatusOK)
+}
+
+// GetDeliveryPhotoHandler godoc
+// @Summary Download order delivery photo
+// @Description The endpoint returns the photo the rider took when delivering the order
+// @Tags Order
+// @Accept json
+// @produce image/jpeg,image/png
+// @Param			order_id	path		string	true	"order id"
+// @Security BearerToken
+// @success 200 {file} binary
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 404 {object} pkg.DefaultErrorResponse
+// @Router /order/{order_id}/delivery-photo [get]
+func (handler *OrderHttpHandler) GetDeliveryPhotoHandler(w http.ResponseWriter, r *http.Request) {
+	photo, err := handler.OrderApplication.DeliveryPhoto(r.Context(), chi.URLParam(r, "order_id"))
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	w.Header().Set("Content-Type", photo.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", photo.Filename))
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(photo.Data); err != nil {
+		log.Debug().Msgf("error writing delivery photo: %v", err)
+	}
+}
+
+// UpdateRiderLocationHandler godoc
+// @Summary Update rider location
+// @Description The endpoint lets the rider delivering a shipped order report their location, which is streamed to the users tracking the order
+// @Tags Order
+// @Accept json
+// @produce json
+// @Param			order_id	path		string	true	"order id"
+// @Param models.Coordinates body models.Coordinates true "rider location"
+// @Security BearerToken
+// @success 200 {object} models.TrackingEvent
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Failure 409 {object} pkg.DefaultErrorResponse
+// @Router /order/rider/jobs/{order_id}/location [post]
+func (handler *OrderHttpHandler) UpdateRiderLocationHandler(w http.ResponseWriter, r *http.Request) {
+	var location models.Coordinates
+	err := json.NewDecoder(r.Body).Decode(&location)
+	if err != nil {
+		jwtmiddleware.WriteJSONErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	event, err := handler.OrderApplication.UpdateRiderLocation(r.Context(), chi.URLParam(r, "order_id"), location)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, event, http.StatusOK)
+}
+
+// TrackOrderHandler godoc
+// @Summary Track order delivery
+// @Description The endpoint streams the order delivery as Server-Sent Events. The first event is a snapshot of the order,
+// @Description followed by rider locations with the estimated time of arrival and status changes. The stream ends once the order is completed, cancelled or rejected
+// @Tags Order
+// @produce text/event-stream
+// @Param			order_id	path		string	true	"order id"
+// @Security BearerToken
+// @success 200 {object} models.TrackingEvent
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 404 {object} pkg.DefaultErrorResponse
+// @Router /order/{order_id}/track [get]
+func (handler *OrderHttpHandler) TrackOrderHandler(w http.ResponseWriter, r *http.Request) {
+	snapshot, subscription, err := handler.OrderApplication.TrackOrder(r.Context(), chi.URLParam(r, "order_id"))
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+	defer subscription.Close()
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		jwtmiddleware.WriteJSONErrorResponse(w, http.StatusInternalServerError, errs.Body(errs.InternalError, errors.New("streaming is not supported")))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	message, err := json.Marshal(snapshot)
+	if err != nil {
+		log.Err(err).Msg("error encoding tracking snapshot")
+		return
+	}
+	writeTrackingEvent(w, snapshot.Type, message)
+	flusher.Flush()
+	if models.IsTerminalOrderStatus(snapshot.Status) {
+		return
+	}
+
+	keepAlive := time.NewTicker(trackingKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			_, _ = fmt.Fprint(w, ": keep-alive\n\n")
+			flusher.Flush()
+		case message, ok := <-subscription.Messages():
+			if !ok {
+				return
+			}
+
+			var event models.TrackingEvent
+			err = json.Unmarshal(message, &event)
+			if err != nil {
+				log.Err(err).Msg("error decoding tracking event")
+				continue
+			}
+
+			writeTrackingEvent(w, event.Type, message)
+			flusher.Flush()
+			if models.IsTerminalOrderStatus(event.Status) {
+				return
+			}
+		}
+	}
+}
+
+func writeTrackingEvent(w http.ResponseWriter, eventType models.TrackingEventType, message []byte) {
+	_, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", eventType, message)
+	if err != nil {
+		log.Err(err).Msg("error writing tracking event")
+	}
+}
+
 func toFilterOption(options filter.RequestOption, _ int) filter.RequestOption {
 	return options
 }
+
+// GetInvoiceHandler godoc
+// @Summary Download order invoice
+// @Description The endpoint returns the PDF invoice of a completed order. The invoice is numbered when the order is completed, or the first time it is requested
+// @Tags Order
+// @Accept json
+// @produce application/pdf
+// @Param			order_id	path		string	true	"order id"
+// @Security BearerToken
+// @success 200 {file} binary
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 404 {object} pkg.DefaultErrorResponse
+// @Router /order/{order_id}/invoice [get]
+func (handler *OrderHttpHandler) GetInvoiceHandler(w http.ResponseWriter, r *http.Request) {
+	invoice, document, err := handler.OrderApplication.Invoice(r.Context(), chi.URLParam(r, "order_id"))
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", application.InvoiceFilename(invoice)))
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(document); err != nil {
+		log.Debug().Msgf("error writing invoice: %v", err)
+	}
+}
+
+// VendorInboxHandler godoc
+// @Summary List vendor order inbox
+// @Description The endpoint lists the orders of the signed in vendor, newest first. Orders can be filtered by status and by the unix time they were placed
+// @Tags Order
+// @Accept json
+// @produce json
+// @param query.ResultSelector body query.ResultSelector true "list vendor orders request body"
+// @Security BearerToken
+// @success 200 {object} query.ResponseListWithMetadata[models.Order]
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Router /order/vendor/inbox [put]
+func (handler *OrderHttpHandler) VendorInboxHandler(w http.ResponseWriter, r *http.Request) {
+	resultSelector, err := web.PrepareResultSelector(r, vendorInboxOptions, vendorInboxSortFields, web.ResultSelectorDefaults(vendorInboxSortingRequest))
+	if err != nil {
+		jwtmiddleware.WriteJSONErrorResponse(w, http.StatusBadRequest, errs.Body(errs.InvalidRequestError, err))
+		return
+	}
+
+	orders, totalRecord, err := handler.OrderApplication.VendorInbox(r.Context(), resultSelector)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	response := query.ResponseListWithMetadata[models.Order]{
+		Metadata: query.NewMetadata(resultSelector, totalRecord),
+		Data:     orders,
+	}
+	jwtmiddleware.WriteJSONResponse(w, response, http.StatusOK)
+}
+
+// VendorInboxOptions godoc
+// @Summary Get vendor order inbox filter options
+// @Description Retrieve the vendor order inbox filter options
+// @Tags Order
+// @Accept json
+// @Produce json
+// @Security BearerToken
+// @Success 200 {object} filter.RequestOption
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Router /order/vendor/inbox/options [get]
+func (handler *OrderHttpHandler) VendorInboxOptions(w http.ResponseWriter, r *http.Request) {
+	requestOptions := lo.Map(vendorInboxOptions, toFilterOption)
+	jwtmiddleware.WriteJSONResponse(w, requestOptions, http.StatusOK)
+}
+
+// VendorInboxCountsHandler godoc
+// @Summary Count vendor orders by status
+// @Description The endpoint returns how many orders of the signed in vendor are in each status
+// @Tags Order
+// @Accept json
+// @produce json
+// @Security BearerToken
+// @success 200 {object} []domain.StatusCount
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Router /order/vendor/inbox/counts [get]
+func (handler *OrderHttpHandler) VendorInboxCountsHandler(w http.ResponseWriter, r *http.Request) {
+	counts, err := handler.OrderApplication.VendorInboxCounts(r.Context())
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, counts, http.StatusOK)
+}
+
+// AcceptOrderHandler godoc
+// @Summary Accept order
+// @Description The endpoint lets the vendor of a pending order accept it, which approves the order
+// @Tags Order
+// @Accept json
+// @produce json
+// @Param			order_id	path		string	true	"order id"
+// @Security BearerToken
+// @success 200 {object} pkg.DefaultResponse
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Failure 409 {object} pkg.DefaultErrorResponse
+// @Router /order/vendor/inbox/{order_id}/accept [put]
+func (handler *OrderHttpHandler) AcceptOrderHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := handler.OrderApplication.AcceptOrder(r.Context(), chi.URLParam(r, "order_id"))
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, resp, http.StatusOK)
+}
+
+// RejectOrderHandler godoc
+// @Summary Reject order
+// @Description The endpoint lets the vendor of a pending order reject it. A reason is required
+// @Tags Order
+// @Accept json
+// @produce json
+// @Param			order_id	path		string	true	"order id"
+// @Param domain.RejectOrderRequest body domain.RejectOrderRequest true "reject order request body"
+// @Security BearerToken
+// @success 200 {object} pkg.DefaultResponse
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Failure 409 {object} pkg.DefaultErrorResponse
+// @Router /order/vendor/inbox/{order_id}/reject [put]
+func (handler *OrderHttpHandler) RejectOrderHandler(w http.ResponseWriter, r *http.Request) {
+	var request domain.RejectOrderRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		jwtmiddleware.WriteJSONErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := handler.OrderApplication.RejectOrder(r.Context(), chi.URLParam(r, "order_id"), request)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, resp, http.StatusOK)
+}
+
+// ReorderHandler godoc
+// @Summary Reorder a previous order
+// @Description The endpoint copies the items of one of the customer's orders into the active cart, priced at the current product fees. Items whose product is gone, out of stock or has no active fee are returned as skipped
+// @Tags Order
+// @Accept json
+// @produce json
+// @Param			order_id	path		string	true	"order id"
+// @Security BearerToken
+// @success 200 {object} domain.ReorderResponse
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 404 {object} pkg.DefaultErrorResponse
+// @Router /order/{order_id}/reorder [post]
+func (handler *OrderHttpHandler) ReorderHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := handler.OrderApplication.Reorder(r.Context(), chi.URLParam(r, "order_id"))
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, resp, http.StatusOK)
+}
+
+// ReturnDepositHandler godoc
+// @Summary Record returned cylinders
+// @Description The endpoint records cylinders of a completed new cylinder purchase the customer returned. The deposit is refunded when the order was paid online, and given back in cash otherwise. Only the order vendor or an admin can record returns
+// @Tags Order
+// @Accept json
+// @produce json
+// @Param			order_id	path		string	true	"order id"
+// @Param domain.ReturnDepositRequest body domain.ReturnDepositRequest true "return deposit request body"
+// @Security BearerToken
+// @success 200 {object} models.DepositReturn
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Failure 409 {object} pkg.DefaultErrorResponse
+// @Router /order/{order_id}/deposit-returns [post]
+func (handler *OrderHttpHandler) ReturnDepositHandler(w http.ResponseWriter, r *http.Request) {
+	var request domain.ReturnDepositRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		jwtmiddleware.WriteJSONErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := handler.OrderApplication.ReturnDeposit(r.Context(), chi.URLParam(r, "order_id"), request)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, resp, http.StatusOK)
+}
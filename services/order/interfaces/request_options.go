@@ -3,6 +3,7 @@ package interfaces
 import (
 	"github.com/greenbone/opensight-golang-libraries/pkg/query/filter"
 	"github.com/greenbone/opensight-golang-libraries/pkg/query/sorting"
+	"github.com/leetatech/leeta_backend/services/models"
 )
 
 var statusRequestName = filter.ReadableValue[string]{
@@ -10,9 +11,16 @@ var statusRequestName = filter.ReadableValue[string]{
 	Value: "status",
 }
 
+var tsRequestName = filter.ReadableValue[string]{
+	Label: "date",
+	Value: "ts",
+}
+
 // LabelIsEqualTo holds filter request options operator labels
 const (
-	LabelIsEqualTo = "is equal to"
+	LabelIsEqualTo    = "is equal to"
+	LabelIsOnOrAfter  = "is on or after"
+	LabelIsOnOrBefore = "is on or before"
 )
 
 var operatorEqual = filter.ReadableValue[filter.CompareOperator]{
@@ -39,3 +47,52 @@ var defaultSortingRequest = &sorting.Request{
 	SortColumn:    "name",
 	SortDirection: sorting.DirectionDescending,
 }
+
+var operatorOnOrAfter = filter.ReadableValue[filter.CompareOperator]{
+	Label: LabelIsOnOrAfter,
+	Value: filter.CompareOperatorIsGreaterThanOrEqualTo,
+}
+
+var operatorOnOrBefore = filter.ReadableValue[filter.CompareOperator]{
+	Label: LabelIsOnOrBefore,
+	Value: filter.CompareOperatorIsLessThanOrEqualTo,
+}
+
+// vendorInboxOptions filter the vendor inbox by status and by the unix time the order was placed
+var vendorInboxOptions = []filter.RequestOption{
+	{
+		Name: statusRequestName,
+		Control: filter.RequestOptionType{
+			Type: filter.ControlTypeEnum,
+		},
+		Operators: []filter.ReadableValue[filter.CompareOperator]{
+			operatorEqual,
+		},
+		Values: []string{
+			string(models.OrderAwaitingPayment),
+			string(models.OrderPending),
+			string(models.OrderApproved),
+			string(models.OrderShipped),
+			string(models.OrderCompleted),
+			string(models.OrderCancelled),
+			string(models.OrderRejected),
+		},
+	},
+	{
+		Name: tsRequestName,
+		Control: filter.RequestOptionType{
+			Type: filter.ControlTypeInteger,
+		},
+		Operators: []filter.ReadableValue[filter.CompareOperator]{
+			operatorOnOrAfter,
+			operatorOnOrBefore,
+		},
+	},
+}
+
+var vendorInboxSortFields = []string{"ts", "status_ts"}
+
+var vendorInboxSortingRequest = &sorting.Request{
+	SortColumn:    "ts",
+	SortDirection: sorting.DirectionDescending,
+}
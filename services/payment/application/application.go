@@ -0,0 +1,158 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/leetatech/leeta_backend/pkg"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/pkg/jwtmiddleware"
+	"github.com/leetatech/leeta_backend/pkg/payment"
+	"github.com/leetatech/leeta_backend/services/models"
+	"net/http"
+	"time"
+)
+
+type paymentAppHandler struct {
+	jwtManager    jwtmiddleware.Manager
+	providers     payment.Providers
+	allRepository pkg.RepositoryManager
+}
+
+type Payment interface {
+	HandleWebhook(ctx context.Context, providerName string, header http.Header, body []byte) (*pkg.DefaultResponse, error)
+	VerifyPayment(ctx context.Context, reference string) (*models.Payment, error)
+}
+
+func New(request pkg.ApplicationContext) Payment {
+	return &paymentAppHandler{
+		jwtManager:    request.JwtManager,
+		providers:     request.PaymentProviders,
+		allRepository: request.RepositoryManager,
+	}
+}
+
+func (p *paymentAppHandler) HandleWebhook(ctx context.Context, providerName string, header http.Header, body []byte) (*pkg.DefaultResponse, error) {
+	provider, err := p.providers.ByName(providerName)
+	if err != nil {
+		return nil, errs.Body(errs.InvalidRequestError, err)
+	}
+
+	event, err := provider.ParseWebhook(header, body)
+	if err != nil {
+		return nil, errs.Body(errs.WebhookSignatureError, err)
+	}
+
+	record, err := p.allRepository.PaymentRepository.ByReference(ctx, event.Reference)
+	if err != nil {
+		return nil, err
+	}
+
+	if record.Provider != provider.Name() {
+		return nil, errs.Body(errs.PaymentError, fmt.Errorf("payment %s was not made through %s", record.Reference, provider.Name()))
+	}
+
+	_, err = p.confirm(ctx, record, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pkg.DefaultResponse{Success: "success", Message: "Webhook processed successfully"}, nil
+}
+
+func (p *paymentAppHandler) VerifyPayment(ctx context.Context, reference string) (*models.Payment, error) {
+	claims, err := p.jwtManager.ExtractUserClaims(ctx)
+	if err != nil {
+		return nil, errs.Body(errs.ErrorUnauthorized, err)
+	}
+
+	record, err := p.allRepository.PaymentRepository.ByReference(ctx, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Role != models.AdminCategory && record.CustomerID != claims.UserID {
+		return nil, errs.Body(errs.ErrorUnauthorized, errors.New("you cannot view this payment"))
+	}
+
+	provider, err := p.providers.ByName(record.Provider)
+	if err != nil {
+		return nil, errs.Body(errs.PaymentError, err)
+	}
+
+	return p.confirm(ctx, record, provider)
+}
+
+// confirm asks the provider for the state of a pending payment rather than trusting the caller,
+// and releases the checkout's orders for processing once the full amount has been paid.
+// When the payment failed the orders are cancelled instead and give their room in the delivery slot back.
+func (p *paymentAppHandler) confirm(ctx context.Context, record *models.Payment, provider payment.PaymentProvider) (*models.Payment, error) {
+	if record.Status != models.PaymentPending {
+		return record, nil
+	}
+
+	verification, err := provider.Verify(ctx, record.Reference)
+	if err != nil {
+		return nil, errs.Body(errs.PaymentError, fmt.Errorf("error verifying payment %s: %w", record.Reference, err))
+	}
+
+	history := models.StatusHistory{StatusTs: time.Now().Unix()}
+	switch verification.Status {
+	case models.PaymentSuccessful:
+		if verification.Amount.Currency != record.Amount.Currency || verification.Amount.Amount < record.Amount.Amount {
+			return nil, errs.Body(errs.PaymentError, fmt.Errorf("amount paid %s is less than the amount due %s", verification.Amount, record.Amount))
+		}
+		history.Status = models.OrderPending
+		history.Reason = "payment confirmed"
+	case models.PaymentFailed:
+		history.Status = models.OrderCancelled
+		history.Reason = "payment failed"
+	default:
+		return record, nil
+	}
+
+	err = p.allRepository.Transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		err := p.allRepository.PaymentRepository.UpdateStatus(ctx, record.ID, models.PaymentPending, verification.Status)
+		if err != nil {
+			return err
+		}
+
+		orders, err := p.allRepository.OrderRepository.OrdersByCheckoutID(ctx, record.CheckoutID)
+		if err != nil {
+			return err
+		}
+
+		err = p.allRepository.OrderRepository.UpdateStatusByCheckoutID(ctx, record.CheckoutID, models.OrderAwaitingPayment, history)
+		if err != nil {
+			return err
+		}
+
+		if history.Status != models.OrderCancelled {
+			return nil
+		}
+
+		// the unpaid checkout gives back the promotion it redeemed, so a limited promotion is not used up by it
+		err = p.allRepository.PromotionRepository.VoidRedemptions(ctx, record.CheckoutID)
+		if err != nil {
+			return err
+		}
+
+		for _, order := range orders {
+			if order.DeliverySlot == nil || order.CurrentStatus() != models.OrderAwaitingPayment {
+				continue
+			}
+
+			err = p.allRepository.SlotRepository.Release(ctx, order.DeliverySlot.ID, 1)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	record.Status = verification.Status
+
+	return record, nil
+}
@@ -0,0 +1,13 @@
+package domain
+
+import (
+	"context"
+	"github.com/leetatech/leeta_backend/services/models"
+)
+
+type PaymentRepository interface {
+	Create(ctx context.Context, request models.Payment) error
+	ByReference(ctx context.Context, reference string) (*models.Payment, error)
+	ByCheckoutID(ctx context.Context, checkoutID string) (*models.Payment, error)
+	UpdateStatus(ctx context.Context, id string, currentStatus, status models.PaymentStatus) error
+}
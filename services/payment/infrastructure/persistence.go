@@ -0,0 +1,75 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/services/models"
+	"github.com/leetatech/leeta_backend/services/payment/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"time"
+)
+
+type paymentStoreHandler struct {
+	client       *mongo.Client
+	databaseName string
+}
+
+func (p *paymentStoreHandler) col(collectionName string) *mongo.Collection {
+	return p.client.Database(p.databaseName).Collection(collectionName)
+}
+
+func New(client *mongo.Client, databaseName string) domain.PaymentRepository {
+	return &paymentStoreHandler{client: client, databaseName: databaseName}
+}
+
+func (p *paymentStoreHandler) Create(ctx context.Context, request models.Payment) error {
+	updatedCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := p.col(models.PaymentsCollectionName).InsertOne(updatedCtx, request)
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	return nil
+}
+
+func (p *paymentStoreHandler) ByReference(ctx context.Context, reference string) (*models.Payment, error) {
+	return p.findOne(ctx, bson.M{"reference": reference})
+}
+
+func (p *paymentStoreHandler) ByCheckoutID(ctx context.Context, checkoutID string) (*models.Payment, error) {
+	return p.findOne(ctx, bson.M{"checkout_id": checkoutID})
+}
+
+func (p *paymentStoreHandler) findOne(ctx context.Context, filter bson.M) (*models.Payment, error) {
+	payment := &models.Payment{}
+	err := p.col(models.PaymentsCollectionName).FindOne(ctx, filter).Decode(payment)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errs.Body(errs.DatabaseNoRecordError, errors.New("payment not found"))
+		}
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	return payment, nil
+}
+
+func (p *paymentStoreHandler) UpdateStatus(ctx context.Context, id string, currentStatus, status models.PaymentStatus) error {
+	filter := bson.M{"id": id, "status": currentStatus}
+	update := bson.M{"$set": bson.M{"status": status, "status_ts": time.Now().Unix()}}
+
+	result, err := p.col(models.PaymentsCollectionName).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	if result.MatchedCount == 0 {
+		return errs.Body(errs.PaymentError, fmt.Errorf("payment %s is no longer %s", id, currentStatus))
+	}
+
+	return nil
+}
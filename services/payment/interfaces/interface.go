@@ -0,0 +1,76 @@
+package interfaces
+
+import (
+	"github.com/go-chi/chi/v5"
+	_ "github.com/leetatech/leeta_backend/pkg"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/pkg/helpers"
+	"github.com/leetatech/leeta_backend/pkg/jwtmiddleware"
+	"github.com/leetatech/leeta_backend/services/payment/application"
+	"github.com/rs/zerolog/log"
+	"io"
+	"net/http"
+)
+
+// maxWebhookBodySize caps the size of webhook payloads read into memory
+const maxWebhookBodySize = 1 << 20
+
+type PaymentHttpHandler struct {
+	PaymentApplication application.Payment
+}
+
+func New(paymentApplication application.Payment) *PaymentHttpHandler {
+	return &PaymentHttpHandler{
+		PaymentApplication: paymentApplication,
+	}
+}
+
+// WebhookHandler godoc
+// @Summary Payment provider webhook
+// @Description The endpoint receives signed payment notifications from the payment provider. Confirmed payments move the checkout orders from AWAITING_PAYMENT to PENDING
+// @Tags Payment
+// @Accept json
+// @Produce json
+// @Param			provider	path		string	true	"payment provider name"
+// @Success 200 {object} pkg.DefaultResponse
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Router /payment/webhook/{provider} [post]
+func (handler *PaymentHttpHandler) WebhookHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodySize))
+	if err != nil {
+		jwtmiddleware.WriteJSONErrorResponse(w, http.StatusBadRequest, errs.Body(errs.InvalidRequestError, err))
+		return
+	}
+
+	resp, err := handler.PaymentApplication.HandleWebhook(r.Context(), chi.URLParam(r, "provider"), r.Header, body)
+	if err != nil {
+		log.Err(err).Msg("error handling payment webhook")
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, resp, http.StatusOK)
+}
+
+// VerifyPaymentHandler godoc
+// @Summary Verify payment
+// @Description The endpoint checks the state of a payment with the payment provider and confirms the checkout orders once it is paid
+// @Tags Payment
+// @Accept json
+// @Produce json
+// @Param			reference	path		string	true	"payment reference"
+// @Security BearerToken
+// @Success 200 {object} models.Payment
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Router /payment/verify/{reference} [get]
+func (handler *PaymentHttpHandler) VerifyPaymentHandler(w http.ResponseWriter, r *http.Request) {
+	payment, err := handler.PaymentApplication.VerifyPayment(r.Context(), chi.URLParam(r, "reference"))
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, payment, http.StatusOK)
+}
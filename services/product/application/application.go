@@ -22,6 +22,7 @@ type productAppHandler struct {
 	otpGenerator  otp.Generator
 	EmailClient   mailer.Client
 	allRepository pkg.RepositoryManager
+	currency      string
 }
 
 type Product interface {
@@ -40,6 +41,7 @@ func New(request pkg.ApplicationContext) Product {
 		otpGenerator:  otp.New(),
 		EmailClient:   request.MailClient,
 		allRepository: request.RepositoryManager,
+		currency:      request.Config.Payment.Currency,
 	}
 }
 
@@ -67,9 +69,12 @@ func (p *productAppHandler) Create(ctx context.Context, request domain.ProductRe
 		}
 
 	}
-	finalPrice := request.OriginalPriceAndVat
+	originalPrice := models.MoneyFromMajor(request.OriginalPrice, p.currency)
+	vat := models.MoneyFromMajor(request.Vat, p.currency)
+	discountPrice := models.MoneyFromMajor(request.DiscountPrice, p.currency)
+	finalPrice := models.MoneyFromMajor(request.OriginalPriceAndVat, p.currency)
 	if request.Discount {
-		finalPrice = (request.OriginalPrice - request.DiscountPrice) + request.Vat
+		finalPrice = originalPrice.Sub(discountPrice).Add(vat)
 	}
 
 	product := models.Product{
@@ -80,11 +85,11 @@ func (p *productAppHandler) Create(ctx context.Context, request domain.ProductRe
 		Name:                request.Name,
 		Weight:              request.Weight,
 		Description:         request.Description,
-		OriginalPrice:       request.OriginalPrice,
-		Vat:                 request.Vat,
-		OriginalPriceAndVat: request.OriginalPriceAndVat,
+		OriginalPrice:       originalPrice,
+		Vat:                 vat,
+		OriginalPriceAndVat: models.MoneyFromMajor(request.OriginalPriceAndVat, p.currency),
 		Discount:            true,
-		DiscountPrice:       request.DiscountPrice,
+		DiscountPrice:       discountPrice,
 		FinalPrice:          finalPrice,
 		Status:              request.Status,
 		StatusTs:            time.Now().Unix(),
@@ -16,6 +16,7 @@ type GetVendorProductsResponse struct {
 	HasNextPage bool             `json:"has_next_page" bson:"has_next_page"`
 }
 
+// ProductRequest creates a product. Prices are given in major units of the payment currency.
 type ProductRequest struct {
 	VendorID            string                    `json:"vendor_id"`
 	ParentCategory      models.ProductCategory    `json:"parent_category,omitempty"`
@@ -0,0 +1,141 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query"
+	"github.com/leetatech/leeta_backend/pkg"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/pkg/idgenerator"
+	"github.com/leetatech/leeta_backend/pkg/jwtmiddleware"
+	"github.com/leetatech/leeta_backend/services/models"
+	"github.com/leetatech/leeta_backend/services/promotion/domain"
+	"strings"
+	"time"
+)
+
+type promotionAppHandler struct {
+	jwtManager    jwtmiddleware.Manager
+	idGenerator   idgenerator.Generator
+	allRepository pkg.RepositoryManager
+	currency      string
+}
+
+// Promotion lets admins manage promotions. Customers apply their codes on the cart.
+type Promotion interface {
+	Create(ctx context.Context, request domain.CreatePromotionRequest) (*models.Promotion, error)
+	Promotion(ctx context.Context, id string) (*models.Promotion, error)
+	List(ctx context.Context, request query.ResultSelector) ([]models.Promotion, uint64, error)
+	// UpdateStatus activates or deactivates a promotion, inactive promotions can no longer be applied or redeemed
+	UpdateStatus(ctx context.Context, id string, request domain.UpdatePromotionStatusRequest) (*models.Promotion, error)
+}
+
+func New(request pkg.ApplicationContext) Promotion {
+	return &promotionAppHandler{
+		jwtManager:    request.JwtManager,
+		idGenerator:   idgenerator.New(),
+		allRepository: request.RepositoryManager,
+		currency:      request.Config.Payment.Currency,
+	}
+}
+
+func (p *promotionAppHandler) Create(ctx context.Context, request domain.CreatePromotionRequest) (*models.Promotion, error) {
+	claims, err := p.adminClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// discounts are taken off amounts in the payment currency
+	amount, err := request.Amount.In(p.currency)
+	if err != nil {
+		return nil, errs.Body(errs.InvalidRequestError, err)
+	}
+	maxDiscount, err := request.MaxDiscount.In(p.currency)
+	if err != nil {
+		return nil, errs.Body(errs.InvalidRequestError, err)
+	}
+
+	now := time.Now().Unix()
+	promotion := models.Promotion{
+		ID:               p.idGenerator.Generate(),
+		Code:             models.NormalizePromoCode(request.Code),
+		Description:      strings.TrimSpace(request.Description),
+		Type:             request.Type,
+		Value:            request.Value,
+		Amount:           amount,
+		MaxDiscount:      maxDiscount,
+		MinWeight:        request.MinWeight,
+		FirstOrderOnly:   request.FirstOrderOnly,
+		Scope:            request.Scope,
+		UsageLimit:       request.UsageLimit,
+		PerCustomerLimit: request.PerCustomerLimit,
+		StartsAt:         request.StartsAt,
+		EndsAt:           request.EndsAt,
+		Status:           models.PromotionActive,
+		CreatedBy:        claims.UserID,
+		StatusTs:         now,
+		Ts:               now,
+	}
+	// lgas are matched the way checkout addresses are, with the state in upper case
+	for i, lga := range promotion.Scope.LGAs {
+		promotion.Scope.LGAs[i].State = strings.ToUpper(lga.State)
+	}
+
+	if err = promotion.Validate(); err != nil {
+		return nil, errs.Body(errs.InvalidRequestError, err)
+	}
+
+	err = p.allRepository.PromotionRepository.Create(ctx, promotion)
+	if err != nil {
+		return nil, err
+	}
+
+	return &promotion, nil
+}
+
+func (p *promotionAppHandler) Promotion(ctx context.Context, id string) (*models.Promotion, error) {
+	if _, err := p.adminClaims(ctx); err != nil {
+		return nil, err
+	}
+
+	return p.allRepository.PromotionRepository.ByID(ctx, id)
+}
+
+func (p *promotionAppHandler) List(ctx context.Context, request query.ResultSelector) ([]models.Promotion, uint64, error) {
+	if _, err := p.adminClaims(ctx); err != nil {
+		return nil, 0, err
+	}
+
+	return p.allRepository.PromotionRepository.Promotions(ctx, request)
+}
+
+func (p *promotionAppHandler) UpdateStatus(ctx context.Context, id string, request domain.UpdatePromotionStatusRequest) (*models.Promotion, error) {
+	if _, err := p.adminClaims(ctx); err != nil {
+		return nil, err
+	}
+
+	if !models.IsValidPromotionStatus(request.Status) {
+		return nil, errs.Body(errs.InvalidRequestError, fmt.Errorf("status must be %s or %s", models.PromotionActive, models.PromotionInactive))
+	}
+
+	err := p.allRepository.PromotionRepository.UpdateStatus(ctx, id, request.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.allRepository.PromotionRepository.ByID(ctx, id)
+}
+
+func (p *promotionAppHandler) adminClaims(ctx context.Context) (*jwtmiddleware.UserClaims, error) {
+	claims, err := p.jwtManager.ExtractUserClaims(ctx)
+	if err != nil {
+		return nil, errs.Body(errs.ErrorUnauthorized, err)
+	}
+
+	if claims.Role != models.AdminCategory {
+		return nil, errs.Body(errs.ErrorUnauthorized, errors.New("only admins can manage promotions"))
+	}
+
+	return claims, nil
+}
@@ -0,0 +1,26 @@
+package domain
+
+import "github.com/leetatech/leeta_backend/services/models"
+
+// CreatePromotionRequest creates a promotion. Percentage promotions take Value percent off the eligible items,
+// fixed amount promotions take Amount off them and free delivery promotions waive the delivery fee. Amounts are in the
+// payment currency.
+type CreatePromotionRequest struct {
+	Code             string                `json:"code"`
+	Description      string                `json:"description"`
+	Type             models.PromotionType  `json:"type"`
+	Value            float64               `json:"value"`
+	Amount           models.Money          `json:"amount"`
+	MaxDiscount      models.Money          `json:"max_discount"`
+	MinWeight        float64               `json:"min_weight"`
+	FirstOrderOnly   bool                  `json:"first_order_only"`
+	Scope            models.PromotionScope `json:"scope"`
+	UsageLimit       int                   `json:"usage_limit"`
+	PerCustomerLimit int                   `json:"per_customer_limit"`
+	StartsAt         int64                 `json:"starts_at"`
+	EndsAt           int64                 `json:"ends_at"`
+} // @name CreatePromotionRequest
+
+type UpdatePromotionStatusRequest struct {
+	Status models.PromotionStatus `json:"status"`
+} // @name UpdatePromotionStatusRequest
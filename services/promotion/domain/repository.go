@@ -0,0 +1,23 @@
+package domain
+
+import (
+	"context"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query"
+	"github.com/leetatech/leeta_backend/services/models"
+)
+
+type PromotionRepository interface {
+	// Create fails with PromotionExistsError when the code is taken
+	Create(ctx context.Context, promotion models.Promotion) error
+	ByID(ctx context.Context, id string) (*models.Promotion, error)
+	ByCode(ctx context.Context, code string) (*models.Promotion, error)
+	Promotions(ctx context.Context, request query.ResultSelector) ([]models.Promotion, uint64, error)
+	UpdateStatus(ctx context.Context, id string, status models.PromotionStatus) error
+	// Redeem counts the redemption towards the usage limit and the per customer limit of the promotion and records it. It
+	// fails with PromotionError when the promotion is no longer active or was used up, or used up by the customer, in the meantime.
+	Redeem(ctx context.Context, redemption models.PromotionRedemption) error
+	// VoidRedemptions removes the redemptions made on a checkout that was never paid and gives their uses back to the promotions
+	VoidRedemptions(ctx context.Context, checkoutID string) error
+	// CustomerRedemptions counts the checkouts the customer redeemed the promotion on
+	CustomerRedemptions(ctx context.Context, promotionID, customerID string) (int64, error)
+}
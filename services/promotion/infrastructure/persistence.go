@@ -0,0 +1,258 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query/sorting"
+	"github.com/leetatech/leeta_backend/pkg/database"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/services/models"
+	"github.com/leetatech/leeta_backend/services/promotion/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type promotionStoreHandler struct {
+	client       *mongo.Client
+	databaseName string
+}
+
+func (p *promotionStoreHandler) col(collectionName string) *mongo.Collection {
+	return p.client.Database(p.databaseName).Collection(collectionName)
+}
+
+func New(client *mongo.Client, databaseName string) domain.PromotionRepository {
+	return &promotionStoreHandler{client: client, databaseName: databaseName}
+}
+
+// EnsureIndexes keeps promo codes unique and the redemptions of a customer quick to count. The sequence of the redemptions
+// of a promotion limited per customer is unique per customer, so concurrent checkouts cannot both take the last one.
+func EnsureIndexes(ctx context.Context, client *mongo.Client, databaseName string) error {
+	_, err := client.Database(databaseName).Collection(models.PromotionsCollectionName).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "code", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("error creating promotion indexes: %w", err)
+	}
+
+	_, err = client.Database(databaseName).Collection(models.PromotionRedemptionsCollectionName).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "promotion_id", Value: 1}, {Key: "customer_id", Value: 1}},
+	})
+	if err != nil {
+		return fmt.Errorf("error creating promotion redemption indexes: %w", err)
+	}
+
+	_, err = client.Database(databaseName).Collection(models.PromotionRedemptionsCollectionName).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "promotion_id", Value: 1}, {Key: "customer_id", Value: 1}, {Key: "sequence", Value: 1}},
+		Options: options.Index().SetUnique(true).
+			SetPartialFilterExpression(bson.M{"sequence": bson.M{"$exists": true}}),
+	})
+	if err != nil {
+		return fmt.Errorf("error creating promotion redemption sequence index: %w", err)
+	}
+
+	return nil
+}
+
+func (p *promotionStoreHandler) Create(ctx context.Context, promotion models.Promotion) error {
+	_, err := p.col(models.PromotionsCollectionName).InsertOne(ctx, promotion)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return errs.Body(errs.PromotionExistsError, fmt.Errorf("promo code %s is already used by another promotion", promotion.Code))
+		}
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	return nil
+}
+
+func (p *promotionStoreHandler) ByID(ctx context.Context, id string) (*models.Promotion, error) {
+	return p.findOne(ctx, bson.M{"id": id}, fmt.Sprintf("promotion with id %s not found", id))
+}
+
+func (p *promotionStoreHandler) ByCode(ctx context.Context, code string) (*models.Promotion, error) {
+	return p.findOne(ctx, bson.M{"code": code}, fmt.Sprintf("promo code %s not found", code))
+}
+
+func (p *promotionStoreHandler) findOne(ctx context.Context, filter bson.M, notFound string) (*models.Promotion, error) {
+	promotion := &models.Promotion{}
+	err := p.col(models.PromotionsCollectionName).FindOne(ctx, filter).Decode(promotion)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errs.Body(errs.DatabaseNoRecordError, errors.New(notFound))
+		}
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	return promotion, nil
+}
+
+func (p *promotionStoreHandler) Promotions(ctx context.Context, request query.ResultSelector) ([]models.Promotion, uint64, error) {
+	updatedCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if request.Filter != nil {
+		filter = database.BuildMongoFilterQuery(request.Filter, nil)
+	}
+
+	totalRecord, err := p.col(models.PromotionsCollectionName).CountDocuments(updatedCtx, filter)
+	if err != nil {
+		return nil, 0, errs.Body(errs.DatabaseError, err)
+	}
+
+	skip := int64(request.Paging.PageSize * request.Paging.PageIndex)
+	if skip < 0 {
+		skip = 0
+	}
+	opts := options.Find().SetSkip(skip).SetLimit(int64(request.Paging.PageSize))
+	if request.Sorting != nil && request.Sorting.SortColumn != "" {
+		direction := 1
+		if request.Sorting.SortDirection == sorting.DirectionDescending {
+			direction = -1
+		}
+		opts.SetSort(bson.D{{Key: request.Sorting.SortColumn, Value: direction}})
+	}
+
+	cursor, err := p.col(models.PromotionsCollectionName).Find(updatedCtx, filter, opts)
+	if err != nil {
+		return nil, 0, errs.Body(errs.DatabaseError, err)
+	}
+
+	promotions := make([]models.Promotion, 0)
+	if err = cursor.All(updatedCtx, &promotions); err != nil {
+		return nil, 0, errs.Body(errs.DatabaseError, err)
+	}
+
+	return promotions, uint64(totalRecord), nil
+}
+
+func (p *promotionStoreHandler) UpdateStatus(ctx context.Context, id string, status models.PromotionStatus) error {
+	update := bson.M{"$set": bson.M{"status": status, "status_ts": time.Now().Unix()}}
+
+	result, err := p.col(models.PromotionsCollectionName).UpdateOne(ctx, bson.M{"id": id}, update)
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	if result.MatchedCount == 0 {
+		return errs.Body(errs.DatabaseNoRecordError, fmt.Errorf("promotion with id %s not found", id))
+	}
+
+	return nil
+}
+
+func (p *promotionStoreHandler) Redeem(ctx context.Context, redemption models.PromotionRedemption) error {
+	// the usage limit is checked in the update, so concurrent checkouts cannot redeem the promotion past it
+	filter := bson.M{
+		"id":     redemption.PromotionID,
+		"status": models.PromotionActive,
+		"$or": bson.A{
+			bson.M{"usage_limit": bson.M{"$exists": false}},
+			bson.M{"$expr": bson.M{"$lt": bson.A{"$usage_count", "$usage_limit"}}},
+		},
+	}
+
+	var promotion models.Promotion
+	err := p.col(models.PromotionsCollectionName).FindOneAndUpdate(ctx, filter, bson.M{"$inc": bson.M{"usage_count": 1}}).Decode(&promotion)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return errs.Body(errs.PromotionError, fmt.Errorf("promo code %s is no longer available", redemption.Code))
+		}
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	// the redemption takes the lowest sequence of the customer still free, which is unique, so concurrent checkouts of the
+	// customer cannot redeem the promotion past its per customer limit. A voided redemption frees its sequence.
+	if promotion.PerCustomerLimit > 0 {
+		redemption.Sequence, err = p.freeSequence(ctx, redemption.PromotionID, redemption.CustomerID, promotion.PerCustomerLimit)
+		if err != nil {
+			return err
+		}
+		if redemption.Sequence == 0 {
+			return errs.Body(errs.PromotionError, fmt.Errorf("you have already used promo code %s", redemption.Code))
+		}
+	}
+
+	_, err = p.col(models.PromotionRedemptionsCollectionName).InsertOne(ctx, redemption)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return errs.Body(errs.PromotionError, fmt.Errorf("you have already used promo code %s", redemption.Code))
+		}
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	return nil
+}
+
+// freeSequence returns the lowest sequence from one to the limit the customer has not redeemed the promotion with, or zero
+// when the customer used all of them
+func (p *promotionStoreHandler) freeSequence(ctx context.Context, promotionID, customerID string, limit int) (int, error) {
+	values, err := p.col(models.PromotionRedemptionsCollectionName).Distinct(ctx, "sequence", bson.M{"promotion_id": promotionID, "customer_id": customerID})
+	if err != nil {
+		return 0, errs.Body(errs.DatabaseError, err)
+	}
+
+	taken := make(map[int64]bool, len(values))
+	for _, value := range values {
+		switch sequence := value.(type) {
+		case int32:
+			taken[int64(sequence)] = true
+		case int64:
+			taken[sequence] = true
+		}
+	}
+
+	for sequence := 1; sequence <= limit; sequence++ {
+		if !taken[int64(sequence)] {
+			return sequence, nil
+		}
+	}
+
+	return 0, nil
+}
+
+func (p *promotionStoreHandler) VoidRedemptions(ctx context.Context, checkoutID string) error {
+	cursor, err := p.col(models.PromotionRedemptionsCollectionName).Find(ctx, bson.M{"checkout_id": checkoutID})
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	var redemptions []models.PromotionRedemption
+	if err = cursor.All(ctx, &redemptions); err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	for _, redemption := range redemptions {
+		result, err := p.col(models.PromotionRedemptionsCollectionName).DeleteOne(ctx, bson.M{"id": redemption.ID})
+		if err != nil {
+			return errs.Body(errs.DatabaseError, err)
+		}
+		// a redemption voided concurrently already gave its use back
+		if result.DeletedCount == 0 {
+			continue
+		}
+
+		filter := bson.M{"id": redemption.PromotionID, "usage_count": bson.M{"$gt": 0}}
+		_, err = p.col(models.PromotionsCollectionName).UpdateOne(ctx, filter, bson.M{"$inc": bson.M{"usage_count": -1}})
+		if err != nil {
+			return errs.Body(errs.DatabaseError, err)
+		}
+	}
+
+	return nil
+}
+
+func (p *promotionStoreHandler) CustomerRedemptions(ctx context.Context, promotionID, customerID string) (int64, error) {
+	count, err := p.col(models.PromotionRedemptionsCollectionName).CountDocuments(ctx, bson.M{"promotion_id": promotionID, "customer_id": customerID})
+	if err != nil {
+		return 0, errs.Body(errs.DatabaseError, err)
+	}
+
+	return count, nil
+}
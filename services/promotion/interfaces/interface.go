@@ -0,0 +1,162 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query/filter"
+	_ "github.com/leetatech/leeta_backend/pkg"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/pkg/helpers"
+	"github.com/leetatech/leeta_backend/pkg/jwtmiddleware"
+	"github.com/leetatech/leeta_backend/services/models"
+	"github.com/leetatech/leeta_backend/services/promotion/application"
+	"github.com/leetatech/leeta_backend/services/promotion/domain"
+	"github.com/leetatech/leeta_backend/services/web"
+	"github.com/samber/lo"
+	"net/http"
+)
+
+type PromotionHttpHandler struct {
+	PromotionApplication application.Promotion
+}
+
+func New(promotionApplication application.Promotion) *PromotionHttpHandler {
+	return &PromotionHttpHandler{
+		PromotionApplication: promotionApplication,
+	}
+}
+
+// CreatePromotionHandler godoc
+// @Summary Create promotion
+// @Description The endpoint lets admins create a promo code. The promotion takes a percentage or a fixed amount off the eligible items, or waives the delivery fee, between its start and end. It can be limited to products, delivery lgas, customer segments and first orders, and to a number of uses overall and per customer
+// @Tags Promotion
+// @Accept json
+// @produce json
+// @Param domain.CreatePromotionRequest body domain.CreatePromotionRequest true "create promotion request body"
+// @Security BearerToken
+// @success 201 {object} models.Promotion
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Failure 409 {object} pkg.DefaultErrorResponse
+// @Router /promotion/ [post]
+func (handler *PromotionHttpHandler) CreatePromotionHandler(w http.ResponseWriter, r *http.Request) {
+	var request domain.CreatePromotionRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		jwtmiddleware.WriteJSONErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := handler.PromotionApplication.Create(r.Context(), request)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, resp, http.StatusCreated)
+}
+
+// GetPromotionHandler godoc
+// @Summary Get promotion
+// @Description The endpoint returns a promotion with the number of times it was redeemed
+// @Tags Promotion
+// @Accept json
+// @produce json
+// @Param			promotion_id	path		string	true	"promotion id"
+// @Security BearerToken
+// @success 200 {object} models.Promotion
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 404 {object} pkg.DefaultErrorResponse
+// @Router /promotion/{promotion_id} [get]
+func (handler *PromotionHttpHandler) GetPromotionHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := handler.PromotionApplication.Promotion(r.Context(), chi.URLParam(r, "promotion_id"))
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, resp, http.StatusOK)
+}
+
+// ListPromotionsHandler godoc
+// @Summary List promotions
+// @Description The endpoint lists promotions. List endpoint can be configured with the filters
+// @Tags Promotion
+// @Accept json
+// @produce json
+// @param query.ResultSelector body query.ResultSelector true "list promotions request body"
+// @Security BearerToken
+// @success 200 {object} query.ResponseListWithMetadata[models.Promotion]
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Router /promotion/ [put]
+func (handler *PromotionHttpHandler) ListPromotionsHandler(w http.ResponseWriter, r *http.Request) {
+	resultSelector, err := web.PrepareResultSelector(r, listPromotionsOptions, promotionSortFields, web.ResultSelectorDefaults(promotionSortingRequest))
+	if err != nil {
+		jwtmiddleware.WriteJSONErrorResponse(w, http.StatusBadRequest, errs.Body(errs.InvalidRequestError, err))
+		return
+	}
+
+	promotions, totalRecord, err := handler.PromotionApplication.List(r.Context(), resultSelector)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	response := query.ResponseListWithMetadata[models.Promotion]{
+		Metadata: query.NewMetadata(resultSelector, totalRecord),
+		Data:     promotions,
+	}
+	jwtmiddleware.WriteJSONResponse(w, response, http.StatusOK)
+}
+
+// ListPromotionsOptions godoc
+// @Summary Get promotions filter options
+// @Description Retrieve promotions filter options
+// @Tags Promotion
+// @Accept json
+// @Produce json
+// @Security BearerToken
+// @Success 200 {object} filter.RequestOption
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Router /promotion/options [get]
+func (handler *PromotionHttpHandler) ListPromotionsOptions(w http.ResponseWriter, r *http.Request) {
+	requestOptions := lo.Map(listPromotionsOptions, toFilterOption)
+	jwtmiddleware.WriteJSONResponse(w, requestOptions, http.StatusOK)
+}
+
+// UpdatePromotionStatusHandler godoc
+// @Summary Update promotion status
+// @Description The endpoint activates or deactivates a promotion. Inactive promo codes can no longer be applied to carts or redeemed at checkout
+// @Tags Promotion
+// @Accept json
+// @produce json
+// @Param			promotion_id	path		string	true	"promotion id"
+// @Param domain.UpdatePromotionStatusRequest body domain.UpdatePromotionStatusRequest true "update promotion status request body"
+// @Security BearerToken
+// @success 200 {object} models.Promotion
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Failure 404 {object} pkg.DefaultErrorResponse
+// @Router /promotion/{promotion_id}/status [put]
+func (handler *PromotionHttpHandler) UpdatePromotionStatusHandler(w http.ResponseWriter, r *http.Request) {
+	var request domain.UpdatePromotionStatusRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		jwtmiddleware.WriteJSONErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := handler.PromotionApplication.UpdateStatus(r.Context(), chi.URLParam(r, "promotion_id"), request)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, resp, http.StatusOK)
+}
+
+func toFilterOption(options filter.RequestOption, _ int) filter.RequestOption {
+	return options
+}
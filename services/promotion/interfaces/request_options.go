@@ -0,0 +1,111 @@
+package interfaces
+
+import (
+	"github.com/greenbone/opensight-golang-libraries/pkg/query/filter"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query/sorting"
+	"github.com/leetatech/leeta_backend/services/models"
+)
+
+// LabelIsEqualTo holds filter request options operator labels
+const (
+	LabelIsEqualTo    = "is equal to"
+	LabelIsOnOrAfter  = "is on or after"
+	LabelIsOnOrBefore = "is on or before"
+)
+
+var operatorEqual = filter.ReadableValue[filter.CompareOperator]{
+	Label: LabelIsEqualTo,
+	Value: filter.CompareOperatorIsEqualTo,
+}
+
+var operatorOnOrAfter = filter.ReadableValue[filter.CompareOperator]{
+	Label: LabelIsOnOrAfter,
+	Value: filter.CompareOperatorIsGreaterThanOrEqualTo,
+}
+
+var operatorOnOrBefore = filter.ReadableValue[filter.CompareOperator]{
+	Label: LabelIsOnOrBefore,
+	Value: filter.CompareOperatorIsLessThanOrEqualTo,
+}
+
+// listPromotionsOptions filter promotions by code, type, status and the unix times they run between
+var listPromotionsOptions = []filter.RequestOption{
+	{
+		Name: filter.ReadableValue[string]{
+			Label: "code",
+			Value: "code",
+		},
+		Control: filter.RequestOptionType{
+			Type: filter.ControlTypeString,
+		},
+		Operators: []filter.ReadableValue[filter.CompareOperator]{
+			operatorEqual,
+		},
+	},
+	{
+		Name: filter.ReadableValue[string]{
+			Label: "type",
+			Value: "type",
+		},
+		Control: filter.RequestOptionType{
+			Type: filter.ControlTypeEnum,
+		},
+		Operators: []filter.ReadableValue[filter.CompareOperator]{
+			operatorEqual,
+		},
+		Values: []string{
+			string(models.PromotionPercentage),
+			string(models.PromotionFixedAmount),
+			string(models.PromotionFreeDelivery),
+		},
+	},
+	{
+		Name: filter.ReadableValue[string]{
+			Label: "status",
+			Value: "status",
+		},
+		Control: filter.RequestOptionType{
+			Type: filter.ControlTypeEnum,
+		},
+		Operators: []filter.ReadableValue[filter.CompareOperator]{
+			operatorEqual,
+		},
+		Values: []string{
+			string(models.PromotionActive),
+			string(models.PromotionInactive),
+		},
+	},
+	{
+		Name: filter.ReadableValue[string]{
+			Label: "starts at",
+			Value: "starts_at",
+		},
+		Control: filter.RequestOptionType{
+			Type: filter.ControlTypeInteger,
+		},
+		Operators: []filter.ReadableValue[filter.CompareOperator]{
+			operatorOnOrAfter,
+			operatorOnOrBefore,
+		},
+	},
+	{
+		Name: filter.ReadableValue[string]{
+			Label: "ends at",
+			Value: "ends_at",
+		},
+		Control: filter.RequestOptionType{
+			Type: filter.ControlTypeInteger,
+		},
+		Operators: []filter.ReadableValue[filter.CompareOperator]{
+			operatorOnOrAfter,
+			operatorOnOrBefore,
+		},
+	},
+}
+
+var promotionSortFields = []string{"ts", "starts_at", "ends_at", "usage_count", "code"}
+
+var promotionSortingRequest = &sorting.Request{
+	SortColumn:    "ts",
+	SortDirection: sorting.DirectionDescending,
+}
@@ -0,0 +1,277 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/leetatech/leeta_backend/pkg"
+	"github.com/leetatech/leeta_backend/pkg/config"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/pkg/idgenerator"
+	"github.com/leetatech/leeta_backend/pkg/jwtmiddleware"
+	mailer "github.com/leetatech/leeta_backend/pkg/notification/mailer/aws"
+	"github.com/leetatech/leeta_backend/pkg/payment"
+	"github.com/leetatech/leeta_backend/services/models"
+	"github.com/leetatech/leeta_backend/services/refund/domain"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+type refundAppHandler struct {
+	jwtManager    jwtmiddleware.Manager
+	idGenerator   idgenerator.Generator
+	EmailClient   mailer.Client
+	allRepository pkg.RepositoryManager
+	providers     payment.Providers
+	mailerConfig  config.NotificationConfig
+}
+
+type Refund interface {
+	Refunds(ctx context.Context, statuses []models.RefundStatus) ([]models.Refund, error)
+	Refund(ctx context.Context, id string) (*models.Refund, error)
+	Approve(ctx context.Context, id string, request domain.ReviewRefundRequest) (*models.Refund, error)
+	Reject(ctx context.Context, id string, request domain.ReviewRefundRequest) (*models.Refund, error)
+	// Pay pays an approved refund back through the provider the order was paid with
+	Pay(ctx context.Context, id string) (*models.Refund, error)
+}
+
+func New(request pkg.ApplicationContext) Refund {
+	return &refundAppHandler{
+		jwtManager:    request.JwtManager,
+		idGenerator:   idgenerator.New(),
+		EmailClient:   request.MailClient,
+		allRepository: request.RepositoryManager,
+		providers:     request.PaymentProviders,
+		mailerConfig:  request.Config.Notification,
+	}
+}
+
+func (r *refundAppHandler) Refunds(ctx context.Context, statuses []models.RefundStatus) ([]models.Refund, error) {
+	claims, err := r.jwtManager.ExtractUserClaims(ctx)
+	if err != nil {
+		return nil, errs.Body(errs.ErrorUnauthorized, err)
+	}
+
+	filter := domain.RefundFilter{Statuses: statuses}
+	switch claims.Role {
+	case models.AdminCategory:
+	case models.VendorCategory:
+		filter.VendorID = claims.UserID
+	case models.CustomerCategory:
+		filter.CustomerID = claims.UserID
+	default:
+		return nil, errs.Body(errs.ErrorUnauthorized, errors.New("you cannot view refunds"))
+	}
+
+	return r.allRepository.RefundRepository.Refunds(ctx, filter)
+}
+
+func (r *refundAppHandler) Refund(ctx context.Context, id string) (*models.Refund, error) {
+	claims, err := r.jwtManager.ExtractUserClaims(ctx)
+	if err != nil {
+		return nil, errs.Body(errs.ErrorUnauthorized, err)
+	}
+
+	refund, err := r.allRepository.RefundRepository.ByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case claims.Role == models.AdminCategory,
+		claims.Role == models.VendorCategory && refund.VendorID == claims.UserID,
+		claims.Role == models.CustomerCategory && refund.CustomerID == claims.UserID:
+		return refund, nil
+	default:
+		return nil, errs.Body(errs.ErrorUnauthorized, errors.New("you cannot view this refund"))
+	}
+}
+
+func (r *refundAppHandler) Approve(ctx context.Context, id string, request domain.ReviewRefundRequest) (*models.Refund, error) {
+	return r.review(ctx, id, models.RefundApproved, request.Reason)
+}
+
+func (r *refundAppHandler) Reject(ctx context.Context, id string, request domain.ReviewRefundRequest) (*models.Refund, error) {
+	if request.Reason == "" {
+		return nil, errs.Body(errs.InvalidRequestError, errors.New("reason is required"))
+	}
+
+	return r.review(ctx, id, models.RefundRejected, request.Reason)
+}
+
+func (r *refundAppHandler) Pay(ctx context.Context, id string) (*models.Refund, error) {
+	claims, refund, err := r.adminRefund(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = models.ValidateRefundStatusTransition(refund.Status, models.RefundPaying)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := r.allRepository.PaymentRepository.ByReference(ctx, refund.PaymentReference)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := r.providers.ByName(record.Provider)
+	if err != nil {
+		return nil, errs.Body(errs.PaymentError, err)
+	}
+
+	// the refund is claimed before the provider is called, so concurrent calls cannot pay it twice
+	err = r.setStatus(ctx, claims, refund, models.RefundPaying, "")
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.checkRefundable(ctx, refund, record)
+	if err == nil {
+		_, err = provider.Refund(ctx, payment.RefundRequest{
+			Reference:      refund.PaymentReference,
+			Amount:         refund.Amount,
+			Reason:         refund.Reason,
+			IdempotencyKey: refund.ID,
+		})
+		if err != nil {
+			err = errs.Body(errs.PaymentError, fmt.Errorf("error refunding payment %s: %w", refund.PaymentReference, err))
+		}
+	}
+	if err != nil {
+		releaseErr := r.setStatus(ctx, claims, refund, models.RefundApproved, "refund could not be paid")
+		if releaseErr != nil {
+			log.Error().Err(releaseErr).Msgf("error approving refund %s again after it could not be paid", refund.ID)
+		}
+		return nil, err
+	}
+
+	return r.updateStatus(ctx, claims, refund, models.RefundPaid, "")
+}
+
+// checkRefundable checks the refunds being paid and paid on the payment, the claimed refund included, add up to at most
+// the amount paid. Cancellation, cylinder deposit and support ticket refunds all come out of the same payment.
+func (r *refundAppHandler) checkRefundable(ctx context.Context, refund *models.Refund, record *models.Payment) error {
+	refunds, err := r.allRepository.RefundRepository.Refunds(ctx, domain.RefundFilter{
+		PaymentReference: refund.PaymentReference,
+		Statuses:         []models.RefundStatus{models.RefundPaying, models.RefundPaid},
+	})
+	if err != nil {
+		return err
+	}
+
+	var refunded models.Money
+	for _, paid := range refunds {
+		refunded = refunded.Add(paid.Amount)
+	}
+
+	if refunded.Amount > record.Amount.Amount {
+		return errs.Body(errs.InvalidRequestError, fmt.Errorf("refunds of %s would exceed the %s paid", refunded, record.Amount))
+	}
+
+	return nil
+}
+
+func (r *refundAppHandler) review(ctx context.Context, id string, status models.RefundStatus, reason string) (*models.Refund, error) {
+	claims, refund, err := r.adminRefund(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = models.ValidateRefundStatusTransition(refund.Status, status)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.updateStatus(ctx, claims, refund, status, reason)
+}
+
+func (r *refundAppHandler) adminRefund(ctx context.Context, id string) (*jwtmiddleware.UserClaims, *models.Refund, error) {
+	claims, err := r.jwtManager.ExtractUserClaims(ctx)
+	if err != nil {
+		return nil, nil, errs.Body(errs.ErrorUnauthorized, err)
+	}
+
+	if claims.Role != models.AdminCategory {
+		return nil, nil, errs.Body(errs.ErrorUnauthorized, errors.New("only admins can review refunds"))
+	}
+
+	refund, err := r.allRepository.RefundRepository.ByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return claims, refund, nil
+}
+
+func (r *refundAppHandler) updateStatus(ctx context.Context, claims *jwtmiddleware.UserClaims, refund *models.Refund, status models.RefundStatus, reason string) (*models.Refund, error) {
+	err := r.setStatus(ctx, claims, refund, status, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	r.notify(*refund, reason)
+
+	return refund, nil
+}
+
+// setStatus moves the refund to the status if it was not changed since it was read, without notifying anyone
+func (r *refundAppHandler) setStatus(ctx context.Context, claims *jwtmiddleware.UserClaims, refund *models.Refund, status models.RefundStatus, reason string) error {
+	history := models.RefundStatusHistory{
+		Status:    status,
+		Reason:    reason,
+		UpdatedBy: claims.UserID,
+		StatusTs:  time.Now().Unix(),
+	}
+
+	err := r.allRepository.RefundRepository.UpdateStatus(ctx, refund.ID, refund.Status, history)
+	if err != nil {
+		return err
+	}
+
+	refund.Status = status
+	refund.StatusTs = history.StatusTs
+	refund.StatusHistory = append(refund.StatusHistory, history)
+
+	return nil
+}
+
+// notify lets the customer and the vendor know the refund changed status. Failing to notify them does not undo the change.
+func (r *refundAppHandler) notify(refund models.Refund, reason string) {
+	recipients := map[string]string{}
+
+	customer, err := r.allRepository.UserRepository.GetCustomerByID(refund.CustomerID)
+	if err != nil {
+		log.Error().Err(err).Msgf("error getting customer %s to notify of refund %s", refund.CustomerID, refund.ID)
+	} else if customer.Email.Address != "" {
+		recipients[customer.Email.Address] = customer.FirstName
+	}
+
+	vendor, err := r.allRepository.UserRepository.GetVendorByID(refund.VendorID)
+	if err != nil {
+		log.Error().Err(err).Msgf("error getting vendor %s to notify of refund %s", refund.VendorID, refund.ID)
+	} else if vendor.Email.Address != "" {
+		recipients[vendor.Email.Address] = vendor.FirstName
+	}
+
+	for email, name := range recipients {
+		err := r.EmailClient.Send(pkg.RefundStatusTemplatePath, models.Message{
+			ID:         r.idGenerator.Generate(),
+			TemplateID: pkg.RefundStatusTemplatePath,
+			Title:      "Refund update",
+			Sender:     r.mailerConfig.VerificationEmail,
+			DataMap: map[string]string{
+				"Name":    name,
+				"OrderID": refund.OrderID,
+				"Amount":  refund.Amount.String(),
+				"Status":  string(refund.Status),
+				"Reason":  reason,
+			},
+			Recipients: []string{email},
+			Ts:         time.Now().Unix(),
+		})
+		if err != nil {
+			log.Error().Err(err).Msgf("error notifying %s of refund %s", email, refund.ID)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package domain
+
+import "github.com/leetatech/leeta_backend/services/models"
+
+// RefundFilter selects refunds. Empty fields match every refund.
+type RefundFilter struct {
+	OrderID          string
+	PaymentReference string
+	CustomerID       string
+	VendorID         string
+	Statuses         []models.RefundStatus
+}
+
+type ReviewRefundRequest struct {
+	Reason string `json:"reason"`
+} // @name ReviewRefundRequest
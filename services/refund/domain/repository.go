@@ -0,0 +1,14 @@
+package domain
+
+import (
+	"context"
+	"github.com/leetatech/leeta_backend/services/models"
+)
+
+type RefundRepository interface {
+	Create(ctx context.Context, refund models.Refund) error
+	ByID(ctx context.Context, id string) (*models.Refund, error)
+	Refunds(ctx context.Context, filter RefundFilter) ([]models.Refund, error)
+	// UpdateStatus moves the refund to the status of the history entry. It fails if the refund is no longer in currentStatus.
+	UpdateStatus(ctx context.Context, id string, currentStatus models.RefundStatus, history models.RefundStatusHistory) error
+}
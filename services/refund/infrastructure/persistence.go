@@ -0,0 +1,111 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/services/models"
+	"github.com/leetatech/leeta_backend/services/refund/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type refundStoreHandler struct {
+	client       *mongo.Client
+	databaseName string
+}
+
+func (r *refundStoreHandler) col(collectionName string) *mongo.Collection {
+	return r.client.Database(r.databaseName).Collection(collectionName)
+}
+
+func New(client *mongo.Client, databaseName string) domain.RefundRepository {
+	return &refundStoreHandler{client: client, databaseName: databaseName}
+}
+
+func (r *refundStoreHandler) Create(ctx context.Context, refund models.Refund) error {
+	updatedCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := r.col(models.RefundsCollectionName).InsertOne(updatedCtx, refund)
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	return nil
+}
+
+func (r *refundStoreHandler) ByID(ctx context.Context, id string) (*models.Refund, error) {
+	refund := &models.Refund{}
+	err := r.col(models.RefundsCollectionName).FindOne(ctx, bson.M{"id": id}).Decode(refund)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errs.Body(errs.DatabaseNoRecordError, fmt.Errorf("refund with id %s not found", id))
+		}
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	return refund, nil
+}
+
+func (r *refundStoreHandler) Refunds(ctx context.Context, request domain.RefundFilter) ([]models.Refund, error) {
+	filter := bson.M{}
+	if request.OrderID != "" {
+		filter["order_id"] = request.OrderID
+	}
+	if request.PaymentReference != "" {
+		filter["payment_reference"] = request.PaymentReference
+	}
+	if request.CustomerID != "" {
+		filter["customer_id"] = request.CustomerID
+	}
+	if request.VendorID != "" {
+		filter["vendor_id"] = request.VendorID
+	}
+	if len(request.Statuses) > 0 {
+		filter["status"] = bson.M{"$in": request.Statuses}
+	}
+
+	opts := options.Find().SetSort(bson.M{"ts": -1})
+	cursor, err := r.col(models.RefundsCollectionName).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	refunds := make([]models.Refund, 0)
+	if err = cursor.All(ctx, &refunds); err != nil {
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	return refunds, nil
+}
+
+func (r *refundStoreHandler) UpdateStatus(ctx context.Context, id string, currentStatus models.RefundStatus, history models.RefundStatusHistory) error {
+	filter := bson.M{
+		"id":     id,
+		"status": currentStatus,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":    history.Status,
+			"status_ts": history.StatusTs,
+		},
+		"$push": bson.M{
+			"status_history": history,
+		},
+	}
+
+	result, err := r.col(models.RefundsCollectionName).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	if result.MatchedCount == 0 {
+		return errs.Body(errs.OrderStatusConflictError, fmt.Errorf("refund %s is no longer %s", id, currentStatus))
+	}
+
+	return nil
+}
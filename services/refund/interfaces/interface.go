@@ -0,0 +1,157 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	_ "github.com/leetatech/leeta_backend/pkg"
+	"github.com/leetatech/leeta_backend/pkg/helpers"
+	"github.com/leetatech/leeta_backend/pkg/jwtmiddleware"
+	"github.com/leetatech/leeta_backend/services/models"
+	"github.com/leetatech/leeta_backend/services/refund/application"
+	"github.com/leetatech/leeta_backend/services/refund/domain"
+	"github.com/samber/lo"
+	"net/http"
+)
+
+type RefundHttpHandler struct {
+	RefundApplication application.Refund
+}
+
+func New(refundApplication application.Refund) *RefundHttpHandler {
+	return &RefundHttpHandler{
+		RefundApplication: refundApplication,
+	}
+}
+
+// ListRefundsHandler godoc
+// @Summary List refunds
+// @Description The endpoint returns the refunds of the signed in customer or vendor, optionally filtered by status. Admins see every refund
+// @Tags Refund
+// @Accept json
+// @produce json
+// @Param			status	query		[]string	false	"refund statuses"	collectionFormat(multi)
+// @Security BearerToken
+// @success 200 {object} []models.Refund
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Router /refund/ [get]
+func (handler *RefundHttpHandler) ListRefundsHandler(w http.ResponseWriter, r *http.Request) {
+	statuses := lo.Map(r.URL.Query()["status"], func(status string, _ int) models.RefundStatus {
+		return models.RefundStatus(status)
+	})
+
+	refunds, err := handler.RefundApplication.Refunds(r.Context(), statuses)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, refunds, http.StatusOK)
+}
+
+// GetRefundHandler godoc
+// @Summary Get refund
+// @Description The endpoint returns a refund of the signed in customer or vendor. Admins can read any refund
+// @Tags Refund
+// @Accept json
+// @produce json
+// @Param			refund_id	path		string	true	"refund id"
+// @Security BearerToken
+// @success 200 {object} models.Refund
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 404 {object} pkg.DefaultErrorResponse
+// @Router /refund/{refund_id} [get]
+func (handler *RefundHttpHandler) GetRefundHandler(w http.ResponseWriter, r *http.Request) {
+	refund, err := handler.RefundApplication.Refund(r.Context(), chi.URLParam(r, "refund_id"))
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, refund, http.StatusOK)
+}
+
+// ApproveRefundHandler godoc
+// @Summary Approve refund
+// @Description The endpoint lets an admin approve a requested refund, so it can be paid
+// @Tags Refund
+// @Accept json
+// @produce json
+// @Param			refund_id	path		string	true	"refund id"
+// @Param domain.ReviewRefundRequest body domain.ReviewRefundRequest true "review refund request body"
+// @Security BearerToken
+// @success 200 {object} models.Refund
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Failure 409 {object} pkg.DefaultErrorResponse
+// @Router /refund/{refund_id}/approve [put]
+func (handler *RefundHttpHandler) ApproveRefundHandler(w http.ResponseWriter, r *http.Request) {
+	var request domain.ReviewRefundRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		jwtmiddleware.WriteJSONErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	refund, err := handler.RefundApplication.Approve(r.Context(), chi.URLParam(r, "refund_id"), request)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, refund, http.StatusOK)
+}
+
+// RejectRefundHandler godoc
+// @Summary Reject refund
+// @Description The endpoint lets an admin reject a requested refund. A reason is required
+// @Tags Refund
+// @Accept json
+// @produce json
+// @Param			refund_id	path		string	true	"refund id"
+// @Param domain.ReviewRefundRequest body domain.ReviewRefundRequest true "review refund request body"
+// @Security BearerToken
+// @success 200 {object} models.Refund
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Failure 409 {object} pkg.DefaultErrorResponse
+// @Router /refund/{refund_id}/reject [put]
+func (handler *RefundHttpHandler) RejectRefundHandler(w http.ResponseWriter, r *http.Request) {
+	var request domain.ReviewRefundRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		jwtmiddleware.WriteJSONErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	refund, err := handler.RefundApplication.Reject(r.Context(), chi.URLParam(r, "refund_id"), request)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, refund, http.StatusOK)
+}
+
+// PayRefundHandler godoc
+// @Summary Pay refund
+// @Description The endpoint lets an admin pay an approved refund back through the payment provider the order was paid with
+// @Tags Refund
+// @Accept json
+// @produce json
+// @Param			refund_id	path		string	true	"refund id"
+// @Security BearerToken
+// @success 200 {object} models.Refund
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Failure 409 {object} pkg.DefaultErrorResponse
+// @Router /refund/{refund_id}/pay [put]
+func (handler *RefundHttpHandler) PayRefundHandler(w http.ResponseWriter, r *http.Request) {
+	refund, err := handler.RefundApplication.Pay(r.Context(), chi.URLParam(r, "refund_id"))
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, refund, http.StatusOK)
+}
@@ -0,0 +1,244 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query"
+	"github.com/leetatech/leeta_backend/pkg"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/pkg/idgenerator"
+	"github.com/leetatech/leeta_backend/pkg/jwtmiddleware"
+	"github.com/leetatech/leeta_backend/services/models"
+	"github.com/leetatech/leeta_backend/services/review/domain"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxCommentLength is the longest comment, in characters, a customer can leave on a rating
+const maxCommentLength = 1000
+
+type reviewAppHandler struct {
+	jwtManager    jwtmiddleware.Manager
+	idGenerator   idgenerator.Generator
+	allRepository pkg.RepositoryManager
+}
+
+type Review interface {
+	// Create reviews a completed order. Only the customer who placed the order can review it, once.
+	Create(ctx context.Context, orderID string, request domain.CreateReviewRequest) (*models.Review, error)
+	ByOrderID(ctx context.Context, orderID string) (*models.Review, error)
+	// VendorReviews lists the visible reviews of the vendor. Admins also see the hidden ones.
+	VendorReviews(ctx context.Context, vendorID string, request query.ResultSelector) ([]models.Review, uint64, error)
+	VendorRating(ctx context.Context, vendorID string) (*models.RatingSummary, error)
+	// List lists every review for admins to moderate
+	List(ctx context.Context, request query.ResultSelector) ([]models.Review, uint64, error)
+	Moderate(ctx context.Context, id string, request domain.ModerateReviewRequest) (*models.Review, error)
+}
+
+func New(request pkg.ApplicationContext) Review {
+	return &reviewAppHandler{
+		jwtManager:    request.JwtManager,
+		idGenerator:   idgenerator.New(),
+		allRepository: request.RepositoryManager,
+	}
+}
+
+func (r *reviewAppHandler) Create(ctx context.Context, orderID string, request domain.CreateReviewRequest) (*models.Review, error) {
+	claims, err := r.jwtManager.ExtractUserClaims(ctx)
+	if err != nil {
+		return nil, errs.Body(errs.ErrorUnauthorized, err)
+	}
+
+	if claims.Role != models.CustomerCategory {
+		return nil, errs.Body(errs.ErrorUnauthorized, errors.New("only customers can review orders"))
+	}
+
+	order, err := r.allRepository.OrderRepository.OrderByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.CustomerID != claims.UserID {
+		return nil, errs.Body(errs.ErrorUnauthorized, errors.New("you can only review your own orders"))
+	}
+
+	if order.CurrentStatus() != models.OrderCompleted {
+		return nil, errs.Body(errs.InvalidRequestError, fmt.Errorf("only %s orders can be reviewed, order is %s", models.OrderCompleted, order.CurrentStatus()))
+	}
+
+	riderID := ""
+	if order.Delivery != nil {
+		riderID = order.Delivery.RiderID
+	}
+
+	err = validateReview(request, riderID != "")
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	review := models.Review{
+		ID:              r.idGenerator.Generate(),
+		OrderID:         order.ID,
+		CustomerID:      claims.UserID,
+		VendorID:        order.VendorID,
+		RiderID:         riderID,
+		VendorRating:    request.VendorRating,
+		VendorComment:   strings.TrimSpace(request.VendorComment),
+		DeliveryRating:  request.DeliveryRating,
+		DeliveryComment: strings.TrimSpace(request.DeliveryComment),
+		Status:          models.ReviewVisible,
+		StatusTs:        now,
+		Ts:              now,
+	}
+
+	// the ratings on the profiles are refreshed with the review, so they always match the visible reviews
+	err = r.allRepository.Transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		err := r.allRepository.ReviewRepository.Create(ctx, review)
+		if err != nil {
+			return err
+		}
+
+		return r.allRepository.ReviewRepository.RefreshRatings(ctx, review.VendorID, review.RiderID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &review, nil
+}
+
+// validateReview checks the ratings are in range. Orders without a rider have no delivery to rate.
+func validateReview(request domain.CreateReviewRequest, delivered bool) error {
+	if !models.IsValidRating(request.VendorRating) {
+		return errs.Body(errs.InvalidRequestError, fmt.Errorf("vendor rating must be between %d and %d", models.MinRating, models.MaxRating))
+	}
+
+	switch {
+	case delivered && !models.IsValidRating(request.DeliveryRating):
+		return errs.Body(errs.InvalidRequestError, fmt.Errorf("delivery rating must be between %d and %d", models.MinRating, models.MaxRating))
+	case !delivered && (request.DeliveryRating != 0 || request.DeliveryComment != ""):
+		return errs.Body(errs.InvalidRequestError, errors.New("order was not delivered by a rider, the delivery cannot be rated"))
+	}
+
+	if utf8.RuneCountInString(request.VendorComment) > maxCommentLength || utf8.RuneCountInString(request.DeliveryComment) > maxCommentLength {
+		return errs.Body(errs.InvalidRequestError, fmt.Errorf("comments cannot be longer than %d characters", maxCommentLength))
+	}
+
+	return nil
+}
+
+func (r *reviewAppHandler) ByOrderID(ctx context.Context, orderID string) (*models.Review, error) {
+	claims, err := r.jwtManager.ExtractUserClaims(ctx)
+	if err != nil {
+		return nil, errs.Body(errs.ErrorUnauthorized, err)
+	}
+
+	review, err := r.allRepository.ReviewRepository.ByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	// the customer still sees their review once it is hidden
+	switch {
+	case claims.Role == models.AdminCategory, review.CustomerID == claims.UserID:
+	case review.Status == models.ReviewVisible && (review.VendorID == claims.UserID || review.RiderID == claims.UserID):
+	default:
+		return nil, errs.Body(errs.ErrorUnauthorized, errors.New("you cannot view this review"))
+	}
+
+	return review, nil
+}
+
+func (r *reviewAppHandler) VendorReviews(ctx context.Context, vendorID string, request query.ResultSelector) ([]models.Review, uint64, error) {
+	claims, err := r.jwtManager.ExtractUserClaims(ctx)
+	if err != nil {
+		return nil, 0, errs.Body(errs.ErrorUnauthorized, err)
+	}
+
+	scope := domain.ReviewScope{
+		VendorID:      vendorID,
+		IncludeHidden: claims.Role == models.AdminCategory,
+	}
+	return r.allRepository.ReviewRepository.Reviews(ctx, request, scope)
+}
+
+func (r *reviewAppHandler) VendorRating(ctx context.Context, vendorID string) (*models.RatingSummary, error) {
+	if _, err := r.jwtManager.ExtractUserClaims(ctx); err != nil {
+		return nil, errs.Body(errs.ErrorUnauthorized, err)
+	}
+
+	vendor, err := r.allRepository.UserRepository.GetVendorByID(vendorID)
+	if err != nil {
+		return nil, err
+	}
+
+	// vendors that were never reviewed have no rating stored
+	if vendor.Rating == nil {
+		return &models.RatingSummary{}, nil
+	}
+
+	return vendor.Rating, nil
+}
+
+func (r *reviewAppHandler) List(ctx context.Context, request query.ResultSelector) ([]models.Review, uint64, error) {
+	if _, err := r.adminClaims(ctx); err != nil {
+		return nil, 0, err
+	}
+
+	return r.allRepository.ReviewRepository.Reviews(ctx, request, domain.ReviewScope{IncludeHidden: true})
+}
+
+func (r *reviewAppHandler) Moderate(ctx context.Context, id string, request domain.ModerateReviewRequest) (*models.Review, error) {
+	claims, err := r.adminClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !models.IsValidReviewStatus(request.Status) {
+		return nil, errs.Body(errs.InvalidRequestError, fmt.Errorf("status must be %s or %s", models.ReviewVisible, models.ReviewHidden))
+	}
+
+	request.Reason = strings.TrimSpace(request.Reason)
+	if request.Status == models.ReviewHidden && request.Reason == "" {
+		return nil, errs.Body(errs.InvalidRequestError, errors.New("reason is required to hide a review"))
+	}
+
+	review, err := r.allRepository.ReviewRepository.ByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// hiding or showing a review changes the ratings it counts towards
+	err = r.allRepository.Transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		err := r.allRepository.ReviewRepository.Moderate(ctx, id, request, claims.UserID)
+		if err != nil {
+			return err
+		}
+
+		if review.Status == request.Status {
+			return nil
+		}
+		return r.allRepository.ReviewRepository.RefreshRatings(ctx, review.VendorID, review.RiderID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return r.allRepository.ReviewRepository.ByID(ctx, id)
+}
+
+func (r *reviewAppHandler) adminClaims(ctx context.Context) (*jwtmiddleware.UserClaims, error) {
+	claims, err := r.jwtManager.ExtractUserClaims(ctx)
+	if err != nil {
+		return nil, errs.Body(errs.ErrorUnauthorized, err)
+	}
+
+	if claims.Role != models.AdminCategory {
+		return nil, errs.Body(errs.ErrorUnauthorized, errors.New("only admins can moderate reviews"))
+	}
+
+	return claims, nil
+}
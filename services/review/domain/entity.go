@@ -0,0 +1,24 @@
+package domain
+
+import "github.com/leetatech/leeta_backend/services/models"
+
+// CreateReviewRequest rates the vendor and the delivery of a completed order. The delivery is only rated for orders a rider delivered.
+type CreateReviewRequest struct {
+	VendorRating    int    `json:"vendor_rating"`
+	VendorComment   string `json:"vendor_comment"`
+	DeliveryRating  int    `json:"delivery_rating"`
+	DeliveryComment string `json:"delivery_comment"`
+} // @name CreateReviewRequest
+
+// ModerateReviewRequest hides or shows a review and flags it for follow up. A reason is required to hide a review.
+type ModerateReviewRequest struct {
+	Status  models.ReviewStatus `json:"status"`
+	Flagged bool                `json:"flagged"`
+	Reason  string              `json:"reason"`
+} // @name ModerateReviewRequest
+
+// ReviewScope limits reviews to those of a vendor. Hidden reviews are left out unless they are included.
+type ReviewScope struct {
+	VendorID      string
+	IncludeHidden bool
+}
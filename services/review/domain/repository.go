@@ -0,0 +1,18 @@
+package domain
+
+import (
+	"context"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query"
+	"github.com/leetatech/leeta_backend/services/models"
+)
+
+type ReviewRepository interface {
+	// Create fails with ReviewExistsError when the order was already reviewed
+	Create(ctx context.Context, review models.Review) error
+	ByID(ctx context.Context, id string) (*models.Review, error)
+	ByOrderID(ctx context.Context, orderID string) (*models.Review, error)
+	Reviews(ctx context.Context, request query.ResultSelector, scope ReviewScope) ([]models.Review, uint64, error)
+	Moderate(ctx context.Context, id string, request ModerateReviewRequest, moderatedBy string) error
+	// RefreshRatings recomputes the rating of the vendor and the delivery rating of the rider on their profiles from their visible reviews
+	RefreshRatings(ctx context.Context, vendorID, riderID string) error
+}
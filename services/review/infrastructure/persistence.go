@@ -0,0 +1,200 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query/sorting"
+	"github.com/leetatech/leeta_backend/pkg/database"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/services/models"
+	"github.com/leetatech/leeta_backend/services/review/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type reviewStoreHandler struct {
+	client       *mongo.Client
+	databaseName string
+}
+
+func (r *reviewStoreHandler) col(collectionName string) *mongo.Collection {
+	return r.client.Database(r.databaseName).Collection(collectionName)
+}
+
+func New(client *mongo.Client, databaseName string) domain.ReviewRepository {
+	return &reviewStoreHandler{client: client, databaseName: databaseName}
+}
+
+// EnsureIndexes keeps a single review per order
+func EnsureIndexes(ctx context.Context, client *mongo.Client, databaseName string) error {
+	_, err := client.Database(databaseName).Collection(models.ReviewsCollectionName).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "order_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "vendor_id", Value: 1}, {Key: "status", Value: 1}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("error creating review indexes: %w", err)
+	}
+
+	return nil
+}
+
+func (r *reviewStoreHandler) Create(ctx context.Context, review models.Review) error {
+	_, err := r.col(models.ReviewsCollectionName).InsertOne(ctx, review)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return errs.Body(errs.ReviewExistsError, fmt.Errorf("order %s has already been reviewed", review.OrderID))
+		}
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	return nil
+}
+
+func (r *reviewStoreHandler) ByID(ctx context.Context, id string) (*models.Review, error) {
+	return r.findOne(ctx, bson.M{"id": id}, fmt.Sprintf("review with id %s not found", id))
+}
+
+func (r *reviewStoreHandler) ByOrderID(ctx context.Context, orderID string) (*models.Review, error) {
+	return r.findOne(ctx, bson.M{"order_id": orderID}, fmt.Sprintf("order %s has no review", orderID))
+}
+
+func (r *reviewStoreHandler) findOne(ctx context.Context, filter bson.M, notFound string) (*models.Review, error) {
+	review := &models.Review{}
+	err := r.col(models.ReviewsCollectionName).FindOne(ctx, filter).Decode(review)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errs.Body(errs.DatabaseNoRecordError, errors.New(notFound))
+		}
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	return review, nil
+}
+
+func (r *reviewStoreHandler) Reviews(ctx context.Context, request query.ResultSelector, scope domain.ReviewScope) ([]models.Review, uint64, error) {
+	updatedCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if request.Filter != nil {
+		filter = database.BuildMongoFilterQuery(request.Filter, nil)
+	}
+	// the scope is applied last, so a filter can never widen it
+	if scope.VendorID != "" {
+		filter["vendor_id"] = scope.VendorID
+	}
+	if !scope.IncludeHidden {
+		filter["status"] = models.ReviewVisible
+	}
+
+	totalRecord, err := r.col(models.ReviewsCollectionName).CountDocuments(updatedCtx, filter)
+	if err != nil {
+		return nil, 0, errs.Body(errs.DatabaseError, err)
+	}
+
+	skip := int64(request.Paging.PageSize * request.Paging.PageIndex)
+	if skip < 0 {
+		skip = 0
+	}
+	opts := options.Find().SetSkip(skip).SetLimit(int64(request.Paging.PageSize))
+	if request.Sorting != nil && request.Sorting.SortColumn != "" {
+		direction := 1
+		if request.Sorting.SortDirection == sorting.DirectionDescending {
+			direction = -1
+		}
+		opts.SetSort(bson.D{{Key: request.Sorting.SortColumn, Value: direction}})
+	}
+
+	cursor, err := r.col(models.ReviewsCollectionName).Find(updatedCtx, filter, opts)
+	if err != nil {
+		return nil, 0, errs.Body(errs.DatabaseError, err)
+	}
+
+	reviews := make([]models.Review, 0)
+	if err = cursor.All(updatedCtx, &reviews); err != nil {
+		return nil, 0, errs.Body(errs.DatabaseError, err)
+	}
+
+	return reviews, uint64(totalRecord), nil
+}
+
+func (r *reviewStoreHandler) Moderate(ctx context.Context, id string, request domain.ModerateReviewRequest, moderatedBy string) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":            request.Status,
+			"flagged":           request.Flagged,
+			"moderation_reason": request.Reason,
+			"moderated_by":      moderatedBy,
+			"status_ts":         time.Now().Unix(),
+		},
+	}
+
+	result, err := r.col(models.ReviewsCollectionName).UpdateOne(ctx, bson.M{"id": id}, update)
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	if result.MatchedCount == 0 {
+		return errs.Body(errs.DatabaseNoRecordError, fmt.Errorf("review with id %s not found", id))
+	}
+
+	return nil
+}
+
+func (r *reviewStoreHandler) RefreshRatings(ctx context.Context, vendorID, riderID string) error {
+	err := r.refreshRating(ctx, bson.M{"vendor_id": vendorID}, "$vendor_rating", vendorID, "rating")
+	if err != nil {
+		return err
+	}
+
+	if riderID == "" {
+		return nil
+	}
+	return r.refreshRating(ctx, bson.M{"rider_id": riderID, "delivery_rating": bson.M{"$gt": 0}}, "$delivery_rating", riderID, "delivery_rating")
+}
+
+// refreshRating averages the rating field of the visible reviews matching the filter onto the field of the user's profile
+func (r *reviewStoreHandler) refreshRating(ctx context.Context, filter bson.M, ratingField, userID, profileField string) error {
+	filter["status"] = models.ReviewVisible
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     nil,
+			"average": bson.M{"$avg": ratingField},
+			"count":   bson.M{"$sum": 1},
+		}}},
+		{{Key: "$set", Value: bson.M{"average": bson.M{"$round": bson.A{"$average", 2}}}}},
+	}
+
+	cursor, err := r.col(models.ReviewsCollectionName).Aggregate(ctx, pipeline)
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	var summaries []models.RatingSummary
+	if err = cursor.All(ctx, &summaries); err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	// a user whose reviews were all hidden has no rating left
+	summary := models.RatingSummary{}
+	if len(summaries) > 0 {
+		summary = summaries[0]
+	}
+
+	_, err = r.col(models.UsersCollectionName).UpdateOne(ctx, bson.M{"user.id": userID}, bson.M{"$set": bson.M{profileField: summary}})
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,234 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query/filter"
+	_ "github.com/leetatech/leeta_backend/pkg"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/pkg/helpers"
+	"github.com/leetatech/leeta_backend/pkg/jwtmiddleware"
+	"github.com/leetatech/leeta_backend/services/models"
+	"github.com/leetatech/leeta_backend/services/review/application"
+	"github.com/leetatech/leeta_backend/services/review/domain"
+	"github.com/leetatech/leeta_backend/services/web"
+	"github.com/samber/lo"
+	"net/http"
+)
+
+type ReviewHttpHandler struct {
+	ReviewApplication application.Review
+}
+
+func New(reviewApplication application.Review) *ReviewHttpHandler {
+	return &ReviewHttpHandler{
+		ReviewApplication: reviewApplication,
+	}
+}
+
+// CreateReviewHandler godoc
+// @Summary Review order
+// @Description The endpoint lets the customer rate the vendor and the delivery of their completed order from 1 to 5, with optional comments. An order can be reviewed once, and its delivery only rated when a rider delivered it
+// @Tags Review
+// @Accept json
+// @produce json
+// @Param			order_id	path		string	true	"order id"
+// @Param domain.CreateReviewRequest body domain.CreateReviewRequest true "create review request body"
+// @Security BearerToken
+// @success 201 {object} models.Review
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Failure 404 {object} pkg.DefaultErrorResponse
+// @Failure 409 {object} pkg.DefaultErrorResponse
+// @Router /review/order/{order_id} [post]
+func (handler *ReviewHttpHandler) CreateReviewHandler(w http.ResponseWriter, r *http.Request) {
+	var request domain.CreateReviewRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		jwtmiddleware.WriteJSONErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := handler.ReviewApplication.Create(r.Context(), chi.URLParam(r, "order_id"), request)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, resp, http.StatusCreated)
+}
+
+// GetOrderReviewHandler godoc
+// @Summary Get order review
+// @Description The endpoint returns the review of the order to its customer, vendor, rider and admins. Hidden reviews are only returned to the customer and admins
+// @Tags Review
+// @Accept json
+// @produce json
+// @Param			order_id	path		string	true	"order id"
+// @Security BearerToken
+// @success 200 {object} models.Review
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 404 {object} pkg.DefaultErrorResponse
+// @Router /review/order/{order_id} [get]
+func (handler *ReviewHttpHandler) GetOrderReviewHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := handler.ReviewApplication.ByOrderID(r.Context(), chi.URLParam(r, "order_id"))
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, resp, http.StatusOK)
+}
+
+// VendorReviewsHandler godoc
+// @Summary List vendor reviews
+// @Description The endpoint lists the reviews of the vendor, newest first. Admins also see the hidden reviews. List endpoint can be configured with the filters
+// @Tags Review
+// @Accept json
+// @produce json
+// @Param			vendor_id	path		string	true	"vendor id"
+// @param query.ResultSelector body query.ResultSelector true "list vendor reviews request body"
+// @Security BearerToken
+// @success 200 {object} query.ResponseListWithMetadata[models.Review]
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Router /review/vendor/{vendor_id} [put]
+func (handler *ReviewHttpHandler) VendorReviewsHandler(w http.ResponseWriter, r *http.Request) {
+	resultSelector, err := web.PrepareResultSelector(r, vendorReviewsOptions, reviewSortFields, web.ResultSelectorDefaults(reviewSortingRequest))
+	if err != nil {
+		jwtmiddleware.WriteJSONErrorResponse(w, http.StatusBadRequest, errs.Body(errs.InvalidRequestError, err))
+		return
+	}
+
+	reviews, totalRecord, err := handler.ReviewApplication.VendorReviews(r.Context(), chi.URLParam(r, "vendor_id"), resultSelector)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	response := query.ResponseListWithMetadata[models.Review]{
+		Metadata: query.NewMetadata(resultSelector, totalRecord),
+		Data:     reviews,
+	}
+	jwtmiddleware.WriteJSONResponse(w, response, http.StatusOK)
+}
+
+// VendorReviewsOptions godoc
+// @Summary Get vendor reviews filter options
+// @Description Retrieve vendor reviews filter options
+// @Tags Review
+// @Accept json
+// @Produce json
+// @Security BearerToken
+// @Success 200 {object} filter.RequestOption
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Router /review/vendor/options [get]
+func (handler *ReviewHttpHandler) VendorReviewsOptions(w http.ResponseWriter, r *http.Request) {
+	requestOptions := lo.Map(vendorReviewsOptions, toFilterOption)
+	jwtmiddleware.WriteJSONResponse(w, requestOptions, http.StatusOK)
+}
+
+// VendorRatingHandler godoc
+// @Summary Get vendor rating
+// @Description The endpoint returns the average rating of the vendor and how many visible reviews it is made of
+// @Tags Review
+// @Accept json
+// @produce json
+// @Param			vendor_id	path		string	true	"vendor id"
+// @Security BearerToken
+// @success 200 {object} models.RatingSummary
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 404 {object} pkg.DefaultErrorResponse
+// @Router /review/vendor/{vendor_id}/rating [get]
+func (handler *ReviewHttpHandler) VendorRatingHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := handler.ReviewApplication.VendorRating(r.Context(), chi.URLParam(r, "vendor_id"))
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, resp, http.StatusOK)
+}
+
+// ListReviewsHandler godoc
+// @Summary List reviews
+// @Description The endpoint lists every review, including the hidden ones, for admins to moderate. List endpoint can be configured with the filters
+// @Tags Review
+// @Accept json
+// @produce json
+// @param query.ResultSelector body query.ResultSelector true "list reviews request body"
+// @Security BearerToken
+// @success 200 {object} query.ResponseListWithMetadata[models.Review]
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Router /review/ [put]
+func (handler *ReviewHttpHandler) ListReviewsHandler(w http.ResponseWriter, r *http.Request) {
+	resultSelector, err := web.PrepareResultSelector(r, listReviewsOptions, reviewSortFields, web.ResultSelectorDefaults(reviewSortingRequest))
+	if err != nil {
+		jwtmiddleware.WriteJSONErrorResponse(w, http.StatusBadRequest, errs.Body(errs.InvalidRequestError, err))
+		return
+	}
+
+	reviews, totalRecord, err := handler.ReviewApplication.List(r.Context(), resultSelector)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	response := query.ResponseListWithMetadata[models.Review]{
+		Metadata: query.NewMetadata(resultSelector, totalRecord),
+		Data:     reviews,
+	}
+	jwtmiddleware.WriteJSONResponse(w, response, http.StatusOK)
+}
+
+// ListReviewsOptions godoc
+// @Summary Get reviews filter options
+// @Description Retrieve reviews filter options
+// @Tags Review
+// @Accept json
+// @Produce json
+// @Security BearerToken
+// @Success 200 {object} filter.RequestOption
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Router /review/options [get]
+func (handler *ReviewHttpHandler) ListReviewsOptions(w http.ResponseWriter, r *http.Request) {
+	requestOptions := lo.Map(listReviewsOptions, toFilterOption)
+	jwtmiddleware.WriteJSONResponse(w, requestOptions, http.StatusOK)
+}
+
+// ModerateReviewHandler godoc
+// @Summary Moderate review
+// @Description The endpoint lets admins hide or show a review and flag it for follow up. Hidden reviews no longer count towards the ratings of the vendor and the rider
+// @Tags Review
+// @Accept json
+// @produce json
+// @Param			review_id	path		string	true	"review id"
+// @Param domain.ModerateReviewRequest body domain.ModerateReviewRequest true "moderate review request body"
+// @Security BearerToken
+// @success 200 {object} models.Review
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Failure 404 {object} pkg.DefaultErrorResponse
+// @Router /review/{review_id}/moderation [put]
+func (handler *ReviewHttpHandler) ModerateReviewHandler(w http.ResponseWriter, r *http.Request) {
+	var request domain.ModerateReviewRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		jwtmiddleware.WriteJSONErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := handler.ReviewApplication.Moderate(r.Context(), chi.URLParam(r, "review_id"), request)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, resp, http.StatusOK)
+}
+
+func toFilterOption(options filter.RequestOption, _ int) filter.RequestOption {
+	return options
+}
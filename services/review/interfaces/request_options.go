@@ -0,0 +1,126 @@
+package interfaces
+
+import (
+	"github.com/greenbone/opensight-golang-libraries/pkg/query/filter"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query/sorting"
+	"github.com/leetatech/leeta_backend/services/models"
+)
+
+// LabelIsEqualTo holds filter request options operator labels
+const (
+	LabelIsEqualTo    = "is equal to"
+	LabelIsOnOrAfter  = "is on or after"
+	LabelIsOnOrBefore = "is on or before"
+)
+
+var operatorEqual = filter.ReadableValue[filter.CompareOperator]{
+	Label: LabelIsEqualTo,
+	Value: filter.CompareOperatorIsEqualTo,
+}
+
+var operatorOnOrAfter = filter.ReadableValue[filter.CompareOperator]{
+	Label: LabelIsOnOrAfter,
+	Value: filter.CompareOperatorIsGreaterThanOrEqualTo,
+}
+
+var operatorOnOrBefore = filter.ReadableValue[filter.CompareOperator]{
+	Label: LabelIsOnOrBefore,
+	Value: filter.CompareOperatorIsLessThanOrEqualTo,
+}
+
+var ratingOption = filter.RequestOption{
+	Name: filter.ReadableValue[string]{
+		Label: "vendor rating",
+		Value: "vendor_rating",
+	},
+	Control: filter.RequestOptionType{
+		Type: filter.ControlTypeInteger,
+	},
+	Operators: []filter.ReadableValue[filter.CompareOperator]{
+		operatorEqual,
+		operatorOnOrAfter,
+		operatorOnOrBefore,
+	},
+}
+
+var tsOption = filter.RequestOption{
+	Name: filter.ReadableValue[string]{
+		Label: "date",
+		Value: "ts",
+	},
+	Control: filter.RequestOptionType{
+		Type: filter.ControlTypeInteger,
+	},
+	Operators: []filter.ReadableValue[filter.CompareOperator]{
+		operatorOnOrAfter,
+		operatorOnOrBefore,
+	},
+}
+
+// vendorReviewsOptions filter the reviews of a vendor by rating and by the unix time they were left
+var vendorReviewsOptions = []filter.RequestOption{ratingOption, tsOption}
+
+// listReviewsOptions also let admins find the hidden and flagged reviews and the reviews of a vendor or a rider
+var listReviewsOptions = []filter.RequestOption{
+	ratingOption,
+	tsOption,
+	{
+		Name: filter.ReadableValue[string]{
+			Label: "status",
+			Value: "status",
+		},
+		Control: filter.RequestOptionType{
+			Type: filter.ControlTypeEnum,
+		},
+		Operators: []filter.ReadableValue[filter.CompareOperator]{
+			operatorEqual,
+		},
+		Values: []string{
+			string(models.ReviewVisible),
+			string(models.ReviewHidden),
+		},
+	},
+	{
+		Name: filter.ReadableValue[string]{
+			Label: "flagged",
+			Value: "flagged",
+		},
+		Control: filter.RequestOptionType{
+			Type: "bool",
+		},
+		Operators: []filter.ReadableValue[filter.CompareOperator]{
+			operatorEqual,
+		},
+	},
+	{
+		Name: filter.ReadableValue[string]{
+			Label: "vendor",
+			Value: "vendor_id",
+		},
+		Control: filter.RequestOptionType{
+			Type: filter.ControlTypeString,
+		},
+		Operators: []filter.ReadableValue[filter.CompareOperator]{
+			operatorEqual,
+		},
+	},
+	{
+		Name: filter.ReadableValue[string]{
+			Label: "rider",
+			Value: "rider_id",
+		},
+		Control: filter.RequestOptionType{
+			Type: filter.ControlTypeString,
+		},
+		Operators: []filter.ReadableValue[filter.CompareOperator]{
+			operatorEqual,
+		},
+	},
+}
+
+var reviewSortFields = []string{"ts", "vendor_rating", "delivery_rating"}
+
+var reviewSortingRequest = &sorting.Request{
+	SortColumn:    "ts",
+	SortDirection: sorting.DirectionDescending,
+}
@@ -0,0 +1,139 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"github.com/leetatech/leeta_backend/pkg"
+	"github.com/leetatech/leeta_backend/pkg/config"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/pkg/idgenerator"
+	"github.com/leetatech/leeta_backend/pkg/jwtmiddleware"
+	"github.com/leetatech/leeta_backend/services/models"
+	"github.com/leetatech/leeta_backend/services/slot/domain"
+	"github.com/samber/lo"
+	"strings"
+	"time"
+)
+
+type slotAppHandler struct {
+	jwtManager    jwtmiddleware.Manager
+	idGenerator   idgenerator.Generator
+	allRepository pkg.RepositoryManager
+	slotConfig    config.SlotConfig
+}
+
+type Slot interface {
+	Create(ctx context.Context, request domain.CreateSlotsRequest) ([]models.DeliverySlot, error)
+	Update(ctx context.Context, id string, request domain.UpdateSlotRequest) (*models.DeliverySlot, error)
+	// Available lists the slots orders to the address can still be booked in
+	Available(ctx context.Context, request domain.AvailableSlotsRequest) ([]models.DeliverySlot, error)
+}
+
+func New(request pkg.ApplicationContext) Slot {
+	return &slotAppHandler{
+		jwtManager:    request.JwtManager,
+		idGenerator:   idgenerator.New(),
+		allRepository: request.RepositoryManager,
+		slotConfig:    request.Config.Slot,
+	}
+}
+
+// slotLGA is the LGA slots are kept under. States are compared in upper case, like those of delivery fees.
+func slotLGA(lga, state string) models.LGA {
+	return models.LGA{LGA: lga, State: strings.ToUpper(state)}
+}
+
+func (s *slotAppHandler) Create(ctx context.Context, request domain.CreateSlotsRequest) ([]models.DeliverySlot, error) {
+	if err := s.adminOnly(ctx); err != nil {
+		return nil, err
+	}
+
+	if request.LGA.State == "" || request.LGA.LGA == "" {
+		return nil, errs.Body(errs.InvalidRequestError, errors.New("lga and state are required"))
+	}
+	if request.Capacity <= 0 {
+		return nil, errs.Body(errs.InvalidRequestError, errors.New("capacity must be greater than zero"))
+	}
+	if len(request.Windows) == 0 {
+		return nil, errs.Body(errs.InvalidRequestError, errors.New("at least one window is required"))
+	}
+
+	now := time.Now().Unix()
+	lga := slotLGA(request.LGA.LGA, request.LGA.State)
+	slots := make([]models.DeliverySlot, len(request.Windows))
+	for i, window := range request.Windows {
+		if window.StartTs <= now || window.EndTs <= window.StartTs {
+			return nil, errs.Body(errs.InvalidRequestError, errors.New("windows must start in the future and end after they start"))
+		}
+
+		slots[i] = models.DeliverySlot{
+			ID:       s.idGenerator.Generate(),
+			LGA:      lga,
+			StartTs:  window.StartTs,
+			EndTs:    window.EndTs,
+			Capacity: request.Capacity,
+			Status:   models.SlotActive,
+			StatusTs: now,
+			Ts:       now,
+		}
+	}
+
+	err := s.allRepository.SlotRepository.Create(ctx, slots)
+	if err != nil {
+		return nil, err
+	}
+
+	return slots, nil
+}
+
+func (s *slotAppHandler) Update(ctx context.Context, id string, request domain.UpdateSlotRequest) (*models.DeliverySlot, error) {
+	if err := s.adminOnly(ctx); err != nil {
+		return nil, err
+	}
+
+	if request.Capacity < 0 {
+		return nil, errs.Body(errs.InvalidRequestError, errors.New("capacity cannot be negative"))
+	}
+	if request.Status != models.SlotActive && request.Status != models.SlotInactive {
+		return nil, errs.Body(errs.InvalidRequestError, errors.New("status must be ACTIVE or INACTIVE"))
+	}
+
+	err := s.allRepository.SlotRepository.Update(ctx, id, request.Capacity, request.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.allRepository.SlotRepository.ByID(ctx, id)
+}
+
+func (s *slotAppHandler) Available(ctx context.Context, request domain.AvailableSlotsRequest) ([]models.DeliverySlot, error) {
+	if _, err := s.jwtManager.ExtractUserClaims(ctx); err != nil {
+		return nil, errs.Body(errs.ErrorUnauthorized, err)
+	}
+
+	if request.Address.State == "" || request.Address.LGA == "" {
+		return nil, errs.Body(errs.InvalidRequestError, errors.New("address state and lga are required"))
+	}
+
+	slots, err := s.allRepository.SlotRepository.Upcoming(ctx, slotLGA(request.Address.LGA, request.Address.State), time.Now().Add(s.slotConfig.BookingLead).Unix())
+	if err != nil {
+		return nil, err
+	}
+
+	return lo.Filter(slots, func(slot models.DeliverySlot, _ int) bool {
+		return slot.Available() > 0
+	}), nil
+}
+
+func (s *slotAppHandler) adminOnly(ctx context.Context) error {
+	claims, err := s.jwtManager.ExtractUserClaims(ctx)
+	if err != nil {
+		return errs.Body(errs.ErrorUnauthorized, err)
+	}
+
+	if claims.Role != models.AdminCategory {
+		return errs.Body(errs.ErrorUnauthorized, errors.New("only admins can manage delivery slots"))
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package domain
+
+import "github.com/leetatech/leeta_backend/services/models"
+
+// CreateSlotsRequest opens delivery slots in an LGA, one per window, each with the same capacity
+type CreateSlotsRequest struct {
+	LGA      models.LGA   `json:"lga"`
+	Capacity int          `json:"capacity"`
+	Windows  []SlotWindow `json:"windows"`
+} // @name CreateSlotsRequest
+
+type SlotWindow struct {
+	StartTs int64 `json:"start_ts"`
+	EndTs   int64 `json:"end_ts"`
+} // @name SlotWindow
+
+// UpdateSlotRequest changes the capacity or status of a slot. The capacity cannot go below the orders already booked in it.
+type UpdateSlotRequest struct {
+	Capacity int               `json:"capacity"`
+	Status   models.SlotStatus `json:"status"`
+} // @name UpdateSlotRequest
+
+type AvailableSlotsRequest struct {
+	Address models.Address `json:"address"`
+} // @name AvailableSlotsRequest
@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"context"
+	"github.com/leetatech/leeta_backend/services/models"
+)
+
+type SlotRepository interface {
+	Create(ctx context.Context, slots []models.DeliverySlot) error
+	ByID(ctx context.Context, id string) (*models.DeliverySlot, error)
+	// Upcoming lists the active slots of the LGA starting after the time, including the full ones
+	Upcoming(ctx context.Context, lga models.LGA, afterTs int64) ([]models.DeliverySlot, error)
+	// Update fails with SlotUnavailableError when the capacity is below the orders already booked in the slot
+	Update(ctx context.Context, id string, capacity int, status models.SlotStatus) error
+	// Reserve books count orders in the active slot of the LGA if it starts after the time and has room for all of them
+	Reserve(ctx context.Context, id string, lga models.LGA, count int, afterTs int64) error
+	// Release frees the room of count orders booked in the slot
+	Release(ctx context.Context, id string, count int) error
+}
@@ -0,0 +1,146 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/services/models"
+	"github.com/leetatech/leeta_backend/services/slot/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type slotStoreHandler struct {
+	client       *mongo.Client
+	databaseName string
+}
+
+func (s *slotStoreHandler) col(collectionName string) *mongo.Collection {
+	return s.client.Database(s.databaseName).Collection(collectionName)
+}
+
+func New(client *mongo.Client, databaseName string) domain.SlotRepository {
+	return &slotStoreHandler{client: client, databaseName: databaseName}
+}
+
+func (s *slotStoreHandler) Create(ctx context.Context, slots []models.DeliverySlot) error {
+	updatedCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	documents := make([]interface{}, len(slots))
+	for i, slot := range slots {
+		documents[i] = slot
+	}
+
+	_, err := s.col(models.SlotsCollectionName).InsertMany(updatedCtx, documents)
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	return nil
+}
+
+func (s *slotStoreHandler) ByID(ctx context.Context, id string) (*models.DeliverySlot, error) {
+	slot := &models.DeliverySlot{}
+	err := s.col(models.SlotsCollectionName).FindOne(ctx, bson.M{"id": id}).Decode(slot)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errs.Body(errs.DatabaseNoRecordError, fmt.Errorf("delivery slot with id %s not found", id))
+		}
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	return slot, nil
+}
+
+func (s *slotStoreHandler) Upcoming(ctx context.Context, lga models.LGA, afterTs int64) ([]models.DeliverySlot, error) {
+	filter := bson.M{
+		"lga.state": lga.State,
+		"lga.lga":   lga.LGA,
+		"status":    models.SlotActive,
+		"start_ts":  bson.M{"$gt": afterTs},
+	}
+	opts := options.Find().SetSort(bson.M{"start_ts": 1})
+
+	cursor, err := s.col(models.SlotsCollectionName).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	slots := make([]models.DeliverySlot, 0)
+	if err = cursor.All(ctx, &slots); err != nil {
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	return slots, nil
+}
+
+func (s *slotStoreHandler) Update(ctx context.Context, id string, capacity int, status models.SlotStatus) error {
+	filter := bson.M{
+		"id":       id,
+		"reserved": bson.M{"$lte": capacity},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"capacity":  capacity,
+			"status":    status,
+			"status_ts": time.Now().Unix(),
+		},
+	}
+
+	result, err := s.col(models.SlotsCollectionName).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	if result.MatchedCount == 0 {
+		if _, err = s.ByID(ctx, id); err != nil {
+			return err
+		}
+		return errs.Body(errs.SlotUnavailableError, fmt.Errorf("delivery slot %s has more orders booked than a capacity of %d", id, capacity))
+	}
+
+	return nil
+}
+
+func (s *slotStoreHandler) Reserve(ctx context.Context, id string, lga models.LGA, count int, afterTs int64) error {
+	// the room is checked and taken in a single update, so concurrent checkouts cannot overbook the slot
+	filter := bson.M{
+		"id":        id,
+		"lga.state": lga.State,
+		"lga.lga":   lga.LGA,
+		"status":    models.SlotActive,
+		"start_ts":  bson.M{"$gt": afterTs},
+		"$expr":     bson.M{"$lte": bson.A{bson.M{"$add": bson.A{"$reserved", count}}, "$capacity"}},
+	}
+	update := bson.M{"$inc": bson.M{"reserved": count}}
+
+	result, err := s.col(models.SlotsCollectionName).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	if result.MatchedCount == 0 {
+		return errs.Body(errs.SlotUnavailableError, fmt.Errorf("delivery slot %s cannot take %d more orders", id, count))
+	}
+
+	return nil
+}
+
+func (s *slotStoreHandler) Release(ctx context.Context, id string, count int) error {
+	filter := bson.M{
+		"id":       id,
+		"reserved": bson.M{"$gte": count},
+	}
+	update := bson.M{"$inc": bson.M{"reserved": -count}}
+
+	_, err := s.col(models.SlotsCollectionName).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	return nil
+}
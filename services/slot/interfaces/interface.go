@@ -0,0 +1,112 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	_ "github.com/leetatech/leeta_backend/pkg"
+	"github.com/leetatech/leeta_backend/pkg/helpers"
+	"github.com/leetatech/leeta_backend/pkg/jwtmiddleware"
+	"github.com/leetatech/leeta_backend/services/slot/application"
+	"github.com/leetatech/leeta_backend/services/slot/domain"
+	"net/http"
+)
+
+type SlotHttpHandler struct {
+	SlotApplication application.Slot
+}
+
+func New(slotApplication application.Slot) *SlotHttpHandler {
+	return &SlotHttpHandler{
+		SlotApplication: slotApplication,
+	}
+}
+
+// CreateSlotsHandler godoc
+// @Summary Create delivery slots
+// @Description The endpoint opens a delivery slot in the LGA for every window, each taking up to capacity orders. Only admins can create slots
+// @Tags Slot
+// @Accept json
+// @produce json
+// @Param domain.CreateSlotsRequest body domain.CreateSlotsRequest true "create delivery slots request body"
+// @Security BearerToken
+// @success 201 {object} []models.DeliverySlot
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Router /slot/ [post]
+func (handler *SlotHttpHandler) CreateSlotsHandler(w http.ResponseWriter, r *http.Request) {
+	var request domain.CreateSlotsRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		jwtmiddleware.WriteJSONErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := handler.SlotApplication.Create(r.Context(), request)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, resp, http.StatusCreated)
+}
+
+// UpdateSlotHandler godoc
+// @Summary Update delivery slot
+// @Description The endpoint changes the capacity of a delivery slot or stops it taking orders. The capacity cannot go below the orders already booked. Only admins can update slots
+// @Tags Slot
+// @Accept json
+// @produce json
+// @Param			slot_id	path		string	true	"delivery slot id"
+// @Param domain.UpdateSlotRequest body domain.UpdateSlotRequest true "update delivery slot request body"
+// @Security BearerToken
+// @success 200 {object} models.DeliverySlot
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Failure 404 {object} pkg.DefaultErrorResponse
+// @Failure 409 {object} pkg.DefaultErrorResponse
+// @Router /slot/{slot_id} [put]
+func (handler *SlotHttpHandler) UpdateSlotHandler(w http.ResponseWriter, r *http.Request) {
+	var request domain.UpdateSlotRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		jwtmiddleware.WriteJSONErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := handler.SlotApplication.Update(r.Context(), chi.URLParam(r, "slot_id"), request)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, resp, http.StatusOK)
+}
+
+// AvailableSlotsHandler godoc
+// @Summary List available delivery slots
+// @Description The endpoint lists the upcoming delivery slots of the LGA of the address that can still take an order. The slot chosen is sent on checkout
+// @Tags Slot
+// @Accept json
+// @produce json
+// @Param domain.AvailableSlotsRequest body domain.AvailableSlotsRequest true "available delivery slots request body"
+// @Security BearerToken
+// @success 200 {object} []models.DeliverySlot
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Router /slot/available [post]
+func (handler *SlotHttpHandler) AvailableSlotsHandler(w http.ResponseWriter, r *http.Request) {
+	var request domain.AvailableSlotsRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		jwtmiddleware.WriteJSONErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := handler.SlotApplication.Available(r.Context(), request)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, resp, http.StatusOK)
+}
@@ -0,0 +1,302 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/leetatech/leeta_backend/pkg"
+	"github.com/leetatech/leeta_backend/pkg/config"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/pkg/idgenerator"
+	"github.com/leetatech/leeta_backend/pkg/jwtmiddleware"
+	mailer "github.com/leetatech/leeta_backend/pkg/notification/mailer/aws"
+	"github.com/leetatech/leeta_backend/pkg/payment"
+	"github.com/leetatech/leeta_backend/pkg/pricing"
+	"github.com/leetatech/leeta_backend/services/models"
+	"github.com/leetatech/leeta_backend/services/subscription/domain"
+	"time"
+)
+
+type subscriptionAppHandler struct {
+	jwtManager         jwtmiddleware.Manager
+	idGenerator        idgenerator.Generator
+	EmailClient        mailer.Client
+	allRepository      pkg.RepositoryManager
+	paymentProviders   payment.Providers
+	paymentConfig      config.PaymentConfig
+	subscriptionConfig config.SubscriptionConfig
+	mailerConfig       config.NotificationConfig
+	pricer             *pricing.Pricer
+}
+
+type Subscription interface {
+	Create(ctx context.Context, request domain.SubscriptionRequest) (*models.Subscription, error)
+	Subscription(ctx context.Context, id string) (*models.Subscription, error)
+	Subscriptions(ctx context.Context) ([]models.Subscription, error)
+	Update(ctx context.Context, id string, request domain.UpdateSubscriptionRequest) (*models.Subscription, error)
+	Pause(ctx context.Context, id string) (*models.Subscription, error)
+	Resume(ctx context.Context, id string) (*models.Subscription, error)
+	Skip(ctx context.Context, id string) (*models.Subscription, error)
+	Cancel(ctx context.Context, id string) (*models.Subscription, error)
+	// RunScheduler reminds customers of upcoming runs and creates the orders of due runs until the context is done
+	RunScheduler(ctx context.Context)
+}
+
+func New(request pkg.ApplicationContext) Subscription {
+	return &subscriptionAppHandler{
+		jwtManager:         request.JwtManager,
+		idGenerator:        idgenerator.New(),
+		EmailClient:        request.MailClient,
+		allRepository:      request.RepositoryManager,
+		paymentProviders:   request.PaymentProviders,
+		paymentConfig:      request.Config.Payment,
+		subscriptionConfig: request.Config.Subscription,
+		mailerConfig:       request.Config.Notification,
+		pricer:             pricing.New(request.RepositoryManager),
+	}
+}
+
+func (s *subscriptionAppHandler) Create(ctx context.Context, request domain.SubscriptionRequest) (*models.Subscription, error) {
+	claims, err := s.jwtManager.ExtractUserClaims(ctx)
+	if err != nil {
+		return nil, errs.Body(errs.ErrorUnauthorized, err)
+	}
+
+	if claims.Role != models.CustomerCategory {
+		return nil, errs.Body(errs.ErrorUnauthorized, errors.New("only customers can subscribe to refills"))
+	}
+
+	cadence, err := models.SetSubscriptionCadence(request.Cadence)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.allRepository.ProductRepository.Product(ctx, request.ProductID)
+	if err != nil {
+		return nil, errs.Body(errs.InvalidProductIdError, fmt.Errorf("error getting product id %s: %w", request.ProductID, err))
+	}
+
+	// the first run is at least a reminder lead away, so the customer is reminded of it like every other run
+	now := time.Now()
+	firstRun := now.Add(s.subscriptionConfig.ReminderLead)
+	if request.FirstRunTs != 0 {
+		if request.FirstRunTs < firstRun.Unix() {
+			return nil, errs.Body(errs.InvalidRequestError, fmt.Errorf("first run must be at least %s from now", s.subscriptionConfig.ReminderLead))
+		}
+		firstRun = time.Unix(request.FirstRunTs, 0)
+	}
+
+	if request.DeliveryDetails.Email == "" {
+		request.DeliveryDetails.Email = claims.Email
+	}
+
+	subscription := models.Subscription{
+		ID:              s.idGenerator.Generate(),
+		CustomerID:      claims.UserID,
+		ProductID:       product.ID,
+		VendorID:        product.VendorID,
+		Weight:          request.Weight,
+		Quantity:        request.Quantity,
+		DeliveryDetails: request.DeliveryDetails,
+		PaymentMethod:   request.PaymentMethod,
+		Cadence:         cadence,
+		NextRunTs:       firstRun.Unix(),
+		Status:          models.SubscriptionActive,
+		StatusTs:        now.Unix(),
+		Ts:              now.Unix(),
+	}
+
+	// price a run up front, so a subscription that can never be ordered is rejected now rather than by the scheduler
+	err = s.validate(ctx, subscription, product)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.allRepository.SubscriptionRepository.Create(ctx, subscription)
+	if err != nil {
+		return nil, err
+	}
+
+	return &subscription, nil
+}
+
+func (s *subscriptionAppHandler) Subscription(ctx context.Context, id string) (*models.Subscription, error) {
+	claims, err := s.jwtManager.ExtractUserClaims(ctx)
+	if err != nil {
+		return nil, errs.Body(errs.ErrorUnauthorized, err)
+	}
+
+	subscription, err := s.allRepository.SubscriptionRepository.ByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Role != models.AdminCategory && subscription.CustomerID != claims.UserID {
+		return nil, errs.Body(errs.ErrorUnauthorized, errors.New("you cannot access this subscription"))
+	}
+
+	return subscription, nil
+}
+
+func (s *subscriptionAppHandler) Subscriptions(ctx context.Context) ([]models.Subscription, error) {
+	claims, err := s.jwtManager.ExtractUserClaims(ctx)
+	if err != nil {
+		return nil, errs.Body(errs.ErrorUnauthorized, err)
+	}
+
+	return s.allRepository.SubscriptionRepository.ByCustomerID(ctx, claims.UserID)
+}
+
+func (s *subscriptionAppHandler) Update(ctx context.Context, id string, request domain.UpdateSubscriptionRequest) (*models.Subscription, error) {
+	subscription, err := s.ownSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Weight != 0 {
+		subscription.Weight = request.Weight
+	}
+	if request.Quantity != 0 {
+		subscription.Quantity = request.Quantity
+	}
+	if request.DeliveryDetails != nil {
+		subscription.DeliveryDetails = *request.DeliveryDetails
+	}
+	if request.PaymentMethod != "" {
+		subscription.PaymentMethod = request.PaymentMethod
+	}
+	if request.Cadence != "" {
+		subscription.Cadence, err = models.SetSubscriptionCadence(request.Cadence)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	product, err := s.allRepository.ProductRepository.Product(ctx, subscription.ProductID)
+	if err != nil {
+		return nil, errs.Body(errs.InvalidProductIdError, fmt.Errorf("error getting product id %s: %w", subscription.ProductID, err))
+	}
+
+	err = s.validate(ctx, *subscription, product)
+	if err != nil {
+		return nil, err
+	}
+
+	return subscription, s.allRepository.SubscriptionRepository.Update(ctx, *subscription)
+}
+
+func (s *subscriptionAppHandler) Pause(ctx context.Context, id string) (*models.Subscription, error) {
+	subscription, err := s.ownSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if subscription.Status != models.SubscriptionActive {
+		return nil, errs.Body(errs.InvalidRequestError, fmt.Errorf("subscription is %s and cannot be paused", subscription.Status))
+	}
+
+	subscription.Status = models.SubscriptionPaused
+	subscription.StatusTs = time.Now().Unix()
+
+	return subscription, s.allRepository.SubscriptionRepository.Update(ctx, *subscription)
+}
+
+func (s *subscriptionAppHandler) Resume(ctx context.Context, id string) (*models.Subscription, error) {
+	subscription, err := s.ownSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if subscription.Status != models.SubscriptionPaused {
+		return nil, errs.Body(errs.InvalidRequestError, fmt.Errorf("subscription is %s and cannot be resumed", subscription.Status))
+	}
+
+	// runs missed while the subscription was paused are not ordered
+	now := time.Now()
+	subscription.NextRunTs = subscription.Cadence.NextAfter(time.Unix(subscription.NextRunTs, 0), now).Unix()
+	subscription.FailedRuns = 0
+	subscription.Status = models.SubscriptionActive
+	subscription.StatusTs = now.Unix()
+
+	return subscription, s.allRepository.SubscriptionRepository.Update(ctx, *subscription)
+}
+
+// Skip moves the subscription to the run after its next one
+func (s *subscriptionAppHandler) Skip(ctx context.Context, id string) (*models.Subscription, error) {
+	subscription, err := s.ownSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if subscription.Status == models.SubscriptionCancelled {
+		return nil, errs.Body(errs.InvalidRequestError, errors.New("subscription is cancelled"))
+	}
+
+	subscription.NextRunTs = subscription.Cadence.Next(time.Unix(subscription.NextRunTs, 0)).Unix()
+	subscription.FailedRuns = 0
+
+	return subscription, s.allRepository.SubscriptionRepository.Update(ctx, *subscription)
+}
+
+func (s *subscriptionAppHandler) Cancel(ctx context.Context, id string) (*models.Subscription, error) {
+	subscription, err := s.ownSubscription(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if subscription.Status == models.SubscriptionCancelled {
+		return nil, errs.Body(errs.InvalidRequestError, errors.New("subscription is already cancelled"))
+	}
+
+	subscription.Status = models.SubscriptionCancelled
+	subscription.StatusTs = time.Now().Unix()
+
+	return subscription, s.allRepository.SubscriptionRepository.Update(ctx, *subscription)
+}
+
+func (s *subscriptionAppHandler) ownSubscription(ctx context.Context, id string) (*models.Subscription, error) {
+	claims, err := s.jwtManager.ExtractUserClaims(ctx)
+	if err != nil {
+		return nil, errs.Body(errs.ErrorUnauthorized, err)
+	}
+
+	subscription, err := s.allRepository.SubscriptionRepository.ByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if subscription.CustomerID != claims.UserID {
+		return nil, errs.Body(errs.ErrorUnauthorized, errors.New("you cannot change this subscription"))
+	}
+
+	return subscription, nil
+}
+
+func (s *subscriptionAppHandler) validate(ctx context.Context, subscription models.Subscription, product models.Product) error {
+	switch product.ParentCategory {
+	case models.LNGProductCategory, models.LPGProductCategory:
+		if subscription.Weight <= 0 {
+			return errs.Body(errs.InvalidRequestError, errors.New("weight is required for gas refills"))
+		}
+	}
+
+	if subscription.Quantity <= 0 {
+		return errs.Body(errs.InvalidRequestError, errors.New("quantity must be at least 1"))
+	}
+
+	if subscription.DeliveryDetails.Address.State == "" || subscription.DeliveryDetails.Address.LGA == "" {
+		return errs.Body(errs.InvalidRequestError, errors.New("delivery address state and lga are required"))
+	}
+
+	method, err := models.SetPaymentMethod(subscription.PaymentMethod)
+	if err != nil {
+		return err
+	}
+	// recurring orders cannot be charged online until customers can save a card
+	if !method.IsPaidOnDelivery() {
+		return errs.Body(errs.PaymentMethodError, fmt.Errorf("subscriptions can only be paid with %s", models.PaymentMethodCashOnDelivery))
+	}
+
+	_, err = s.price(ctx, subscription, product, time.Now().Unix())
+	return err
+}
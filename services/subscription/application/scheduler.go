@@ -0,0 +1,243 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"github.com/leetatech/leeta_backend/pkg"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/pkg/payment"
+	"github.com/leetatech/leeta_backend/pkg/pricing"
+	"github.com/leetatech/leeta_backend/services/models"
+	"github.com/rs/zerolog/log"
+	"strings"
+	"time"
+)
+
+const (
+	// runLock is how long a claimed run is kept from other schedulers, and how long a failed run waits before its first retry
+	runLock = 10 * time.Minute
+	// maxRunAttempts is how many times the order of a run is attempted before the subscription is paused
+	maxRunAttempts = 5
+	// maxClaimsPerTick bounds the work of a single tick, the rest is picked up by the next ones
+	maxClaimsPerTick = 100
+)
+
+func (s *subscriptionAppHandler) RunScheduler(ctx context.Context) {
+	ticker := time.NewTicker(s.subscriptionConfig.SchedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		s.tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// tick recovers from a panic processing a subscription, so it does not stop the scheduler or crash the service
+func (s *subscriptionAppHandler) tick(ctx context.Context) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error().Msgf("subscription scheduler recovered from panic: %v", r)
+		}
+	}()
+
+	s.sendReminders(ctx)
+	s.createDueOrders(ctx)
+}
+
+func (s *subscriptionAppHandler) sendReminders(ctx context.Context) {
+	for i := 0; i < maxClaimsPerTick; i++ {
+		now := time.Now()
+		subscription, err := s.allRepository.SubscriptionRepository.ClaimDueReminder(ctx, now.Unix(), now.Add(s.subscriptionConfig.ReminderLead).Unix())
+		if err != nil {
+			log.Error().Err(err).Msg("error claiming subscription reminder")
+			return
+		}
+		if subscription == nil {
+			return
+		}
+
+		err = s.sendReminder(*subscription)
+		if err != nil {
+			log.Error().Err(err).Msgf("error sending reminder of subscription %s", subscription.ID)
+		}
+	}
+}
+
+func (s *subscriptionAppHandler) sendReminder(subscription models.Subscription) error {
+	if subscription.DeliveryDetails.Email == "" {
+		return nil
+	}
+
+	return s.EmailClient.Send(pkg.SubscriptionReminderTemplatePath, models.Message{
+		ID:         s.idGenerator.Generate(),
+		UserID:     subscription.CustomerID,
+		TemplateID: pkg.SubscriptionReminderTemplatePath,
+		Title:      "Your refill is coming up",
+		Sender:     s.mailerConfig.VerificationEmail,
+		DataMap: map[string]string{
+			"Name":    subscription.DeliveryDetails.Name,
+			"Cadence": strings.ToLower(string(subscription.Cadence)),
+			"RunDate": time.Unix(subscription.NextRunTs, 0).Format("Monday, 2 January 2006"),
+			"Address": subscription.DeliveryDetails.Address.FullAddress,
+		},
+		Recipients: []string{
+			subscription.DeliveryDetails.Email,
+		},
+		Ts: time.Now().Unix(),
+	})
+}
+
+func (s *subscriptionAppHandler) createDueOrders(ctx context.Context) {
+	for i := 0; i < maxClaimsPerTick; i++ {
+		now := time.Now()
+		subscription, err := s.allRepository.SubscriptionRepository.ClaimDue(ctx, now.Unix(), now.Add(runLock).Unix())
+		if err != nil {
+			log.Error().Err(err).Msg("error claiming due subscription")
+			return
+		}
+		if subscription == nil {
+			return
+		}
+
+		err = s.createOrder(ctx, *subscription, now)
+		if err != nil {
+			log.Error().Err(err).Msgf("error creating order of subscription %s", subscription.ID)
+			s.failRun(ctx, *subscription, now)
+		}
+	}
+}
+
+// failRun locks a failed run for twice as long after every attempt before it is retried, and pauses the subscription once
+// maxRunAttempts have failed, so a run that can never be ordered is not retried forever. The customer resumes it once it can be.
+func (s *subscriptionAppHandler) failRun(ctx context.Context, subscription models.Subscription, now time.Time) {
+	failedRuns := subscription.FailedRuns + 1
+	pause := failedRuns >= maxRunAttempts
+	retryTs := now.Add(runLock << (failedRuns - 1)).Unix()
+
+	err := s.allRepository.SubscriptionRepository.FailRun(ctx, subscription.ID, subscription.NextRunTs, failedRuns, retryTs, pause)
+	if err != nil {
+		log.Error().Err(err).Msgf("error recording failed run of subscription %s", subscription.ID)
+		return
+	}
+
+	if pause {
+		log.Warn().Msgf("subscription %s paused after %d failed runs", subscription.ID, failedRuns)
+	}
+}
+
+// createOrder checks out the subscription's run as a single vendor order and moves the subscription to its next run
+func (s *subscriptionAppHandler) createOrder(ctx context.Context, subscription models.Subscription, now time.Time) error {
+	product, err := s.allRepository.ProductRepository.Product(ctx, subscription.ProductID)
+	if err != nil {
+		return fmt.Errorf("error getting product id %s: %w", subscription.ProductID, err)
+	}
+
+	price, err := s.price(ctx, subscription, product, now.Unix())
+	if err != nil {
+		return err
+	}
+
+	provider, err := s.paymentProviders.ForMethod(subscription.PaymentMethod)
+	if err != nil {
+		return errs.Body(errs.PaymentMethodError, err)
+	}
+
+	ts := now.Unix()
+	checkout := models.Checkout{
+		ID:              s.idGenerator.Generate(),
+		CustomerID:      subscription.CustomerID,
+		DeliveryDetails: subscription.DeliveryDetails,
+		PaymentMethod:   subscription.PaymentMethod,
+		Total:           price.Total,
+		Ts:              ts,
+	}
+
+	order := models.Order{
+		ID:              s.idGenerator.Generate(),
+		CheckoutID:      checkout.ID,
+		Orders:          price.Items,
+		CustomerID:      subscription.CustomerID,
+		VendorID:        subscription.VendorID,
+		DeliveryDetails: subscription.DeliveryDetails,
+		PaymentMethod:   subscription.PaymentMethod,
+		DeliveryFee:     price.DeliveryFee,
+		ServiceFee:      price.ServiceFee,
+		Total:           price.Total,
+		Status:          models.OrderPending,
+		StatusHistory: []models.StatusHistory{
+			{
+				Status:   models.OrderPending,
+				StatusTs: ts,
+			},
+		},
+		StatusTs: ts,
+		Ts:       ts,
+	}
+	checkout.OrderIDs = []string{order.ID}
+
+	paymentRecord := models.Payment{
+		ID:         s.idGenerator.Generate(),
+		CheckoutID: checkout.ID,
+		CustomerID: subscription.CustomerID,
+		Method:     subscription.PaymentMethod,
+		Provider:   provider.Name(),
+		Amount:     price.Total,
+		Status:     models.PaymentPending,
+		StatusTs:   ts,
+		Ts:         ts,
+	}
+	paymentRecord.Reference = paymentRecord.ID
+	checkout.PaymentID = paymentRecord.ID
+
+	_, err = provider.Initialize(ctx, payment.InitializeRequest{
+		Reference:   paymentRecord.Reference,
+		Amount:      paymentRecord.Amount,
+		Email:       subscription.DeliveryDetails.Email,
+		CallbackURL: s.paymentConfig.CallbackURL,
+	})
+	if err != nil {
+		return errs.Body(errs.PaymentError, fmt.Errorf("error initializing payment: %w", err))
+	}
+
+	// runs missed while the scheduler was down are not ordered
+	nextRun := subscription.Cadence.NextAfter(subscription.Cadence.Next(time.Unix(subscription.NextRunTs, 0)), now)
+
+	return s.allRepository.Transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		err := s.allRepository.OrderRepository.CreateCheckout(ctx, checkout, []models.Order{order})
+		if err != nil {
+			return fmt.Errorf("error creating subscription order: %w", err)
+		}
+
+		err = s.allRepository.PaymentRepository.Create(ctx, paymentRecord)
+		if err != nil {
+			return fmt.Errorf("error creating subscription payment: %w", err)
+		}
+
+		return s.allRepository.SubscriptionRepository.Advance(ctx, subscription.ID, subscription.NextRunTs, nextRun.Unix(), order.ID)
+	})
+}
+
+// price prices one run of the subscription with the fees in force at the unix time, as the cart would quote it
+func (s *subscriptionAppHandler) price(ctx context.Context, subscription models.Subscription, product models.Product, at int64) (*pricing.Line, error) {
+	// refills on a schedule swap the cylinders the customer already has, so they carry no deposit
+	item := models.CartItem{
+		ID:              s.idGenerator.Generate(),
+		ProductID:       product.ID,
+		ProductCategory: product.ParentCategory,
+		VendorID:        subscription.VendorID,
+		Weight:          subscription.Weight,
+		Quantity:        subscription.Quantity,
+	}
+
+	price, err := s.pricer.Price(ctx, []models.CartItem{item}, subscription.DeliveryDetails.Address, at)
+	if err != nil {
+		return nil, err
+	}
+
+	return &price.Lines[0], nil
+}
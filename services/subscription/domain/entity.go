@@ -0,0 +1,22 @@
+package domain
+
+import "github.com/leetatech/leeta_backend/services/models"
+
+type SubscriptionRequest struct {
+	ProductID       string                     `json:"product_id"`
+	Weight          float64                    `json:"weight,omitempty"`
+	Quantity        int                        `json:"quantity"`
+	DeliveryDetails models.ShippingInfo        `json:"delivery_details"`
+	PaymentMethod   models.PaymentMethod       `json:"payment_method"`
+	Cadence         models.SubscriptionCadence `json:"cadence"`
+	// FirstRunTs is when the first order is created, at least the reminder lead from now, which is the default
+	FirstRunTs int64 `json:"first_run_ts,omitempty"`
+} // @name SubscriptionRequest
+
+type UpdateSubscriptionRequest struct {
+	Weight          float64                    `json:"weight,omitempty"`
+	Quantity        int                        `json:"quantity,omitempty"`
+	DeliveryDetails *models.ShippingInfo       `json:"delivery_details,omitempty"`
+	PaymentMethod   models.PaymentMethod       `json:"payment_method,omitempty"`
+	Cadence         models.SubscriptionCadence `json:"cadence,omitempty"`
+} // @name UpdateSubscriptionRequest
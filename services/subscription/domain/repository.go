@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"context"
+	"github.com/leetatech/leeta_backend/services/models"
+)
+
+type SubscriptionRepository interface {
+	Create(ctx context.Context, subscription models.Subscription) error
+	ByID(ctx context.Context, id string) (*models.Subscription, error)
+	ByCustomerID(ctx context.Context, customerID string) ([]models.Subscription, error)
+	Update(ctx context.Context, subscription models.Subscription) error
+	// ClaimDue locks one active subscription whose run is due, so only one scheduler creates its order.
+	ClaimDue(ctx context.Context, now, lockUntil int64) (*models.Subscription, error)
+	// ClaimDueReminder marks one active subscription running before remindUntil as reminded of its next run and returns it
+	ClaimDueReminder(ctx context.Context, now, remindUntil int64) (*models.Subscription, error)
+	// Advance moves a subscription claimed for the given run to its next run. It fails if the run was changed since it was claimed.
+	Advance(ctx context.Context, id string, claimedRunTs, nextRunTs int64, lastOrderID string) error
+	// FailRun records a failed attempt at the claimed run and locks it until retryTs, or pauses the subscription when pause is set
+	FailRun(ctx context.Context, id string, claimedRunTs int64, failedRuns int, retryTs int64, pause bool) error
+}
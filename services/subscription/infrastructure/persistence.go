@@ -0,0 +1,190 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/services/models"
+	"github.com/leetatech/leeta_backend/services/subscription/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type subscriptionStoreHandler struct {
+	client       *mongo.Client
+	databaseName string
+}
+
+func (s *subscriptionStoreHandler) col(collectionName string) *mongo.Collection {
+	return s.client.Database(s.databaseName).Collection(collectionName)
+}
+
+func New(client *mongo.Client, databaseName string) domain.SubscriptionRepository {
+	return &subscriptionStoreHandler{client: client, databaseName: databaseName}
+}
+
+func (s *subscriptionStoreHandler) Create(ctx context.Context, subscription models.Subscription) error {
+	updatedCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := s.col(models.SubscriptionsCollectionName).InsertOne(updatedCtx, subscription)
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	return nil
+}
+
+func (s *subscriptionStoreHandler) ByID(ctx context.Context, id string) (*models.Subscription, error) {
+	subscription := &models.Subscription{}
+	err := s.col(models.SubscriptionsCollectionName).FindOne(ctx, bson.M{"id": id}).Decode(subscription)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errs.Body(errs.DatabaseNoRecordError, fmt.Errorf("subscription with id %s not found", id))
+		}
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	return subscription, nil
+}
+
+func (s *subscriptionStoreHandler) ByCustomerID(ctx context.Context, customerID string) ([]models.Subscription, error) {
+	opts := options.Find().SetSort(bson.M{"ts": -1})
+	cursor, err := s.col(models.SubscriptionsCollectionName).Find(ctx, bson.M{"customer_id": customerID}, opts)
+	if err != nil {
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	subscriptions := make([]models.Subscription, 0)
+	if err = cursor.All(ctx, &subscriptions); err != nil {
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	return subscriptions, nil
+}
+
+func (s *subscriptionStoreHandler) Update(ctx context.Context, subscription models.Subscription) error {
+	update := bson.M{
+		"$set": bson.M{
+			"weight":           subscription.Weight,
+			"quantity":         subscription.Quantity,
+			"delivery_details": subscription.DeliveryDetails,
+			"payment_method":   subscription.PaymentMethod,
+			"cadence":          subscription.Cadence,
+			"next_run_ts":      subscription.NextRunTs,
+			"status":           subscription.Status,
+			"status_ts":        subscription.StatusTs,
+			"failed_runs":      subscription.FailedRuns,
+		},
+	}
+
+	result, err := s.col(models.SubscriptionsCollectionName).UpdateOne(ctx, bson.M{"id": subscription.ID}, update)
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	if result.MatchedCount == 0 {
+		return errs.Body(errs.DatabaseNoRecordError, fmt.Errorf("subscription with id %s not found", subscription.ID))
+	}
+
+	return nil
+}
+
+func (s *subscriptionStoreHandler) ClaimDue(ctx context.Context, now, lockUntil int64) (*models.Subscription, error) {
+	filter := bson.M{
+		"status":       models.SubscriptionActive,
+		"next_run_ts":  bson.M{"$lte": now},
+		"locked_until": bson.M{"$lt": now},
+	}
+	update := bson.M{"$set": bson.M{"locked_until": lockUntil}}
+	opts := options.FindOneAndUpdate().SetSort(bson.M{"next_run_ts": 1}).SetReturnDocument(options.After)
+
+	return s.claim(ctx, filter, update, opts)
+}
+
+func (s *subscriptionStoreHandler) ClaimDueReminder(ctx context.Context, now, remindUntil int64) (*models.Subscription, error) {
+	filter := bson.M{
+		"status":      models.SubscriptionActive,
+		"next_run_ts": bson.M{"$gt": now, "$lte": remindUntil},
+		"$expr":       bson.M{"$ne": bson.A{"$reminded_run_ts", "$next_run_ts"}},
+	}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"reminded_run_ts": "$next_run_ts"}}},
+	}
+	opts := options.FindOneAndUpdate().SetSort(bson.M{"next_run_ts": 1}).SetReturnDocument(options.After)
+
+	return s.claim(ctx, filter, update, opts)
+}
+
+// claim returns nil when no subscription matches
+func (s *subscriptionStoreHandler) claim(ctx context.Context, filter bson.M, update any, opts *options.FindOneAndUpdateOptions) (*models.Subscription, error) {
+	subscription := &models.Subscription{}
+	err := s.col(models.SubscriptionsCollectionName).FindOneAndUpdate(ctx, filter, update, opts).Decode(subscription)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	return subscription, nil
+}
+
+func (s *subscriptionStoreHandler) Advance(ctx context.Context, id string, claimedRunTs, nextRunTs int64, lastOrderID string) error {
+	filter := bson.M{
+		"id":          id,
+		"status":      models.SubscriptionActive,
+		"next_run_ts": claimedRunTs,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"next_run_ts":   nextRunTs,
+			"last_order_id": lastOrderID,
+			"failed_runs":   0,
+			"locked_until":  0,
+		},
+	}
+
+	result, err := s.col(models.SubscriptionsCollectionName).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	if result.MatchedCount == 0 {
+		return errs.Body(errs.InvalidRequestError, fmt.Errorf("subscription %s changed while its run was processed", id))
+	}
+
+	return nil
+}
+
+func (s *subscriptionStoreHandler) FailRun(ctx context.Context, id string, claimedRunTs int64, failedRuns int, retryTs int64, pause bool) error {
+	filter := bson.M{
+		"id":          id,
+		"status":      models.SubscriptionActive,
+		"next_run_ts": claimedRunTs,
+	}
+	set := bson.M{
+		"failed_runs":  failedRuns,
+		"locked_until": retryTs,
+	}
+	if pause {
+		set["failed_runs"] = 0
+		set["locked_until"] = 0
+		set["status"] = models.SubscriptionPaused
+		set["status_ts"] = time.Now().Unix()
+	}
+
+	result, err := s.col(models.SubscriptionsCollectionName).UpdateOne(ctx, filter, bson.M{"$set": set})
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	if result.MatchedCount == 0 {
+		return errs.Body(errs.InvalidRequestError, fmt.Errorf("subscription %s changed while its run was processed", id))
+	}
+
+	return nil
+}
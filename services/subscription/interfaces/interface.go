@@ -0,0 +1,206 @@
+package interfaces
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	_ "github.com/leetatech/leeta_backend/pkg"
+	"github.com/leetatech/leeta_backend/pkg/helpers"
+	"github.com/leetatech/leeta_backend/pkg/jwtmiddleware"
+	"github.com/leetatech/leeta_backend/services/models"
+	"github.com/leetatech/leeta_backend/services/subscription/application"
+	"github.com/leetatech/leeta_backend/services/subscription/domain"
+	"net/http"
+)
+
+type SubscriptionHttpHandler struct {
+	SubscriptionApplication application.Subscription
+}
+
+func New(subscriptionApplication application.Subscription) *SubscriptionHttpHandler {
+	return &SubscriptionHttpHandler{
+		SubscriptionApplication: subscriptionApplication,
+	}
+}
+
+// CreateSubscriptionHandler godoc
+// @Summary Create refill subscription
+// @Description The endpoint subscribes the signed in customer to a recurring refill of a product. An order is created on every run of the cadence, starting from first_run_ts or now
+// @Tags Subscription
+// @Accept json
+// @produce json
+// @Param domain.SubscriptionRequest body domain.SubscriptionRequest true "subscription request body"
+// @Security BearerToken
+// @success 201 {object} models.Subscription
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Failure 404 {object} pkg.DefaultErrorResponse
+// @Router /subscription/ [post]
+func (handler *SubscriptionHttpHandler) CreateSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	var request domain.SubscriptionRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		jwtmiddleware.WriteJSONErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := handler.SubscriptionApplication.Create(r.Context(), request)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, resp, http.StatusCreated)
+}
+
+// ListSubscriptionsHandler godoc
+// @Summary List refill subscriptions
+// @Description The endpoint returns the refill subscriptions of the signed in customer
+// @Tags Subscription
+// @Accept json
+// @produce json
+// @Security BearerToken
+// @success 200 {object} []models.Subscription
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Router /subscription/ [get]
+func (handler *SubscriptionHttpHandler) ListSubscriptionsHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := handler.SubscriptionApplication.Subscriptions(r.Context())
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, resp, http.StatusOK)
+}
+
+// GetSubscriptionHandler godoc
+// @Summary Get refill subscription
+// @Description The endpoint returns a refill subscription of the signed in customer. Admins can read any subscription
+// @Tags Subscription
+// @Accept json
+// @produce json
+// @Param			subscription_id	path		string	true	"subscription id"
+// @Security BearerToken
+// @success 200 {object} models.Subscription
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 404 {object} pkg.DefaultErrorResponse
+// @Router /subscription/{subscription_id} [get]
+func (handler *SubscriptionHttpHandler) GetSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := handler.SubscriptionApplication.Subscription(r.Context(), chi.URLParam(r, "subscription_id"))
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, resp, http.StatusOK)
+}
+
+// UpdateSubscriptionHandler godoc
+// @Summary Update refill subscription
+// @Description The endpoint changes the quantity, weight, delivery details, payment method or cadence of a refill subscription. Omitted fields are left unchanged
+// @Tags Subscription
+// @Accept json
+// @produce json
+// @Param			subscription_id	path		string	true	"subscription id"
+// @Param domain.UpdateSubscriptionRequest body domain.UpdateSubscriptionRequest true "update subscription request body"
+// @Security BearerToken
+// @success 200 {object} models.Subscription
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Failure 404 {object} pkg.DefaultErrorResponse
+// @Router /subscription/{subscription_id} [put]
+func (handler *SubscriptionHttpHandler) UpdateSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	var request domain.UpdateSubscriptionRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		jwtmiddleware.WriteJSONErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := handler.SubscriptionApplication.Update(r.Context(), chi.URLParam(r, "subscription_id"), request)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, resp, http.StatusOK)
+}
+
+// PauseSubscriptionHandler godoc
+// @Summary Pause refill subscription
+// @Description The endpoint pauses an active refill subscription. No orders are created until it is resumed
+// @Tags Subscription
+// @Accept json
+// @produce json
+// @Param			subscription_id	path		string	true	"subscription id"
+// @Security BearerToken
+// @success 200 {object} models.Subscription
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Failure 404 {object} pkg.DefaultErrorResponse
+// @Router /subscription/{subscription_id}/pause [put]
+func (handler *SubscriptionHttpHandler) PauseSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	handler.changeSubscription(w, r, handler.SubscriptionApplication.Pause)
+}
+
+// ResumeSubscriptionHandler godoc
+// @Summary Resume refill subscription
+// @Description The endpoint resumes a paused refill subscription from its next run after now. Runs missed while paused are not ordered
+// @Tags Subscription
+// @Accept json
+// @produce json
+// @Param			subscription_id	path		string	true	"subscription id"
+// @Security BearerToken
+// @success 200 {object} models.Subscription
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Failure 404 {object} pkg.DefaultErrorResponse
+// @Router /subscription/{subscription_id}/resume [put]
+func (handler *SubscriptionHttpHandler) ResumeSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	handler.changeSubscription(w, r, handler.SubscriptionApplication.Resume)
+}
+
+// SkipSubscriptionHandler godoc
+// @Summary Skip next refill
+// @Description The endpoint skips the next run of a refill subscription, moving it one cycle later
+// @Tags Subscription
+// @Accept json
+// @produce json
+// @Param			subscription_id	path		string	true	"subscription id"
+// @Security BearerToken
+// @success 200 {object} models.Subscription
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Failure 404 {object} pkg.DefaultErrorResponse
+// @Router /subscription/{subscription_id}/skip [put]
+func (handler *SubscriptionHttpHandler) SkipSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	handler.changeSubscription(w, r, handler.SubscriptionApplication.Skip)
+}
+
+// CancelSubscriptionHandler godoc
+// @Summary Cancel refill subscription
+// @Description The endpoint cancels a refill subscription. Cancelled subscriptions cannot be resumed
+// @Tags Subscription
+// @Accept json
+// @produce json
+// @Param			subscription_id	path		string	true	"subscription id"
+// @Security BearerToken
+// @success 200 {object} models.Subscription
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Failure 404 {object} pkg.DefaultErrorResponse
+// @Router /subscription/{subscription_id}/cancel [put]
+func (handler *SubscriptionHttpHandler) CancelSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	handler.changeSubscription(w, r, handler.SubscriptionApplication.Cancel)
+}
+
+func (handler *SubscriptionHttpHandler) changeSubscription(w http.ResponseWriter, r *http.Request, change func(ctx context.Context, id string) (*models.Subscription, error)) {
+	resp, err := change(r.Context(), chi.URLParam(r, "subscription_id"))
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, resp, http.StatusOK)
+}
@@ -0,0 +1,543 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query"
+	"github.com/leetatech/leeta_backend/pkg"
+	"github.com/leetatech/leeta_backend/pkg/config"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/pkg/idgenerator"
+	"github.com/leetatech/leeta_backend/pkg/jwtmiddleware"
+	mailer "github.com/leetatech/leeta_backend/pkg/notification/mailer/aws"
+	"github.com/leetatech/leeta_backend/services/models"
+	refundDomain "github.com/leetatech/leeta_backend/services/refund/domain"
+	"github.com/leetatech/leeta_backend/services/ticket/domain"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	maxSubjectLength = 200
+	maxMessageLength = 4000
+)
+
+type ticketAppHandler struct {
+	jwtManager    jwtmiddleware.Manager
+	idGenerator   idgenerator.Generator
+	EmailClient   mailer.Client
+	allRepository pkg.RepositoryManager
+	mailerConfig  config.NotificationConfig
+	ticketConfig  config.TicketConfig
+}
+
+type Ticket interface {
+	// Open opens a ticket against an order of the signed in customer
+	Open(ctx context.Context, request domain.OpenTicketRequest) (*models.Ticket, error)
+	Ticket(ctx context.Context, id string) (*models.Ticket, error)
+	// List lists the tickets of the signed in customer, or every ticket for admins
+	List(ctx context.Context, request query.ResultSelector) ([]models.Ticket, uint64, error)
+	Reply(ctx context.Context, id string, request domain.ReplyTicketRequest) (*models.Ticket, error)
+	Triage(ctx context.Context, id string, request domain.TriageTicketRequest) (*models.Ticket, error)
+	// Resolve settles the ticket, refunding the customer or delivering the order again when the resolution calls for it
+	Resolve(ctx context.Context, id string, request domain.ResolveTicketRequest) (*models.Ticket, error)
+}
+
+func New(request pkg.ApplicationContext) Ticket {
+	return &ticketAppHandler{
+		jwtManager:    request.JwtManager,
+		idGenerator:   idgenerator.New(),
+		EmailClient:   request.MailClient,
+		allRepository: request.RepositoryManager,
+		mailerConfig:  request.Config.Notification,
+		ticketConfig:  request.Config.Ticket,
+	}
+}
+
+func (t *ticketAppHandler) Open(ctx context.Context, request domain.OpenTicketRequest) (*models.Ticket, error) {
+	claims, err := t.jwtManager.ExtractUserClaims(ctx)
+	if err != nil {
+		return nil, errs.Body(errs.ErrorUnauthorized, err)
+	}
+
+	if claims.Role != models.CustomerCategory {
+		return nil, errs.Body(errs.ErrorUnauthorized, errors.New("only customers can open tickets"))
+	}
+
+	if !models.IsValidTicketCategory(request.Category) {
+		return nil, errs.Body(errs.InvalidRequestError, fmt.Errorf("invalid ticket category %s", request.Category))
+	}
+
+	subject := strings.TrimSpace(request.Subject)
+	if subject == "" || utf8.RuneCountInString(subject) > maxSubjectLength {
+		return nil, errs.Body(errs.InvalidRequestError, fmt.Errorf("subject is required and cannot be longer than %d characters", maxSubjectLength))
+	}
+
+	body, err := messageBody(request.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := t.allRepository.OrderRepository.OrderByID(ctx, request.OrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.CustomerID != claims.UserID {
+		return nil, errs.Body(errs.ErrorUnauthorized, errors.New("you can only open tickets about your own orders"))
+	}
+
+	if order.CurrentStatus() == models.OrderAwaitingPayment {
+		return nil, errs.Body(errs.InvalidRequestError, errors.New("order has not been paid for yet"))
+	}
+
+	now := time.Now()
+	message := models.TicketMessage{
+		ID:       t.idGenerator.Generate(),
+		AuthorID: claims.UserID,
+		Role:     claims.Role,
+		Body:     body,
+		Ts:       now.Unix(),
+	}
+	ticket := models.Ticket{
+		ID:         t.idGenerator.Generate(),
+		OrderID:    order.ID,
+		CustomerID: claims.UserID,
+		VendorID:   order.VendorID,
+		Category:   request.Category,
+		Subject:    subject,
+		Priority:   models.TicketPriorityNormal,
+		Messages:   []models.TicketMessage{message},
+		SLA: models.TicketSLA{
+			FirstResponseDueTs: now.Add(t.ticketConfig.FirstResponseSLA).Unix(),
+			ResolutionDueTs:    now.Add(t.ticketConfig.ResolutionSLA).Unix(),
+		},
+		Status: models.TicketOpen,
+		StatusHistory: []models.TicketStatusHistory{
+			{
+				Status:    models.TicketOpen,
+				Reason:    "opened by customer",
+				UpdatedBy: claims.UserID,
+				StatusTs:  now.Unix(),
+			},
+		},
+		StatusTs: now.Unix(),
+		Ts:       now.Unix(),
+	}
+
+	err = t.allRepository.TicketRepository.Create(ctx, ticket)
+	if err != nil {
+		return nil, err
+	}
+
+	t.notifyMessage(&ticket, order, message)
+
+	return &ticket, nil
+}
+
+func (t *ticketAppHandler) Ticket(ctx context.Context, id string) (*models.Ticket, error) {
+	_, ticket, err := t.accessibleTicket(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return ticket, nil
+}
+
+func (t *ticketAppHandler) List(ctx context.Context, request query.ResultSelector) ([]models.Ticket, uint64, error) {
+	claims, err := t.jwtManager.ExtractUserClaims(ctx)
+	if err != nil {
+		return nil, 0, errs.Body(errs.ErrorUnauthorized, err)
+	}
+
+	var scope domain.TicketScope
+	switch claims.Role {
+	case models.AdminCategory:
+	case models.CustomerCategory:
+		scope.CustomerID = claims.UserID
+	default:
+		return nil, 0, errs.Body(errs.ErrorUnauthorized, errors.New("only customers and support can list tickets"))
+	}
+
+	return t.allRepository.TicketRepository.Tickets(ctx, request, scope)
+}
+
+// Reply adds a message to the ticket. The first support reply meets the first response SLA, and replies move the ticket
+// back to support once the customer answers.
+func (t *ticketAppHandler) Reply(ctx context.Context, id string, request domain.ReplyTicketRequest) (*models.Ticket, error) {
+	claims, ticket, err := t.accessibleTicket(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := messageBody(request.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	if !models.IsTicketOpenForMessages(ticket.Status) {
+		return nil, errs.Body(errs.TicketStatusTransitionError, fmt.Errorf("ticket is %s, open a new ticket", ticket.Status))
+	}
+
+	now := time.Now().Unix()
+	message := models.TicketMessage{
+		ID:       t.idGenerator.Generate(),
+		AuthorID: claims.UserID,
+		Role:     claims.Role,
+		Body:     body,
+		Ts:       now,
+	}
+	update := domain.TicketUpdate{
+		CurrentStatus: ticket.Status,
+		Message:       &message,
+	}
+
+	switch {
+	case claims.Role == models.AdminCategory:
+		if ticket.SLA.FirstResponseTs == 0 {
+			update.FirstResponseTs = now
+		}
+		if ticket.Status == models.TicketOpen {
+			update.History = ticketHistory(models.TicketInProgress, "replied by support", claims.UserID, now)
+		}
+	case ticket.Status == models.TicketAwaitingCustomer:
+		update.History = ticketHistory(models.TicketInProgress, "replied by customer", claims.UserID, now)
+	}
+
+	err = t.allRepository.TicketRepository.Update(ctx, id, update)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.updatedTicket(ctx, id, message)
+}
+
+func (t *ticketAppHandler) Triage(ctx context.Context, id string, request domain.TriageTicketRequest) (*models.Ticket, error) {
+	claims, err := t.adminClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ticket, err := t.allRepository.TicketRepository.ByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	update := domain.TicketUpdate{CurrentStatus: ticket.Status}
+
+	if request.Status != "" && request.Status != ticket.Status {
+		if request.Status == models.TicketResolved {
+			return nil, errs.Body(errs.TicketStatusTransitionError, errors.New("tickets are resolved with a resolution"))
+		}
+		err = models.ValidateTicketStatusTransition(ticket.Status, request.Status)
+		if err != nil {
+			return nil, err
+		}
+
+		reason := strings.TrimSpace(request.Reason)
+		if reason == "" {
+			reason = "triaged by support"
+		}
+		update.History = ticketHistory(request.Status, reason, claims.UserID, now)
+		if request.Status == models.TicketClosed {
+			update.ClosedTs = now
+		}
+	}
+
+	if request.Priority != "" {
+		if !models.IsValidTicketPriority(request.Priority) {
+			return nil, errs.Body(errs.InvalidRequestError, fmt.Errorf("invalid ticket priority %s", request.Priority))
+		}
+		update.Priority = request.Priority
+	}
+
+	if request.AssignedTo != "" {
+		identity, err := t.allRepository.AuthRepository.IdentityByUserID(ctx, request.AssignedTo)
+		if err != nil || identity.Role != models.AdminCategory {
+			return nil, errs.Body(errs.InvalidRequestError, fmt.Errorf("user %s is not a support admin", request.AssignedTo))
+		}
+		update.AssignedTo = request.AssignedTo
+	}
+
+	err = t.allRepository.TicketRepository.Update(ctx, id, update)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.allRepository.TicketRepository.ByID(ctx, id)
+}
+
+func (t *ticketAppHandler) Resolve(ctx context.Context, id string, request domain.ResolveTicketRequest) (*models.Ticket, error) {
+	claims, err := t.adminClaims(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !models.IsValidTicketResolutionAction(request.Action) {
+		return nil, errs.Body(errs.InvalidRequestError, fmt.Errorf("invalid resolution action %s", request.Action))
+	}
+
+	note, err := messageBody(request.Note)
+	if err != nil {
+		return nil, err
+	}
+
+	ticket, err := t.allRepository.TicketRepository.ByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = models.ValidateTicketStatusTransition(ticket.Status, models.TicketResolved)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := t.allRepository.OrderRepository.OrderByID(ctx, ticket.OrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	resolution := models.TicketResolution{
+		Action:     request.Action,
+		Note:       note,
+		ResolvedBy: claims.UserID,
+		Ts:         now,
+	}
+
+	var (
+		refund      *models.Refund
+		replacement *models.Order
+	)
+	switch request.Action {
+	case models.TicketRefund:
+		refund, err = t.ticketRefund(ctx, claims, ticket, order, request.RefundAmount, now)
+		if err != nil {
+			return nil, err
+		}
+		resolution.RefundID = refund.ID
+		resolution.RefundAmount = refund.Amount
+	case models.TicketRedelivery:
+		replacement, err = t.replacementOrder(claims, ticket, order, now)
+		if err != nil {
+			return nil, err
+		}
+		resolution.ReplacementOrderID = replacement.ID
+	}
+
+	message := models.TicketMessage{
+		ID:       t.idGenerator.Generate(),
+		AuthorID: claims.UserID,
+		Role:     claims.Role,
+		Body:     note,
+		Ts:       now,
+	}
+	update := domain.TicketUpdate{
+		CurrentStatus: ticket.Status,
+		Message:       &message,
+		History:       ticketHistory(models.TicketResolved, fmt.Sprintf("resolved with %s", request.Action), claims.UserID, now),
+		Resolution:    &resolution,
+		ResolvedTs:    now,
+	}
+	if ticket.SLA.FirstResponseTs == 0 {
+		update.FirstResponseTs = now
+	}
+
+	// the refund or the replacement order is created with the resolution, so a ticket is never settled without it
+	err = t.allRepository.Transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		err := t.allRepository.TicketRepository.Update(ctx, id, update)
+		if err != nil {
+			return err
+		}
+
+		if refund != nil {
+			return t.allRepository.RefundRepository.Create(ctx, *refund)
+		}
+		if replacement != nil {
+			return t.allRepository.OrderRepository.Create(ctx, *replacement)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return t.updatedTicket(ctx, id, message)
+}
+
+// ticketRefund requests the refund of part or all of what the customer paid online for the order.
+// Cancelled and rejected orders were already refunded when they were cancelled.
+func (t *ticketAppHandler) ticketRefund(ctx context.Context, claims *jwtmiddleware.UserClaims, ticket *models.Ticket, order *models.Order, amount models.Money, now int64) (*models.Refund, error) {
+	amount, err := amount.In(order.Total.Currency)
+	if err != nil {
+		return nil, errs.Body(errs.InvalidRequestError, err)
+	}
+
+	// refunds already requested for the order, on earlier tickets, count against its total unless they were rejected
+	refunds, err := t.allRepository.RefundRepository.Refunds(ctx, refundDomain.RefundFilter{
+		OrderID:  order.ID,
+		Statuses: []models.RefundStatus{models.RefundRequested, models.RefundApproved, models.RefundPaying, models.RefundPaid},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	refundable := order.Total
+	for _, refund := range refunds {
+		refundable = refundable.Sub(refund.Amount)
+	}
+
+	if !amount.IsPositive() || amount.Amount > refundable.Amount {
+		return nil, errs.Body(errs.InvalidRequestError, fmt.Errorf("refund amount must be greater than zero and at most the %s of the order total of %s not already refunded", refundable, order.Total))
+	}
+
+	status := order.CurrentStatus()
+	if status == models.OrderCancelled || status == models.OrderRejected {
+		return nil, errs.Body(errs.InvalidRequestError, fmt.Errorf("order is %s, its refund was requested when it was cancelled", status))
+	}
+
+	if order.PaymentMethod.IsPaidOnDelivery() || order.CheckoutID == "" {
+		return nil, errs.Body(errs.InvalidRequestError, errors.New("order was not paid online and cannot be refunded through the payment provider"))
+	}
+
+	payment, err := t.allRepository.PaymentRepository.ByCheckoutID(ctx, order.CheckoutID)
+	if err != nil {
+		return nil, err
+	}
+
+	if payment.Status != models.PaymentSuccessful {
+		return nil, errs.Body(errs.InvalidRequestError, errors.New("order payment was not successful, there is nothing to refund"))
+	}
+
+	reason := fmt.Sprintf("ticket %s: %s", ticket.ID, ticket.Subject)
+	return &models.Refund{
+		ID:               t.idGenerator.Generate(),
+		OrderID:          order.ID,
+		CheckoutID:       order.CheckoutID,
+		PaymentID:        payment.ID,
+		PaymentReference: payment.Reference,
+		CustomerID:       order.CustomerID,
+		VendorID:         order.VendorID,
+		OrderTotal:       order.Total,
+		Amount:           amount,
+		Reason:           reason,
+		Status:           models.RefundRequested,
+		StatusHistory: []models.RefundStatusHistory{
+			{
+				Status:    models.RefundRequested,
+				Reason:    reason,
+				UpdatedBy: claims.UserID,
+				StatusTs:  now,
+			},
+		},
+		StatusTs: now,
+		Ts:       now,
+	}, nil
+}
+
+// replacementOrder delivers the items of a completed order again at no cost. It goes to the vendor like any new order.
+func (t *ticketAppHandler) replacementOrder(claims *jwtmiddleware.UserClaims, ticket *models.Ticket, order *models.Order, now int64) (*models.Order, error) {
+	if order.CurrentStatus() != models.OrderCompleted {
+		return nil, errs.Body(errs.InvalidRequestError, fmt.Errorf("only %s orders can be delivered again, order is %s", models.OrderCompleted, order.CurrentStatus()))
+	}
+
+	items := make([]models.CartItem, len(order.Orders))
+	for i, item := range order.Orders {
+		item.ID = t.idGenerator.Generate()
+		item.Cost = models.Money{}
+		item.Deposit = models.Money{}
+		items[i] = item
+	}
+
+	return &models.Order{
+		ID:              t.idGenerator.Generate(),
+		Orders:          items,
+		CustomerID:      order.CustomerID,
+		VendorID:        order.VendorID,
+		DeliveryDetails: order.DeliveryDetails,
+		PaymentMethod:   order.PaymentMethod,
+		Status:          models.OrderPending,
+		ReplacesOrderID: order.ID,
+		StatusHistory: []models.StatusHistory{
+			{
+				Status:    models.OrderPending,
+				Reason:    fmt.Sprintf("re-delivery of order %s for ticket %s", order.ID, ticket.ID),
+				UpdatedBy: claims.UserID,
+				Role:      claims.Role,
+				StatusTs:  now,
+			},
+		},
+		StatusTs: now,
+		Ts:       now,
+	}, nil
+}
+
+// accessibleTicket returns the ticket if the signed in user is its customer or a support admin
+func (t *ticketAppHandler) accessibleTicket(ctx context.Context, id string) (*jwtmiddleware.UserClaims, *models.Ticket, error) {
+	claims, err := t.jwtManager.ExtractUserClaims(ctx)
+	if err != nil {
+		return nil, nil, errs.Body(errs.ErrorUnauthorized, err)
+	}
+
+	ticket, err := t.allRepository.TicketRepository.ByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if claims.Role != models.AdminCategory && ticket.CustomerID != claims.UserID {
+		return nil, nil, errs.Body(errs.ErrorUnauthorized, errors.New("you cannot view this ticket"))
+	}
+
+	return claims, ticket, nil
+}
+
+// updatedTicket reads the ticket back after the message was added and emails the message
+func (t *ticketAppHandler) updatedTicket(ctx context.Context, id string, message models.TicketMessage) (*models.Ticket, error) {
+	ticket, err := t.allRepository.TicketRepository.ByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := t.allRepository.OrderRepository.OrderByID(ctx, ticket.OrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	t.notifyMessage(ticket, order, message)
+
+	return ticket, nil
+}
+
+func (t *ticketAppHandler) adminClaims(ctx context.Context) (*jwtmiddleware.UserClaims, error) {
+	claims, err := t.jwtManager.ExtractUserClaims(ctx)
+	if err != nil {
+		return nil, errs.Body(errs.ErrorUnauthorized, err)
+	}
+
+	if claims.Role != models.AdminCategory {
+		return nil, errs.Body(errs.ErrorUnauthorized, errors.New("only support admins can handle tickets"))
+	}
+
+	return claims, nil
+}
+
+func messageBody(message string) (string, error) {
+	body := strings.TrimSpace(message)
+	if body == "" || utf8.RuneCountInString(body) > maxMessageLength {
+		return "", errs.Body(errs.InvalidRequestError, fmt.Errorf("message is required and cannot be longer than %d characters", maxMessageLength))
+	}
+
+	return body, nil
+}
+
+func ticketHistory(status models.TicketStatus, reason, updatedBy string, now int64) *models.TicketStatusHistory {
+	return &models.TicketStatusHistory{
+		Status:    status,
+		Reason:    reason,
+		UpdatedBy: updatedBy,
+		StatusTs:  now,
+	}
+}
@@ -0,0 +1,46 @@
+package application
+
+import (
+	"fmt"
+	"github.com/leetatech/leeta_backend/pkg"
+	"github.com/leetatech/leeta_backend/services/models"
+	"github.com/rs/zerolog/log"
+	"time"
+)
+
+// supportName signs the messages support admins send to customers
+const supportName = "Leeta Support"
+
+// notifyMessage emails a ticket message to the other side of the ticket: customer messages go to support and
+// support messages to the customer. Failing to send it does not undo the message, which is kept on the ticket.
+func (t *ticketAppHandler) notifyMessage(ticket *models.Ticket, order *models.Order, message models.TicketMessage) {
+	author, name, email := order.DeliveryDetails.Name, supportName, t.ticketConfig.SupportEmail
+	if message.Role == models.AdminCategory {
+		author, name, email = supportName, order.DeliveryDetails.Name, order.DeliveryDetails.Email
+	}
+	if email == "" {
+		return
+	}
+
+	err := t.EmailClient.Send(pkg.TicketMessageTemplatePath, models.Message{
+		ID:         t.idGenerator.Generate(),
+		UserID:     ticket.CustomerID,
+		TemplateID: pkg.TicketMessageTemplatePath,
+		Title:      fmt.Sprintf("[Ticket %s] %s", ticket.ID, ticket.Subject),
+		Sender:     t.mailerConfig.VerificationEmail,
+		DataMap: map[string]string{
+			"Name":     name,
+			"TicketID": ticket.ID,
+			"OrderID":  ticket.OrderID,
+			"Subject":  ticket.Subject,
+			"Author":   author,
+			"Message":  message.Body,
+			"Status":   string(ticket.Status),
+		},
+		Recipients: []string{email},
+		Ts:         time.Now().Unix(),
+	})
+	if err != nil {
+		log.Error().Err(err).Msgf("error emailing message %s of ticket %s", message.ID, ticket.ID)
+	}
+}
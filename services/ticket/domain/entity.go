@@ -0,0 +1,50 @@
+package domain
+
+import "github.com/leetatech/leeta_backend/services/models"
+
+// OpenTicketRequest opens a ticket against an order of the signed in customer with its first message
+type OpenTicketRequest struct {
+	OrderID  string                `json:"order_id"`
+	Category models.TicketCategory `json:"category"`
+	Subject  string                `json:"subject"`
+	Message  string                `json:"message"`
+} // @name OpenTicketRequest
+
+type ReplyTicketRequest struct {
+	Message string `json:"message"`
+} // @name ReplyTicketRequest
+
+// TriageTicketRequest lets support change the status, priority and assignee of a ticket. Empty fields are left unchanged.
+type TriageTicketRequest struct {
+	Status     models.TicketStatus   `json:"status,omitempty"`
+	Priority   models.TicketPriority `json:"priority,omitempty"`
+	AssignedTo string                `json:"assigned_to,omitempty"`
+	Reason     string                `json:"reason"`
+} // @name TriageTicketRequest
+
+// ResolveTicketRequest settles the ticket. RefundAmount is required to refund the customer and cannot exceed the order total,
+// it is in the currency of the order.
+type ResolveTicketRequest struct {
+	Action       models.TicketResolutionAction `json:"action"`
+	Note         string                        `json:"note"`
+	RefundAmount models.Money                  `json:"refund_amount,omitempty"`
+} // @name ResolveTicketRequest
+
+// TicketScope limits tickets to those of a customer. An empty scope matches every ticket.
+type TicketScope struct {
+	CustomerID string
+}
+
+// TicketUpdate is applied to a ticket still in CurrentStatus. Empty fields are left unchanged.
+type TicketUpdate struct {
+	CurrentStatus models.TicketStatus
+	Message       *models.TicketMessage
+	History       *models.TicketStatusHistory
+	Priority      models.TicketPriority
+	AssignedTo    string
+	Resolution    *models.TicketResolution
+	// FirstResponseTs is only recorded on the first support reply
+	FirstResponseTs int64
+	ResolvedTs      int64
+	ClosedTs        int64
+}
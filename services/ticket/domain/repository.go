@@ -0,0 +1,15 @@
+package domain
+
+import (
+	"context"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query"
+	"github.com/leetatech/leeta_backend/services/models"
+)
+
+type TicketRepository interface {
+	Create(ctx context.Context, ticket models.Ticket) error
+	ByID(ctx context.Context, id string) (*models.Ticket, error)
+	Tickets(ctx context.Context, request query.ResultSelector, scope TicketScope) ([]models.Ticket, uint64, error)
+	// Update fails with TicketStatusConflictError when the ticket is no longer in the current status of the update
+	Update(ctx context.Context, id string, update TicketUpdate) error
+}
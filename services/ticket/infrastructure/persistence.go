@@ -0,0 +1,149 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query/sorting"
+	"github.com/leetatech/leeta_backend/pkg/database"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/services/models"
+	"github.com/leetatech/leeta_backend/services/ticket/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"time"
+)
+
+type ticketStoreHandler struct {
+	client       *mongo.Client
+	databaseName string
+}
+
+func (t *ticketStoreHandler) col(collectionName string) *mongo.Collection {
+	return t.client.Database(t.databaseName).Collection(collectionName)
+}
+
+func New(client *mongo.Client, databaseName string) domain.TicketRepository {
+	return &ticketStoreHandler{client: client, databaseName: databaseName}
+}
+
+func (t *ticketStoreHandler) Create(ctx context.Context, ticket models.Ticket) error {
+	_, err := t.col(models.TicketsCollectionName).InsertOne(ctx, ticket)
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	return nil
+}
+
+func (t *ticketStoreHandler) ByID(ctx context.Context, id string) (*models.Ticket, error) {
+	ticket := &models.Ticket{}
+	err := t.col(models.TicketsCollectionName).FindOne(ctx, bson.M{"id": id}).Decode(ticket)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, errs.Body(errs.DatabaseNoRecordError, fmt.Errorf("ticket with id %s not found", id))
+		}
+		return nil, errs.Body(errs.DatabaseError, err)
+	}
+
+	return ticket, nil
+}
+
+func (t *ticketStoreHandler) Tickets(ctx context.Context, request query.ResultSelector, scope domain.TicketScope) ([]models.Ticket, uint64, error) {
+	updatedCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{}
+	if request.Filter != nil {
+		filter = database.BuildMongoFilterQuery(request.Filter, nil)
+	}
+	// the scope is applied last, so a filter can never widen it
+	if scope.CustomerID != "" {
+		filter["customer_id"] = scope.CustomerID
+	}
+
+	totalRecord, err := t.col(models.TicketsCollectionName).CountDocuments(updatedCtx, filter)
+	if err != nil {
+		return nil, 0, errs.Body(errs.DatabaseError, err)
+	}
+
+	skip := int64(request.Paging.PageSize * request.Paging.PageIndex)
+	if skip < 0 {
+		skip = 0
+	}
+	opts := options.Find().SetSkip(skip).SetLimit(int64(request.Paging.PageSize))
+	if request.Sorting != nil && request.Sorting.SortColumn != "" {
+		direction := 1
+		if request.Sorting.SortDirection == sorting.DirectionDescending {
+			direction = -1
+		}
+		opts.SetSort(bson.D{{Key: request.Sorting.SortColumn, Value: direction}})
+	}
+
+	cursor, err := t.col(models.TicketsCollectionName).Find(updatedCtx, filter, opts)
+	if err != nil {
+		return nil, 0, errs.Body(errs.DatabaseError, err)
+	}
+
+	tickets := make([]models.Ticket, 0)
+	if err = cursor.All(updatedCtx, &tickets); err != nil {
+		return nil, 0, errs.Body(errs.DatabaseError, err)
+	}
+
+	return tickets, uint64(totalRecord), nil
+}
+
+func (t *ticketStoreHandler) Update(ctx context.Context, id string, update domain.TicketUpdate) error {
+	set := bson.M{}
+	push := bson.M{}
+	if update.Message != nil {
+		push["messages"] = update.Message
+	}
+	if update.History != nil {
+		set["status"] = update.History.Status
+		set["status_ts"] = update.History.StatusTs
+		push["status_history"] = update.History
+	}
+	if update.Priority != "" {
+		set["priority"] = update.Priority
+	}
+	if update.AssignedTo != "" {
+		set["assigned_to"] = update.AssignedTo
+	}
+	if update.Resolution != nil {
+		set["resolution"] = update.Resolution
+	}
+	if update.FirstResponseTs > 0 {
+		set["sla.first_response_ts"] = update.FirstResponseTs
+	}
+	if update.ResolvedTs > 0 {
+		set["sla.resolved_ts"] = update.ResolvedTs
+	}
+	if update.ClosedTs > 0 {
+		set["sla.closed_ts"] = update.ClosedTs
+	}
+
+	changes := bson.M{}
+	if len(set) > 0 {
+		changes["$set"] = set
+	}
+	if len(push) > 0 {
+		changes["$push"] = push
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	result, err := t.col(models.TicketsCollectionName).UpdateOne(ctx, bson.M{"id": id, "status": update.CurrentStatus}, changes)
+	if err != nil {
+		return errs.Body(errs.DatabaseError, err)
+	}
+
+	if result.MatchedCount == 0 {
+		return errs.Body(errs.TicketStatusConflictError, fmt.Errorf("ticket %s is no longer %s", id, update.CurrentStatus))
+	}
+
+	return nil
+}
@@ -0,0 +1,227 @@
+package interfaces
+
+import (
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query/filter"
+	_ "github.com/leetatech/leeta_backend/pkg"
+	"github.com/leetatech/leeta_backend/pkg/errs"
+	"github.com/leetatech/leeta_backend/pkg/helpers"
+	"github.com/leetatech/leeta_backend/pkg/jwtmiddleware"
+	"github.com/leetatech/leeta_backend/services/models"
+	"github.com/leetatech/leeta_backend/services/ticket/application"
+	"github.com/leetatech/leeta_backend/services/ticket/domain"
+	"github.com/leetatech/leeta_backend/services/web"
+	"github.com/samber/lo"
+	"net/http"
+)
+
+type TicketHttpHandler struct {
+	TicketApplication application.Ticket
+}
+
+func New(ticketApplication application.Ticket) *TicketHttpHandler {
+	return &TicketHttpHandler{
+		TicketApplication: ticketApplication,
+	}
+}
+
+// OpenTicketHandler godoc
+// @Summary Open ticket
+// @Description The endpoint lets the customer open a support ticket about one of their orders, such as a wrong cylinder size, a short weight or a late delivery. The message is emailed to support
+// @Tags Ticket
+// @Accept json
+// @produce json
+// @Param domain.OpenTicketRequest body domain.OpenTicketRequest true "open ticket request body"
+// @Security BearerToken
+// @success 201 {object} models.Ticket
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Failure 404 {object} pkg.DefaultErrorResponse
+// @Router /ticket/ [post]
+func (handler *TicketHttpHandler) OpenTicketHandler(w http.ResponseWriter, r *http.Request) {
+	var request domain.OpenTicketRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		jwtmiddleware.WriteJSONErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := handler.TicketApplication.Open(r.Context(), request)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, resp, http.StatusCreated)
+}
+
+// GetTicketHandler godoc
+// @Summary Get ticket
+// @Description The endpoint returns the ticket with its messages to its customer and support
+// @Tags Ticket
+// @Accept json
+// @produce json
+// @Param			ticket_id	path		string	true	"ticket id"
+// @Security BearerToken
+// @success 200 {object} models.Ticket
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 404 {object} pkg.DefaultErrorResponse
+// @Router /ticket/{ticket_id} [get]
+func (handler *TicketHttpHandler) GetTicketHandler(w http.ResponseWriter, r *http.Request) {
+	resp, err := handler.TicketApplication.Ticket(r.Context(), chi.URLParam(r, "ticket_id"))
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, resp, http.StatusOK)
+}
+
+// ListTicketsHandler godoc
+// @Summary List tickets
+// @Description The endpoint lists the tickets of the signed in customer, or every ticket for support, newest first. List endpoint can be configured with the filters
+// @Tags Ticket
+// @Accept json
+// @produce json
+// @param query.ResultSelector body query.ResultSelector true "list tickets request body"
+// @Security BearerToken
+// @success 200 {object} query.ResponseListWithMetadata[models.Ticket]
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Router /ticket/ [put]
+func (handler *TicketHttpHandler) ListTicketsHandler(w http.ResponseWriter, r *http.Request) {
+	resultSelector, err := web.PrepareResultSelector(r, listTicketsOptions, ticketSortFields, web.ResultSelectorDefaults(ticketSortingRequest))
+	if err != nil {
+		jwtmiddleware.WriteJSONErrorResponse(w, http.StatusBadRequest, errs.Body(errs.InvalidRequestError, err))
+		return
+	}
+
+	tickets, totalRecord, err := handler.TicketApplication.List(r.Context(), resultSelector)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	response := query.ResponseListWithMetadata[models.Ticket]{
+		Metadata: query.NewMetadata(resultSelector, totalRecord),
+		Data:     tickets,
+	}
+	jwtmiddleware.WriteJSONResponse(w, response, http.StatusOK)
+}
+
+// ListTicketsOptions godoc
+// @Summary Get tickets filter options
+// @Description Retrieve tickets filter options
+// @Tags Ticket
+// @Accept json
+// @Produce json
+// @Security BearerToken
+// @Success 200 {object} filter.RequestOption
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Router /ticket/options [get]
+func (handler *TicketHttpHandler) ListTicketsOptions(w http.ResponseWriter, r *http.Request) {
+	requestOptions := lo.Map(listTicketsOptions, toFilterOption)
+	jwtmiddleware.WriteJSONResponse(w, requestOptions, http.StatusOK)
+}
+
+// ReplyTicketHandler godoc
+// @Summary Reply to ticket
+// @Description The endpoint adds a message to an open ticket and emails it to the other side. Customers reply to their own tickets and support to any ticket
+// @Tags Ticket
+// @Accept json
+// @produce json
+// @Param			ticket_id	path		string	true	"ticket id"
+// @Param domain.ReplyTicketRequest body domain.ReplyTicketRequest true "reply ticket request body"
+// @Security BearerToken
+// @success 200 {object} models.Ticket
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Failure 404 {object} pkg.DefaultErrorResponse
+// @Failure 409 {object} pkg.DefaultErrorResponse
+// @Router /ticket/{ticket_id}/messages [post]
+func (handler *TicketHttpHandler) ReplyTicketHandler(w http.ResponseWriter, r *http.Request) {
+	var request domain.ReplyTicketRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		jwtmiddleware.WriteJSONErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := handler.TicketApplication.Reply(r.Context(), chi.URLParam(r, "ticket_id"), request)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, resp, http.StatusOK)
+}
+
+// TriageTicketHandler godoc
+// @Summary Triage ticket
+// @Description The endpoint lets support change the status and priority of a ticket and assign it to a support admin
+// @Tags Ticket
+// @Accept json
+// @produce json
+// @Param			ticket_id	path		string	true	"ticket id"
+// @Param domain.TriageTicketRequest body domain.TriageTicketRequest true "triage ticket request body"
+// @Security BearerToken
+// @success 200 {object} models.Ticket
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Failure 404 {object} pkg.DefaultErrorResponse
+// @Failure 409 {object} pkg.DefaultErrorResponse
+// @Router /ticket/{ticket_id}/triage [put]
+func (handler *TicketHttpHandler) TriageTicketHandler(w http.ResponseWriter, r *http.Request) {
+	var request domain.TriageTicketRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		jwtmiddleware.WriteJSONErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := handler.TicketApplication.Triage(r.Context(), chi.URLParam(r, "ticket_id"), request)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, resp, http.StatusOK)
+}
+
+// ResolveTicketHandler godoc
+// @Summary Resolve ticket
+// @Description The endpoint lets support settle a ticket. A REFUND resolution requests a refund of the amount for the order, and a REDELIVERY resolution sends the vendor a new order delivering the items again at no cost. The note is emailed to the customer
+// @Tags Ticket
+// @Accept json
+// @produce json
+// @Param			ticket_id	path		string	true	"ticket id"
+// @Param domain.ResolveTicketRequest body domain.ResolveTicketRequest true "resolve ticket request body"
+// @Security BearerToken
+// @success 200 {object} models.Ticket
+// @Failure 401 {object} pkg.DefaultErrorResponse
+// @Failure 400 {object} pkg.DefaultErrorResponse
+// @Failure 404 {object} pkg.DefaultErrorResponse
+// @Failure 409 {object} pkg.DefaultErrorResponse
+// @Router /ticket/{ticket_id}/resolve [put]
+func (handler *TicketHttpHandler) ResolveTicketHandler(w http.ResponseWriter, r *http.Request) {
+	var request domain.ResolveTicketRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	if err != nil {
+		jwtmiddleware.WriteJSONErrorResponse(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := handler.TicketApplication.Resolve(r.Context(), chi.URLParam(r, "ticket_id"), request)
+	if err != nil {
+		helpers.CheckErrorType(err, w)
+		return
+	}
+
+	jwtmiddleware.WriteJSONResponse(w, resp, http.StatusOK)
+}
+
+func toFilterOption(options filter.RequestOption, _ int) filter.RequestOption {
+	return options
+}
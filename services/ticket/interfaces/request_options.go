@@ -0,0 +1,107 @@
+package interfaces
+
+import (
+	"github.com/greenbone/opensight-golang-libraries/pkg/query/filter"
+	"github.com/greenbone/opensight-golang-libraries/pkg/query/sorting"
+	"github.com/leetatech/leeta_backend/services/models"
+)
+
+// LabelIsEqualTo holds filter request options operator labels
+const (
+	LabelIsEqualTo    = "is equal to"
+	LabelIsOnOrAfter  = "is on or after"
+	LabelIsOnOrBefore = "is on or before"
+)
+
+var operatorEqual = filter.ReadableValue[filter.CompareOperator]{
+	Label: LabelIsEqualTo,
+	Value: filter.CompareOperatorIsEqualTo,
+}
+
+var operatorOnOrAfter = filter.ReadableValue[filter.CompareOperator]{
+	Label: LabelIsOnOrAfter,
+	Value: filter.CompareOperatorIsGreaterThanOrEqualTo,
+}
+
+var operatorOnOrBefore = filter.ReadableValue[filter.CompareOperator]{
+	Label: LabelIsOnOrBefore,
+	Value: filter.CompareOperatorIsLessThanOrEqualTo,
+}
+
+func enumOption(label, value string, values ...string) filter.RequestOption {
+	return filter.RequestOption{
+		Name: filter.ReadableValue[string]{
+			Label: label,
+			Value: value,
+		},
+		Control: filter.RequestOptionType{
+			Type: filter.ControlTypeEnum,
+		},
+		Operators: []filter.ReadableValue[filter.CompareOperator]{
+			operatorEqual,
+		},
+		Values: values,
+	}
+}
+
+func timeOption(label, value string) filter.RequestOption {
+	return filter.RequestOption{
+		Name: filter.ReadableValue[string]{
+			Label: label,
+			Value: value,
+		},
+		Control: filter.RequestOptionType{
+			Type: filter.ControlTypeInteger,
+		},
+		Operators: []filter.ReadableValue[filter.CompareOperator]{
+			operatorOnOrAfter,
+			operatorOnOrBefore,
+		},
+	}
+}
+
+// listTicketsOptions filter tickets by status, category and priority. The SLA due times let support find the tickets
+// that are overdue, or about to be.
+var listTicketsOptions = []filter.RequestOption{
+	enumOption("status", "status",
+		string(models.TicketOpen),
+		string(models.TicketInProgress),
+		string(models.TicketAwaitingCustomer),
+		string(models.TicketResolved),
+		string(models.TicketClosed),
+	),
+	enumOption("category", "category",
+		string(models.TicketWrongSize),
+		string(models.TicketShortWeight),
+		string(models.TicketLateDelivery),
+		string(models.TicketDamaged),
+		string(models.TicketOther),
+	),
+	enumOption("priority", "priority",
+		string(models.TicketPriorityLow),
+		string(models.TicketPriorityNormal),
+		string(models.TicketPriorityHigh),
+	),
+	{
+		Name: filter.ReadableValue[string]{
+			Label: "assigned to",
+			Value: "assigned_to",
+		},
+		Control: filter.RequestOptionType{
+			Type: filter.ControlTypeString,
+		},
+		Operators: []filter.ReadableValue[filter.CompareOperator]{
+			operatorEqual,
+		},
+	},
+	timeOption("date", "ts"),
+	timeOption("first response due", "sla.first_response_due_ts"),
+	timeOption("resolution due", "sla.resolution_due_ts"),
+}
+
+var ticketSortFields = []string{"ts", "status_ts", "sla.first_response_due_ts", "sla.resolution_due_ts"}
+
+var ticketSortingRequest = &sorting.Request{
+	SortColumn:    "ts",
+	SortDirection: sorting.DirectionDescending,
+}
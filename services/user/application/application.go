@@ -81,7 +81,7 @@ func (u *userAppHandler) VendorVerification(ctx context.Context, request domain.
 		Status:      models.Registered,
 		Timestamp:   time.Now().Unix(),
 	}
-	err = u.allRepository.UserRepository.RegisterVendorBusiness(business)
+	err = u.allRepository.UserRepository.RegisterVendorBusiness(ctx, business)
 	if err != nil {
 		return nil, err
 	}
@@ -114,11 +114,6 @@ func (u *userAppHandler) AddVendorByAdmin(ctx context.Context, request domain.Ve
 			Time: time.Now().Unix(),
 		},
 	}
-	err = u.allRepository.AuthRepository.CreateUser(ctx, vendor)
-	if err != nil {
-		return nil, err
-	}
-
 	category, err := models.SetBusinessCategory(request.Category)
 	if err != nil {
 		return nil, err
@@ -136,7 +131,15 @@ func (u *userAppHandler) AddVendorByAdmin(ctx context.Context, request domain.Ve
 		Status:      models.Registered,
 		Timestamp:   time.Now().Unix(),
 	}
-	err = u.allRepository.UserRepository.RegisterVendorBusiness(business)
+	// a vendor is only created together with its business
+	err = u.allRepository.Transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		err := u.allRepository.AuthRepository.CreateUser(ctx, vendor)
+		if err != nil {
+			return err
+		}
+
+		return u.allRepository.UserRepository.RegisterVendorBusiness(ctx, business)
+	})
 	if err != nil {
 		return nil, err
 	}
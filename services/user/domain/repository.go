@@ -1,10 +1,15 @@
 package domain
 
-import "github.com/leetatech/leeta_backend/services/models"
+import (
+	"context"
+	"github.com/leetatech/leeta_backend/services/models"
+)
 
 type UserRepository interface {
 	VendorDetailsUpdate(request VendorDetailsUpdateRequest) error
-	RegisterVendorBusiness(request models.Business) error
+	RegisterVendorBusiness(ctx context.Context, request models.Business) error
+	// VendorBusiness returns the business the vendor registered, or mongo.ErrNoDocuments when it has none
+	VendorBusiness(ctx context.Context, vendorID string) (*models.Business, error)
 	GetVendorByID(id string) (*models.Vendor, error)
 	GetCustomerByID(id string) (*models.Customer, error)
 	UpdateUserRecord(request *models.User) error
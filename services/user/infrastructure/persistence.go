@@ -40,17 +40,30 @@ func (u userStoreHandler) VendorDetailsUpdate(request domain.VendorDetailsUpdate
 	return nil
 }
 
-func (u userStoreHandler) RegisterVendorBusiness(request models.Business) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+func (u userStoreHandler) RegisterVendorBusiness(ctx context.Context, request models.Business) error {
+	updatedCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	_, err := u.col(models.BusinessCollectionName).InsertOne(ctx, request)
+	_, err := u.col(models.BusinessCollectionName).InsertOne(updatedCtx, request)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+func (u userStoreHandler) VendorBusiness(ctx context.Context, vendorID string) (*models.Business, error) {
+	updatedCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	business := &models.Business{}
+	err := u.col(models.BusinessCollectionName).FindOne(updatedCtx, bson.M{"vendor_id": vendorID}).Decode(business)
+	if err != nil {
+		return nil, err
+	}
+
+	return business, nil
+}
+
 func (u userStoreHandler) GetVendorByID(id string) (*models.Vendor, error) {
 	vendor := &models.Vendor{}
 	filter := bson.M{